@@ -0,0 +1,151 @@
+// Package audit describes the append-only log of the changes made to the data of the service. Every row a
+// caller creates, changes or removes is recorded with who did it, through which operation and request, and the
+// row before and after the change.
+//
+// The entries of every tenant form a hash chain of their own, changes of data shared by all tenants are chained
+// under tenant 0: each entry holds the hash of the entry of its tenant before it and its own hash covers its
+// content and that link. Changing, removing or reordering an entry breaks the chain of its tenant from that entry
+// on, which a Verifier walking the log from its first entry detects. Chains are per tenant so writers of
+// different tenants never wait for each other to append.
+//
+// Removing the newest entries of a chain leaves the rest of it intact. A cut short chain only shows against the
+// heads recorded by an earlier walk, which the Verifier is anchored to.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"parking_lot_service/internal/repo/models"
+	"slices"
+	"time"
+)
+
+// Operation is the piece of work a change is made by, an API request or a run of a background job.
+type Operation struct {
+	Name      string // Route of the request as method and path, or the job
+	RequestID string // ID of the request, empty for jobs
+}
+
+type operationKey struct{}
+
+// NewContext returns a copy of ctx that carries the operation the changes made with it belong to.
+func NewContext(ctx context.Context, operation Operation) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// FromContext returns the operation carried by ctx, if any.
+func FromContext(ctx context.Context) (Operation, bool) {
+	operation, ok := ctx.Value(operationKey{}).(Operation)
+	return operation, ok
+}
+
+// Hash returns the hash of an entry, which covers every field but its ID and its own hash. The ID is left out
+// as it is only known once the entry is written, the link to the entry before keeps the order of the log.
+func Hash(entry *models.AuditEntry) string {
+	content, _ := json.Marshal(struct {
+		PrevHash  string `json:"prev_hash"`
+		TenantId  uint   `json:"tenant_id"`
+		Actor     string `json:"actor"`
+		Role      string `json:"role"`
+		Operation string `json:"operation"`
+		RequestId string `json:"request_id"`
+		Action    string `json:"action"`
+		Entity    string `json:"entity"`
+		EntityId  string `json:"entity_id"`
+		Before    string `json:"before"`
+		After     string `json:"after"`
+		CreatedAt string `json:"created_at"`
+	}{
+		PrevHash:  entry.PrevHash,
+		TenantId:  entry.TenantId,
+		Actor:     entry.Actor,
+		Role:      entry.Role,
+		Operation: entry.Operation,
+		RequestId: entry.RequestId,
+		Action:    string(entry.Action),
+		Entity:    entry.Entity,
+		EntityId:  entry.EntityId,
+		Before:    entry.Before,
+		After:     entry.After,
+		CreatedAt: entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// ChainError reports the first entry at which the chain of the log is broken.
+type ChainError struct {
+	EntryID uint
+	Reason  string
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("audit entry %d %s", e.EntryID, e.Reason)
+}
+
+// Verifier walks the entries of the log in the order they were written and checks that each one is intact
+// and follows the entry of its tenant before it. Entries can be fed page by page.
+type Verifier struct {
+	heads   map[uint]string
+	anchors map[uint]string // Recorded heads not reached yet
+	checked int
+}
+
+// Anchor sets the heads of the chains recorded by an earlier walk of the log, a chain without its recorded head
+// was cut short. The heads must be kept outside the database, where whoever can change the log cannot change them.
+func (v *Verifier) Anchor(heads map[uint]string) {
+	v.anchors = make(map[uint]string, len(heads))
+	for tenantId, head := range heads {
+		if head != "" {
+			v.anchors[tenantId] = head
+		}
+	}
+}
+
+// Check checks the next entry of the log, it returns a *ChainError if the chain breaks at the entry.
+func (v *Verifier) Check(entry *models.AuditEntry) error {
+	if entry.PrevHash != v.heads[entry.TenantId] {
+		return &ChainError{EntryID: entry.ID, Reason: "does not follow the entry of its tenant before it"}
+	}
+	if Hash(entry) != entry.Hash {
+		return &ChainError{EntryID: entry.ID, Reason: "was changed after it was written"}
+	}
+
+	if v.heads == nil {
+		v.heads = map[uint]string{}
+	}
+	v.heads[entry.TenantId] = entry.Hash
+	if v.anchors[entry.TenantId] == entry.Hash {
+		delete(v.anchors, entry.TenantId)
+	}
+	v.checked++
+	return nil
+}
+
+// Truncated returns the IDs of the tenants, in order, whose recorded head was not found in their chain.
+func (v *Verifier) Truncated() []uint {
+	tenantIds := make([]uint, 0, len(v.anchors))
+	for tenantId := range v.anchors {
+		tenantIds = append(tenantIds, tenantId)
+	}
+	slices.Sort(tenantIds)
+	return tenantIds
+}
+
+// Checked returns the number of entries found intact.
+func (v *Verifier) Checked() int {
+	return v.checked
+}
+
+// Heads returns the hash of the last entry found intact of every tenant with entries, by tenant ID.
+func (v *Verifier) Heads() map[uint]string {
+	heads := make(map[uint]string, len(v.heads))
+	for tenantId, head := range v.heads {
+		heads[tenantId] = head
+	}
+	return heads
+}
@@ -0,0 +1,149 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"parking_lot_service/internal/repo/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestVerifier(t *testing.T) {
+	chain := func() []*models.AuditEntry {
+		var entries []*models.AuditEntry
+		prevHash := ""
+		for i, action := range []models.AuditAction{models.AuditActionCreate, models.AuditActionUpdate,
+			models.AuditActionDelete} {
+			entry := &models.AuditEntry{ID: uint(i + 1), Actor: "admin", Action: action, Entity: "holidays",
+				EntityId: "4", CreatedAt: time.Date(2026, 3, 1, 9, i, 0, 0, time.UTC), PrevHash: prevHash}
+			entry.Hash = Hash(entry)
+			prevHash = entry.Hash
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	tests := []struct {
+		name        string
+		tamper      func(entries []*models.AuditEntry) []*models.AuditEntry
+		wantBreakAt uint // Zero for an intact chain
+	}{
+		{
+			name:   "Intact chain",
+			tamper: func(entries []*models.AuditEntry) []*models.AuditEntry { return entries },
+		},
+		{
+			name: "Changed entry",
+			tamper: func(entries []*models.AuditEntry) []*models.AuditEntry {
+				entries[1].Actor = "someone-else"
+				return entries
+			},
+			wantBreakAt: 2,
+		},
+		{
+			name: "Changed entry with its hash recomputed",
+			tamper: func(entries []*models.AuditEntry) []*models.AuditEntry {
+				entries[0].Actor = "someone-else"
+				entries[0].Hash = Hash(entries[0])
+				return entries
+			},
+			wantBreakAt: 2,
+		},
+		{
+			name: "Removed entry",
+			tamper: func(entries []*models.AuditEntry) []*models.AuditEntry {
+				return append(entries[:1], entries[2:]...)
+			},
+			wantBreakAt: 3,
+		},
+		{
+			name: "Reordered entries",
+			tamper: func(entries []*models.AuditEntry) []*models.AuditEntry {
+				return []*models.AuditEntry{entries[0], entries[2], entries[1]}
+			},
+			wantBreakAt: 3,
+		},
+		{
+			name: "Entry moved to the chain of another tenant",
+			tamper: func(entries []*models.AuditEntry) []*models.AuditEntry {
+				entries[1].TenantId = 2
+				entries[1].Hash = Hash(entries[1])
+				return entries
+			},
+			wantBreakAt: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := &Verifier{}
+			var chainErr *ChainError
+			for _, entry := range tt.tamper(chain()) {
+				if err := verifier.Check(entry); err != nil {
+					if !errors.As(err, &chainErr) {
+						t.Fatalf("Check() error = %v, want a *ChainError", err)
+					}
+					break
+				}
+			}
+
+			switch {
+			case tt.wantBreakAt == 0 && chainErr != nil:
+				t.Errorf("Check() of an intact chain error = %v", chainErr)
+			case tt.wantBreakAt != 0 && (chainErr == nil || chainErr.EntryID != tt.wantBreakAt):
+				t.Errorf("Check() error = %v, want the chain broken at entry %d", chainErr, tt.wantBreakAt)
+			}
+		})
+	}
+}
+
+func TestVerifier_Anchor(t *testing.T) {
+	var entries []*models.AuditEntry
+	for i := 1; i <= 3; i++ {
+		entry := &models.AuditEntry{ID: uint(i), Actor: "admin", Action: models.AuditActionUpdate, Entity: "holidays",
+			EntityId: "4", CreatedAt: time.Date(2026, 3, 1, 9, i, 0, 0, time.UTC)}
+		if i > 1 {
+			entry.PrevHash = entries[i-2].Hash
+		}
+		entry.Hash = Hash(entry)
+		entries = append(entries, entry)
+	}
+
+	tests := []struct {
+		name          string
+		heads         map[uint]string
+		walked        int
+		wantTruncated []uint
+	}{
+		{name: "Recorded head is the last entry", heads: map[uint]string{0: entries[2].Hash}, walked: 3},
+		{name: "Entries written after the recorded head", heads: map[uint]string{0: entries[1].Hash}, walked: 3},
+		{name: "Newest entries removed", heads: map[uint]string{0: entries[2].Hash}, walked: 2, wantTruncated: []uint{0}},
+		{name: "Chain of a tenant removed", heads: map[uint]string{0: entries[2].Hash, 3: "beef"}, walked: 3,
+			wantTruncated: []uint{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := &Verifier{}
+			verifier.Anchor(tt.heads)
+			for _, entry := range entries[:tt.walked] {
+				if err := verifier.Check(entry); err != nil {
+					t.Fatalf("Check() error = %v", err)
+				}
+			}
+			if got := verifier.Truncated(); !slices.Equal(got, tt.wantTruncated) {
+				t.Errorf("Truncated() = %v, want %v", got, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Errorf("FromContext() of a context without an operation = true")
+	}
+
+	operation := Operation{Name: "POST /parking-lot/park-vehicle", RequestID: "req-1"}
+	if got, ok := FromContext(NewContext(context.Background(), operation)); !ok || got != operation {
+		t.Errorf("FromContext() = %+v, %v, want %+v", got, ok, operation)
+	}
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// APIKey is a key a kiosk or gate controller authenticates with. Only the SHA-256 hash of the key is
+// configured, so the configuration does not give the keys away.
+type APIKey struct {
+	Name           string
+	Role           Role
+	TenantID       uint
+	ParkingLotIDs  []int
+	AllParkingLots bool
+	Hash           [sha256.Size]byte
+}
+
+// HashAPIKey returns the hash an API key is configured by.
+func HashAPIKey(key string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(key))
+}
+
+// ParseAPIKeys reads API keys configured as name:role:tenant:lots:hash entries separated by semicolons. The
+// tenant is the ID of the tenant the key belongs to, or * for a platform admin key. The lots are the parking
+// lot IDs separated by commas, or * for every lot of the tenant, and the hash is the hex SHA-256 of the key.
+//
+//	kiosk-1:kiosk:1:1:9f86d081...;gate-north:gate-controller:1:1,2:60303ae2...
+func ParseAPIKeys(config string) ([]APIKey, error) {
+	var apiKeys []APIKey
+	for _, entry := range strings.Split(config, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		fields := strings.Split(entry, ":")
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("API key %q must be name:role:tenant:lots:hash", entry)
+		}
+		apiKey := APIKey{Name: fields[0], Role: Role(fields[1])}
+		if apiKey.Name == "" {
+			return nil, fmt.Errorf("API key %q has no name", entry)
+		}
+		if !apiKey.Role.Valid() {
+			return nil, fmt.Errorf("API key %s has unknown role %q", apiKey.Name, fields[1])
+		}
+
+		var err error
+		if fields[2] != "*" {
+			tenantId, err := strconv.ParseUint(fields[2], 10, 0)
+			if err != nil || tenantId == 0 {
+				return nil, fmt.Errorf("API key %s: tenant must be * or a tenant ID", apiKey.Name)
+			}
+			apiKey.TenantID = uint(tenantId)
+		}
+		if err = validTenant(apiKey.Role, apiKey.TenantID); err != nil {
+			return nil, fmt.Errorf("API key %s: %w", apiKey.Name, err)
+		}
+
+		apiKey.ParkingLotIDs, apiKey.AllParkingLots, err = parseParkingLots(fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("API key %s: %w", apiKey.Name, err)
+		}
+
+		hash, err := hex.DecodeString(fields[4])
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("API key %s: hash must be a hex SHA-256", apiKey.Name)
+		}
+		copy(apiKey.Hash[:], hash)
+		apiKeys = append(apiKeys, apiKey)
+	}
+	return apiKeys, nil
+}
+
+// parseParkingLots reads parking lot IDs separated by commas, or * for every lot.
+func parseParkingLots(lots string) ([]int, bool, error) {
+	if lots == "*" {
+		return nil, true, nil
+	}
+
+	var parkingLotIds []int
+	for _, lot := range strings.Split(lots, ",") {
+		parkingLotId, err := strconv.Atoi(strings.TrimSpace(lot))
+		if err != nil || parkingLotId <= 0 {
+			return nil, false, errors.New("parking lots must be * or parking lot IDs separated by commas")
+		}
+		parkingLotIds = append(parkingLotIds, parkingLotId)
+	}
+	return parkingLotIds, false, nil
+}
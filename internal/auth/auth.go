@@ -0,0 +1,177 @@
+// Package auth identifies the callers of the HTTP API and decides what they may do. Kiosks and gate
+// controllers authenticate with API keys, operators with JSON Web Tokens signed by locally configured keys.
+//
+// Every caller has a role, which grants a fixed set of permissions, and is scoped to the parking lots it works
+// at. Admins reach every lot, other roles only the lots their key or token lists, unless it grants all lots.
+//
+// Callers belong to a tenant, the property owner whose lots they work at, and never reach the data of another
+// tenant. Only admins may belong to no tenant, they run the platform and reach every tenant.
+package auth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Role is the job of a caller, it decides the permissions the caller has.
+type Role string
+
+const (
+	RoleKiosk          Role = "kiosk"           // Pay station or entry kiosk used by drivers
+	RoleGateController Role = "gate-controller" // Barrier controller at the entry or exit of a lot
+	RoleAttendant      Role = "attendant"       // Operator at the booth of a lot
+	RoleLotManager     Role = "lot-manager"     // Runs the configuration and pricing of a lot
+	RoleFinance        Role = "finance"         // Reconciles payments, refunds and revenue
+	RoleAdmin          Role = "admin"           // Runs the catalogue of lots and can do everything
+)
+
+// Permission is an action on the API a role may be granted.
+type Permission string
+
+const (
+	PermissionViewLots           Permission = "lots:view"             // Read lots, spots, tariffs and policies
+	PermissionParkVehicles       Permission = "sessions:park"         // Let vehicles in
+	PermissionExitVehicles       Permission = "sessions:exit"         // Quote, pay and let vehicles out by ticket
+	PermissionLostTicketExits    Permission = "sessions:lost-ticket"  // Let vehicles out without their ticket
+	PermissionViewSessions       Permission = "sessions:view"         // Read the history of parking sessions
+	PermissionManageReservations Permission = "reservations:manage"   // Book, change and cancel reservations
+	PermissionManagePasses       Permission = "passes:manage"         // Sell, change and cancel passes
+	PermissionValidateTickets    Permission = "validations:create"    // Apply merchant validations to tickets
+	PermissionConfigureLots      Permission = "lots:configure"        // Change capacity, spots, holidays and policies
+	PermissionManagePricing      Permission = "pricing:manage"        // Change tariffs, pass products and discounts
+	PermissionAdministerLots     Permission = "lots:administer"       // Add and remove lots and vehicle types
+	PermissionViewInvoices       Permission = "invoices:view"         // Read invoices and their payments
+	PermissionPayInvoices        Permission = "invoices:pay"          // Pay invoices through a payment gateway
+	PermissionBillAccounts       Permission = "invoices:bill-account" // Bill invoices to an account
+	PermissionRefundPayments     Permission = "payments:refund"       // Refund payments
+	PermissionAdjustFares        Permission = "fares:adjust"          // Correct or waive the fare of closed sessions
+	PermissionViewFinance        Permission = "finance:view"          // Read revenue reports and fare adjustments
+	PermissionManageRates        Permission = "exchange-rates:manage" // Change exchange rates
+	PermissionManageTenants      Permission = "tenants:manage"        // Read and change tenants
+	PermissionViewAuditLog       Permission = "audit-log:view"        // Read who changed what in the audit log
+)
+
+// rolePermissions is the permission matrix, admins are granted every permission.
+var rolePermissions = map[Role][]Permission{
+	RoleKiosk: {
+		PermissionViewLots, PermissionParkVehicles, PermissionExitVehicles, PermissionManageReservations,
+		PermissionValidateTickets, PermissionViewInvoices, PermissionPayInvoices,
+	},
+	RoleGateController: {
+		PermissionViewLots, PermissionParkVehicles, PermissionExitVehicles,
+	},
+	RoleAttendant: {
+		PermissionViewLots, PermissionParkVehicles, PermissionExitVehicles, PermissionLostTicketExits,
+		PermissionViewSessions, PermissionManageReservations, PermissionManagePasses, PermissionValidateTickets,
+		PermissionViewInvoices, PermissionPayInvoices, PermissionBillAccounts,
+	},
+	RoleLotManager: {
+		PermissionViewLots, PermissionParkVehicles, PermissionExitVehicles, PermissionLostTicketExits,
+		PermissionViewSessions, PermissionManageReservations, PermissionManagePasses, PermissionValidateTickets,
+		PermissionConfigureLots, PermissionManagePricing, PermissionViewInvoices, PermissionPayInvoices,
+		PermissionBillAccounts, PermissionAdjustFares, PermissionViewFinance,
+	},
+	RoleFinance: {
+		PermissionViewLots, PermissionViewSessions, PermissionViewInvoices, PermissionPayInvoices,
+		PermissionBillAccounts, PermissionRefundPayments, PermissionAdjustFares, PermissionViewFinance,
+		PermissionManageRates, PermissionViewAuditLog,
+	},
+}
+
+// Roles lists the roles from the least to the most privileged.
+var Roles = []Role{RoleKiosk, RoleGateController, RoleAttendant, RoleLotManager, RoleFinance, RoleAdmin}
+
+// Valid reports whether the role is one of the roles.
+func (r Role) Valid() bool {
+	return slices.Contains(Roles, r)
+}
+
+// Can reports whether the role is granted a permission.
+func (r Role) Can(permission Permission) bool {
+	return r == RoleAdmin || slices.Contains(rolePermissions[r], permission)
+}
+
+// Principal is an authenticated caller.
+type Principal struct {
+	Subject        string // Name of the API key, or the subject of the token
+	Role           Role
+	TenantID       uint  // Tenant the caller belongs to, zero for platform admins
+	ParkingLotIDs  []int // Parking lots the caller is scoped to
+	AllParkingLots bool  // Whether the caller reaches every parking lot of its tenant, admins always do
+}
+
+// Can reports whether the role of the principal is granted a permission.
+func (p *Principal) Can(permission Permission) bool {
+	return p.Role.Can(permission)
+}
+
+// CanAccessParkingLot reports whether the principal is scoped to a parking lot.
+func (p *Principal) CanAccessParkingLot(parkingLotId int) bool {
+	return p.CanAccessAllParkingLots() || slices.Contains(p.ParkingLotIDs, parkingLotId)
+}
+
+// CanAccessAllParkingLots reports whether the principal reaches every parking lot.
+func (p *Principal) CanAccessAllParkingLots() bool {
+	return p.Role == RoleAdmin || p.AllParkingLots
+}
+
+// IsPlatform reports whether the principal runs the platform rather than working for a tenant.
+func (p *Principal) IsPlatform() bool {
+	return p.TenantID == 0
+}
+
+// CanAccessTenant reports whether the principal reaches the data of a tenant.
+func (p *Principal) CanAccessTenant(tenantId uint) bool {
+	return p.IsPlatform() || p.TenantID == tenantId
+}
+
+// validTenant checks that only admins are left without a tenant.
+func validTenant(role Role, tenantId uint) error {
+	if tenantId == 0 && role != RoleAdmin {
+		return fmt.Errorf("role %s must belong to a tenant", role)
+	}
+	return nil
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx that carries the principal making the request.
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the principal carried by ctx, if any.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
+
+// CanAccessParkingLot reports whether the principal of ctx is scoped to a parking lot. Work done without a
+// principal, like the background jobs, is not started by a caller of the API and reaches every lot.
+func CanAccessParkingLot(ctx context.Context, parkingLotId int) bool {
+	principal, ok := FromContext(ctx)
+	return !ok || principal.CanAccessParkingLot(parkingLotId)
+}
+
+// CanAccessAllParkingLots reports whether the principal of ctx, if any, reaches every parking lot.
+func CanAccessAllParkingLots(ctx context.Context) bool {
+	principal, ok := FromContext(ctx)
+	return !ok || principal.CanAccessAllParkingLots()
+}
+
+// TenantFromContext returns the tenant of the principal of ctx. It reports false for platform admins and for
+// work done without a principal, which reach every tenant.
+func TenantFromContext(ctx context.Context) (uint, bool) {
+	principal, ok := FromContext(ctx)
+	if !ok || principal.IsPlatform() {
+		return 0, false
+	}
+	return principal.TenantID, true
+}
+
+// CanAccessTenant reports whether the principal of ctx, if any, reaches the data of a tenant.
+func CanAccessTenant(ctx context.Context, tenantId uint) bool {
+	principal, ok := FromContext(ctx)
+	return !ok || principal.CanAccessTenant(tenantId)
+}
@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRole_Can(t *testing.T) {
+	tests := []struct {
+		role       Role
+		permission Permission
+		want       bool
+	}{
+		{role: RoleKiosk, permission: PermissionExitVehicles, want: true},
+		{role: RoleKiosk, permission: PermissionLostTicketExits, want: false},
+		{role: RoleGateController, permission: PermissionPayInvoices, want: false},
+		{role: RoleAttendant, permission: PermissionLostTicketExits, want: true},
+		{role: RoleAttendant, permission: PermissionRefundPayments, want: false},
+		{role: RoleLotManager, permission: PermissionManagePricing, want: true},
+		{role: RoleLotManager, permission: PermissionAdministerLots, want: false},
+		{role: RoleFinance, permission: PermissionRefundPayments, want: true},
+		{role: RoleFinance, permission: PermissionParkVehicles, want: false},
+		{role: RoleAdmin, permission: PermissionAdministerLots, want: true},
+		{role: "janitor", permission: PermissionViewLots, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role)+" "+string(tt.permission), func(t *testing.T) {
+			if got := tt.role.Can(tt.permission); got != tt.want {
+				t.Errorf("Can() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrincipal_CanAccessParkingLot(t *testing.T) {
+	scoped := &Principal{Role: RoleAttendant, ParkingLotIDs: []int{1, 3}}
+	if !scoped.CanAccessParkingLot(3) || scoped.CanAccessParkingLot(2) || scoped.CanAccessAllParkingLots() {
+		t.Errorf("principal scoped to lots 1 and 3 reaches %+v", scoped)
+	}
+	allLots := &Principal{Role: RoleFinance, AllParkingLots: true}
+	admin := &Principal{Role: RoleAdmin}
+	if !allLots.CanAccessParkingLot(2) || !admin.CanAccessParkingLot(2) {
+		t.Errorf("principals of every lot are kept out of lot 2")
+	}
+}
+
+func TestTenantFromContext(t *testing.T) {
+	if _, ok := TenantFromContext(context.Background()); ok {
+		t.Errorf("TenantFromContext() of work without a principal has a tenant")
+	}
+	if _, ok := TenantFromContext(NewContext(context.Background(), &Principal{Role: RoleAdmin})); ok {
+		t.Errorf("TenantFromContext() of a platform admin has a tenant")
+	}
+	ctx := NewContext(context.Background(), &Principal{Role: RoleAdmin, TenantID: 2})
+	if tenantId, ok := TenantFromContext(ctx); !ok || tenantId != 2 {
+		t.Errorf("TenantFromContext() = %d, %v, want 2, true", tenantId, ok)
+	}
+	if !CanAccessTenant(ctx, 2) || CanAccessTenant(ctx, 1) {
+		t.Errorf("admin of tenant 2 reaches another tenant or not its own")
+	}
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	hash := HashAPIKey("kiosk-secret")
+	config := " kiosk-1:kiosk:1:1,2:" + hex.EncodeToString(hash[:]) + ";;gate:gate-controller:2:*:" +
+		hex.EncodeToString(hash[:]) + ";platform:admin:*:*:" + hex.EncodeToString(hash[:])
+
+	got, err := ParseAPIKeys(config)
+	if err != nil {
+		t.Fatalf("ParseAPIKeys() error = %v", err)
+	}
+	want := []APIKey{
+		{Name: "kiosk-1", Role: RoleKiosk, TenantID: 1, ParkingLotIDs: []int{1, 2}, Hash: hash},
+		{Name: "gate", Role: RoleGateController, TenantID: 2, AllParkingLots: true, Hash: hash},
+		{Name: "platform", Role: RoleAdmin, AllParkingLots: true, Hash: hash},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseAPIKeys() = %+v, want %+v", got, want)
+	}
+
+	for _, invalid := range []string{
+		"kiosk-1:kiosk:1:1",
+		"kiosk-1:kiosk:1:" + hex.EncodeToString(hash[:]),
+		"kiosk-1:janitor:1:1:" + hex.EncodeToString(hash[:]),
+		"kiosk-1:kiosk:1:one:" + hex.EncodeToString(hash[:]),
+		"kiosk-1:kiosk:0:1:" + hex.EncodeToString(hash[:]),
+		"kiosk-1:kiosk:*:1:" + hex.EncodeToString(hash[:]),
+		"kiosk-1:kiosk:1:1:not-a-hash",
+	} {
+		if _, err := ParseAPIKeys(invalid); err == nil {
+			t.Errorf("ParseAPIKeys(%q) error = nil, want an error", invalid)
+		}
+	}
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	key := []byte(strings.Repeat("k", minSigningKeyLength))
+	authenticator := NewAuthenticator(
+		[]APIKey{{Name: "kiosk-1", Role: RoleKiosk, TenantID: 1, ParkingLotIDs: []int{1},
+			Hash: HashAPIKey("kiosk-secret")}},
+		SigningKeys{"2024-03": key},
+		"parking-lot",
+	)
+	authenticator.now = func() time.Time { return now }
+
+	operator := &Claims{Subject: "asha", Issuer: "parking-lot", Role: RoleAttendant, TenantID: 1,
+		ParkingLotIDs: []int{2}, ExpiresAt: now.Add(time.Hour).Unix()}
+	token := func(keyId string, key []byte, change func(claims *Claims)) string {
+		claims := *operator
+		if change != nil {
+			change(&claims)
+		}
+		signed, err := SignToken(keyId, key, &claims)
+		if err != nil {
+			t.Fatalf("SignToken() error = %v", err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name    string
+		header  string
+		value   string
+		want    *Principal
+		wantErr bool
+	}{
+		{
+			name:   "API key",
+			header: APIKeyHeader,
+			value:  "kiosk-secret",
+			want:   &Principal{Subject: "kiosk-1", Role: RoleKiosk, TenantID: 1, ParkingLotIDs: []int{1}},
+		},
+		{
+			name:    "Unknown API key",
+			header:  APIKeyHeader,
+			value:   "guessed",
+			wantErr: true,
+		},
+		{
+			name:   "Bearer token",
+			header: "Authorization",
+			value:  "Bearer " + token("2024-03", key, nil),
+			want:   &Principal{Subject: "asha", Role: RoleAttendant, TenantID: 1, ParkingLotIDs: []int{2}},
+		},
+		{
+			name:   "Platform admin token",
+			header: "Authorization",
+			value: "Bearer " + token("2024-03", key, func(claims *Claims) {
+				claims.Role, claims.TenantID, claims.ParkingLotIDs = RoleAdmin, 0, nil
+			}),
+			want: &Principal{Subject: "asha", Role: RoleAdmin},
+		},
+		{
+			name:    "Token without a tenant",
+			header:  "Authorization",
+			value:   "Bearer " + token("2024-03", key, func(claims *Claims) { claims.TenantID = 0 }),
+			wantErr: true,
+		},
+		{
+			name:    "Expired token",
+			header:  "Authorization",
+			value:   "Bearer " + token("2024-03", key, func(claims *Claims) { claims.ExpiresAt = now.Unix() }),
+			wantErr: true,
+		},
+		{
+			name:    "Token without expiry",
+			header:  "Authorization",
+			value:   "Bearer " + token("2024-03", key, func(claims *Claims) { claims.ExpiresAt = 0 }),
+			wantErr: true,
+		},
+		{
+			name:    "Token signed by another key",
+			header:  "Authorization",
+			value:   "Bearer " + token("2024-03", []byte(strings.Repeat("x", minSigningKeyLength)), nil),
+			wantErr: true,
+		},
+		{
+			name:    "Token of an unknown key",
+			header:  "Authorization",
+			value:   "Bearer " + token("2023-12", key, nil),
+			wantErr: true,
+		},
+		{
+			name:    "Token of another issuer",
+			header:  "Authorization",
+			value:   "Bearer " + token("2024-03", key, func(claims *Claims) { claims.Issuer = "elsewhere" }),
+			wantErr: true,
+		},
+		{
+			name:    "Unsigned token",
+			header:  "Authorization",
+			value:   "Bearer eyJhbGciOiJub25lIn0.eyJzdWIiOiJhc2hhIiwicm9sZSI6ImFkbWluIiwiZXhwIjo5OTk5OTk5OTk5fQ.",
+			wantErr: true,
+		},
+		{
+			name:    "Without credentials",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/parking-lot/lots", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+
+			got, err := authenticator.Authenticate(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Authenticate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNoCredentials is returned for a request without an API key or a bearer token.
+var ErrNoCredentials = errors.New("API key or bearer token is required")
+
+// APIKeyHeader is the header kiosks and gate controllers send their API key in.
+const APIKeyHeader = "X-API-Key"
+
+// Authenticator identifies the principal of a request by its API key or its bearer token.
+type Authenticator struct {
+	apiKeys     map[[sha256.Size]byte]*Principal
+	signingKeys SigningKeys
+	issuer      string
+	now         func() time.Time
+}
+
+// NewAuthenticator returns an authenticator accepting the API keys and the tokens signed by the signing keys.
+// Tokens must be issued by the issuer, unless it is empty.
+func NewAuthenticator(apiKeys []APIKey, signingKeys SigningKeys, issuer string) *Authenticator {
+	authenticator := &Authenticator{
+		apiKeys:     make(map[[sha256.Size]byte]*Principal, len(apiKeys)),
+		signingKeys: signingKeys,
+		issuer:      issuer,
+		now:         time.Now,
+	}
+	for _, apiKey := range apiKeys {
+		authenticator.apiKeys[apiKey.Hash] = &Principal{
+			Subject:        apiKey.Name,
+			Role:           apiKey.Role,
+			TenantID:       apiKey.TenantID,
+			ParkingLotIDs:  apiKey.ParkingLotIDs,
+			AllParkingLots: apiKey.AllParkingLots,
+		}
+	}
+	return authenticator
+}
+
+// NewAuthenticatorFromEnv configures an authenticator from the AUTH_API_KEYS, AUTH_JWT_KEYS and
+// AUTH_JWT_ISSUER environment variables, see ParseAPIKeys and ParseSigningKeys for their format.
+func NewAuthenticatorFromEnv() (*Authenticator, error) {
+	apiKeys, err := ParseAPIKeys(os.Getenv("AUTH_API_KEYS"))
+	if err != nil {
+		return nil, fmt.Errorf("AUTH_API_KEYS: %w", err)
+	}
+	signingKeys, err := ParseSigningKeys(os.Getenv("AUTH_JWT_KEYS"))
+	if err != nil {
+		return nil, fmt.Errorf("AUTH_JWT_KEYS: %w", err)
+	}
+	return NewAuthenticator(apiKeys, signingKeys, os.Getenv("AUTH_JWT_ISSUER")), nil
+}
+
+// Authenticate returns the principal of a request, identified by the API key header or else by the bearer
+// token of the Authorization header.
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		principal, ok := a.apiKeys[HashAPIKey(key)]
+		if !ok {
+			return nil, errors.New("API key is unknown")
+		}
+		return principal, nil
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, ErrNoCredentials
+	}
+	claims, err := a.signingKeys.VerifyToken(strings.TrimSpace(token), a.issuer, a.now())
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{
+		Subject:        claims.Subject,
+		Role:           claims.Role,
+		TenantID:       claims.TenantID,
+		ParkingLotIDs:  claims.ParkingLotIDs,
+		AllParkingLots: claims.AllParkingLots,
+	}, nil
+}
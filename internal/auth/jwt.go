@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// minSigningKeyLength is the shortest signing key accepted, HS256 keys shorter than its hash are weak.
+const minSigningKeyLength = sha256.Size
+
+// Claims are the claims of the tokens operators authenticate with.
+type Claims struct {
+	Subject        string `json:"sub"`
+	Issuer         string `json:"iss,omitempty"`
+	Role           Role   `json:"role"`
+	TenantID       uint   `json:"tenant,omitempty"` // Omitted for platform admins
+	ParkingLotIDs  []int  `json:"lots,omitempty"`
+	AllParkingLots bool   `json:"all_lots,omitempty"`
+	ExpiresAt      int64  `json:"exp"`           // Unix time the token expires at
+	NotBefore      int64  `json:"nbf,omitempty"` // Unix time the token is valid from
+}
+
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+	KeyId     string `json:"kid,omitempty"`
+}
+
+// SigningKeys holds the keys tokens are signed with by key ID, more than one key lets keys be rotated.
+type SigningKeys map[string][]byte
+
+// ParseSigningKeys reads signing keys configured as kid:key entries separated by semicolons.
+func ParseSigningKeys(config string) (SigningKeys, error) {
+	keys := SigningKeys{}
+	for _, entry := range strings.Split(config, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		keyId, key, ok := strings.Cut(entry, ":")
+		switch {
+		case !ok || keyId == "":
+			return nil, errors.New("signing keys must be kid:key")
+		case len(key) < minSigningKeyLength:
+			return nil, fmt.Errorf("signing key %s must be at least %d bytes", keyId, minSigningKeyLength)
+		}
+		keys[keyId] = []byte(key)
+	}
+	return keys, nil
+}
+
+// SignToken signs claims with the signing key of a key ID as an HS256 JSON Web Token.
+func SignToken(keyId string, key []byte, claims *Claims) (string, error) {
+	header, err := json.Marshal(&tokenHeader{Algorithm: "HS256", Type: "JWT", KeyId: keyId})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign(key, signed)), nil
+}
+
+// VerifyToken checks the signature and the validity of an HS256 JSON Web Token and returns its claims. Tokens
+// must name their key ID unless there is a single signing key, and must expire.
+func (k SigningKeys) VerifyToken(token, issuer string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token is not a JSON Web Token")
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, errors.New("token header is malformed")
+	}
+	if header.Algorithm != "HS256" {
+		return nil, fmt.Errorf("token algorithm %q is not accepted", header.Algorithm)
+	}
+	key, ok := k[header.KeyId]
+	if header.KeyId == "" && len(k) == 1 {
+		for _, only := range k {
+			key, ok = only, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("token key %q is unknown", header.KeyId)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, sign(key, parts[0]+"."+parts[1])) {
+		return nil, errors.New("token signature is invalid")
+	}
+
+	var claims Claims
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return nil, errors.New("token claims are malformed")
+	}
+	switch {
+	case claims.ExpiresAt == 0:
+		return nil, errors.New("token does not expire")
+	case !now.Before(time.Unix(claims.ExpiresAt, 0)):
+		return nil, errors.New("token has expired")
+	case claims.NotBefore != 0 && now.Before(time.Unix(claims.NotBefore, 0)):
+		return nil, errors.New("token is not valid yet")
+	case issuer != "" && claims.Issuer != issuer:
+		return nil, fmt.Errorf("token issuer %q is not accepted", claims.Issuer)
+	case claims.Subject == "":
+		return nil, errors.New("token has no subject")
+	case !claims.Role.Valid():
+		return nil, fmt.Errorf("token role %q is unknown", claims.Role)
+	}
+	if err = validTenant(claims.Role, claims.TenantID); err != nil {
+		return nil, fmt.Errorf("token %w", err)
+	}
+	return &claims, nil
+}
+
+func sign(key []byte, signed string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(segment string, v any) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(decoded, v)
+}
@@ -30,7 +30,8 @@ func InitDB() {
 
 	// Open database connection
 	var err error
-	db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// TranslateError maps driver errors such as unique violations to gorm.ErrDuplicatedKey
+	db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatal("Error connecting to database:", err)
 	}
@@ -3,14 +3,194 @@ package migration
 import (
 	"gorm.io/gorm"
 	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/ticket"
 )
 
 func MigrateAll(db *gorm.DB) error {
+	if err := db.AutoMigrate(&models.Tenant{}); err != nil {
+		return err
+	}
+	if err := seedDefaultTenant(db); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.VehicleType{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.ParkingLot{}); err != nil {
+		return err
+	}
 	if err := db.AutoMigrate(&models.ParkingSpace{}); err != nil {
 		return err
 	}
-	if err := db.AutoMigrate(&models.ParkedVehicle{}); err != nil {
+	if err := db.AutoMigrate(&models.ParkingSession{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.Tariff{}, &models.TariffBand{}, &models.Holiday{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.Spot{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.UpsizeRule{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.Reservation{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.PassProduct{}, &models.Pass{}, &models.PassVehicle{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.Merchant{}, &models.DiscountRule{}, &models.Validation{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.ExchangeRate{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.Invoice{}, &models.Payment{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.FareQuote{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.FareAdjustment{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.LostTicketExit{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.AuditEntry{}); err != nil {
+		return err
+	}
+	if err := protectAuditEntries(db); err != nil {
 		return err
 	}
+	if err := db.AutoMigrate(&models.OutboxEvent{}); err != nil {
+		return err
+	}
+	if err := migrateParkedVehicles(db); err != nil {
+		return err
+	}
+	if err := backfillTotalSpots(db); err != nil {
+		return err
+	}
+	if err := backfillSpotVehicleTypes(db); err != nil {
+		return err
+	}
+	if err := backfillTicketNumbers(db); err != nil {
+		return err
+	}
+	return nil
+}
+
+// seedDefaultTenant creates the tenant that the rows from before tenants existed default to, and moves the
+// ID sequence of tenants past it.
+func seedDefaultTenant(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Where(models.Tenant{ID: models.DefaultTenantId}).
+			FirstOrCreate(&models.Tenant{ID: models.DefaultTenantId, Name: "Default"}).
+			Error
+		if err != nil {
+			return err
+		}
+		return tx.Exec(`SELECT setval(pg_get_serial_sequence('tenants', 'id'), (SELECT MAX(id) FROM tenants))`).
+			Error
+	})
+}
+
+// protectAuditEntries makes the database refuse to update, delete or truncate audit entries, whoever asks.
+func protectAuditEntries(db *gorm.DB) error {
+	return db.Exec(`
+		CREATE OR REPLACE FUNCTION refuse_audit_entry_changes() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit entries are append-only';
+		END;
+		$$ LANGUAGE plpgsql;
+
+		CREATE OR REPLACE TRIGGER audit_entries_append_only
+			BEFORE UPDATE OR DELETE ON audit_entries
+			FOR EACH ROW EXECUTE FUNCTION refuse_audit_entry_changes();
+
+		CREATE OR REPLACE TRIGGER audit_entries_no_truncate
+			BEFORE TRUNCATE ON audit_entries
+			FOR EACH STATEMENT EXECUTE FUNCTION refuse_audit_entry_changes();`).
+		Error
+}
+
+// migrateParkedVehicles turns the rows of the former parked_vehicles table into open parking sessions
+// and drops the table. Spot columns are only copied if the table already had them.
+func migrateParkedVehicles(db *gorm.DB) error {
+	if !db.Migrator().HasTable("parked_vehicles") {
+		return nil
+	}
+
+	columns := "vehicle_number, parking_lot_id, vehicle_type_id, vehicle_name, entry_time"
+	for _, column := range []string{"spot_id", "spot_vehicle_type_id"} {
+		if db.Migrator().HasColumn("parked_vehicles", column) {
+			columns += ", " + column
+		}
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			INSERT INTO parking_sessions (` + columns + `, status, created_at, updated_at)
+			SELECT ` + columns + `, 'open', entry_time, entry_time FROM parked_vehicles`).
+			Error
+		if err != nil {
+			return err
+		}
+		return tx.Migrator().DropTable("parked_vehicles")
+	})
+}
+
+// backfillTotalSpots derives the capacity of parking spaces created before capacity was stored
+// from their free spots and the vehicles currently parked in them.
+func backfillTotalSpots(db *gorm.DB) error {
+	return db.Exec(`
+		UPDATE parking_spaces SET total_spots = available_spots + (
+			SELECT COUNT(*) FROM parking_sessions
+			WHERE parking_sessions.parking_lot_id = parking_spaces.parking_lot_id
+			AND parking_sessions.vehicle_type_id = parking_spaces.vehicle_type_id
+			AND parking_sessions.status = 'open'
+		)
+		WHERE total_spots = 0`).
+		Error
+}
+
+// backfillSpotVehicleTypes records the own pool of vehicles parked before they could overflow into other pools.
+func backfillSpotVehicleTypes(db *gorm.DB) error {
+	return db.Exec(`
+		UPDATE parking_sessions SET spot_vehicle_type_id = vehicle_type_id
+		WHERE spot_vehicle_type_id = 0`).
+		Error
+}
+
+// backfillTicketNumbers issues ticket numbers to the sessions opened before tickets were numbered.
+func backfillTicketNumbers(db *gorm.DB) error {
+	var sessionIds []uint
+	err := db.
+		Model(&models.ParkingSession{}).
+		Where("ticket_number IS NULL OR ticket_number = ''").
+		Pluck("id", &sessionIds).
+		Error
+	if err != nil {
+		return err
+	}
+
+	for _, sessionId := range sessionIds {
+		ticketNumber, err := ticket.NewNumber()
+		if err != nil {
+			return err
+		}
+
+		err = db.
+			Model(&models.ParkingSession{}).
+			Where("id = ?", sessionId).
+			Update("ticket_number", ticketNumber).
+			Error
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -2,39 +2,92 @@ package di
 
 import (
 	"context"
+	"fmt"
 	"github.com/labstack/echo/v4"
+	"os"
+	"parking_lot_service/internal/auth"
 	"parking_lot_service/internal/database/postgresql/config"
 	"parking_lot_service/internal/database/postgresql/migration"
+	"parking_lot_service/internal/events"
 	handler2 "parking_lot_service/internal/handler"
 	"parking_lot_service/internal/repo"
 	router2 "parking_lot_service/internal/router"
+	"parking_lot_service/internal/scheduler"
 	"parking_lot_service/internal/service"
+	"parking_lot_service/internal/service/payment"
+	"time"
 )
 
 // Container struct holds references to all dependencies
 type Container struct {
-	echoInstance *echo.Echo
-	db           repo.ParkingLotRepo
+	echoInstance  *echo.Echo
+	db            repo.ParkingLotRepo
+	gateways      []payment.PaymentGateway
+	authenticator *auth.Authenticator
+	sinks         []events.Sink
 }
 
-// NewContainer initializes and returns a new Container instance
-func NewContainer() *Container {
+// NewContainer initializes and returns a new Container instance. It fails when the database cannot be prepared
+// or the configuration is invalid.
+func NewContainer() (*Container, error) {
 	config.InitDB()
 	err := migration.MigrateAll(config.GetDB())
 	if err != nil {
-		return nil
+		return nil, fmt.Errorf("error migrating the database: %w", err)
+	}
+
+	// Callers of a tenant only reach the rows of their tenant
+	err = config.GetDB().Use(repo.TenantScope{})
+	if err != nil {
+		return nil, fmt.Errorf("error registering the tenant scope: %w", err)
+	}
+
+	// Every change made through the repo is recorded in the audit log, after the tenant scope narrowed it down
+	err = config.GetDB().Use(repo.AuditLog{})
+	if err != nil {
+		return nil, fmt.Errorf("error registering the audit log: %w", err)
 	}
 
 	e := echo.New()
 	db := repo.NewParkingLotRepo(config.GetDB())
 	err = db.SeedParkingSpace(context.Background())
 	if err != nil {
-		return nil
+		return nil, fmt.Errorf("error seeding parking spaces: %w", err)
 	}
-	return &Container{
-		echoInstance: e,
-		db:           db,
+
+	// Cash is taken at the booth of every lot, the local gateway approves card payments without moving money
+	// and is only for development
+	gateways := []payment.PaymentGateway{payment.NewCash()}
+	if os.Getenv("PAYMENT_LOCAL_GATEWAY") == "true" {
+		gateways = append(gateways, payment.NewLocal())
 	}
+
+	// Kiosks and gate controllers authenticate with API keys, operators with tokens of the configured keys
+	authenticator, err := auth.NewAuthenticatorFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("error configuring authentication: %w", err)
+	}
+
+	// Domain events are delivered to the log and a webhook of the downstream systems, as configured
+	sinks, err := events.NewSinksFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("error configuring event sinks: %w", err)
+	}
+	return &Container{
+		echoInstance:  e,
+		db:            db,
+		gateways:      gateways,
+		authenticator: authenticator,
+		sinks:         sinks,
+	}, nil
+}
+
+// NewAuditVerifier opens the database and returns the service the audit log is verified with. Unlike NewContainer
+// it neither migrates nor seeds the database and needs no configuration but the database's, so the log can be
+// verified with read-only credentials.
+func NewAuditVerifier() service.ParkingLotService {
+	config.InitDB()
+	return service.NewParkingLotService(repo.NewParkingLotRepo(config.GetDB()))
 }
 
 func (c *Container) GetEchoInstance() *echo.Echo {
@@ -45,12 +98,44 @@ func (c *Container) GetParkingLotRepo() repo.ParkingLotRepo {
 	return c.db
 }
 
+func (c *Container) GetParkingLotService() service.ParkingLotService {
+	return service.NewParkingLotService(c.db, c.gateways...)
+}
+
 func (c *Container) GetHandler() handler2.ParkingLotHandler {
-	srvc := service.NewParkingLotService(c.db)
+	srvc := c.GetParkingLotService()
 	return handler2.NewParkingLotHandler(srvc)
 }
 
 func (c *Container) GetRouter() router2.Router {
 	handler := c.GetHandler()
-	return router2.NewRouter(handler)
+	return router2.NewRouter(handler, c.authenticator)
+}
+
+// GetEventDispatcher returns the dispatcher delivering the events of the outbox to the sinks, or nil when no sink
+// is configured and the events wait in the outbox
+func (c *Container) GetEventDispatcher() *events.Dispatcher {
+	if len(c.sinks) == 0 {
+		return nil
+	}
+	return events.NewDispatcher(c.db, c.sinks...)
+}
+
+// GetJobs returns the background jobs to run alongside the server
+func (c *Container) GetJobs() []scheduler.Job {
+	srvc := c.GetParkingLotService()
+	return []scheduler.Job{
+		{
+			// Repairs drift between the spots and the legacy spot counters of the parking spaces
+			Name:     "reconcile-parking-spaces",
+			Interval: 15 * time.Minute,
+			Run:      srvc.ReconcileParkingSpaces,
+		},
+		{
+			// Marks the reservations whose vehicle did not arrive within the grace period as no-shows
+			Name:     "mark-no-show-reservations",
+			Interval: time.Minute,
+			Run:      srvc.MarkNoShowReservations,
+		},
+	}
 }
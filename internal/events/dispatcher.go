@@ -0,0 +1,122 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"parking_lot_service/internal/repo/models"
+	"strings"
+	"time"
+)
+
+const (
+	batchSize    = 20               // Events claimed at a time
+	lease        = 10 * time.Minute // Time a claimed batch has to be delivered before it is claimed again
+	pollInterval = time.Second      // Wait before looking for due events again once the outbox is drained
+	minBackoff   = 5 * time.Second
+	maxBackoff   = 10 * time.Minute
+	maxErrorLen  = 500 // Length of the last error kept with an event
+)
+
+// Store is the outbox the dispatcher delivers from.
+type Store interface {
+	// ClaimOutboxEvents leases up to limit undelivered events due at now in the order they were written, so other
+	// dispatchers skip them until the lease ends, and counts the delivery attempt.
+	ClaimOutboxEvents(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.OutboxEvent, error)
+	// MarkOutboxEventDelivered records that every sink took an event.
+	MarkOutboxEventDelivered(ctx context.Context, eventId uint, deliveredAt time.Time) error
+	// RetryOutboxEvent records why the delivery of an event failed and when it is tried again.
+	RetryOutboxEvent(ctx context.Context, eventId uint, nextAttemptAt time.Time, lastError string) error
+}
+
+// Dispatcher delivers the events of the outbox to its sinks. Several dispatchers can share an outbox, each event
+// is leased to one of them at a time.
+type Dispatcher struct {
+	store Store
+	sinks []Sink
+	now   func() time.Time
+}
+
+// NewDispatcher returns a dispatcher delivering the events of store to every one of sinks.
+func NewDispatcher(store Store, sinks ...Sink) *Dispatcher {
+	return &Dispatcher{store: store, sinks: sinks, now: time.Now}
+}
+
+// Start runs the dispatcher in its own goroutine until ctx is cancelled.
+func (d *Dispatcher) Start(ctx context.Context) {
+	go d.run(ctx)
+}
+
+func (d *Dispatcher) run(ctx context.Context) {
+	for {
+		claimed, err := d.Dispatch(ctx)
+		if err != nil {
+			log.Printf("events: dispatch failed: %v", err)
+		}
+
+		// A full batch leaves more events due, look for them right away
+		wait := pollInterval
+		if err == nil && claimed == batchSize {
+			wait = 0
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Dispatch delivers one batch of due events and returns the number of events it claimed. An event one of the
+// sinks failed to take is retried later with a growing backoff, the sinks that took it see it again then.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	outboxEvents, err := d.store.ClaimOutboxEvents(ctx, d.now(), lease, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, outboxEvent := range outboxEvents {
+		if err = d.deliver(ctx, toEnvelope(outboxEvent)); err != nil {
+			log.Printf("events: delivery of event %d failed, attempt %d: %v", outboxEvent.ID, outboxEvent.Attempts, err)
+			err = d.store.RetryOutboxEvent(ctx, outboxEvent.ID, d.now().Add(Backoff(outboxEvent.Attempts)),
+				truncate(err.Error(), maxErrorLen))
+		} else {
+			err = d.store.MarkOutboxEventDelivered(ctx, outboxEvent.ID, d.now())
+		}
+		// The event is claimed again once its lease ends
+		if err != nil {
+			return len(outboxEvents), err
+		}
+	}
+	return len(outboxEvents), nil
+}
+
+// deliver hands an event to every sink and reports the sinks that failed to take it.
+func (d *Dispatcher) deliver(ctx context.Context, event *Envelope) error {
+	var errs []error
+	for _, sink := range d.sinks {
+		if err := sink.Deliver(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Backoff returns the wait before an event is tried again after its given number of failed attempts. It doubles
+// with every attempt up to a limit, events are retried until they are delivered.
+func Backoff(attempts int) time.Duration {
+	backoff := minBackoff
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxBackoff)
+}
+
+// truncate cuts s down to at most n bytes, without splitting a character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
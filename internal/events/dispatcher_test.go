@@ -0,0 +1,131 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"parking_lot_service/internal/repo/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fakeStore is an in-memory outbox.
+type fakeStore struct {
+	events map[uint]*models.OutboxEvent
+}
+
+func newFakeStore(ids ...uint) *fakeStore {
+	store := &fakeStore{events: map[uint]*models.OutboxEvent{}}
+	for _, id := range ids {
+		store.events[id] = &models.OutboxEvent{ID: id, Type: string(VehicleParked), ParkingLotId: 1, Payload: `{}`}
+	}
+	return store
+}
+
+func (f *fakeStore) ClaimOutboxEvents(_ context.Context, now time.Time, lease time.Duration,
+	limit int) ([]*models.OutboxEvent, error) {
+
+	var claimed []*models.OutboxEvent
+	for id := uint(1); id <= uint(len(f.events)) && len(claimed) < limit; id++ {
+		event := f.events[id]
+		if event.DeliveredAt == nil && !event.NextAttemptAt.After(now) {
+			event.Attempts++
+			event.NextAttemptAt = now.Add(lease)
+			eventCopy := *event
+			claimed = append(claimed, &eventCopy)
+		}
+	}
+	return claimed, nil
+}
+
+func (f *fakeStore) MarkOutboxEventDelivered(_ context.Context, eventId uint, deliveredAt time.Time) error {
+	f.events[eventId].DeliveredAt = &deliveredAt
+	return nil
+}
+
+func (f *fakeStore) RetryOutboxEvent(_ context.Context, eventId uint, nextAttemptAt time.Time, lastError string) error {
+	f.events[eventId].NextAttemptAt = nextAttemptAt
+	f.events[eventId].LastError = lastError
+	return nil
+}
+
+// fakeSink records the events it took and fails the events it is told to.
+type fakeSink struct {
+	name      string
+	delivered []uint
+	failing   map[uint]bool
+}
+
+func (f *fakeSink) Name() string {
+	return f.name
+}
+
+func (f *fakeSink) Deliver(_ context.Context, event *Envelope) error {
+	if f.failing[event.ID] {
+		return errors.New("connection refused")
+	}
+	f.delivered = append(f.delivered, event.ID)
+	return nil
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	store := newFakeStore(1, 2, 3)
+	billing := &fakeSink{name: "billing"}
+	signage := &fakeSink{name: "signage", failing: map[uint]bool{2: true}}
+
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	dispatcher := NewDispatcher(store, billing, signage)
+	dispatcher.now = func() time.Time { return now }
+
+	claimed, err := dispatcher.Dispatch(context.Background())
+	if err != nil || claimed != 3 {
+		t.Fatalf("Dispatch() = %d, %v, want 3 events claimed", claimed, err)
+	}
+	if store.events[1].DeliveredAt == nil || store.events[3].DeliveredAt == nil {
+		t.Errorf("events 1 and 3 taken by every sink are not delivered")
+	}
+
+	// An event a sink failed to take is retried after the backoff, the sinks that took it get it again
+	failed := store.events[2]
+	if failed.DeliveredAt != nil || failed.LastError != "signage: connection refused" ||
+		!failed.NextAttemptAt.Equal(now.Add(minBackoff)) {
+		t.Fatalf("failed event = %+v, want a retry in %v", failed, minBackoff)
+	}
+	if claimed, _ = dispatcher.Dispatch(context.Background()); claimed != 0 {
+		t.Errorf("Dispatch() before the backoff ended claimed %d events, want none", claimed)
+	}
+
+	now = now.Add(minBackoff)
+	delete(signage.failing, 2)
+	if claimed, _ = dispatcher.Dispatch(context.Background()); claimed != 1 {
+		t.Fatalf("Dispatch() after the backoff claimed %d events, want 1", claimed)
+	}
+	if failed.DeliveredAt == nil || failed.Attempts != 2 {
+		t.Errorf("retried event = %+v, want delivered on the second attempt", failed)
+	}
+	if want := []uint{1, 2, 3, 2}; !slices.Equal(billing.delivered, want) {
+		t.Errorf("billing took %v, want %v", billing.delivered, want)
+	}
+	if want := []uint{1, 3, 2}; !slices.Equal(signage.delivered, want) {
+		t.Errorf("signage took %v, want %v", signage.delivered, want)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 5 * time.Second},
+		{attempts: 2, want: 10 * time.Second},
+		{attempts: 5, want: 80 * time.Second},
+		{attempts: 7, want: 320 * time.Second},
+		{attempts: 8, want: maxBackoff}, // 640 seconds is past the limit
+		{attempts: 1000, want: maxBackoff},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.attempts); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
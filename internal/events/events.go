@@ -0,0 +1,118 @@
+// Package events describes the domain events the service publishes to downstream systems like billing, signage
+// and analytics, and delivers them from the outbox.
+//
+// Events are written to the outbox table in the transaction of the change they describe, so an event is only
+// published for a change that was committed and never lost for one that was. A Dispatcher delivers the events of
+// the outbox to its sinks afterwards. Delivery is at least once: an event is retried until every sink took it,
+// so a sink can see an event more than once and consumers deduplicate by its ID. A single dispatcher delivers the
+// events in the order they were written, as long as none of them has to be retried.
+package events
+
+import (
+	"encoding/json"
+	"parking_lot_service/internal/repo/models"
+	"time"
+)
+
+// Type is the kind of change an event describes.
+type Type string
+
+const (
+	VehicleParked   Type = "VehicleParked"   // Vehicle entered a lot, see VehicleParkedData
+	VehicleUnparked Type = "VehicleUnparked" // Vehicle left a lot, see VehicleUnparkedData
+	CapacityChanged Type = "CapacityChanged" // Capacity of a parking space was set, see CapacityChangedData
+	LotFull         Type = "LotFull"         // Last free spot of a parking space was taken, see AvailabilityData
+	LotAvailable    Type = "LotAvailable"    // Full parking space has a free spot again, see AvailabilityData
+)
+
+// VehicleParkedData is the data of a VehicleParked event.
+type VehicleParkedData struct {
+	TicketNumber  string    `json:"ticket_number"`
+	VehicleNumber string    `json:"vehicle_number"`
+	VehicleTypeID int       `json:"vehicle_type_id"`
+	SpotID        uint      `json:"spot_id"`
+	SpotTypeID    int       `json:"spot_vehicle_type_id"` // Pool of the spot, another type's for overflow parking
+	ReservationID uint      `json:"reservation_id,omitempty"`
+	PassID        uint      `json:"pass_id,omitempty"`
+	EntryTime     time.Time `json:"entry_time"`
+}
+
+// VehicleUnparkedData is the data of a VehicleUnparked event. The fare is in minor units of its currency.
+type VehicleUnparkedData struct {
+	TicketNumber  string    `json:"ticket_number"`
+	VehicleNumber string    `json:"vehicle_number"`
+	VehicleTypeID int       `json:"vehicle_type_id"`
+	SpotID        uint      `json:"spot_id,omitempty"`
+	EntryTime     time.Time `json:"entry_time"`
+	ExitTime      time.Time `json:"exit_time"`
+	Fare          int64     `json:"fare"`
+	Currency      string    `json:"currency"`
+	LostTicket    bool      `json:"lost_ticket"`
+}
+
+// CapacityChangedData is the data of a CapacityChanged event.
+type CapacityChangedData struct {
+	VehicleTypeID      int  `json:"vehicle_type_id"`
+	PreviousTotalSpots int  `json:"previous_total_spots"`
+	TotalSpots         int  `json:"total_spots"`
+	AvailableSpots     int  `json:"available_spots"`
+	OverCapacity       bool `json:"over_capacity"`
+}
+
+// AvailabilityData is the data of the LotFull and LotAvailable events, which tell when a parking space of a lot
+// fills up or has room again. Parking spaces are per vehicle type, so a lot is full for one type at a time.
+type AvailabilityData struct {
+	VehicleTypeID  int `json:"vehicle_type_id"`
+	TotalSpots     int `json:"total_spots"`
+	AvailableSpots int `json:"available_spots"`
+}
+
+// Envelope is an event as delivered to the sinks.
+type Envelope struct {
+	ID           uint            `json:"id"` // Same for every delivery of the event, consumers deduplicate by it
+	Type         Type            `json:"type"`
+	TenantID     uint            `json:"tenant_id"`
+	ParkingLotID int             `json:"parking_lot_id"`
+	OccurredAt   time.Time       `json:"occurred_at"`
+	Data         json.RawMessage `json:"data"`
+}
+
+// New returns the outbox row of an event of a lot that occurred at the given time. It is due right away.
+func New(eventType Type, parkingLot *models.ParkingLot, occurredAt time.Time, data any) (*models.OutboxEvent, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &models.OutboxEvent{
+		TenantId:      parkingLot.TenantId,
+		Type:          string(eventType),
+		ParkingLotId:  parkingLot.ID,
+		Payload:       string(payload),
+		OccurredAt:    occurredAt,
+		NextAttemptAt: occurredAt,
+	}, nil
+}
+
+// AvailabilityChange returns the event telling that a change of a parking space from availableBefore free spots
+// filled it up or gave it room again, if it did.
+func AvailabilityChange(availableBefore int, parkingSpace *models.ParkingSpace) (Type, bool) {
+	switch {
+	case availableBefore > 0 && parkingSpace.AvailableSpots <= 0:
+		return LotFull, true
+	case availableBefore <= 0 && parkingSpace.AvailableSpots > 0:
+		return LotAvailable, true
+	}
+	return "", false
+}
+
+// toEnvelope returns the envelope an outbox row is delivered in.
+func toEnvelope(event *models.OutboxEvent) *Envelope {
+	return &Envelope{
+		ID:           event.ID,
+		Type:         Type(event.Type),
+		TenantID:     event.TenantId,
+		ParkingLotID: event.ParkingLotId,
+		OccurredAt:   event.OccurredAt,
+		Data:         json.RawMessage(event.Payload),
+	}
+}
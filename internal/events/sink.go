@@ -0,0 +1,122 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const webhookTimeout = 10 * time.Second
+
+// Headers of the webhook requests.
+const (
+	EventIDHeader   = "X-Event-Id"
+	EventTypeHeader = "X-Event-Type"
+	SignatureHeader = "X-Event-Signature" // Hex HMAC-SHA256 of the body with the shared secret
+)
+
+// Sink is a downstream system events are delivered to. Deliver returns once the sink took the event, an error
+// makes the dispatcher try again later.
+type Sink interface {
+	Name() string
+	Deliver(ctx context.Context, event *Envelope) error
+}
+
+// NewSinksFromEnv configures the sinks from the EVENTS_LOG, EVENTS_WEBHOOK_URL and EVENTS_WEBHOOK_SECRET
+// environment variables. Without any sink the events wait in the outbox until one is configured.
+func NewSinksFromEnv() ([]Sink, error) {
+	var sinks []Sink
+	if os.Getenv("EVENTS_LOG") == "true" {
+		sinks = append(sinks, LogSink{})
+	}
+	if rawURL := os.Getenv("EVENTS_WEBHOOK_URL"); rawURL != "" {
+		sink, err := NewWebhookSink(rawURL, os.Getenv("EVENTS_WEBHOOK_SECRET"))
+		if err != nil {
+			return nil, fmt.Errorf("EVENTS_WEBHOOK_URL: %w", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+// LogSink writes events to the log of the service, for development.
+type LogSink struct{}
+
+func (LogSink) Name() string {
+	return "log"
+}
+
+func (LogSink) Deliver(_ context.Context, event *Envelope) error {
+	log.Printf("events: %s %d at lot %d: %s", event.Type, event.ID, event.ParkingLotID, event.Data)
+	return nil
+}
+
+// WebhookSink posts every event as JSON to a URL. Any status but 2xx is a failed delivery. Requests are signed
+// with the secret, if one is set, so the receiver can tell they come from the service.
+type WebhookSink struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhookSink returns a sink posting events to an http or https URL.
+func NewWebhookSink(rawURL, secret string) (*WebhookSink, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("%q is not an http or https URL", rawURL)
+	}
+	return &WebhookSink{
+		url:    rawURL,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: webhookTimeout},
+	}, nil
+}
+
+func (w *WebhookSink) Name() string {
+	return "webhook"
+}
+
+func (w *WebhookSink) Deliver(ctx context.Context, event *Envelope) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIDHeader, fmt.Sprint(event.ID))
+	req.Header.Set(EventTypeHeader, string(event.Type))
+	if len(w.secret) > 0 {
+		mac := hmac.New(sha256.New, w.secret)
+		mac.Write(body)
+		req.Header.Set(SignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body) // Lets the connection be reused
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
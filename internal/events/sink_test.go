@@ -0,0 +1,66 @@
+package events
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookSink_Deliver(t *testing.T) {
+	var (
+		status   = http.StatusNoContent
+		received *http.Request
+		body     []byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink, err := NewWebhookSink(server.URL, "s3cret")
+	if err != nil {
+		t.Fatalf("NewWebhookSink() error = %v", err)
+	}
+	event := &Envelope{ID: 42, Type: LotFull, TenantID: 1, ParkingLotID: 3,
+		OccurredAt: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC), Data: json.RawMessage(`{"vehicle_type_id":2}`)}
+
+	if err = sink.Deliver(context.Background(), event); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if received.Header.Get(EventIDHeader) != "42" || received.Header.Get(EventTypeHeader) != "LotFull" {
+		t.Errorf("Deliver() headers = %v, want the ID and type of the event", received.Header)
+	}
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	if got, want := received.Header.Get(SignatureHeader), hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Errorf("Deliver() signature = %q, want %q", got, want)
+	}
+	var delivered Envelope
+	if err = json.Unmarshal(body, &delivered); err != nil || delivered.ID != 42 ||
+		string(delivered.Data) != `{"vehicle_type_id":2}` {
+		t.Errorf("Deliver() body = %s, want the event", body)
+	}
+
+	// Any answer but 2xx is a failed delivery, which is tried again
+	status = http.StatusServiceUnavailable
+	if err = sink.Deliver(context.Background(), event); err == nil {
+		t.Errorf("Deliver() to a failing webhook error = nil")
+	}
+}
+
+func TestNewWebhookSink_RejectsInvalidURL(t *testing.T) {
+	for _, rawURL := range []string{"billing.example.com/events", "ftp://billing.example.com", "https://"} {
+		if _, err := NewWebhookSink(rawURL, ""); err == nil {
+			t.Errorf("NewWebhookSink(%q) error = nil", rawURL)
+		}
+	}
+}
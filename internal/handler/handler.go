@@ -10,6 +10,70 @@ type ParkingLotHandler interface {
 	GetParkingSpaceByParkingLotId(c echo.Context) error
 	ParkVehicle(c echo.Context) error
 	UnParkVehicle(c echo.Context) error
+	GetParkingSessions(c echo.Context) error
+	GetParkingLots(c echo.Context) error
+	GetParkingLotById(c echo.Context) error
+	CreateParkingLot(c echo.Context) error
+	UpdateParkingLot(c echo.Context) error
+	DeleteParkingLot(c echo.Context) error
+	GetVehicleTypes(c echo.Context) error
+	GetVehicleTypeById(c echo.Context) error
+	CreateVehicleType(c echo.Context) error
+	UpdateVehicleType(c echo.Context) error
+	DeleteVehicleType(c echo.Context) error
+	GetTariffs(c echo.Context) error
+	GetTariffById(c echo.Context) error
+	CreateTariff(c echo.Context) error
+	UpdateTariff(c echo.Context) error
+	DeleteTariff(c echo.Context) error
+	GetParkingLotCapacity(c echo.Context) error
+	UpdateParkingLotCapacity(c echo.Context) error
+	GetUpsizePolicy(c echo.Context) error
+	UpdateUpsizePolicy(c echo.Context) error
+	GetSpots(c echo.Context) error
+	CreateSpot(c echo.Context) error
+	UpdateSpot(c echo.Context) error
+	DeleteSpot(c echo.Context) error
+	CreateReservation(c echo.Context) error
+	GetReservationById(c echo.Context) error
+	UpdateReservation(c echo.Context) error
+	CancelReservation(c echo.Context) error
+	GetPassProducts(c echo.Context) error
+	CreatePassProduct(c echo.Context) error
+	DeletePassProduct(c echo.Context) error
+	CreatePass(c echo.Context) error
+	GetPassById(c echo.Context) error
+	UpdatePassVehicles(c echo.Context) error
+	CancelPass(c echo.Context) error
+	GetMerchants(c echo.Context) error
+	CreateMerchant(c echo.Context) error
+	GetDiscountRules(c echo.Context) error
+	CreateDiscountRule(c echo.Context) error
+	DeleteDiscountRule(c echo.Context) error
+	ValidateTicket(c echo.Context) error
+	GetHolidays(c echo.Context) error
+	CreateHoliday(c echo.Context) error
+	DeleteHoliday(c echo.Context) error
+	PayTicket(c echo.Context) error
+	GetExchangeRates(c echo.Context) error
+	SaveExchangeRate(c echo.Context) error
+	DeleteExchangeRate(c echo.Context) error
+	GetRevenueReport(c echo.Context) error
+	GetInvoiceById(c echo.Context) error
+	PayInvoice(c echo.Context) error
+	BillInvoiceToAccount(c echo.Context) error
+	RefundPayment(c echo.Context) error
+	GetQuote(c echo.Context) error
+	AdjustFare(c echo.Context) error
+	GetFareAdjustments(c echo.Context) error
+	GetPenaltyPolicy(c echo.Context) error
+	UpdatePenaltyPolicy(c echo.Context) error
+	LostTicketExit(c echo.Context) error
+	GetTenants(c echo.Context) error
+	GetTenantById(c echo.Context) error
+	CreateTenant(c echo.Context) error
+	UpdateTenant(c echo.Context) error
+	GetAuditEntries(c echo.Context) error
 }
 
 type impl struct {
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+)
+
+// @Summary Adjust the fare of a parking session
+// @Description Correct or waive the fare of a closed parking session with a reason code, the change is appended to the ledger of fare adjustments of the session. What the session paid through payment gateways over the new fare is refunded, refunds that do not go through are listed as failed
+// @ID adjust-fare
+// @Accept json
+// @Produce json
+// @Param id path integer true "Parking Session ID"
+// @Param request body model.FareAdjustmentRequest true "Fare adjustment details"
+// @Success 201 {object} model.FareAdjustmentResponse
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/sessions/{id}/adjustments [post]
+func (s *impl) AdjustFare(c echo.Context) error {
+	var (
+		ctx            = c.Request().Context()
+		req            = &model.FareAdjustmentRequest{}
+		sessionId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking session id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.AdjustFare(ctx, uint(sessionId), req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// @Summary List the fare adjustments of a parking session
+// @Description Retrieve the ledger of fare adjustments of a parking session in the order they were made, with the original and adjusted fares, who made them and the refunds made for them
+// @ID get-fare-adjustments
+// @Produce json
+// @Param id path integer true "Parking Session ID"
+// @Success 200 {array} model.FareAdjustmentResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/sessions/{id}/adjustments [get]
+func (s *impl) GetFareAdjustments(c echo.Context) error {
+	var (
+		ctx            = c.Request().Context()
+		sessionId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking session id should be a number")
+	}
+
+	resp, err := s.parkingLotSvc.GetFareAdjustments(ctx, uint(sessionId))
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+	"time"
+)
+
+// @Summary List the audit log
+// @Description Retrieve the changes recorded in the audit log newest first, filtered by entity, actor, request and time range. Callers of a tenant only see the changes of their tenant. Pass the next_cursor of a page as cursor to fetch the following page.
+// @ID get-audit-entries
+// @Param entity query string false "Table of the changed rows, e.g. parking_sessions"
+// @Param entity_id query string false "ID of the changed row"
+// @Param actor query string false "API key name or token subject of the caller"
+// @Param request_id query string false "Request ID, as returned in the X-Request-Id header"
+// @Param from query string false "Earliest time of the change, RFC 3339"
+// @Param to query string false "Time of the change before which entries are listed, RFC 3339"
+// @Param cursor query string false "Cursor of the page to fetch"
+// @Param limit query integer false "Entries per page, at most 200"
+// @Produce json
+// @Success 200 {object} model.AuditEntryPage
+// @Failure 400,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/audit-log [get]
+func (s *impl) GetAuditEntries(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req = &model.AuditEntryQuery{
+			Entity:    c.QueryParam("entity"),
+			EntityID:  c.QueryParam("entity_id"),
+			Actor:     c.QueryParam("actor"),
+			RequestID: c.QueryParam("request_id"),
+			Cursor:    c.QueryParam("cursor"),
+		}
+		err error
+	)
+
+	if param := c.QueryParam("limit"); param != "" {
+		if req.Limit, err = strconv.Atoi(param); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Limit should be a number")
+		}
+	}
+	if param := c.QueryParam("from"); param != "" {
+		from, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "From should be an RFC 3339 time")
+		}
+		req.From = &from
+	}
+	if param := c.QueryParam("to"); param != "" {
+		to, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "To should be an RFC 3339 time")
+		}
+		req.To = &to
+	}
+
+	resp, err := s.parkingLotSvc.GetAuditEntries(ctx, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
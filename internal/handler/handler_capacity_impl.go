@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+)
+
+// @Summary Get parking lot capacity
+// @Description Retrieve the capacity and occupancy of every vehicle type pool in a parking lot
+// @ID get-parking-lot-capacity
+// @Param id path integer true "Parking Lot ID"
+// @Produce json
+// @Success 200 {array} model.CapacityResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id}/capacity [get]
+func (s *impl) GetParkingLotCapacity(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+
+	resp, err := s.parkingLotSvc.GetParkingLotCapacity(ctx, parkingLotId)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Resize a vehicle type pool
+// @Description Set the number of spots of a vehicle type in a parking lot. Shrinking below the number of parked vehicles is rejected unless allow_over_capacity is set.
+// @ID update-parking-lot-capacity
+// @Accept json
+// @Produce json
+// @Param id path integer true "Parking Lot ID"
+// @Param request body model.CapacityRequest true "Capacity details"
+// @Success 200 {object} model.CapacityResponse
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id}/capacity [put]
+func (s *impl) UpdateParkingLotCapacity(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		req               = &model.CapacityRequest{}
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.UpdateParkingLotCapacity(ctx, parkingLotId, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Get the upsize policy of a parking lot
+// @Description Retrieve the vehicle types whose spots each vehicle type may overflow into once its own pool is full, and how such stays are priced
+// @ID get-upsize-policy
+// @Param id path integer true "Parking Lot ID"
+// @Produce json
+// @Success 200 {object} model.UpsizePolicyResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id}/upsize-policy [get]
+func (s *impl) GetUpsizePolicy(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+
+	resp, err := s.parkingLotSvc.GetUpsizePolicy(ctx, parkingLotId)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Replace the upsize policy of a parking lot
+// @Description Set the ordered overflow vehicle types per vehicle type and whether overflowing stays are priced by the vehicle type or the spot class
+// @ID update-upsize-policy
+// @Accept json
+// @Produce json
+// @Param id path integer true "Parking Lot ID"
+// @Param request body model.UpsizePolicyRequest true "Upsize policy"
+// @Success 200 {object} model.UpsizePolicyResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id}/upsize-policy [put]
+func (s *impl) UpdateUpsizePolicy(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		req               = &model.UpsizePolicyRequest{}
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.UpdateUpsizePolicy(ctx, parkingLotId, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
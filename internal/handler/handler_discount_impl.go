@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+)
+
+// @Summary List merchants
+// @Description Retrieve the merchants of a parking lot ordered by name
+// @ID get-merchants
+// @Param id path integer true "Parking Lot ID"
+// @Produce json
+// @Success 200 {array} model.MerchantResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id}/merchants [get]
+func (s *impl) GetMerchants(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+
+	resp, err := s.parkingLotSvc.GetMerchants(ctx, parkingLotId)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Register a merchant
+// @Description Register a merchant that validates the parking of its customers with a parking lot
+// @ID create-merchant
+// @Accept json
+// @Produce json
+// @Param id path integer true "Parking Lot ID"
+// @Param request body model.MerchantRequest true "Merchant details"
+// @Success 201 {object} model.MerchantResponse
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id}/merchants [post]
+func (s *impl) CreateMerchant(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		req               = &model.MerchantRequest{}
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.CreateMerchant(ctx, parkingLotId, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// @Summary List discount rules
+// @Description Retrieve the merchant validations and discount codes of a parking lot ordered by name
+// @ID get-discount-rules
+// @Param id path integer true "Parking Lot ID"
+// @Produce json
+// @Success 200 {array} model.DiscountRuleResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id}/discount-rules [get]
+func (s *impl) GetDiscountRules(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+
+	resp, err := s.parkingLotSvc.GetDiscountRules(ctx, parkingLotId)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Create a discount rule
+// @Description Add a percentage, fixed-amount or free-hours discount to a parking lot, issued by a merchant or applied with a discount code at exit
+// @ID create-discount-rule
+// @Accept json
+// @Produce json
+// @Param id path integer true "Parking Lot ID"
+// @Param request body model.DiscountRuleRequest true "Discount rule details"
+// @Success 201 {object} model.DiscountRuleResponse
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id}/discount-rules [post]
+func (s *impl) CreateDiscountRule(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		req               = &model.DiscountRuleRequest{}
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.CreateDiscountRule(ctx, parkingLotId, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// @Summary Delete a discount rule
+// @Description Remove a discount rule, tickets already validated with it keep their discount
+// @ID delete-discount-rule
+// @Param id path integer true "Discount Rule ID"
+// @Success 204
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/discount-rules/{id} [delete]
+func (s *impl) DeleteDiscountRule(c echo.Context) error {
+	var (
+		ctx                 = c.Request().Context()
+		discountRuleId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Discount rule id should be a number")
+	}
+
+	err = s.parkingLotSvc.DeleteDiscountRule(ctx, uint(discountRuleId))
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary Validate a ticket
+// @Description Apply one of a merchant's discount rules to an open ticket, the discount is taken off the fare when the vehicle leaves
+// @ID validate-ticket
+// @Accept json
+// @Produce json
+// @Param id path integer true "Merchant ID"
+// @Param request body model.ValidationRequest true "Validation details"
+// @Success 201 {object} model.ValidationResponse
+// @Failure 400,403,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/merchants/{id}/validations [post]
+func (s *impl) ValidateTicket(c echo.Context) error {
+	var (
+		ctx             = c.Request().Context()
+		req             = &model.ValidationRequest{}
+		merchantId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Merchant id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.ValidateTicket(ctx, uint(merchantId), req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+)
+
+// @Summary List exchange rates
+// @Description Retrieve the exchange rates used to convert revenue reports into one currency, ordered by currency pair
+// @ID get-exchange-rates
+// @Produce json
+// @Success 200 {array} model.ExchangeRateResponse
+// @Failure 500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/exchange-rates [get]
+func (s *impl) GetExchangeRates(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	resp, err := s.parkingLotSvc.GetExchangeRates(ctx)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Set an exchange rate
+// @Description Set the price of one unit of the base currency in the quote currency, replacing the rate of the pair if it has one. The opposite conversion uses the inverse rate unless its pair has a rate of its own
+// @ID save-exchange-rate
+// @Accept json
+// @Produce json
+// @Param request body model.ExchangeRateRequest true "Exchange rate details"
+// @Success 200 {object} model.ExchangeRateResponse
+// @Failure 400,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/exchange-rates [put]
+func (s *impl) SaveExchangeRate(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req = &model.ExchangeRateRequest{}
+		err = c.Bind(&req)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.SaveExchangeRate(ctx, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Delete an exchange rate
+// @Description Remove the exchange rate of a currency pair
+// @ID delete-exchange-rate
+// @Param id path integer true "Exchange Rate ID"
+// @Success 204
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/exchange-rates/{id} [delete]
+func (s *impl) DeleteExchangeRate(c echo.Context) error {
+	var (
+		ctx                 = c.Request().Context()
+		exchangeRateId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Exchange rate id should be a number")
+	}
+
+	err = s.parkingLotSvc.DeleteExchangeRate(ctx, uint(exchangeRateId))
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
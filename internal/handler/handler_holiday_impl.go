@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+)
+
+// @Summary List public holidays
+// @Description Retrieve the public holiday calendar of a parking lot ordered by date
+// @ID get-holidays
+// @Param id path integer true "Parking Lot ID"
+// @Produce json
+// @Success 200 {array} model.HolidayResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id}/holidays [get]
+func (s *impl) GetHolidays(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+
+	resp, err := s.parkingLotSvc.GetHolidays(ctx, parkingLotId)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Add a public holiday
+// @Description Add a public holiday to the calendar of a parking lot, holiday tariff bands apply on that local date
+// @ID create-holiday
+// @Accept json
+// @Produce json
+// @Param id path integer true "Parking Lot ID"
+// @Param request body model.HolidayRequest true "Holiday details"
+// @Success 201 {object} model.HolidayResponse
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id}/holidays [post]
+func (s *impl) CreateHoliday(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		req               = &model.HolidayRequest{}
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.CreateHoliday(ctx, parkingLotId, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// @Summary Delete a public holiday
+// @Description Remove a public holiday from the calendar of its parking lot
+// @ID delete-holiday
+// @Param id path integer true "Holiday ID"
+// @Success 204
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/holidays/{id} [delete]
+func (s *impl) DeleteHoliday(c echo.Context) error {
+	var (
+		ctx            = c.Request().Context()
+		holidayId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Holiday id should be a number")
+	}
+
+	err = s.parkingLotSvc.DeleteHoliday(ctx, uint(holidayId))
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
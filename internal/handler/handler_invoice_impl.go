@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+)
+
+// @Summary Get an invoice
+// @Description Get the fare a parking session owes for its stay, with the payments and refunds made against it
+// @ID get-invoice-by-id
+// @Produce json
+// @Param id path integer true "Invoice ID"
+// @Success 200 {object} model.InvoiceResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/invoices/{id} [get]
+func (s *impl) GetInvoiceById(c echo.Context) error {
+	var (
+		ctx            = c.Request().Context()
+		invoiceId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invoice id should be a number")
+	}
+
+	resp, err := s.parkingLotSvc.GetInvoiceById(ctx, uint(invoiceId))
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Pay an invoice
+// @Description Pay an invoice through a payment gateway, "cash" at the booth or a card gateway. Without an amount the balance is paid, a smaller amount pays the invoice in part. Once the invoice is paid in full the vehicle may leave. A declined payment is recorded as failed and answered with 402, an unreachable gateway with 502
+// @ID pay-invoice
+// @Accept json
+// @Produce json
+// @Param id path integer true "Invoice ID"
+// @Param request body model.PaymentRequest true "Payment details"
+// @Success 200 {object} model.InvoiceResponse
+// @Failure 400,402,404,409,500,502 {object} genericresponse.GenericResponse
+// @Router /parking-lot/invoices/{id}/payments [post]
+func (s *impl) PayInvoice(c echo.Context) error {
+	var (
+		ctx            = c.Request().Context()
+		req            = &model.PaymentRequest{}
+		invoiceId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invoice id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.PayInvoice(ctx, uint(invoiceId), req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Bill an invoice to an account
+// @Description Settle an invoice by billing what is left to pay to the account of the customer, the vehicle may then leave without paying at the lot
+// @ID bill-invoice-to-account
+// @Accept json
+// @Produce json
+// @Param id path integer true "Invoice ID"
+// @Param request body model.AccountBillingRequest true "Account to bill"
+// @Success 200 {object} model.InvoiceResponse
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/invoices/{id}/account-billing [post]
+func (s *impl) BillInvoiceToAccount(c echo.Context) error {
+	var (
+		ctx            = c.Request().Context()
+		req            = &model.AccountBillingRequest{}
+		invoiceId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invoice id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.BillInvoiceToAccount(ctx, uint(invoiceId), req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -8,13 +8,13 @@ import (
 )
 
 // @Summary Park a vehicle
-// @Description Park a vehicle in the parking lot based on the provided details
+// @Description Park a vehicle in the parking lot based on the provided details, checking it in to its reservation if one is given
 // @ID park-vehicle
 // @Accept json
 // @Produce json
 // @Param request body model.ParkVehicleRequest true "Vehicle details to park"
 // @Success 200 {object} model.ParkVehicleResponse
-// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
 // @Router /parking-lot/park-vehicle [post]
 func (s *impl) ParkVehicle(c echo.Context) error {
 	var (
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+)
+
+// @Summary List parking lots
+// @Description Retrieve every parking lot in the catalogue
+// @ID get-parking-lots
+// @Produce json
+// @Success 200 {array} model.ParkingLotResponse
+// @Failure 500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots [get]
+func (s *impl) GetParkingLots(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	resp, err := s.parkingLotSvc.GetParkingLots(ctx)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Get a parking lot
+// @Description Retrieve a parking lot from the catalogue by its ID
+// @ID get-parking-lot
+// @Param id path integer true "Parking Lot ID"
+// @Produce json
+// @Success 200 {object} model.ParkingLotResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id} [get]
+func (s *impl) GetParkingLotById(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+
+	resp, err := s.parkingLotSvc.GetParkingLotById(ctx, parkingLotId)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Create a parking lot
+// @Description Add a new parking lot to the catalogue
+// @ID create-parking-lot
+// @Accept json
+// @Produce json
+// @Param request body model.ParkingLotRequest true "Parking lot details"
+// @Success 201 {object} model.ParkingLotResponse
+// @Failure 400,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots [post]
+func (s *impl) CreateParkingLot(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req = &model.ParkingLotRequest{}
+		err = c.Bind(&req)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.CreateParkingLot(ctx, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// @Summary Update a parking lot
+// @Description Replace the name, address, timezone and status of a parking lot
+// @ID update-parking-lot
+// @Accept json
+// @Produce json
+// @Param id path integer true "Parking Lot ID"
+// @Param request body model.ParkingLotRequest true "Parking lot details"
+// @Success 200 {object} model.ParkingLotResponse
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id} [put]
+func (s *impl) UpdateParkingLot(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		req               = &model.ParkingLotRequest{}
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.UpdateParkingLot(ctx, parkingLotId, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Delete a parking lot
+// @Description Remove a parking lot and its parking spaces, only allowed when no vehicle is parked in it
+// @ID delete-parking-lot
+// @Param id path integer true "Parking Lot ID"
+// @Success 204
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id} [delete]
+func (s *impl) DeleteParkingLot(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+
+	err = s.parkingLotSvc.DeleteParkingLot(ctx, parkingLotId)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+)
+
+// @Summary List pass products
+// @Description Retrieve the pass products sold in a parking lot ordered by name
+// @ID get-pass-products
+// @Param id path integer true "Parking Lot ID"
+// @Produce json
+// @Success 200 {array} model.PassProductResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id}/pass-products [get]
+func (s *impl) GetPassProducts(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+
+	resp, err := s.parkingLotSvc.GetPassProducts(ctx, parkingLotId)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Create a pass product
+// @Description Add a weekly or monthly pass product for a vehicle type to a parking lot, optionally reserving spots for its pass holders
+// @ID create-pass-product
+// @Accept json
+// @Produce json
+// @Param id path integer true "Parking Lot ID"
+// @Param request body model.PassProductRequest true "Pass product details"
+// @Success 201 {object} model.PassProductResponse
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id}/pass-products [post]
+func (s *impl) CreatePassProduct(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		req               = &model.PassProductRequest{}
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.CreatePassProduct(ctx, parkingLotId, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// @Summary Delete a pass product
+// @Description Remove a pass product together with its expired and cancelled passes
+// @ID delete-pass-product
+// @Param id path integer true "Pass Product ID"
+// @Success 204
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/pass-products/{id} [delete]
+func (s *impl) DeletePassProduct(c echo.Context) error {
+	var (
+		ctx                = c.Request().Context()
+		passProductId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Pass product id should be a number")
+	}
+
+	err = s.parkingLotSvc.DeletePassProduct(ctx, uint(passProductId))
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary Sell a pass
+// @Description Sell a pass of a pass product to a subscriber, parking of its registered vehicles is free within the coverage of the product
+// @ID create-pass
+// @Accept json
+// @Produce json
+// @Param request body model.PassRequest true "Pass details"
+// @Success 201 {object} model.PassResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/passes [post]
+func (s *impl) CreatePass(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req = &model.PassRequest{}
+	)
+
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.CreatePass(ctx, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// @Summary Get a pass
+// @Description Retrieve a single pass with its registered vehicles by its ID
+// @ID get-pass-by-id
+// @Param id path integer true "Pass ID"
+// @Produce json
+// @Success 200 {object} model.PassResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/passes/{id} [get]
+func (s *impl) GetPassById(c echo.Context) error {
+	var (
+		ctx         = c.Request().Context()
+		passId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Pass id should be a number")
+	}
+
+	resp, err := s.parkingLotSvc.GetPassById(ctx, uint(passId))
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Replace the vehicles of a pass
+// @Description Replace the vehicle numbers registered on a pass
+// @ID update-pass-vehicles
+// @Accept json
+// @Produce json
+// @Param id path integer true "Pass ID"
+// @Param request body model.PassVehiclesRequest true "Registered vehicles"
+// @Success 200 {object} model.PassResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/passes/{id}/vehicles [put]
+func (s *impl) UpdatePassVehicles(c echo.Context) error {
+	var (
+		ctx         = c.Request().Context()
+		req         = &model.PassVehiclesRequest{}
+		passId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Pass id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.UpdatePassVehicles(ctx, uint(passId), req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Cancel a pass
+// @Description Cancel a pass, it stops covering parking right away
+// @ID cancel-pass
+// @Param id path integer true "Pass ID"
+// @Success 204
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/passes/{id} [delete]
+func (s *impl) CancelPass(c echo.Context) error {
+	var (
+		ctx         = c.Request().Context()
+		passId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Pass id should be a number")
+	}
+
+	err = s.parkingLotSvc.CancelPass(ctx, uint(passId))
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
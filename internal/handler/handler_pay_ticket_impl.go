@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+)
+
+// @Summary Pay a ticket
+// @Description Price the stay of a parked vehicle at a pay station before leaving and invoice it, with a gateway the invoice is paid right away. Merchant validations and an optional discount code are taken off the fare. Once the invoice is settled, the vehicle can leave without paying more until the exit grace period of its tariff is over, later the time since payment is charged when it is unparked. With the quote_id of a quote of the ticket that is still valid, the quoted fare is charged
+// @ID pay-ticket
+// @Accept json
+// @Produce json
+// @Param request body model.PayTicketRequest true "Ticket to pay"
+// @Success 200 {object} model.PayTicketResponse
+// @Failure 400,402,404,409,500,502 {object} genericresponse.GenericResponse
+// @Router /parking-lot/pay-ticket [post]
+func (s *impl) PayTicket(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req = &model.PayTicketRequest{}
+		err = c.Bind(&req)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.PayTicket(ctx, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+)
+
+// @Summary Refund a payment
+// @Description Pay back part or all of a payment through the gateway it was made with. Without an amount, all that has not been refunded yet is paid back. A refund does not reopen a settled invoice
+// @ID refund-payment
+// @Accept json
+// @Produce json
+// @Param id path integer true "Payment ID"
+// @Param request body model.RefundRequest true "Refund details"
+// @Success 201 {object} model.PaymentResponse
+// @Failure 400,402,404,409,500,502 {object} genericresponse.GenericResponse
+// @Router /parking-lot/payments/{id}/refunds [post]
+func (s *impl) RefundPayment(c echo.Context) error {
+	var (
+		ctx            = c.Request().Context()
+		req            = &model.RefundRequest{}
+		paymentId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Payment id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.RefundPayment(ctx, uint(paymentId), req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+)
+
+// @Summary Get the penalty policy of a parking lot
+// @Description Retrieve the lost-ticket fee, whether lost tickets are charged at least a full day, the maximum stay and the overstay surcharge of a parking lot
+// @ID get-penalty-policy
+// @Param id path integer true "Parking Lot ID"
+// @Produce json
+// @Success 200 {object} model.PenaltyPolicyResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id}/penalty-policy [get]
+func (s *impl) GetPenaltyPolicy(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+
+	resp, err := s.parkingLotSvc.GetPenaltyPolicy(ctx, parkingLotId)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Replace the penalty policy of a parking lot
+// @Description Set the penalties added to the fare of a stay that has not been paid yet. A lost ticket pays the flat lost-ticket fee and, with lost_ticket_full_day, at least the fare of a full day from entry. Stays longer than max_stay_days pay the overstay surcharge for every started day past it. Discounts do not take penalties off
+// @ID update-penalty-policy
+// @Accept json
+// @Produce json
+// @Param id path integer true "Parking Lot ID"
+// @Param request body model.PenaltyPolicyRequest true "Penalty policy"
+// @Success 200 {object} model.PenaltyPolicyResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id}/penalty-policy [put]
+func (s *impl) UpdatePenaltyPolicy(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		req               = &model.PenaltyPolicyRequest{}
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.UpdatePenaltyPolicy(ctx, parkingLotId, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Let a vehicle out without its ticket
+// @Description Unpark a vehicle by its vehicle number after an operator has checked the identity of the driver and the registration papers of the vehicle. The check is recorded with the last four characters of the identity document, and the lost-ticket penalties of the lot are added to the receipt. A fare left to pay is answered with 402 and a pending invoice, the vehicle exits when this is called again after the invoice is paid or billed to an account
+// @ID lost-ticket-exit
+// @Accept json
+// @Produce json
+// @Param request body model.LostTicketExitRequest true "Vehicle and identity of the driver"
+// @Success 200 {object} model.UnParkVehicleResponse
+// @Success 402 {object} model.UnParkVehicleResponse
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lost-ticket-exits [post]
+func (s *impl) LostTicketExit(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req = &model.LostTicketExitRequest{}
+		err = c.Bind(&req)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.LostTicketExit(ctx, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	// The vehicle stays until the invoice of its fare is settled
+	if !resp.Exited {
+		return c.JSON(http.StatusPaymentRequired, resp)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
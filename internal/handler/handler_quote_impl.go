@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+)
+
+// @Summary Quote the fare of a parked vehicle
+// @Description Price the stay of a parked vehicle as if it left now, found by its ticket number or vehicle number, without paying or changing it, and record the quote. Paying the ticket or unparking with its quote_id before valid_until charges the quoted fare
+// @ID get-quote
+// @Param ticket_number query string false "Ticket Number"
+// @Param vehicle_number query string false "Vehicle Number"
+// @Param discount_code query string false "Discount code of the parking lot"
+// @Produce json
+// @Success 200 {object} model.QuoteResponse
+// @Failure 400,402,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/quote [get]
+func (s *impl) GetQuote(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req = &model.QuoteQuery{
+			TicketNumber:  c.QueryParam("ticket_number"),
+			VehicleNumber: c.QueryParam("vehicle_number"),
+			DiscountCode:  c.QueryParam("discount_code"),
+		}
+	)
+
+	resp, err := s.parkingLotSvc.GetQuote(ctx, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+	"time"
+)
+
+// @Summary Revenue report
+// @Description Add up the gross and net fares of the parking sessions closed in a period per currency. Pass a currency to also get the totals converted into it at the configured exchange rates.
+// @ID get-revenue-report
+// @Param parking_lot_id query integer false "Parking Lot ID"
+// @Param from query string false "Earliest exit time, RFC 3339"
+// @Param to query string false "Exit time before which sessions are counted, RFC 3339"
+// @Param currency query string false "ISO 4217 code of the currency to convert the totals into"
+// @Produce json
+// @Success 200 {object} model.RevenueReportResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/reports/revenue [get]
+func (s *impl) GetRevenueReport(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req = &model.RevenueReportQuery{
+			Currency: c.QueryParam("currency"),
+		}
+		err error
+	)
+
+	if param := c.QueryParam("parking_lot_id"); param != "" {
+		if req.ParkingLotID, err = strconv.Atoi(param); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+		}
+	}
+	if param := c.QueryParam("from"); param != "" {
+		from, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "From should be an RFC 3339 time")
+		}
+		req.From = &from
+	}
+	if param := c.QueryParam("to"); param != "" {
+		to, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "To should be an RFC 3339 time")
+		}
+		req.To = &to
+	}
+
+	resp, err := s.parkingLotSvc.GetRevenueReport(ctx, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
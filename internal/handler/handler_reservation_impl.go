@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+)
+
+// @Summary Book a reservation
+// @Description Hold a spot of a vehicle type in a parking lot for a vehicle arriving in a time window. The spot is taken out of walk-in availability from the start of the window until the lot's grace period has passed
+// @ID create-reservation
+// @Accept json
+// @Produce json
+// @Param request body model.ReservationRequest true "Reservation details"
+// @Success 201 {object} model.ReservationResponse
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/reservations [post]
+func (s *impl) CreateReservation(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req = &model.ReservationRequest{}
+	)
+
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.CreateReservation(ctx, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// @Summary Get a reservation
+// @Description Retrieve a single reservation by its ID
+// @ID get-reservation-by-id
+// @Param id path integer true "Reservation ID"
+// @Produce json
+// @Success 200 {object} model.ReservationResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/reservations/{id} [get]
+func (s *impl) GetReservationById(c echo.Context) error {
+	var (
+		ctx                = c.Request().Context()
+		reservationId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Reservation id should be a number")
+	}
+
+	resp, err := s.parkingLotSvc.GetReservationById(ctx, uint(reservationId))
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Modify a reservation
+// @Description Move a booked reservation to another vehicle or time window
+// @ID update-reservation
+// @Accept json
+// @Produce json
+// @Param id path integer true "Reservation ID"
+// @Param request body model.ReservationUpdateRequest true "Reservation details"
+// @Success 200 {object} model.ReservationResponse
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/reservations/{id} [put]
+func (s *impl) UpdateReservation(c echo.Context) error {
+	var (
+		ctx                = c.Request().Context()
+		req                = &model.ReservationUpdateRequest{}
+		reservationId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Reservation id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.UpdateReservation(ctx, uint(reservationId), req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Cancel a reservation
+// @Description Cancel a booked reservation and release the spot held for it
+// @ID cancel-reservation
+// @Param id path integer true "Reservation ID"
+// @Success 204
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/reservations/{id} [delete]
+func (s *impl) CancelReservation(c echo.Context) error {
+	var (
+		ctx                = c.Request().Context()
+		reservationId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Reservation id should be a number")
+	}
+
+	err = s.parkingLotSvc.CancelReservation(ctx, uint(reservationId))
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+	"time"
+)
+
+// @Summary List parking sessions
+// @Description Retrieve the parking sessions newest first, filtered by vehicle number, parking lot, entry date range and status. Pass the next_cursor of a page as cursor to fetch the following page.
+// @ID get-parking-sessions
+// @Param vehicle_number query string false "Vehicle Number"
+// @Param parking_lot_id query integer false "Parking Lot ID"
+// @Param status query string false "Session status, open or closed"
+// @Param from query string false "Earliest entry time, RFC 3339"
+// @Param to query string false "Entry time before which sessions are listed, RFC 3339"
+// @Param cursor query string false "Cursor of the page to fetch"
+// @Param limit query integer false "Sessions per page, at most 200"
+// @Produce json
+// @Success 200 {object} model.ParkingSessionPage
+// @Failure 400,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/sessions [get]
+func (s *impl) GetParkingSessions(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req = &model.ParkingSessionQuery{
+			VehicleNumber: c.QueryParam("vehicle_number"),
+			Status:        c.QueryParam("status"),
+			Cursor:        c.QueryParam("cursor"),
+		}
+		err error
+	)
+
+	if param := c.QueryParam("parking_lot_id"); param != "" {
+		if req.ParkingLotID, err = strconv.Atoi(param); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+		}
+	}
+	if param := c.QueryParam("limit"); param != "" {
+		if req.Limit, err = strconv.Atoi(param); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Limit should be a number")
+		}
+	}
+	if param := c.QueryParam("from"); param != "" {
+		from, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "From should be an RFC 3339 time")
+		}
+		req.From = &from
+	}
+	if param := c.QueryParam("to"); param != "" {
+		to, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "To should be an RFC 3339 time")
+		}
+		req.To = &to
+	}
+
+	resp, err := s.parkingLotSvc.GetParkingSessions(ctx, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
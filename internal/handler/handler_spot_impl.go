@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+)
+
+// @Summary List spots
+// @Description Retrieve the spots of a parking lot ordered by level and label, optionally filtered by vehicle type
+// @ID get-spots
+// @Param id path integer true "Parking Lot ID"
+// @Param vehicle_id query integer false "Vehicle Type ID"
+// @Produce json
+// @Success 200 {array} model.SpotResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id}/spots [get]
+func (s *impl) GetSpots(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		vehicleTypeId     int
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+	if param := c.QueryParam("vehicle_id"); param != "" {
+		if vehicleTypeId, err = strconv.Atoi(param); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Vehicle id should be a number")
+		}
+	}
+
+	resp, err := s.parkingLotSvc.GetSpots(ctx, parkingLotId, vehicleTypeId)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Create a spot
+// @Description Add a free spot to a parking lot, the pool of its vehicle type grows by one
+// @ID create-spot
+// @Accept json
+// @Produce json
+// @Param id path integer true "Parking Lot ID"
+// @Param request body model.SpotRequest true "Spot details"
+// @Success 201 {object} model.SpotResponse
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/lots/{id}/spots [post]
+func (s *impl) CreateSpot(c echo.Context) error {
+	var (
+		ctx               = c.Request().Context()
+		req               = &model.SpotRequest{}
+		parkingLotId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.CreateSpot(ctx, parkingLotId, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// @Summary Update a spot
+// @Description Replace the level, zone, label and flags of a spot, its vehicle type cannot be changed
+// @ID update-spot
+// @Accept json
+// @Produce json
+// @Param id path integer true "Spot ID"
+// @Param request body model.SpotRequest true "Spot details"
+// @Success 200 {object} model.SpotResponse
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/spots/{id} [put]
+func (s *impl) UpdateSpot(c echo.Context) error {
+	var (
+		ctx         = c.Request().Context()
+		req         = &model.SpotRequest{}
+		spotId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Spot id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.UpdateSpot(ctx, uint(spotId), req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Delete a spot
+// @Description Remove a free spot, the pool of its vehicle type shrinks by one
+// @ID delete-spot
+// @Param id path integer true "Spot ID"
+// @Success 204
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/spots/{id} [delete]
+func (s *impl) DeleteSpot(c echo.Context) error {
+	var (
+		ctx         = c.Request().Context()
+		spotId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Spot id should be a number")
+	}
+
+	err = s.parkingLotSvc.DeleteSpot(ctx, uint(spotId))
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
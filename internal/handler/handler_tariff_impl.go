@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+)
+
+// @Summary List tariffs
+// @Description Retrieve the tariff versions, optionally filtered by parking lot and vehicle type
+// @ID get-tariffs
+// @Param parking_lot_id query integer false "Parking Lot ID"
+// @Param vehicle_id query integer false "Vehicle Type ID"
+// @Produce json
+// @Success 200 {array} model.TariffResponse
+// @Failure 400,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/tariffs [get]
+func (s *impl) GetTariffs(c echo.Context) error {
+	var (
+		ctx           = c.Request().Context()
+		parkingLotId  int
+		vehicleTypeId int
+		err           error
+	)
+
+	if param := c.QueryParam("parking_lot_id"); param != "" {
+		if parkingLotId, err = strconv.Atoi(param); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Parking lot id should be a number")
+		}
+	}
+	if param := c.QueryParam("vehicle_id"); param != "" {
+		if vehicleTypeId, err = strconv.Atoi(param); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Vehicle id should be a number")
+		}
+	}
+
+	resp, err := s.parkingLotSvc.GetTariffs(ctx, parkingLotId, vehicleTypeId)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Get a tariff
+// @Description Retrieve a tariff version by its ID
+// @ID get-tariff
+// @Param id path integer true "Tariff ID"
+// @Produce json
+// @Success 200 {object} model.TariffResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/tariffs/{id} [get]
+func (s *impl) GetTariffById(c echo.Context) error {
+	var (
+		ctx           = c.Request().Context()
+		tariffId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Tariff id should be a number")
+	}
+
+	resp, err := s.parkingLotSvc.GetTariffById(ctx, uint(tariffId))
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Create a tariff version
+// @Description Create a new tariff version for a parking lot and vehicle type, optionally with time bands charged at their own rate in the lot's local time. The current open ended version is closed when the new one becomes effective.
+// @ID create-tariff
+// @Accept json
+// @Produce json
+// @Param request body model.TariffRequest true "Tariff details"
+// @Success 201 {object} model.TariffResponse
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/tariffs [post]
+func (s *impl) CreateTariff(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req = &model.TariffRequest{}
+		err = c.Bind(&req)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.CreateTariff(ctx, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// @Summary Update tariff rates
+// @Description Replace the rates and time bands of a tariff version that is not effective yet
+// @ID update-tariff
+// @Accept json
+// @Produce json
+// @Param id path integer true "Tariff ID"
+// @Param request body model.TariffRatesRequest true "Tariff rates"
+// @Success 200 {object} model.TariffResponse
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/tariffs/{id} [put]
+func (s *impl) UpdateTariff(c echo.Context) error {
+	var (
+		ctx           = c.Request().Context()
+		req           = &model.TariffRatesRequest{}
+		tariffId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Tariff id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.UpdateTariff(ctx, uint(tariffId), req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Delete a tariff version
+// @Description Remove a tariff version that is not effective yet
+// @ID delete-tariff
+// @Param id path integer true "Tariff ID"
+// @Success 204
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/tariffs/{id} [delete]
+func (s *impl) DeleteTariff(c echo.Context) error {
+	var (
+		ctx           = c.Request().Context()
+		tariffId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Tariff id should be a number")
+	}
+
+	err = s.parkingLotSvc.DeleteTariff(ctx, uint(tariffId))
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+)
+
+// @Summary List tenants
+// @Description Retrieve every tenant for platform admins, or the tenant of the caller
+// @ID get-tenants
+// @Produce json
+// @Success 200 {array} model.TenantResponse
+// @Failure 500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/tenants [get]
+func (s *impl) GetTenants(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	resp, err := s.parkingLotSvc.GetTenants(ctx)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Get a tenant
+// @Description Retrieve a tenant by its ID, admins of a tenant only reach their own
+// @ID get-tenant
+// @Param id path integer true "Tenant ID"
+// @Produce json
+// @Success 200 {object} model.TenantResponse
+// @Failure 400,403,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/tenants/{id} [get]
+func (s *impl) GetTenantById(c echo.Context) error {
+	var (
+		ctx           = c.Request().Context()
+		tenantId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Tenant id should be a number")
+	}
+
+	resp, err := s.parkingLotSvc.GetTenantById(ctx, uint(tenantId))
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Create a tenant
+// @Description Add a property owner whose lots are run by the service, only platform admins can create tenants
+// @ID create-tenant
+// @Accept json
+// @Produce json
+// @Param request body model.TenantRequest true "Tenant details"
+// @Success 201 {object} model.TenantResponse
+// @Failure 400,403,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/tenants [post]
+func (s *impl) CreateTenant(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req = &model.TenantRequest{}
+		err = c.Bind(&req)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.CreateTenant(ctx, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// @Summary Rename a tenant
+// @Description Replace the name of a tenant, admins of a tenant only reach their own
+// @ID update-tenant
+// @Accept json
+// @Produce json
+// @Param id path integer true "Tenant ID"
+// @Param request body model.TenantRequest true "Tenant details"
+// @Success 200 {object} model.TenantResponse
+// @Failure 400,403,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/tenants/{id} [put]
+func (s *impl) UpdateTenant(c echo.Context) error {
+	var (
+		ctx           = c.Request().Context()
+		req           = &model.TenantRequest{}
+		tenantId, err = strconv.ParseUint(c.Param("id"), 10, 64)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Tenant id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.UpdateTenant(ctx, uint(tenantId), req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
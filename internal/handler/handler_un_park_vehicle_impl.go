@@ -8,13 +8,14 @@ import (
 )
 
 // @Summary Unpark a vehicle
-// @Description Remove a parked vehicle from the parking lot based on the provided details
+// @Description Remove a parked vehicle from the parking lot by the ticket number issued when it was parked. Merchant validations and an optional discount code are taken off the fare, the receipt lists the gross fare, each discount and the net amount. A paid ticket is only charged for the time past the exit grace period. A fare left to pay is answered with 402 and a pending invoice, the vehicle exits when it is unparked again after the invoice is paid or billed to an account. With the quote_id of a quote of the ticket that is still valid, the quoted fare is charged
 // @ID unpark-vehicle
 // @Accept json
 // @Produce json
-// @Param request body model.UnParkVehicleRequest true "Vehicle details to unpark"
+// @Param request body model.UnParkVehicleRequest true "Ticket to unpark"
 // @Success 200 {object} model.UnParkVehicleResponse
-// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Success 402 {object} model.UnParkVehicleResponse
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
 // @Router /parking-lot/un-park-vehicle [post]
 func (s *impl) UnParkVehicle(c echo.Context) error {
 	var (
@@ -37,5 +38,9 @@ func (s *impl) UnParkVehicle(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
 	}
 
+	// The vehicle stays until the invoice of its fare is settled
+	if !resp.Exited {
+		return c.JSON(http.StatusPaymentRequired, resp)
+	}
 	return c.JSON(http.StatusOK, resp)
 }
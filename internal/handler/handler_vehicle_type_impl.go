@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+)
+
+// @Summary List vehicle types
+// @Description Retrieve every registered vehicle type
+// @ID get-vehicle-types
+// @Produce json
+// @Success 200 {array} model.VehicleTypeResponse
+// @Failure 500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/vehicle-types [get]
+func (s *impl) GetVehicleTypes(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	resp, err := s.parkingLotSvc.GetVehicleTypes(ctx)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Get a vehicle type
+// @Description Retrieve a registered vehicle type by its ID
+// @ID get-vehicle-type
+// @Param id path integer true "Vehicle Type ID"
+// @Produce json
+// @Success 200 {object} model.VehicleTypeResponse
+// @Failure 400,404,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/vehicle-types/{id} [get]
+func (s *impl) GetVehicleTypeById(c echo.Context) error {
+	var (
+		ctx                = c.Request().Context()
+		vehicleTypeId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Vehicle type id should be a number")
+	}
+
+	resp, err := s.parkingLotSvc.GetVehicleTypeById(ctx, vehicleTypeId)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Register a vehicle type
+// @Description Register a new vehicle type, it is listed in the free parking spaces of every lot
+// @ID create-vehicle-type
+// @Accept json
+// @Produce json
+// @Param request body model.VehicleTypeRequest true "Vehicle type details"
+// @Success 201 {object} model.VehicleTypeResponse
+// @Failure 400,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/vehicle-types [post]
+func (s *impl) CreateVehicleType(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req = &model.VehicleTypeRequest{}
+		err = c.Bind(&req)
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.CreateVehicleType(ctx, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// @Summary Update a vehicle type
+// @Description Replace the code, display name, size class and upsizing flag of a vehicle type
+// @ID update-vehicle-type
+// @Accept json
+// @Produce json
+// @Param id path integer true "Vehicle Type ID"
+// @Param request body model.VehicleTypeRequest true "Vehicle type details"
+// @Success 200 {object} model.VehicleTypeResponse
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/vehicle-types/{id} [put]
+func (s *impl) UpdateVehicleType(c echo.Context) error {
+	var (
+		ctx                = c.Request().Context()
+		req                = &model.VehicleTypeRequest{}
+		vehicleTypeId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Vehicle type id should be a number")
+	}
+
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.parkingLotSvc.UpdateVehicleType(ctx, vehicleTypeId, req)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Delete a vehicle type
+// @Description Remove a vehicle type, only allowed when no lot has parking spaces for it
+// @ID delete-vehicle-type
+// @Param id path integer true "Vehicle Type ID"
+// @Success 204
+// @Failure 400,404,409,500 {object} genericresponse.GenericResponse
+// @Router /parking-lot/vehicle-types/{id} [delete]
+func (s *impl) DeleteVehicleType(c echo.Context) error {
+	var (
+		ctx                = c.Request().Context()
+		vehicleTypeId, err = strconv.Atoi(c.Param("id"))
+	)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Vehicle type id should be a number")
+	}
+
+	err = s.parkingLotSvc.DeleteVehicleType(ctx, vehicleTypeId)
+	if err != nil {
+		genericErr, ok := err.(*genericresponse.GenericResponse)
+		if ok {
+			return c.JSON(genericErr.StatusCode, genericErr)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,66 @@
+package money
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxRateDecimals is the number of decimals an exchange rate is kept to.
+const maxRateDecimals = 10
+
+// Rate is the exact price of one major unit of a currency in another currency, e.g. 0.012 USD for 1 INR.
+type Rate struct {
+	value *big.Rat
+}
+
+// ParseRate reads a positive decimal exchange rate such as "83.12" with at most 10 decimals.
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(fraction) || len(fraction) > maxRateDecimals {
+		return Rate{}, errors.New("invalid exchange rate " + strconv.Quote(s))
+	}
+
+	value, ok := new(big.Rat).SetString(s)
+	if !ok || value.Sign() <= 0 {
+		return Rate{}, errors.New("exchange rate " + strconv.Quote(s) + " must be more than zero")
+	}
+	return Rate{value: value}, nil
+}
+
+// Inverse returns the rate of the opposite conversion.
+func (r Rate) Inverse() Rate {
+	return Rate{value: new(big.Rat).Inv(r.value)}
+}
+
+// String formats the rate as a decimal with at most 10 decimals and without trailing zeros.
+func (r Rate) String() string {
+	s := r.value.FloatString(maxRateDecimals)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// Convert returns the amount in another currency at an exchange rate. The converted amount is rounded half up
+// to a minor unit of the currency it is converted to.
+func (m Money) Convert(currency string, rate Rate) Money {
+	// minor units of m -> major units of m -> major units of currency -> minor units of currency
+	value := new(big.Rat).SetInt64(m.Amount)
+	value.Mul(value, rate.value)
+	value.Mul(value, new(big.Rat).SetFrac(pow10(MinorDigits(currency)), pow10(MinorDigits(m.Currency))))
+
+	num, den := new(big.Int).Abs(value.Num()), value.Denom()
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+	if remainder.Lsh(remainder, 1).Cmp(den) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if value.Sign() < 0 {
+		quotient.Neg(quotient)
+	}
+	return Money{Amount: quotient.Int64(), Currency: currency}
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
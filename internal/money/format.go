@@ -0,0 +1,109 @@
+package money
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultLocale is the locale of the parking lots created before lots had a locale.
+const DefaultLocale = "en-IN"
+
+// locale holds how amounts are written in a locale.
+type locale struct {
+	decimal     string // Separates the minor from the major units
+	group       string // Separates the groups of digits of the major units
+	indian      bool   // Digits are grouped by three, then by two (1,23,45,678)
+	symbolAfter bool   // The symbol follows the amount, separated by a space
+}
+
+// locales holds the supported locales by BCP 47 language tag.
+var locales = map[string]locale{
+	"en-US": {decimal: ".", group: ","},
+	"en-GB": {decimal: ".", group: ","},
+	"en-IN": {decimal: ".", group: ",", indian: true},
+	"hi-IN": {decimal: ".", group: ",", indian: true},
+	"en-AE": {decimal: ".", group: ","},
+	"en-SG": {decimal: ".", group: ","},
+	"de-DE": {decimal: ",", group: ".", symbolAfter: true},
+	"es-ES": {decimal: ",", group: ".", symbolAfter: true},
+	"it-IT": {decimal: ",", group: ".", symbolAfter: true},
+	"nl-NL": {decimal: ",", group: ".", symbolAfter: true},
+	"fr-FR": {decimal: ",", group: " ", symbolAfter: true},
+	"ja-JP": {decimal: ".", group: ","},
+}
+
+// symbols holds the currency symbols written instead of the currency code.
+var symbols = map[string]string{
+	"EUR": "€",
+	"GBP": "£",
+	"INR": "₹",
+	"JPY": "¥",
+	"USD": "$",
+}
+
+// ValidLocale reports whether amounts can be formatted for a locale.
+func ValidLocale(tag string) bool {
+	_, ok := locales[tag]
+	return ok
+}
+
+// Locales returns the supported locales in alphabetical order.
+func Locales() []string {
+	tags := make([]string, 0, len(locales))
+	for tag := range locales {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Format writes the amount the way it is read in a locale, with the symbol of its currency or, for currencies
+// without a known symbol, its code, e.g. "₹1,23,456.50" for en-IN or "1.234,50 €" for de-DE. Unknown locales
+// are written as en-US.
+func (m Money) Format(tag string) string {
+	loc, ok := locales[tag]
+	if !ok {
+		loc = locales["en-US"]
+	}
+
+	decimal := m.Neg().Decimal()
+	sign := "-"
+	if m.Amount >= 0 {
+		decimal, sign = m.Decimal(), ""
+	}
+	whole, fraction, _ := strings.Cut(decimal, ".")
+
+	number := groupDigits(whole, loc)
+	if fraction != "" {
+		number += loc.decimal + fraction
+	}
+
+	symbol, ok := symbols[m.Currency]
+	switch {
+	case !ok:
+		return sign + number + " " + m.Currency
+	case loc.symbolAfter:
+		return sign + number + " " + symbol
+	}
+	return sign + symbol + number
+}
+
+// groupDigits separates the groups of digits of a whole number of major units.
+func groupDigits(whole string, loc locale) string {
+	if len(whole) <= 3 {
+		return whole
+	}
+
+	head, tail := whole[:len(whole)-3], whole[len(whole)-3:]
+	size := 3
+	if loc.indian {
+		size = 2
+	}
+	groups := []string{tail}
+	for len(head) > size {
+		groups = append([]string{head[len(head)-size:]}, groups...)
+		head = head[:len(head)-size]
+	}
+	groups = append([]string{head}, groups...)
+	return strings.Join(groups, loc.group)
+}
@@ -0,0 +1,265 @@
+// Package money represents amounts as integer minor units of an ISO 4217 currency, so that fares, receipts and
+// their totals always balance to the smallest unit of the currency.
+//
+// Amounts are never rounded implicitly. Adding, subtracting and multiplying by whole numbers is exact, the only
+// operation that can fall between two minor units is MulRatio, which takes the Rounding to apply.
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency of the amounts stored before amounts carried a currency.
+const DefaultCurrency = "INR"
+
+// Money is an amount in the minor units of a currency, e.g. 2050 INR is 20.50 rupees.
+type Money struct {
+	Amount   int64  // Minor units of the currency
+	Currency string // ISO 4217 currency code
+}
+
+// Rounding tells how an amount that falls between two minor units is rounded.
+type Rounding int
+
+const (
+	HalfUp Rounding = iota // To the nearest minor unit, halves away from zero
+	Down                   // Towards zero
+	Up                     // Away from zero
+)
+
+// minorDigits holds the currencies whose minor unit is not a hundredth of the major unit.
+var minorDigits = map[string]int{
+	"BHD": 3, "JOD": 3, "KWD": 3, "OMR": 3, "TND": 3,
+	"CLP": 0, "ISK": 0, "JPY": 0, "KRW": 0, "UGX": 0, "VND": 0,
+}
+
+// New returns an amount of minor units of a currency.
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Zero returns no money in a currency.
+func Zero(currency string) Money {
+	return Money{Currency: currency}
+}
+
+// ValidCurrency reports whether a code looks like an ISO 4217 currency code, three upper case letters.
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// MinorDigits returns the number of decimal digits of the minor unit of a currency.
+func MinorDigits(currency string) int {
+	if digits, ok := minorDigits[currency]; ok {
+		return digits
+	}
+	return 2
+}
+
+// Parse reads a decimal amount of a currency such as "20.50". The amount may not have more decimals than the
+// minor unit of the currency, it is never rounded.
+func Parse(amount, currency string) (Money, error) {
+	if !ValidCurrency(currency) {
+		return Money{}, errors.New("invalid currency code " + strconv.Quote(currency))
+	}
+
+	s := strings.TrimSpace(amount)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	whole, fraction, _ := strings.Cut(s, ".")
+
+	digits := MinorDigits(currency)
+	switch {
+	case whole == "" || !isDigits(whole) || !isDigits(fraction):
+		return Money{}, errors.New("invalid amount " + strconv.Quote(amount))
+	case len(fraction) > digits:
+		return Money{}, errors.New("amount " + strconv.Quote(amount) + " has more than " +
+			strconv.Itoa(digits) + " decimals for " + currency)
+	}
+
+	minor, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", digits-len(fraction)), 10, 64)
+	if err != nil {
+		return Money{}, errors.New("invalid amount " + strconv.Quote(amount))
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Decimal formats the amount in major units with all the decimals of the currency, e.g. "20.50".
+func (m Money) Decimal() string {
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	s := strconv.FormatInt(amount, 10)
+	digits := MinorDigits(m.Currency)
+	if digits == 0 {
+		return sign + s
+	}
+	if len(s) <= digits {
+		s = strings.Repeat("0", digits-len(s)+1) + s
+	}
+	return sign + s[:len(s)-digits] + "." + s[len(s)-digits:]
+}
+
+// String formats the amount with its currency, e.g. "20.50 INR".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsPositive reports whether the amount is more than zero.
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// IsNegative reports whether the amount is less than zero.
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns the sum of two amounts of the same currency. A zero amount without a currency takes the currency
+// of the other amount.
+func (m Money) Add(o Money) Money {
+	currency := m.sameCurrency(o)
+	return Money{Amount: m.Amount + o.Amount, Currency: currency}
+}
+
+// Sub returns the difference of two amounts of the same currency.
+func (m Money) Sub(o Money) Money {
+	currency := m.sameCurrency(o)
+	return Money{Amount: m.Amount - o.Amount, Currency: currency}
+}
+
+// Neg returns the amount with the opposite sign.
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Mul returns the amount multiplied by a whole number.
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// MulRatio returns the amount multiplied by num/den, rounded to a minor unit as given.
+func (m Money) MulRatio(num, den int64, rounding Rounding) Money {
+	if den < 0 {
+		num, den = -num, -den
+	}
+	product := m.Amount * num
+	quotient, remainder := product/den, product%den
+	if remainder != 0 {
+		away := int64(1)
+		if product < 0 {
+			away = -1
+		}
+		switch rounding {
+		case HalfUp:
+			if 2*abs(remainder) >= den {
+				quotient += away
+			}
+		case Up:
+			quotient += away
+		}
+	}
+	return Money{Amount: quotient, Currency: m.Currency}
+}
+
+// Cmp compares two amounts of the same currency, it returns -1, 0 or +1 when m is less than, equal to or more
+// than o.
+func (m Money) Cmp(o Money) int {
+	m.sameCurrency(o)
+	switch {
+	case m.Amount < o.Amount:
+		return -1
+	case m.Amount > o.Amount:
+		return 1
+	}
+	return 0
+}
+
+// Min returns the smaller of two amounts of the same currency.
+func (m Money) Min(o Money) Money {
+	if m.Cmp(o) <= 0 {
+		return m
+	}
+	return o
+}
+
+// sameCurrency returns the currency two amounts share. Combining amounts of different currencies is a
+// programming error, amounts are only ever combined within a single fare.
+func (m Money) sameCurrency(o Money) string {
+	switch {
+	case m.Currency == o.Currency:
+		return m.Currency
+	case m.Currency == "" && m.Amount == 0:
+		return o.Currency
+	case o.Currency == "" && o.Amount == 0:
+		return m.Currency
+	}
+	panic("money: amounts in different currencies " + m.Currency + " and " + o.Currency)
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// jsonMoney is the JSON encoding of an amount. The amount is a decimal string so that no client reads it
+// into a binary floating point number.
+type jsonMoney struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encodes the amount as {"amount": "20.50", "currency": "INR"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonMoney{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON decodes an amount encoded by MarshalJSON. The amount must be a string in the decimals of the
+// currency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var decoded jsonMoney
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return errors.New("money must be an object with a string amount and a currency")
+	}
+
+	parsed, err := Parse(decoded.Amount, decoded.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
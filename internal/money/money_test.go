@@ -0,0 +1,175 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{name: "Whole amount", amount: "20", currency: "INR", want: New(2000, "INR")},
+		{name: "Cents", amount: "20.5", currency: "INR", want: New(2050, "INR")},
+		{name: "Negative", amount: "-0.05", currency: "INR", want: New(-5, "INR")},
+		{name: "Currency without minor unit", amount: "1500", currency: "JPY", want: New(1500, "JPY")},
+		{name: "Three decimals", amount: "1.234", currency: "KWD", want: New(1234, "KWD")},
+		{name: "Too many decimals are not rounded", amount: "20.505", currency: "INR", wantErr: true},
+		{name: "Decimals of a currency without minor unit", amount: "1500.5", currency: "JPY", wantErr: true},
+		{name: "Not a number", amount: "20,50", currency: "INR", wantErr: true},
+		{name: "Empty", amount: "", currency: "INR", wantErr: true},
+		{name: "Invalid currency", amount: "20", currency: "inr", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.amount, tt.currency)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: New(2050, "INR"), want: "20.50"},
+		{money: New(5, "INR"), want: "0.05"},
+		{money: New(-5, "INR"), want: "-0.05"},
+		{money: New(0, "INR"), want: "0.00"},
+		{money: New(1500, "JPY"), want: "1500"},
+		{money: New(1234, "KWD"), want: "1.234"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.Decimal(); got != tt.want {
+			t.Errorf("%#v.Decimal() = %q, want %q", tt.money, got, tt.want)
+		}
+	}
+}
+
+func TestMulRatio(t *testing.T) {
+	tests := []struct {
+		name     string
+		money    Money
+		num, den int64
+		rounding Rounding
+		want     int64
+	}{
+		{name: "Exact", money: New(2000, "INR"), num: 90, den: 60, rounding: HalfUp, want: 3000},
+		{name: "Half up rounds halves away from zero", money: New(25, "INR"), num: 1, den: 2, rounding: HalfUp, want: 13},
+		{name: "Half up rounds down below a half", money: New(2000, "INR"), num: 1, den: 3, rounding: HalfUp, want: 667},
+		{name: "Down", money: New(2050, "INR"), num: 15, den: 100, rounding: Down, want: 307},
+		{name: "Up", money: New(2050, "INR"), num: 15, den: 100, rounding: Up, want: 308},
+		{name: "Negative half up", money: New(-25, "INR"), num: 1, den: 2, rounding: HalfUp, want: -13},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.money.MulRatio(tt.num, tt.den, tt.rounding)
+			if got != New(tt.want, tt.money.Currency) {
+				t.Errorf("MulRatio() = %v, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdd(t *testing.T) {
+	if got := Zero("").Add(New(150, "INR")); got != New(150, "INR") {
+		t.Errorf("Add() = %v, want 1.50 INR", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Add() of different currencies did not panic")
+		}
+	}()
+	New(100, "INR").Add(New(100, "EUR"))
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(New(2050, "INR"))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(data) != `{"amount":"20.50","currency":"INR"}` {
+		t.Errorf("Marshal() = %s", data)
+	}
+
+	var got Money
+	if err = json.Unmarshal(data, &got); err != nil || got != New(2050, "INR") {
+		t.Errorf("Unmarshal() = %v, %v, want 20.50 INR", got, err)
+	}
+	if err = json.Unmarshal([]byte(`{"amount":20.5,"currency":"INR"}`), &got); err == nil {
+		t.Errorf("Unmarshal() of a number amount did not fail")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		money  Money
+		locale string
+		want   string
+	}{
+		{money: New(12345650, "INR"), locale: "en-IN", want: "₹1,23,456.50"},
+		{money: New(12345650, "INR"), locale: "en-US", want: "₹123,456.50"},
+		{money: New(123450, "EUR"), locale: "de-DE", want: "1.234,50 €"},
+		{money: New(-500, "USD"), locale: "en-US", want: "-$5.00"},
+		{money: New(150000, "JPY"), locale: "ja-JP", want: "¥150,000"},
+		{money: New(1234, "KWD"), locale: "en-US", want: "1.234 KWD"},
+		{money: New(99, "GBP"), locale: "unknown", want: "£0.99"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.Format(tt.locale); got != tt.want {
+			t.Errorf("%v.Format(%q) = %q, want %q", tt.money, tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		money    Money
+		currency string
+		rate     string
+		inverse  bool
+		want     Money
+	}{
+		{name: "Rounded half up", money: New(10000, "INR"), currency: "USD", rate: "0.012045", want: New(120, "USD")},
+		{name: "Inverse rate", money: New(120, "USD"), currency: "INR", rate: "0.012", inverse: true, want: New(10000, "INR")},
+		{name: "Currency without minor unit", money: New(1000, "USD"), currency: "JPY", rate: "151.5", want: New(1515, "JPY")},
+		{name: "Negative", money: New(-10000, "INR"), currency: "USD", rate: "0.01205", want: New(-121, "USD")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := ParseRate(tt.rate)
+			if err != nil {
+				t.Fatalf("ParseRate() error = %v", err)
+			}
+			if tt.inverse {
+				rate = rate.Inverse()
+			}
+			if got := tt.money.Convert(tt.currency, rate); got != tt.want {
+				t.Errorf("Convert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"", "0", "-1.5", "1,5", "0.00000000001"} {
+		if _, err := ParseRate(invalid); err == nil {
+			t.Errorf("ParseRate(%q) did not fail", invalid)
+		}
+	}
+}
@@ -0,0 +1,462 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"parking_lot_service/internal/audit"
+	"parking_lot_service/internal/auth"
+	"parking_lot_service/internal/repo/models"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// auditChainLock is the class of the advisory locks that serialize appends to the audit log chain of a tenant,
+// so each entry links to the entry of its tenant written right before it. The tenant ID is the key of the lock
+// within the class.
+const auditChainLock = 7_240_024
+
+// rowsBeforeKey is the setting of a statement holding the rows it is about to change.
+const rowsBeforeKey = "audit_log:rows_before"
+
+// ErrAuditLogAppendOnly is returned for statements that would change or remove audit entries.
+var ErrAuditLogAppendOnly = errors.New("audit entries cannot be changed or removed")
+
+var auditEntryType = reflect.TypeOf(models.AuditEntry{})
+
+// outboxEventType is left out of the audit log: events only record changes that are audited themselves, and
+// the delivery bookkeeping of the outbox is not a change of the data of the service.
+var outboxEventType = reflect.TypeOf(models.OutboxEvent{})
+
+// auditBuffers holds the audit entries of each open transaction of the repo by its connection, until the
+// transaction commits.
+var auditBuffers sync.Map
+
+type auditBuffer struct {
+	entries []*models.AuditEntry
+}
+
+// AuditLog is a GORM plugin recording every row created, changed or removed through the repo in the audit log.
+// The actor and the tenant are taken from the principal of the statement context, the operation and request
+// from the operation it carries. Rows about to be changed or removed are read and locked first, so the entry
+// holds the row before and after the change.
+//
+// Entries are written in the transaction of the change. They are appended to the hash chains of their tenants
+// when the transaction commits, see transaction; a statement outside a transaction of the repo commits on its own
+// and appends its entries right away.
+type AuditLog struct{}
+
+// Name returns the name the plugin is registered by.
+func (AuditLog) Name() string {
+	return "audit_log"
+}
+
+// Initialize registers the callbacks of the plugin. Rows are read after the tenant scope narrowed the statement
+// down, and entries recorded before the default transaction of the statement commits.
+func (AuditLog) Initialize(db *gorm.DB) error {
+	const commit = "gorm:commit_or_rollback_transaction"
+
+	callbacks := db.Callback()
+	err := callbacks.Update().Before("gorm:update").After("tenant_scope:update").
+		Register("audit_log:rows_before_update", captureRowsBefore)
+	if err != nil {
+		return err
+	}
+	err = callbacks.Update().After("gorm:after_update").Before(commit).Register("audit_log:update", recordUpdate)
+	if err != nil {
+		return err
+	}
+	err = callbacks.Delete().Before("gorm:delete").After("tenant_scope:delete").
+		Register("audit_log:rows_before_delete", captureRowsBefore)
+	if err != nil {
+		return err
+	}
+	err = callbacks.Delete().After("gorm:after_delete").Before(commit).Register("audit_log:delete", recordDelete)
+	if err != nil {
+		return err
+	}
+	return callbacks.Create().After("gorm:after_create").Before(commit).Register("audit_log:create", recordCreate)
+}
+
+// transaction runs fn in a transaction, or in a savepoint when the repo already is in a transaction. The audit
+// entries of the changes fn makes are appended to the log right before the outermost transaction commits: the
+// locks of the chains are only taken once the transaction holds every row lock it needs, so a transaction waiting
+// for a chain never holds a row another transaction appending to the chain waits for.
+func (s *impl) transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	db := s.db.WithContext(ctx)
+	if value, ok := auditBuffers.Load(db.Statement.ConnPool); ok {
+		buffer := value.(*auditBuffer)
+		recorded := len(buffer.entries)
+		err := db.Transaction(fn)
+		if err != nil {
+			// The changes of the savepoint were rolled back
+			buffer.entries = buffer.entries[:recorded]
+		}
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		buffer := &auditBuffer{}
+		auditBuffers.Store(tx.Statement.ConnPool, buffer)
+		defer auditBuffers.Delete(tx.Statement.ConnPool)
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return appendAuditEntries(tx, buffer.entries)
+	})
+}
+
+// captureRowsBefore reads and locks the rows an update or delete is about to change. Audit entries themselves
+// are never changed.
+func captureRowsBefore(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return
+	}
+	if stmt.Schema.ModelType == auditEntryType {
+		_ = db.AddError(ErrAuditLogAppendOnly)
+		return
+	}
+	if stmt.Schema.ModelType == outboxEventType || stmt.Schema.PrioritizedPrimaryField == nil {
+		return
+	}
+
+	var conditions []clause.Expression
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok {
+			conditions = append(conditions, where.Exprs...)
+		}
+	}
+	// GORM only adds the primary key of the model to the statement itself
+	if ids := primaryKeys(stmt, stmt.ReflectValue); len(ids) > 0 {
+		conditions = append(conditions, clause.IN{Column: clause.PrimaryColumn, Values: ids})
+	}
+	if stmt.Dest != stmt.Model {
+		if ids := primaryKeys(stmt, reflect.ValueOf(stmt.Dest)); len(ids) > 0 {
+			conditions = append(conditions, clause.IN{Column: clause.PrimaryColumn, Values: ids})
+		}
+	}
+	if len(conditions) == 0 {
+		return // GORM refuses to change every row of a table
+	}
+
+	rows, err := auditedRows(db, conditions, true)
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("error reading rows for the audit log: %w", err))
+		return
+	}
+	stmt.Settings.Store(rowsBeforeKey, rows)
+}
+
+// recordUpdate records the rows an update changed, rows it left as they were are skipped.
+func recordUpdate(db *gorm.DB) {
+	value, ok := db.Statement.Settings.LoadAndDelete(rowsBeforeKey)
+	if !ok || db.Error != nil || db.RowsAffected == 0 {
+		return
+	}
+	before := value.([]map[string]any)
+	if len(before) == 0 {
+		return
+	}
+
+	ids := make([]any, 0, len(before))
+	for _, row := range before {
+		ids = append(ids, row[db.Statement.Schema.PrioritizedPrimaryField.DBName])
+	}
+	after, err := auditedRows(db, []clause.Expression{clause.IN{Column: clause.PrimaryColumn, Values: ids}}, false)
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("error reading rows for the audit log: %w", err))
+		return
+	}
+	afterById := make(map[string]map[string]any, len(after))
+	for _, row := range after {
+		afterById[rowId(db.Statement, row)] = row
+	}
+
+	entries := make([]*models.AuditEntry, 0, len(before))
+	for _, row := range before {
+		changed, ok := afterById[rowId(db.Statement, row)]
+		if !ok {
+			continue
+		}
+		entry, err := newAuditEntry(db, models.AuditActionUpdate, row, changed)
+		if err != nil {
+			_ = db.AddError(err)
+			return
+		}
+		if entry.Before != entry.After {
+			entries = append(entries, entry)
+		}
+	}
+	recordAuditEntries(db, entries)
+}
+
+// recordDelete records the rows a delete removed.
+func recordDelete(db *gorm.DB) {
+	value, ok := db.Statement.Settings.LoadAndDelete(rowsBeforeKey)
+	if !ok || db.Error != nil || db.RowsAffected == 0 {
+		return
+	}
+	before := value.([]map[string]any)
+
+	entries := make([]*models.AuditEntry, 0, len(before))
+	for _, row := range before {
+		entry, err := newAuditEntry(db, models.AuditActionDelete, row, nil)
+		if err != nil {
+			_ = db.AddError(err)
+			return
+		}
+		entries = append(entries, entry)
+	}
+	recordAuditEntries(db, entries)
+}
+
+// recordCreate records the rows a create inserted, as the database stored them. The rows an upsert replaced
+// are recorded with what they became.
+func recordCreate(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || db.RowsAffected == 0 || stmt.Schema == nil || stmt.Schema.ModelType == auditEntryType ||
+		stmt.Schema.ModelType == outboxEventType || stmt.Schema.PrioritizedPrimaryField == nil {
+		return
+	}
+	ids := primaryKeys(stmt, stmt.ReflectValue)
+	if len(ids) == 0 {
+		return
+	}
+
+	after, err := auditedRows(db, []clause.Expression{clause.IN{Column: clause.PrimaryColumn, Values: ids}}, false)
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("error reading rows for the audit log: %w", err))
+		return
+	}
+
+	action := models.AuditActionCreate
+	if c, ok := stmt.Clauses["ON CONFLICT"]; ok {
+		if onConflict, ok := c.Expression.(clause.OnConflict); ok && !onConflict.DoNothing {
+			action = models.AuditActionUpsert
+		}
+	}
+	entries := make([]*models.AuditEntry, 0, len(after))
+	for _, row := range after {
+		entry, err := newAuditEntry(db, action, nil, row)
+		if err != nil {
+			_ = db.AddError(err)
+			return
+		}
+		entries = append(entries, entry)
+	}
+	recordAuditEntries(db, entries)
+}
+
+// recordAuditEntries holds entries back until the transaction of the repo the statement runs in commits, or
+// appends them right away to the default transaction of a statement on its own.
+func recordAuditEntries(db *gorm.DB, entries []*models.AuditEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	if value, ok := auditBuffers.Load(db.Statement.ConnPool); ok {
+		buffer := value.(*auditBuffer)
+		buffer.entries = append(buffer.entries, entries...)
+		return
+	}
+	if err := appendAuditEntries(db, entries); err != nil {
+		_ = db.AddError(err)
+	}
+}
+
+// appendAuditEntries links entries to the end of the hash chains of their tenants and writes them. The chains
+// stay locked until the transaction ends: transactions of one tenant that change audited rows commit one at a
+// time from their append on, which bounds the write throughput of a tenant rather than of the whole service.
+// Chains are locked in the order of their tenants, so transactions appending to several chains cannot deadlock.
+func appendAuditEntries(db *gorm.DB, entries []*models.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	// The log is shared by all tenants, the chains are read and written without a principal
+	tx := db.Session(&gorm.Session{NewDB: true, Context: context.Background()})
+
+	heads := map[uint]string{}
+	for _, entry := range entries {
+		heads[entry.TenantId] = ""
+	}
+	tenantIds := make([]uint, 0, len(heads))
+	for tenantId := range heads {
+		tenantIds = append(tenantIds, tenantId)
+	}
+	slices.Sort(tenantIds)
+
+	for _, tenantId := range tenantIds {
+		err := tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", auditChainLock, int32(tenantId)).Error
+		if err != nil {
+			return fmt.Errorf("error locking the audit log of tenant %d: %w", tenantId, err)
+		}
+
+		var head []string
+		err = tx.
+			Model(&models.AuditEntry{}).
+			Where("tenant_id = ?", tenantId).
+			Order("id DESC").
+			Limit(1).
+			Pluck("hash", &head).
+			Error
+		if err != nil {
+			return fmt.Errorf("error reading the head of the audit log of tenant %d: %w", tenantId, err)
+		}
+		if len(head) > 0 {
+			heads[tenantId] = head[0]
+		}
+	}
+
+	for _, entry := range entries {
+		entry.PrevHash = heads[entry.TenantId]
+		entry.Hash = audit.Hash(entry)
+		heads[entry.TenantId] = entry.Hash
+	}
+
+	err := tx.
+		CreateInBatches(entries, 100).
+		Error
+	if err != nil {
+		return fmt.Errorf("error appending to the audit log: %w", err)
+	}
+	return nil
+}
+
+// auditedRows reads the rows of the table of a statement matching conditions, optionally locking them. The
+// conditions of the statement are already narrowed down to the tenant of its caller, the rows are read without
+// a principal so they are not narrowed down twice.
+func auditedRows(db *gorm.DB, conditions []clause.Expression, lock bool) ([]map[string]any, error) {
+	stmt := db.Statement
+	query := db.
+		Session(&gorm.Session{NewDB: true, Context: context.Background()}).
+		Model(reflect.New(stmt.Schema.ModelType).Interface()).
+		Table(stmt.Table).
+		Clauses(clause.Where{Exprs: conditions}).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: stmt.Schema.PrioritizedPrimaryField.DBName}})
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var rows []map[string]any
+	err := query.
+		Find(&rows).
+		Error
+	return rows, err
+}
+
+// newAuditEntry describes the change of a row by the caller of a statement.
+func newAuditEntry(db *gorm.DB, action models.AuditAction, before, after map[string]any) (*models.AuditEntry, error) {
+	stmt := db.Statement
+	row := after
+	if row == nil {
+		row = before
+	}
+
+	entry := &models.AuditEntry{
+		Actor:    "system",
+		Action:   action,
+		Entity:   stmt.Table,
+		EntityId: rowId(stmt, row),
+		TenantId: tenantOfRow(db, row),
+		// The database keeps microseconds, the hash must cover the time as it is read back
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if principal, ok := auth.FromContext(stmt.Context); ok {
+		entry.Actor = principal.Subject
+		entry.Role = string(principal.Role)
+	}
+	if operation, ok := audit.FromContext(stmt.Context); ok {
+		entry.Operation = operation.Name
+		entry.RequestId = operation.RequestID
+	}
+
+	var err error
+	if entry.Before, err = rowJSON(before); err != nil {
+		return nil, err
+	}
+	if entry.After, err = rowJSON(after); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// tenantOfRow returns the tenant a changed row belongs to: its own tenant, else the tenant of the caller, else
+// the tenant of its parking lot. Rows shared by all tenants belong to none.
+func tenantOfRow(db *gorm.DB, row map[string]any) uint {
+	stmt := db.Statement
+	switch {
+	case stmt.Schema.ModelType == tenantType:
+		return uintOf(row[stmt.Schema.PrioritizedPrimaryField.DBName])
+	case row["tenant_id"] != nil:
+		return uintOf(row["tenant_id"])
+	}
+	if tenantId, ok := auth.TenantFromContext(stmt.Context); ok {
+		return tenantId
+	}
+	if parkingLotId, ok := row["parking_lot_id"]; ok {
+		var tenantIds []uint
+		db.
+			Session(&gorm.Session{NewDB: true}).
+			Model(&models.ParkingLot{}).
+			Where("id = ?", parkingLotId).
+			Pluck("tenant_id", &tenantIds)
+		if len(tenantIds) > 0 {
+			return tenantIds[0]
+		}
+	}
+	return 0
+}
+
+// primaryKeys returns the non-zero primary keys of the rows of the model of a statement held by value.
+func primaryKeys(stmt *gorm.Statement, value reflect.Value) []any {
+	var ids []any
+	collect := func(row reflect.Value) {
+		row = reflect.Indirect(row)
+		if row.Kind() != reflect.Struct || row.Type() != stmt.Schema.ModelType {
+			return
+		}
+		if id, zero := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, row); !zero {
+			ids = append(ids, id)
+		}
+	}
+
+	value = reflect.Indirect(value)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			collect(value.Index(i))
+		}
+	default:
+		collect(value)
+	}
+	return ids
+}
+
+// rowId returns the primary key of a row read by auditedRows.
+func rowId(stmt *gorm.Statement, row map[string]any) string {
+	return fmt.Sprint(row[stmt.Schema.PrioritizedPrimaryField.DBName])
+}
+
+// rowJSON encodes a row read by auditedRows, or returns empty for no row.
+func rowJSON(row map[string]any) (string, error) {
+	if row == nil {
+		return "", nil
+	}
+	content, err := json.Marshal(row)
+	if err != nil {
+		return "", fmt.Errorf("error encoding row for the audit log: %w", err)
+	}
+	return string(content), nil
+}
+
+func uintOf(value any) uint {
+	id, _ := strconv.ParseUint(fmt.Sprint(value), 10, 64)
+	return uint(id)
+}
@@ -0,0 +1,116 @@
+//go:build integration
+
+package repo
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"os"
+	"parking_lot_service/internal/audit"
+	"parking_lot_service/internal/auth"
+	"parking_lot_service/internal/database/postgresql/migration"
+	"parking_lot_service/internal/repo/models"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newPostgresRepo returns a repo on the database of TEST_DATABASE_DSN with the plugins of the service, and
+// skips the test without one.
+func newPostgresRepo(t *testing.T) (ParkingLotRepo, *gorm.DB) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	if err = migration.MigrateAll(db); err != nil {
+		t.Fatalf("MigrateAll() error = %v", err)
+	}
+	for _, plugin := range []gorm.Plugin{TenantScope{}, AuditLog{}} {
+		if err = db.Use(plugin); err != nil {
+			t.Fatalf("Use(%s) error = %v", plugin.Name(), err)
+		}
+	}
+	return NewParkingLotRepo(db), db
+}
+
+func TestAuditLog_ChainsStayIntactUnderConcurrentWriters(t *testing.T) {
+	const (
+		writers = 8
+		renames = 10
+	)
+	r, db := newPostgresRepo(t)
+	ctx := auth.NewContext(context.Background(), &auth.Principal{Subject: "admin-1", Role: auth.RoleAdmin})
+
+	var before int64
+	if err := db.Model(&models.AuditEntry{}).Count(&before).Error; err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+
+	run := time.Now().UnixNano()
+	tenants := make([]*models.Tenant, 2)
+	for i := range tenants {
+		tenants[i] = &models.Tenant{Name: fmt.Sprintf("audit-%d-%d", run, i)}
+		if err := r.CreateTenant(ctx, tenants[i]); err != nil {
+			t.Fatalf("CreateTenant() error = %v", err)
+		}
+	}
+
+	// Writers rename a tenant each or both in one transaction, which appends to two chains
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*renames)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < renames; i++ {
+				name := fmt.Sprintf("audit-%d-%d-%d", run, w, i)
+				var err error
+				if w%4 == 3 {
+					err = r.WithTx(ctx, func(txRepo ParkingLotRepo) error {
+						for j, tenant := range tenants {
+							renamed := &models.Tenant{ID: tenant.ID, Name: fmt.Sprintf("%s-%d", name, j)}
+							if err := txRepo.UpdateTenant(ctx, renamed); err != nil {
+								return err
+							}
+						}
+						return nil
+					})
+				} else {
+					err = r.UpdateTenant(ctx, &models.Tenant{ID: tenants[w%2].ID, Name: name})
+				}
+				errs <- err
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("rename error = %v", err)
+		}
+	}
+	written := int64(len(tenants)) + writers*renames + (writers/4)*renames
+
+	var entries []*models.AuditEntry
+	if err := db.Order("id").Find(&entries).Error; err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	var verifier audit.Verifier
+	for _, entry := range entries {
+		if err := verifier.Check(entry); err != nil {
+			t.Fatalf("Check() error = %v", err)
+		}
+	}
+	if got := int64(verifier.Checked()); got != before+written {
+		t.Errorf("entries checked = %d, want %d", got, before+written)
+	}
+}
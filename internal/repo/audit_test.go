@@ -0,0 +1,114 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"parking_lot_service/internal/auth"
+	"parking_lot_service/internal/repo/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditLog_LocksRowsBeforeChange(t *testing.T) {
+	tenant := auth.NewContext(context.Background(), &auth.Principal{Role: auth.RoleLotManager, TenantID: 2})
+
+	tests := []struct {
+		name string
+		run  func(ctx context.Context, r ParkingLotRepo) error
+		want []string // Parts the read of the rows before the change must have
+	}{
+		{
+			name: "Update of a lot",
+			run: func(ctx context.Context, r ParkingLotRepo) error {
+				return r.UpdateParkingLot(ctx, &models.ParkingLot{ID: 7, Name: "Mall"})
+			},
+			want: []string{`SELECT * FROM "parking_lots"`, `id = 7`, `"parking_lots"."tenant_id" = 2`, `FOR UPDATE`},
+		},
+		{
+			name: "Delete of a holiday",
+			run: func(ctx context.Context, r ParkingLotRepo) error {
+				return r.DeleteHoliday(ctx, 4)
+			},
+			want: []string{`SELECT * FROM "holidays"`, `4`, `"holidays"."parking_lot_id" IN`, `FOR UPDATE`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, log := newDryRunDB(t, TenantScope{}, AuditLog{})
+			_ = tt.run(tenant, NewParkingLotRepo(db))
+
+			if len(log.statements) != 2 {
+				t.Fatalf("statements = %q, want the read of the rows and the change", log.statements)
+			}
+			for _, part := range tt.want {
+				if !strings.Contains(log.statements[0], part) {
+					t.Errorf("read of the rows %s does not have %s", log.statements[0], part)
+				}
+			}
+		})
+	}
+}
+
+func TestAuditLog_EntriesAreAppendOnly(t *testing.T) {
+	db, log := newDryRunDB(t, AuditLog{})
+
+	err := db.Model(&models.AuditEntry{}).Where("id = ?", 1).Update("after", "{}").Error
+	if !errors.Is(err, ErrAuditLogAppendOnly) {
+		t.Errorf("update of an audit entry error = %v, want %v", err, ErrAuditLogAppendOnly)
+	}
+	err = db.Where("id = ?", 1).Delete(&models.AuditEntry{}).Error
+	if !errors.Is(err, ErrAuditLogAppendOnly) {
+		t.Errorf("delete of an audit entry error = %v, want %v", err, ErrAuditLogAppendOnly)
+	}
+	if len(log.statements) != 0 {
+		t.Errorf("statements = %q, want none", log.statements)
+	}
+}
+
+func TestAuditLog_SkipsOutbox(t *testing.T) {
+	db, log := newDryRunDB(t, AuditLog{})
+	r := NewParkingLotRepo(db)
+
+	_ = r.RetryOutboxEvent(context.Background(), 3, time.Now(), "webhook answered 503 Service Unavailable")
+	_ = r.MarkOutboxEventDelivered(context.Background(), 3, time.Now())
+
+	if len(log.statements) != 2 || !strings.HasPrefix(log.statements[0], `UPDATE "outbox_events"`) {
+		t.Errorf("statements = %q, want the changes of the outbox only", log.statements)
+	}
+}
+
+func TestAppendAuditEntries_ChainsPerTenant(t *testing.T) {
+	db, log := newDryRunDB(t)
+	entries := []*models.AuditEntry{
+		{TenantId: 2, Action: models.AuditActionUpdate, Entity: "spots", EntityId: "7"},
+		{TenantId: 1, Action: models.AuditActionUpdate, Entity: "spots", EntityId: "3"},
+		{TenantId: 2, Action: models.AuditActionUpdate, Entity: "spots", EntityId: "8"},
+	}
+
+	if err := appendAuditEntries(db, entries); err != nil {
+		t.Fatalf("appendAuditEntries() error = %v", err)
+	}
+
+	// Chains are locked in the order of their tenants, each before its head is read
+	want := []string{
+		`SELECT pg_advisory_xact_lock(7240024, 1)`,
+		`SELECT "hash" FROM "audit_entries" WHERE tenant_id = 1 ORDER BY id DESC LIMIT 1`,
+		`SELECT pg_advisory_xact_lock(7240024, 2)`,
+		`SELECT "hash" FROM "audit_entries" WHERE tenant_id = 2 ORDER BY id DESC LIMIT 1`,
+		`INSERT INTO "audit_entries"`,
+	}
+	if len(log.statements) != len(want) {
+		t.Fatalf("statements = %q, want %d", log.statements, len(want))
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(log.statements[i], prefix) {
+			t.Errorf("statement %d = %s, want %s", i, log.statements[i], prefix)
+		}
+	}
+
+	// Entries link to the entry of their tenant before them only
+	if entries[0].PrevHash != "" || entries[1].PrevHash != "" || entries[2].PrevHash != entries[0].Hash {
+		t.Errorf("entries = %+v, want the entries of tenant 2 chained", entries)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build integration
+
+package repo
+
+// NewPostgresRepo lets the integration tests of the repo_test package, which drive the repo through the
+// service, open the same database as the tests of this package.
+var NewPostgresRepo = newPostgresRepo
@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"testing"
+)
+
+// fakeConn is a connection that runs no statement. It lets dry-run databases begin, commit and roll back
+// transactions, and reports every statement it executes outside of a dry run to affect rowsAffected rows.
+type fakeConn struct {
+	rowsAffected int64
+}
+
+var errFakeConn = errors.New("fake connection runs no statement")
+
+func (c *fakeConn) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errFakeConn
+}
+
+func (c *fakeConn) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	return driver.RowsAffected(c.rowsAffected), nil
+}
+
+func (c *fakeConn) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, errFakeConn
+}
+
+func (c *fakeConn) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return nil
+}
+
+func (c *fakeConn) BeginTx(context.Context, *sql.TxOptions) (gorm.ConnPool, error) {
+	return &fakeTx{fakeConn: c}, nil
+}
+
+// fakeTx is a transaction begun on a fakeConn.
+type fakeTx struct {
+	*fakeConn
+}
+
+func (tx *fakeTx) Commit() error {
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	return nil
+}
+
+// openFakeDB returns a database with the given plugins on conn, and the log of the statements. A dry run builds
+// the statements without executing them.
+func openFakeDB(t *testing.T, conn *fakeConn, dryRun bool, plugins ...gorm.Plugin) (*gorm.DB, *statementLog) {
+	t.Helper()
+
+	log := &statementLog{Interface: logger.Discard}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{
+		DryRun:                 dryRun,
+		SkipDefaultTransaction: true,
+		Logger:                 log,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	for _, plugin := range plugins {
+		if err = db.Use(plugin); err != nil {
+			t.Fatalf("Use(%s) error = %v", plugin.Name(), err)
+		}
+	}
+	return db, log
+}
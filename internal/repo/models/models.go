@@ -1,35 +1,648 @@
 package models
 
-import "time"
+import (
+	"parking_lot_service/internal/money"
+	"time"
+)
+
+// DefaultTenantId is the tenant the lots, parking spaces, sessions and tariffs that predate tenants belong to.
+const DefaultTenantId = 1
 
-// ParkingLot represents different types of parking lots.
-type ParkingLot int
+// Tenant represents a property owner whose lots are run by the service. Tenants never see each other's data.
+type Tenant struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"type:varchar(150);not null;uniqueIndex"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ParkingLotStatus represents the operational state of a parking lot.
+type ParkingLotStatus string
 
 const (
-	ParkingLotA ParkingLot = 1
-	ParkingLotB ParkingLot = 2
+	ParkingLotStatusActive ParkingLotStatus = "active" // Lot accepts new vehicles
+	ParkingLotStatusClosed ParkingLotStatus = "closed" // Lot only lets parked vehicles leave
 )
 
-// VehicleType represents different types of vehicles.
-type VehicleType int
+// UpsizePricing selects the tariff a vehicle pays for when it was parked on a spot of a larger vehicle type.
+type UpsizePricing string
+
+const (
+	UpsizePricingVehicleType UpsizePricing = "vehicle-type" // Tariff of the vehicle's own type
+	UpsizePricingSpotClass   UpsizePricing = "spot-class"   // Tariff of the vehicle type whose spot was used
+)
+
+// ParkingLot represents a parking site in the catalogue.
+type ParkingLot struct {
+	ID                      int              `gorm:"primaryKey"`
+	TenantId                uint             `gorm:"not null;default:1;index"`
+	Name                    string           `gorm:"type:varchar(150);not null;uniqueIndex"`
+	Address                 string           `gorm:"type:varchar(255)"`
+	Timezone                string           `gorm:"type:varchar(64);not null;default:'UTC'"` // IANA time zone name, e.g. Asia/Kolkata
+	Status                  ParkingLotStatus `gorm:"type:varchar(20);not null;default:'active'"`
+	AllocationStrategy      string           `gorm:"type:varchar(50);not null;default:'level-by-level'"` // Picks the spot of an arriving vehicle
+	UpsizePricing           UpsizePricing    `gorm:"type:varchar(20);not null;default:'vehicle-type'"`
+	ReservationGraceMinutes int              `gorm:"not null;default:15"`                                // Minutes a reservation's spot is held after its start time
+	MaxDiscountPercent      int              `gorm:"not null;default:100"`                               // Share of a fare that discounts may take off in total
+	Currency                string           `gorm:"type:char(3);not null;default:'INR'"`                // ISO 4217 code of the fares charged in the lot
+	Locale                  string           `gorm:"type:varchar(35);not null;default:'en-IN'"`          // BCP 47 tag receipts are formatted for
+	LostTicketFee           int64            `gorm:"column:lost_ticket_fee_minor;not null;default:0"`    // Flat fee for leaving without the ticket
+	LostTicketFullDay       bool             `gorm:"not null;default:false"`                             // A lost ticket pays at least the fare of a full day
+	MaxStayDays             int              `gorm:"not null;default:0"`                                 // Longest stay without a surcharge, 0 for no limit
+	OverstaySurcharge       int64            `gorm:"column:overstay_surcharge_minor;not null;default:0"` // Charged for every started day past the longest stay
+	CreatedAt               time.Time
+	UpdatedAt               time.Time
+}
 
+// Size classes of the vehicle types seeded on an empty database. Size classes are ordered,
+// a vehicle fits into any spot whose size class is equal to or larger than its own.
 const (
-	MotorcyclesAndScooters VehicleType = 1
-	CarsAndSUVs            VehicleType = 2
-	BusesAndTrucks         VehicleType = 3
+	SizeClassSmall  = 1
+	SizeClassMedium = 2
+	SizeClassLarge  = 3
 )
 
+// VehicleType represents a kind of vehicle registered with the service.
+type VehicleType struct {
+	ID               int    `gorm:"primaryKey"`
+	Code             string `gorm:"type:varchar(50);not null;uniqueIndex"` // Stable machine readable code, e.g. CARS_SUVS
+	DisplayName      string `gorm:"type:varchar(150);not null"`
+	SizeClass        int    `gorm:"not null"`               // Relative size of the spot the vehicle needs
+	AllowLargerSpots bool   `gorm:"not null;default:false"` // Whether the vehicle may use spots of a larger size class
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
 type ParkingSpace struct {
-	ID             uint        `gorm:"primaryKey"` // Unique identifier for each parking space
-	ParkingLotId   ParkingLot  `gorm:"not null;index:idx_parking_lot_vehicle_type"`
-	VehicleTypeId  VehicleType `gorm:"not null;index:idx_parking_lot_vehicle_type"`
-	AvailableSpots int         `gorm:"not null"` // Number of free spots left for the specified vehicle type
-}
-
-type ParkedVehicle struct {
-	VehicleNumber string      `gorm:"primaryKey"`
-	ParkingLotID  ParkingLot  `gorm:"not null"`
-	VehicleTypeId VehicleType `gorm:"not null"`
-	VehicleName   string      `gorm:"type:varchar(150)"`
-	EntryTime     time.Time   `gorm:"not null"`
+	ID             uint `gorm:"primaryKey"` // Unique identifier for each parking space
+	TenantId       uint `gorm:"not null;default:1;index"`
+	ParkingLotId   int  `gorm:"not null;index:idx_parking_lot_vehicle_type"`
+	VehicleTypeId  int  `gorm:"not null;index:idx_parking_lot_vehicle_type"`
+	AvailableSpots int  `gorm:"not null"`           // Number of free spots left for the specified vehicle type, negative while over capacity
+	TotalSpots     int  `gorm:"not null;default:0"` // Capacity of the lot for the specified vehicle type
+	// PendingRemovals counts spots that must be removed as soon as they are freed, because the
+	// parking space was resized below the number of vehicles parked in it.
+	PendingRemovals int `gorm:"not null;default:0"`
+}
+
+// OccupiedSpots returns the number of vehicles currently parked in the parking space.
+func (p *ParkingSpace) OccupiedSpots() int {
+	return p.TotalSpots - p.AvailableSpots
+}
+
+// ParkingSessionStatus represents the state of a parking session.
+type ParkingSessionStatus string
+
+const (
+	ParkingSessionStatusOpen   ParkingSessionStatus = "open"   // Vehicle is parked
+	ParkingSessionStatusClosed ParkingSessionStatus = "closed" // Vehicle has left and paid its fare
+)
+
+// ParkingSession represents one stay of a vehicle in a parking lot. It is opened when the vehicle is parked and
+// closed with the exit time and fare when it leaves, closed sessions are kept as the history of completed stays.
+// A vehicle has at most one open session.
+type ParkingSession struct {
+	ID                uint                 `gorm:"primaryKey"`
+	TenantId          uint                 `gorm:"not null;default:1;index"`
+	TicketNumber      string               `gorm:"type:varchar(20);uniqueIndex"` // Number printed on the ticket handed out at entry
+	VehicleNumber     string               `gorm:"not null;index;uniqueIndex:idx_session_open_vehicle,where:status = 'open'"`
+	ParkingLotId      int                  `gorm:"not null;index"`
+	VehicleTypeId     int                  `gorm:"not null"`
+	VehicleName       string               `gorm:"type:varchar(150)"`
+	SpotId            *uint                `gorm:"index"`              // Spot assigned at entry, nil for vehicles parked before spots existed
+	SpotVehicleTypeId int                  `gorm:"not null;default:0"` // Pool the vehicle is counted against, a larger type's on overflow
+	PassId            *uint                `gorm:"index"`              // Pass of the vehicle at entry, nil for vehicles paying by the hour
+	EntryTime         time.Time            `gorm:"not null;index"`
+	PaidAt            *time.Time           // End of the paid part of the stay, set when an invoice of the session is settled
+	ExitTime          *time.Time           // Set when the session is closed
+	Currency          string               `gorm:"type:char(3);not null;default:'INR'"` // ISO 4217 currency of the fares
+	GrossFare         *int64               `gorm:"column:gross_fare_minor"`             // Fare before discounts in minor units, set when the ticket is paid or the session is closed
+	Fare              *int64               `gorm:"column:fare_minor"`                   // Fare paid after discounts in minor units, set when the ticket is paid or the session is closed
+	Status            ParkingSessionStatus `gorm:"type:varchar(20);not null;default:'open'"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// Spot represents a single marked parking spot of a parking lot.
+type Spot struct {
+	ID               uint   `gorm:"primaryKey"`
+	ParkingLotId     int    `gorm:"not null;uniqueIndex:idx_spot_lot_label;index:idx_spot_lot_vehicle_type"`
+	VehicleTypeId    int    `gorm:"not null;index:idx_spot_lot_vehicle_type"` // Parking space pool the spot belongs to
+	Level            int    `gorm:"not null;default:0"`                       // Floor of the spot, 0 is the ground level
+	Zone             string `gorm:"type:varchar(50)"`
+	Label            string `gorm:"type:varchar(50);not null;uniqueIndex:idx_spot_lot_label"` // Marking painted on the spot
+	SizeClass        int    `gorm:"not null"`
+	Covered          bool   `gorm:"not null;default:false"`
+	Handicap         bool   `gorm:"not null;default:false"`
+	EVCharger        bool   `gorm:"not null;default:false"`
+	Occupied         bool   `gorm:"not null;default:false"`
+	EntranceDistance int    `gorm:"not null;default:0"` // Walking distance from the entrance in meters
+	UsageCount       int    `gorm:"not null;default:0"` // Number of vehicles that have been assigned the spot
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// SpotAvailability holds the number of free spots of a parking lot for a vehicle type.
+type SpotAvailability struct {
+	ParkingLotId  int
+	VehicleTypeId int
+	FreeSpots     int
+}
+
+// UpsizeRule allows vehicles of a type to overflow into the spots of a larger vehicle type in a parking lot
+// once their own pool is full. The rules of a vehicle type are tried in ascending priority.
+type UpsizeRule struct {
+	ID                    uint `gorm:"primaryKey"`
+	ParkingLotId          int  `gorm:"not null;uniqueIndex:idx_upsize_rule"`
+	VehicleTypeId         int  `gorm:"not null;uniqueIndex:idx_upsize_rule"`
+	OverflowVehicleTypeId int  `gorm:"not null;uniqueIndex:idx_upsize_rule"`
+	Priority              int  `gorm:"not null"`
+}
+
+// ReservationStatus represents the state of a reservation.
+type ReservationStatus string
+
+const (
+	ReservationStatusBooked    ReservationStatus = "booked"     // Spot is held for the vehicle
+	ReservationStatusCheckedIn ReservationStatus = "checked-in" // Vehicle has arrived, the reservation became a session
+	ReservationStatusCancelled ReservationStatus = "cancelled"  // Cancelled before the vehicle arrived
+	ReservationStatusNoShow    ReservationStatus = "no-show"    // Vehicle did not arrive within the grace period
+)
+
+// Reservation holds a spot of a vehicle type in a parking lot for a vehicle arriving in [StartTime, EndTime).
+// From StartTime until HoldUntil the spot is taken out of walk-in availability; a reservation that has not
+// been checked in by then is a no-show and releases the hold.
+type Reservation struct {
+	ID            uint              `gorm:"primaryKey"`
+	ParkingLotId  int               `gorm:"not null;index:idx_reservation_hold"`
+	VehicleTypeId int               `gorm:"not null;index:idx_reservation_hold"`
+	VehicleNumber string            `gorm:"not null;index"`
+	StartTime     time.Time         `gorm:"not null"`
+	EndTime       time.Time         `gorm:"not null"`
+	HoldUntil     time.Time         `gorm:"not null;index:idx_reservation_hold"` // Start time plus the lot's grace period, at most the end time
+	Status        ReservationStatus `gorm:"type:varchar(20);not null;default:'booked';index"`
+	SessionId     *uint             // Parking session the reservation was checked in to
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// SpotHold holds the number of spots of a parking lot held back from walk-in vehicles of a vehicle type.
+type SpotHold struct {
+	ParkingLotId  int
+	VehicleTypeId int
+	HeldSpots     int
+}
+
+// PassPeriod represents how long a pass is valid after it is issued.
+type PassPeriod string
+
+const (
+	PassPeriodWeekly  PassPeriod = "weekly"
+	PassPeriodMonthly PassPeriod = "monthly"
+)
+
+// PassCoverage represents the hours of a day in which parking is covered by a pass.
+type PassCoverage string
+
+const (
+	PassCoverageAllDay   PassCoverage = "all-day"  // Any time of any day
+	PassCoverageWeekdays PassCoverage = "weekdays" // Monday to Friday, in the lot's time zone
+	PassCoverageNights   PassCoverage = "nights"   // 20:00 to 08:00, in the lot's time zone
+)
+
+// PassProduct is a kind of pass sold for a vehicle type in a parking lot. A product can reserve spots of the
+// vehicle type's pool for its pass holders, those spots are not given to walk-in vehicles.
+type PassProduct struct {
+	ID            uint         `gorm:"primaryKey"`
+	ParkingLotId  int          `gorm:"not null;uniqueIndex:idx_pass_product_name"`
+	VehicleTypeId int          `gorm:"not null"`
+	Name          string       `gorm:"type:varchar(100);not null;uniqueIndex:idx_pass_product_name"`
+	Period        PassPeriod   `gorm:"type:varchar(20);not null"`
+	Coverage      PassCoverage `gorm:"type:varchar(20);not null"`
+	Price         int64        `gorm:"column:price_minor;not null;default:0"` // Minor units of Currency
+	Currency      string       `gorm:"type:char(3);not null;default:'INR'"`   // ISO 4217 currency of the price
+	ReservedSpots int          `gorm:"not null;default:0"`                    // Spots held back from walk-in vehicles for the pass holders
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// PassStatus represents the state of a pass.
+type PassStatus string
+
+const (
+	PassStatusActive    PassStatus = "active"
+	PassStatusCancelled PassStatus = "cancelled"
+)
+
+// Pass is a pass product sold to a subscriber, it covers the parking of its registered vehicles in
+// [ValidFrom, ValidTo) within the coverage of its product.
+type Pass struct {
+	ID            uint          `gorm:"primaryKey"`
+	PassProductId uint          `gorm:"not null;index"`
+	ParkingLotId  int           `gorm:"not null;index"`
+	VehicleTypeId int           `gorm:"not null"`
+	HolderName    string        `gorm:"type:varchar(150);not null"`
+	Price         int64         `gorm:"column:price_minor;not null;default:0"` // Price paid, the product's price at the time of sale
+	Currency      string        `gorm:"type:char(3);not null;default:'INR'"`
+	ValidFrom     time.Time     `gorm:"not null"`
+	ValidTo       time.Time     `gorm:"not null"`
+	Status        PassStatus    `gorm:"type:varchar(20);not null;default:'active'"`
+	Vehicles      []PassVehicle `gorm:"foreignKey:PassId"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// PassVehicle is a vehicle registered on a pass.
+type PassVehicle struct {
+	ID            uint   `gorm:"primaryKey"`
+	PassId        uint   `gorm:"not null;uniqueIndex:idx_pass_vehicle"`
+	VehicleNumber string `gorm:"not null;uniqueIndex:idx_pass_vehicle;index"`
+}
+
+// PassHold holds the number of spots a pass product reserves that are not taken by one of its pass holders.
+type PassHold struct {
+	PassProductId uint
+	ParkingLotId  int
+	VehicleTypeId int
+	HeldSpots     int
+}
+
+// Merchant is a shop next to a parking lot that validates the parking of its customers.
+type Merchant struct {
+	ID           uint   `gorm:"primaryKey"`
+	ParkingLotId int    `gorm:"not null;uniqueIndex:idx_merchant_name"`
+	Name         string `gorm:"type:varchar(150);not null;uniqueIndex:idx_merchant_name"`
+	Active       bool   `gorm:"not null;default:true"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// DiscountKind represents how a discount reduces a fare.
+type DiscountKind string
+
+const (
+	DiscountKindPercentage  DiscountKind = "percentage"   // Value is the percentage taken off the fare
+	DiscountKindFixedAmount DiscountKind = "fixed-amount" // FixedAmount is taken off the fare
+	DiscountKindFreeHours   DiscountKind = "free-hours"   // Value is the number of hours at the start of the stay that are free
+)
+
+// DiscountRule is a reduction of the fare in a parking lot. It is either issued by a merchant as a validation
+// of an open ticket, or applied with its discount code when the vehicle leaves.
+type DiscountRule struct {
+	ID           uint         `gorm:"primaryKey"`
+	ParkingLotId int          `gorm:"not null;uniqueIndex:idx_discount_code"`
+	MerchantId   *uint        `gorm:"index"`                                          // Merchant issuing the discount, nil for discount codes
+	Code         *string      `gorm:"type:varchar(50);uniqueIndex:idx_discount_code"` // Discount code, nil for merchant validations
+	Name         string       `gorm:"type:varchar(100);not null"`
+	Kind         DiscountKind `gorm:"type:varchar(20);not null"`
+	Value        float64      `gorm:"not null;default:0"`                           // Percentage or free hours, see DiscountKind
+	FixedAmount  int64        `gorm:"column:fixed_amount_minor;not null;default:0"` // Minor units taken off by a fixed-amount rule
+	MaxAmount    int64        `gorm:"column:max_amount_minor;not null;default:0"`   // Cap of the reduction in minor units, zero for no cap
+	Currency     string       `gorm:"type:char(3);not null;default:'INR'"`          // ISO 4217 currency of the amounts
+	Stackable    bool         `gorm:"not null;default:false"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Validation is a discount rule applied to a parking session. The rule is copied so that later changes to
+// it do not alter the receipts of past stays. Amount is set when the session is closed.
+type Validation struct {
+	ID             uint         `gorm:"primaryKey"`
+	SessionId      uint         `gorm:"not null;uniqueIndex:idx_validation"`
+	DiscountRuleId uint         `gorm:"not null;uniqueIndex:idx_validation"`
+	MerchantId     *uint        `gorm:"index"`
+	Name           string       `gorm:"type:varchar(100);not null"`
+	Kind           DiscountKind `gorm:"type:varchar(20);not null"`
+	Value          float64      `gorm:"not null;default:0"`
+	FixedAmount    int64        `gorm:"column:fixed_amount_minor;not null;default:0"`
+	MaxAmount      int64        `gorm:"column:max_amount_minor;not null;default:0"`
+	Currency       string       `gorm:"type:char(3);not null;default:'INR'"`
+	Stackable      bool         `gorm:"not null;default:false"`
+	Amount         int64        `gorm:"column:amount_minor;not null;default:0"` // Reduction in minor units of the session's currency, set when the ticket is paid
+	CreatedAt      time.Time
+}
+
+// Tariff represents one version of the pricing of a vehicle type in a parking lot. A version applies to
+// vehicles entering in [EffectiveFrom, EffectiveTo); an open ended version has no EffectiveTo.
+type Tariff struct {
+	ID                   uint         `gorm:"primaryKey"`
+	TenantId             uint         `gorm:"not null;default:1;index"`
+	ParkingLotId         int          `gorm:"not null;index:idx_tariff_lot_vehicle_type"`
+	VehicleTypeId        int          `gorm:"not null;index:idx_tariff_lot_vehicle_type"`
+	Currency             string       `gorm:"type:char(3);not null;default:'INR'"` // ISO 4217 currency of the rates, all rates are in its minor units
+	HourlyRate           int64        `gorm:"column:hourly_rate_minor;not null;default:0"`
+	DayRate              int64        `gorm:"column:day_rate_minor;not null;default:0"`
+	DayRateHours         int          `gorm:"not null;default:0"` // Hours of parking charged hourly before the day rate applies
+	FirstHourRate        int64        `gorm:"column:first_hour_rate_minor;not null;default:0"`
+	AdditionalHourRate   int64        `gorm:"column:additional_hour_rate_minor;not null;default:0"`
+	RoundingMinutes      int          `gorm:"not null;default:60"`                       // Granularity partial hours are rounded up to
+	EntryGraceMinutes    int          `gorm:"not null;default:0"`                        // Stays up to this long are free
+	ExitGraceMinutes     int          `gorm:"not null;default:0"`                        // Time to leave after paying before more is charged
+	QuoteValidityMinutes int          `gorm:"not null;default:10"`                       // How long a quoted fare holds
+	DailyCap             int64        `gorm:"column:daily_cap_minor;not null;default:0"` // Most charged for any 24 hours of a stay, zero for no cap
+	EffectiveFrom        time.Time    `gorm:"not null"`
+	EffectiveTo          *time.Time   // Nil while the version is the current open ended one
+	Bands                []TariffBand `gorm:"foreignKey:TariffId"` // Time bands charged at their own rate
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+// Money returns an amount of minor units in the currency of the tariff version.
+func (t *Tariff) Money(amount int64) money.Money {
+	return money.New(amount, t.Currency)
+}
+
+// MaxDurationForDayRate returns the duration charged hourly before the day rate applies.
+func (t *Tariff) MaxDurationForDayRate() time.Duration {
+	return time.Duration(t.DayRateHours) * time.Hour
+}
+
+// Rounding returns the granularity partial hours are rounded up to, a whole hour when it is not set.
+func (t *Tariff) Rounding() time.Duration {
+	if t.RoundingMinutes <= 0 {
+		return time.Hour
+	}
+	return time.Duration(t.RoundingMinutes) * time.Minute
+}
+
+// EntryGrace returns how long a stay may last without being charged.
+func (t *Tariff) EntryGrace() time.Duration {
+	return time.Duration(t.EntryGraceMinutes) * time.Minute
+}
+
+// ExitGrace returns how long a driver has to leave after paying before the overstay is charged.
+func (t *Tariff) ExitGrace() time.Duration {
+	return time.Duration(t.ExitGraceMinutes) * time.Minute
+}
+
+// QuoteValidity returns how long a quoted fare can be paid at, ten minutes when it is not set.
+func (t *Tariff) QuoteValidity() time.Duration {
+	if t.QuoteValidityMinutes <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(t.QuoteValidityMinutes) * time.Minute
+}
+
+// BandDays selects the local days a tariff band applies on.
+type BandDays string
+
+const (
+	BandDaysEveryDay BandDays = "every-day"
+	BandDaysWeekdays BandDays = "weekdays" // Monday to Friday, except public holidays
+	BandDaysWeekends BandDays = "weekends" // Saturday and Sunday
+	BandDaysHolidays BandDays = "holidays" // Public holidays in the calendar of the parking lot
+)
+
+// TariffBand charges the part of a stay within a window of the lot's local day at its own rate instead of the
+// standard rates of its tariff version. A window that ends before it starts runs past midnight into the next
+// day, a window that ends when it starts covers the whole day. Where bands overlap, the highest priority wins.
+type TariffBand struct {
+	ID          uint     `gorm:"primaryKey"`
+	TariffId    uint     `gorm:"not null;index"`
+	Name        string   `gorm:"type:varchar(100);not null"`
+	Days        BandDays `gorm:"type:varchar(20);not null"`
+	StartMinute int      `gorm:"not null;default:0"`                          // Start of the window in minutes after local midnight
+	EndMinute   int      `gorm:"not null;default:0"`                          // End of the window in minutes after local midnight
+	FlatRate    int64    `gorm:"column:flat_rate_minor;not null;default:0"`   // Charged once for every window a stay falls into
+	HourlyRate  int64    `gorm:"column:hourly_rate_minor;not null;default:0"` // Charged for every hour of a stay within a window
+	Priority    int      `gorm:"not null;default:0"`
+}
+
+// Holiday is a public holiday in the calendar of a parking lot.
+type Holiday struct {
+	ID           uint   `gorm:"primaryKey"`
+	ParkingLotId int    `gorm:"not null;uniqueIndex:idx_holiday_date"`
+	Date         string `gorm:"type:varchar(10);not null;uniqueIndex:idx_holiday_date"` // Local date as YYYY-MM-DD
+	Name         string `gorm:"type:varchar(100);not null"`
+	CreatedAt    time.Time
+}
+
+// ExchangeRate is the price of one major unit of the base currency in the quote currency, used to convert the
+// revenue of lots charging different currencies into one currency for reports.
+type ExchangeRate struct {
+	ID            uint   `gorm:"primaryKey"`
+	BaseCurrency  string `gorm:"type:char(3);not null;uniqueIndex:idx_exchange_rate_pair"`
+	QuoteCurrency string `gorm:"type:char(3);not null;uniqueIndex:idx_exchange_rate_pair"`
+	Rate          string `gorm:"type:numeric(24,10);not null"` // Decimal, kept exact
+	UpdatedAt     time.Time
+}
+
+// RevenueTotal holds the fares charged for the parking sessions closed in one currency.
+type RevenueTotal struct {
+	Currency  string
+	Sessions  int64
+	GrossFare int64 // Minor units of the currency
+	Fare      int64 // Minor units of the currency
+}
+
+// InvoiceStatus represents the state of an invoice.
+type InvoiceStatus string
+
+const (
+	InvoiceStatusPending       InvoiceStatus = "pending"        // Nothing has been paid yet
+	InvoiceStatusPartiallyPaid InvoiceStatus = "partially-paid" // Part of the amount has been paid
+	InvoiceStatusPaid          InvoiceStatus = "paid"           // The amount has been paid in full
+	InvoiceStatusAccountBilled InvoiceStatus = "account-billed" // What is left is billed to the account of the customer
+	InvoiceStatusVoid          InvoiceStatus = "void"           // Replaced by a new invoice for a later exit
+)
+
+// Invoice is the fare a parking session owes for its stay up to PricedAt. The vehicle may leave once the
+// invoice is paid or billed to an account, the stay is then paid up to PricedAt. A session has at most one
+// pending or partially paid invoice.
+type Invoice struct {
+	ID               uint          `gorm:"primaryKey"`
+	SessionId        uint          `gorm:"not null;index"`
+	TicketNumber     string        `gorm:"type:varchar(20);not null"`
+	ParkingLotId     int           `gorm:"not null;index"`
+	Currency         string        `gorm:"type:char(3);not null;default:'INR'"`
+	GrossFare        int64         `gorm:"column:gross_fare_minor;not null;default:0"`      // Fare before discounts in minor units
+	Amount           int64         `gorm:"column:amount_minor;not null;default:0"`          // Fare after discounts in minor units, the amount due
+	PaidAmount       int64         `gorm:"column:paid_amount_minor;not null;default:0"`     // Collected by the payments of the invoice
+	RefundedAmount   int64         `gorm:"column:refunded_amount_minor;not null;default:0"` // Paid back by the refunds of the invoice
+	Status           InvoiceStatus `gorm:"type:varchar(20);not null;default:'pending';index"`
+	AccountReference *string       `gorm:"type:varchar(100)"` // Account the invoice was billed to
+	PricedAt         time.Time     `gorm:"not null"`          // End of the stay the invoice charges
+	SettledAt        *time.Time    // Set when the invoice is paid in full or billed to an account
+	Payments         []Payment     `gorm:"foreignKey:InvoiceId"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// FareQuote is the fare a parking session was quoted for leaving at QuotedAt. Paying the ticket or unparking
+// with the quote before ValidUntil charges the quoted fare, with the discount code it was quoted with.
+type FareQuote struct {
+	ID           uint      `gorm:"primaryKey"`
+	SessionId    uint      `gorm:"not null;index"`
+	Currency     string    `gorm:"type:char(3);not null;default:'INR'"`
+	Fare         int64     `gorm:"column:fare_minor;not null;default:0"` // Net fare quoted in minor units
+	DiscountCode string    `gorm:"type:varchar(50);not null;default:''"` // Normalised discount code, empty without one
+	QuotedAt     time.Time `gorm:"not null"`                             // End of the stay the quote prices
+	ValidUntil   time.Time `gorm:"not null"`
+	CreatedAt    time.Time
+}
+
+// Balance returns what is left to pay of the invoice in minor units.
+func (i *Invoice) Balance() int64 {
+	return i.Amount - (i.PaidAmount - i.RefundedAmount)
+}
+
+// Settled reports whether the vehicle may leave for the invoice.
+func (i *Invoice) Settled() bool {
+	return i.Status == InvoiceStatusPaid || i.Status == InvoiceStatusAccountBilled
+}
+
+// PaymentKind tells a payment from a refund.
+type PaymentKind string
+
+const (
+	PaymentKindCharge PaymentKind = "charge"
+	PaymentKindRefund PaymentKind = "refund"
+)
+
+// PaymentStatus represents the state of a payment or refund.
+type PaymentStatus string
+
+const (
+	PaymentStatusPending           PaymentStatus = "pending"            // Sent to the gateway
+	PaymentStatusSucceeded         PaymentStatus = "succeeded"          // Approved by the gateway
+	PaymentStatusFailed            PaymentStatus = "failed"             // Declined, or the gateway could not be reached
+	PaymentStatusPartiallyRefunded PaymentStatus = "partially-refunded" // Part of a charge has been paid back
+	PaymentStatusRefunded          PaymentStatus = "refunded"           // All of a charge has been paid back
+)
+
+// Payment is one charge of an invoice through a payment gateway, or a refund of such a charge.
+type Payment struct {
+	ID             uint          `gorm:"primaryKey"`
+	InvoiceId      uint          `gorm:"not null;index"`
+	RefundOf       *uint         `gorm:"index"` // Charge a refund pays back
+	AdjustmentId   *uint         `gorm:"index"` // Fare adjustment a refund was made for
+	Kind           PaymentKind   `gorm:"type:varchar(20);not null"`
+	Gateway        string        `gorm:"type:varchar(50);not null"`
+	Currency       string        `gorm:"type:char(3);not null;default:'INR'"`
+	Amount         int64         `gorm:"column:amount_minor;not null"`
+	RefundedAmount int64         `gorm:"column:refunded_amount_minor;not null;default:0"` // Paid back of a charge
+	Status         PaymentStatus `gorm:"type:varchar(20);not null;default:'pending'"`
+	Reference      string        `gorm:"type:varchar(100)"` // Reference of the payment at the gateway
+	Message        string        `gorm:"type:varchar(255)"` // Why the payment failed, or the reason of a refund
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// FareAdjustmentKind tells a corrected fare from a waived one.
+type FareAdjustmentKind string
+
+const (
+	FareAdjustmentKindAdjust FareAdjustmentKind = "adjust" // The fare was corrected to another amount
+	FareAdjustmentKindWaive  FareAdjustmentKind = "waive"  // The fare was let off in full
+)
+
+// AdjustmentReason is the reason code an operator gives for changing the fare of a parking session.
+type AdjustmentReason string
+
+const (
+	AdjustmentReasonWrongVehicleType  AdjustmentReason = "wrong-vehicle-type" // Priced as another vehicle type
+	AdjustmentReasonWrongTime         AdjustmentReason = "wrong-time"         // Entry or exit recorded at the wrong time
+	AdjustmentReasonEquipmentFault    AdjustmentReason = "equipment-fault"    // A barrier, reader or pay station failed
+	AdjustmentReasonDuplicateCharge   AdjustmentReason = "duplicate-charge"   // The stay was paid twice
+	AdjustmentReasonCustomerComplaint AdjustmentReason = "customer-complaint" // Settled with the customer
+	AdjustmentReasonGoodwill          AdjustmentReason = "goodwill"           // Let off at the discretion of the operator
+	AdjustmentReasonOther             AdjustmentReason = "other"              // Explained in the note
+)
+
+// AdjustmentReasons lists the reason codes in the order they are offered to operators.
+var AdjustmentReasons = []AdjustmentReason{
+	AdjustmentReasonWrongVehicleType,
+	AdjustmentReasonWrongTime,
+	AdjustmentReasonEquipmentFault,
+	AdjustmentReasonDuplicateCharge,
+	AdjustmentReasonCustomerComplaint,
+	AdjustmentReasonGoodwill,
+	AdjustmentReasonOther,
+}
+
+// Valid reports whether the reason is one of the reason codes.
+func (r AdjustmentReason) Valid() bool {
+	for _, reason := range AdjustmentReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// FareAdjustment is an entry of the append-only ledger of changes operators made to the fare of a closed
+// parking session. Entries are never updated or deleted, the refunds made for an adjustment refer to it.
+type FareAdjustment struct {
+	ID           uint               `gorm:"primaryKey"`
+	SessionId    uint               `gorm:"not null;index"`
+	ParkingLotId int                `gorm:"not null;index"`
+	Kind         FareAdjustmentKind `gorm:"type:varchar(20);not null"`
+	ReasonCode   AdjustmentReason   `gorm:"type:varchar(50);not null"`
+	Note         string             `gorm:"type:varchar(255)"`
+	Operator     string             `gorm:"type:varchar(100);not null"` // Who changed the fare
+	Currency     string             `gorm:"type:char(3);not null;default:'INR'"`
+	OriginalFare int64              `gorm:"column:original_fare_minor;not null"` // Fare of the session before the change
+	AdjustedFare int64              `gorm:"column:adjusted_fare_minor;not null"` // Fare of the session after the change
+	Refunds      []Payment          `gorm:"foreignKey:AdjustmentId"`
+	CreatedAt    time.Time
+}
+
+// LostTicketExit records the identity an operator checked before letting a vehicle out without its ticket.
+// Only the last four characters of the identity document number are kept.
+type LostTicketExit struct {
+	ID             uint   `gorm:"primaryKey"`
+	SessionId      uint   `gorm:"not null;index"`
+	ParkingLotId   int    `gorm:"not null;index"`
+	VehicleNumber  string `gorm:"not null"`
+	DriverName     string `gorm:"type:varchar(150);not null"`
+	DocumentType   string `gorm:"type:varchar(50);not null"`  // e.g. driving-licence
+	DocumentNumber string `gorm:"type:varchar(20);not null"`  // Last four characters of the document number
+	Operator       string `gorm:"type:varchar(100);not null"` // Who checked the identity
+	CreatedAt      time.Time
+}
+
+// AuditAction is the kind of change an audit entry records.
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "create" // Row was inserted
+	AuditActionUpsert AuditAction = "upsert" // Row was inserted or replaced a row it conflicted with
+	AuditActionUpdate AuditAction = "update" // Row was changed
+	AuditActionDelete AuditAction = "delete" // Row was removed
+)
+
+// AuditEntry records a change of one row of another table, see package audit. Entries are append-only, the
+// database refuses to update or delete them. Before and after hold the row as JSON, empty for the side of a
+// create or delete without one, and are kept as text so their hash can be recomputed from the exact bytes.
+type AuditEntry struct {
+	ID        uint        `gorm:"primaryKey"`
+	TenantId  uint        `gorm:"not null;default:0;index"`         // Zero for changes of data shared by all tenants
+	Actor     string      `gorm:"type:varchar(150);not null;index"` // Subject of the caller, "system" for background work
+	Role      string      `gorm:"type:varchar(30);not null"`
+	Operation string      `gorm:"type:varchar(200);not null"` // Route or job that made the change
+	RequestId string      `gorm:"type:varchar(100);not null;index"`
+	Action    AuditAction `gorm:"type:varchar(10);not null"`
+	Entity    string      `gorm:"type:varchar(100);not null;index:idx_audit_entries_entity"` // Table of the row
+	EntityId  string      `gorm:"type:varchar(100);not null;index:idx_audit_entries_entity"` // Primary key of the row
+	Before    string      `gorm:"type:text;not null"`
+	After     string      `gorm:"type:text;not null"`
+	CreatedAt time.Time   `gorm:"not null;index"`
+	PrevHash  string      `gorm:"type:varchar(64);not null"` // Hash of the entry before, empty for the first entry
+	Hash      string      `gorm:"type:varchar(64);not null;uniqueIndex"`
+}
+
+// OutboxEvent is a domain event waiting in the outbox to be delivered to the downstream systems, see package
+// events. Events are written in the transaction of the change they describe and delivered afterwards, so an
+// event exists if and only if its change was committed.
+type OutboxEvent struct {
+	ID            uint       `gorm:"primaryKey"`
+	TenantId      uint       `gorm:"not null;index"`
+	Type          string     `gorm:"type:varchar(50);not null"`
+	ParkingLotId  int        `gorm:"not null;index"`
+	Payload       string     `gorm:"type:text;not null"` // Data of the event as JSON
+	OccurredAt    time.Time  `gorm:"not null"`
+	Attempts      int        `gorm:"not null;default:0"`                                  // Deliveries started so far
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbox_events_pending,priority:2"` // Due, or end of the lease
+	DeliveredAt   *time.Time `gorm:"index:idx_outbox_events_pending,priority:1"`          // Nil until delivered
+	LastError     string     `gorm:"type:varchar(500);not null;default:''"`               // Why the last delivery failed
 }
@@ -0,0 +1,135 @@
+//go:build integration
+
+package repo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"parking_lot_service/internal/auth"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/repo"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service"
+	"parking_lot_service/internal/service/model"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParkVehicle_ConcurrentArrivalsNeverOversellThePool(t *testing.T) {
+	const (
+		spots    = 5
+		arrivals = 300
+	)
+	r, db := repo.NewPostgresRepo(t)
+	ctx := auth.NewContext(context.Background(), &auth.Principal{Subject: "admin-1", Role: auth.RoleAdmin})
+
+	// A lot of its own with a single small pool, so nothing else parks in it
+	run := time.Now().UnixNano()
+	vehicleType := &models.VehicleType{
+		Code:        fmt.Sprintf("PARK_%d", run),
+		DisplayName: "Concurrency test vehicle",
+		SizeClass:   models.SizeClassMedium,
+	}
+	if err := r.CreateVehicleType(ctx, vehicleType); err != nil {
+		t.Fatalf("CreateVehicleType() error = %v", err)
+	}
+	parkingLot := &models.ParkingLot{Name: fmt.Sprintf("park-%d", run), Status: models.ParkingLotStatusActive}
+	if err := r.CreateParkingLot(ctx, parkingLot); err != nil {
+		t.Fatalf("CreateParkingLot() error = %v", err)
+	}
+	if _, err := r.ResizeParkingSpace(ctx, parkingLot.ID, vehicleType.ID, spots, false); err != nil {
+		t.Fatalf("ResizeParkingSpace() error = %v", err)
+	}
+
+	s := service.NewParkingLotService(r)
+	var (
+		wg     sync.WaitGroup
+		start  = make(chan struct{})
+		errs   = make(chan error, arrivals)
+		parked = make(chan *model.ParkVehicleResponse, arrivals)
+	)
+	for i := 0; i < arrivals; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			resp, err := s.ParkVehicle(ctx, &model.ParkVehicleRequest{
+				ParkingLotID:  parkingLot.ID,
+				VehicleID:     vehicleType.ID,
+				VehicleNumber: fmt.Sprintf("PARK-%d-%d", run, i),
+			})
+			if err != nil {
+				errs <- err
+				return
+			}
+			parked <- resp
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+	close(parked)
+
+	// Every arrival that did not get a spot was turned away because the pool was full
+	for err := range errs {
+		var resp *genericresponse.GenericResponse
+		if !errors.As(err, &resp) || resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("ParkVehicle() error = %v, want %d", err, http.StatusNotFound)
+		}
+	}
+	if len(parked) != spots {
+		t.Errorf("vehicles parked = %d, want %d", len(parked), spots)
+	}
+
+	var openSessions, occupiedSpots int64
+	err := db.Model(&models.ParkingSession{}).
+		Where("parking_lot_id = ? AND status = ?", parkingLot.ID, models.ParkingSessionStatusOpen).
+		Count(&openSessions).
+		Error
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if openSessions != spots {
+		t.Errorf("open sessions = %d, want %d", openSessions, spots)
+	}
+
+	err = db.Model(&models.Spot{}).
+		Where("parking_lot_id = ? AND occupied = ?", parkingLot.ID, true).
+		Count(&occupiedSpots).
+		Error
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if occupiedSpots != spots {
+		t.Errorf("occupied spots = %d, want %d", occupiedSpots, spots)
+	}
+
+	// No spot went to two sessions
+	var spotIds []uint
+	err = db.Model(&models.ParkingSession{}).
+		Where("parking_lot_id = ? AND status = ?", parkingLot.ID, models.ParkingSessionStatusOpen).
+		Pluck("spot_id", &spotIds).
+		Error
+	if err != nil {
+		t.Fatalf("Pluck() error = %v", err)
+	}
+	seen := make(map[uint]bool, len(spotIds))
+	for _, spotId := range spotIds {
+		if seen[spotId] {
+			t.Errorf("spot %d is taken by two open sessions", spotId)
+		}
+		seen[spotId] = true
+	}
+
+	parkingSpace, err := r.GetParkingSpace(ctx, parkingLot.ID, vehicleType.ID)
+	if err != nil {
+		t.Fatalf("GetParkingSpace() error = %v", err)
+	}
+	if parkingSpace.AvailableSpots != 0 || parkingSpace.TotalSpots != spots {
+		t.Errorf("parking space = %d of %d available, want 0 of %d",
+			parkingSpace.AvailableSpots, parkingSpace.TotalSpots, spots)
+	}
+}
@@ -4,17 +4,163 @@ import (
 	"context"
 	"gorm.io/gorm"
 	"parking_lot_service/internal/repo/models"
+	"time"
 )
 
+// ParkingSessionFilter narrows down the parking sessions returned by GetParkingSessions.
+// Zero values disable the corresponding filter.
+type ParkingSessionFilter struct {
+	VehicleNumber string
+	ParkingLotId  int
+	Status        models.ParkingSessionStatus
+	EnteredFrom   *time.Time // Inclusive lower bound of the entry time
+	EnteredTo     *time.Time // Exclusive upper bound of the entry time
+	BeforeId      uint       // Cursor, only sessions with a lower ID are returned
+	Limit         int
+}
+
+// RevenueFilter narrows down the parking sessions whose fares are added up by GetRevenueByCurrency.
+// Zero values disable the corresponding filter.
+type RevenueFilter struct {
+	ParkingLotId int
+	ExitedFrom   *time.Time // Inclusive lower bound of the exit time
+	ExitedTo     *time.Time // Exclusive upper bound of the exit time
+}
+
+// AuditEntryFilter narrows down the audit entries returned by GetAuditEntries.
+// Zero values disable the corresponding filter.
+type AuditEntryFilter struct {
+	Entity    string
+	EntityId  string
+	Actor     string
+	RequestId string
+	From      *time.Time // Inclusive lower bound of the time of the change
+	To        *time.Time // Exclusive upper bound of the time of the change
+	BeforeId  uint       // Cursor, only entries with a lower ID are returned
+	Limit     int
+}
+
 type ParkingLotRepo interface {
+	// WithTx runs fn in a database transaction. The repo passed to fn is bound to the transaction,
+	// which is committed when fn returns nil and rolled back when it returns an error.
+	WithTx(ctx context.Context, fn func(txRepo ParkingLotRepo) error) error
 	SeedParkingSpace(ctx context.Context) error
-	GetParkingSpaces(ctx context.Context) ([]*models.ParkingSpace, error)
 	GetFreeParkingSpaceById(ctx context.Context, parkingLotId int) ([]*models.ParkingSpace, error)
-	SaveParkedVehicle(ctx context.Context, vehicleDetail *models.ParkedVehicle) error
-	GetAvailableParkingSpotsByParkingLotIdAndVehicleId(ctx context.Context, parkingLotId, VehicleId int) (int, error)
-	UpdateParkingSpace(ctx context.Context, parkingSpace *models.ParkingSpace) error
-	GetParkedVehicle(ctx context.Context, vehicleNumber string) (*models.ParkedVehicle, error)
-	DeleteParkedVehicle(ctx context.Context, parkedVehicle *models.ParkedVehicle) error
+	OpenParkingSession(ctx context.Context, parkingSession *models.ParkingSession) error
+	DecrementAvailableSpots(ctx context.Context, parkingLotId, vehicleTypeId, heldSpots int) (bool, error)
+	IncrementAvailableSpots(ctx context.Context, parkingLotId, vehicleTypeId int) (bool, error)
+	GetParkingSessionByTicket(ctx context.Context, ticketNumber string) (*models.ParkingSession, error)
+	CloseParkingSession(ctx context.Context, parkingSession *models.ParkingSession) error
+	GetParkingSessions(ctx context.Context, filter *ParkingSessionFilter) ([]*models.ParkingSession, error)
+	GetParkingLots(ctx context.Context) ([]*models.ParkingLot, error)
+	GetParkingLotById(ctx context.Context, parkingLotId int) (*models.ParkingLot, error)
+	CreateParkingLot(ctx context.Context, parkingLot *models.ParkingLot) error
+	UpdateParkingLot(ctx context.Context, parkingLot *models.ParkingLot) error
+	DeleteParkingLot(ctx context.Context, parkingLotId int) error
+	CountParkedVehiclesByParkingLotId(ctx context.Context, parkingLotId int) (int64, error)
+	GetVehicleTypes(ctx context.Context) ([]*models.VehicleType, error)
+	GetVehicleTypeById(ctx context.Context, vehicleTypeId int) (*models.VehicleType, error)
+	CreateVehicleType(ctx context.Context, vehicleType *models.VehicleType) error
+	UpdateVehicleType(ctx context.Context, vehicleType *models.VehicleType) error
+	DeleteVehicleType(ctx context.Context, vehicleTypeId int) error
+	CountParkingSpacesByVehicleTypeId(ctx context.Context, vehicleTypeId int) (int64, error)
+	GetTariffs(ctx context.Context, parkingLotId, vehicleTypeId int) ([]*models.Tariff, error)
+	GetTariffById(ctx context.Context, tariffId uint) (*models.Tariff, error)
+	GetTariffEffectiveAt(ctx context.Context, parkingLotId, vehicleTypeId int, at time.Time) (*models.Tariff, error)
+	CreateTariff(ctx context.Context, tariff *models.Tariff) error
+	UpdateTariffRates(ctx context.Context, tariff *models.Tariff) error
+	DeleteTariff(ctx context.Context, tariff *models.Tariff) error
+	GetParkingSpace(ctx context.Context, parkingLotId, vehicleTypeId int) (*models.ParkingSpace, error)
+	ResizeParkingSpace(ctx context.Context, parkingLotId, vehicleTypeId, totalSpots int,
+		allowOverCapacity bool) (*models.ParkingSpace, error)
+	GetFreeSpots(ctx context.Context, parkingLotId int) ([]*models.Spot, error)
+	OccupySpot(ctx context.Context, spotId uint) (bool, error)
+	ReleaseSpot(ctx context.Context, spotId uint) error
+	GetSpots(ctx context.Context, parkingLotId, vehicleTypeId int) ([]*models.Spot, error)
+	GetSpotById(ctx context.Context, spotId uint) (*models.Spot, error)
+	CreateSpot(ctx context.Context, spot *models.Spot) error
+	UpdateSpot(ctx context.Context, spot *models.Spot) error
+	DeleteSpot(ctx context.Context, spot *models.Spot) error
+	GetSpotAvailability(ctx context.Context, parkingLotId int) ([]*models.SpotAvailability, error)
+	ReconcileParkingSpaces(ctx context.Context) error
+	GetUpsizeRules(ctx context.Context, parkingLotId int) ([]*models.UpsizeRule, error)
+	ReplaceUpsizePolicy(ctx context.Context, parkingLotId int, pricing models.UpsizePricing,
+		rules []*models.UpsizeRule) error
+	GetReservationById(ctx context.Context, reservationId uint) (*models.Reservation, error)
+	CreateReservation(ctx context.Context, reservation *models.Reservation) error
+	UpdateReservation(ctx context.Context, reservation *models.Reservation) error
+	CancelReservation(ctx context.Context, reservationId uint) error
+	CheckInReservation(ctx context.Context, reservationId, sessionId uint) (bool, error)
+	GetReservationHolds(ctx context.Context, parkingLotId int, at time.Time) ([]*models.SpotHold, error)
+	MarkNoShowReservations(ctx context.Context, at time.Time) (int64, error)
+	GetPassProducts(ctx context.Context, parkingLotId int) ([]*models.PassProduct, error)
+	GetPassProductById(ctx context.Context, passProductId uint) (*models.PassProduct, error)
+	CreatePassProduct(ctx context.Context, passProduct *models.PassProduct) error
+	DeletePassProduct(ctx context.Context, passProductId uint) error
+	GetPassById(ctx context.Context, passId uint) (*models.Pass, error)
+	CreatePass(ctx context.Context, pass *models.Pass) error
+	ReplacePassVehicles(ctx context.Context, passId uint, vehicles []models.PassVehicle) error
+	CancelPass(ctx context.Context, passId uint, at time.Time) error
+	GetActivePass(ctx context.Context, parkingLotId, vehicleTypeId int, vehicleNumber string,
+		at time.Time) (*models.Pass, error)
+	GetPassHolds(ctx context.Context, parkingLotId int) ([]*models.PassHold, error)
+	GetMerchants(ctx context.Context, parkingLotId int) ([]*models.Merchant, error)
+	GetMerchantById(ctx context.Context, merchantId uint) (*models.Merchant, error)
+	CreateMerchant(ctx context.Context, merchant *models.Merchant) error
+	GetDiscountRules(ctx context.Context, parkingLotId int) ([]*models.DiscountRule, error)
+	GetDiscountRuleById(ctx context.Context, discountRuleId uint) (*models.DiscountRule, error)
+	GetDiscountRuleByCode(ctx context.Context, parkingLotId int, code string) (*models.DiscountRule, error)
+	CreateDiscountRule(ctx context.Context, discountRule *models.DiscountRule) error
+	DeleteDiscountRule(ctx context.Context, discountRuleId uint) error
+	CreateValidation(ctx context.Context, validation *models.Validation) error
+	GetValidations(ctx context.Context, sessionId uint) ([]*models.Validation, error)
+	SaveValidations(ctx context.Context, validations []*models.Validation) error
+	GetHolidays(ctx context.Context, parkingLotId int, fromDate, toDate string) ([]*models.Holiday, error)
+	CreateHoliday(ctx context.Context, holiday *models.Holiday) error
+	DeleteHoliday(ctx context.Context, holidayId uint) error
+	PayParkingSession(ctx context.Context, parkingSession *models.ParkingSession) error
+	GetRevenueByCurrency(ctx context.Context, filter *RevenueFilter) ([]*models.RevenueTotal, error)
+	GetExchangeRates(ctx context.Context) ([]*models.ExchangeRate, error)
+	SaveExchangeRate(ctx context.Context, exchangeRate *models.ExchangeRate) error
+	DeleteExchangeRate(ctx context.Context, exchangeRateId uint) error
+	CountTariffsByParkingLotId(ctx context.Context, parkingLotId int) (int64, error)
+	GetInvoiceById(ctx context.Context, invoiceId uint) (*models.Invoice, error)
+	GetOpenInvoice(ctx context.Context, sessionId uint) (*models.Invoice, error)
+	CreateInvoice(ctx context.Context, invoice *models.Invoice) error
+	VoidInvoice(ctx context.Context, invoiceId uint) error
+	PayInvoice(ctx context.Context, invoiceId uint, amount int64, at time.Time) (bool, error)
+	BillInvoiceToAccount(ctx context.Context, invoiceId uint, accountReference string, at time.Time) (bool, error)
+	RefundInvoice(ctx context.Context, invoiceId uint, amount int64) error
+	GetPaymentById(ctx context.Context, paymentId uint) (*models.Payment, error)
+	CreatePayment(ctx context.Context, payment *models.Payment) error
+	CompletePayment(ctx context.Context, payment *models.Payment) error
+	RefundPayment(ctx context.Context, paymentId uint, amount int64) (bool, error)
+	GetParkingSessionById(ctx context.Context, sessionId uint) (*models.ParkingSession, error)
+	AdjustSessionFare(ctx context.Context, sessionId uint, from, to int64) (bool, error)
+	GetSessionInvoices(ctx context.Context, sessionId uint) ([]*models.Invoice, error)
+	CreateFareAdjustment(ctx context.Context, adjustment *models.FareAdjustment) error
+	GetFareAdjustmentById(ctx context.Context, adjustmentId uint) (*models.FareAdjustment, error)
+	GetFareAdjustments(ctx context.Context, sessionId uint) ([]*models.FareAdjustment, error)
+	UpdatePenaltyPolicy(ctx context.Context, parkingLot *models.ParkingLot) error
+	CreateLostTicketExit(ctx context.Context, lostTicketExit *models.LostTicketExit) error
+	GetHolidayById(ctx context.Context, holidayId uint) (*models.Holiday, error)
+	GetTenants(ctx context.Context) ([]*models.Tenant, error)
+	GetTenantById(ctx context.Context, tenantId uint) (*models.Tenant, error)
+	CreateTenant(ctx context.Context, tenant *models.Tenant) error
+	UpdateTenant(ctx context.Context, tenant *models.Tenant) error
+	GetAuditEntries(ctx context.Context, filter *AuditEntryFilter) ([]*models.AuditEntry, error)
+	GetAuditChain(ctx context.Context, afterId uint, limit int) ([]*models.AuditEntry, error)
+	AddOutboxEvent(ctx context.Context, event *models.OutboxEvent) error
+	ClaimOutboxEvents(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.OutboxEvent, error)
+	MarkOutboxEventDelivered(ctx context.Context, eventId uint, deliveredAt time.Time) error
+	RetryOutboxEvent(ctx context.Context, eventId uint, nextAttemptAt time.Time, lastError string) error
+	CountParkingSessionsByVehicleTypeId(ctx context.Context, vehicleTypeId int) (int64, error)
+	CountPassProductsByParkingLotId(ctx context.Context, parkingLotId int) (int64, error)
+	CountDiscountRulesByParkingLotId(ctx context.Context, parkingLotId int) (int64, error)
+	CountValidationsByParkingLotId(ctx context.Context, parkingLotId int) (int64, error)
+	CreateFareQuote(ctx context.Context, quote *models.FareQuote) error
+	GetFareQuoteById(ctx context.Context, quoteId uint) (*models.FareQuote, error)
+	LockParkingSpaces(ctx context.Context, parkingLotId int, vehicleTypeIds []int) error
 }
 
 type impl struct {
@@ -24,3 +170,10 @@ type impl struct {
 func NewParkingLotRepo(db *gorm.DB) ParkingLotRepo {
 	return &impl{db: db}
 }
+
+func (s *impl) WithTx(ctx context.Context, fn func(txRepo ParkingLotRepo) error) error {
+	return s.
+		transaction(ctx, func(tx *gorm.DB) error {
+			return fn(&impl{db: tx})
+		})
+}
@@ -0,0 +1,57 @@
+package repo
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"parking_lot_service/internal/repo/models"
+)
+
+// CreateFareAdjustment appends an entry to the ledger of fare adjustments.
+func (s *impl) CreateFareAdjustment(ctx context.Context, adjustment *models.FareAdjustment) error {
+	return s.db.
+		WithContext(ctx).
+		Create(adjustment).
+		Error
+}
+
+// GetFareAdjustmentById retrieves a single fare adjustment by its ID with the refunds made for it.
+func (s *impl) GetFareAdjustmentById(ctx context.Context, adjustmentId uint) (*models.FareAdjustment, error) {
+	var adjustment models.FareAdjustment
+
+	err := s.db.
+		WithContext(ctx).
+		Preload("Refunds", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id")
+		}).
+		Where("id = ?", adjustmentId).
+		First(&adjustment).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &adjustment, nil
+}
+
+// GetFareAdjustments retrieves the fare adjustments of a parking session with the refunds made for them, in
+// the order they were made.
+func (s *impl) GetFareAdjustments(ctx context.Context, sessionId uint) ([]*models.FareAdjustment, error) {
+	var adjustments []*models.FareAdjustment
+
+	err := s.db.
+		WithContext(ctx).
+		Preload("Refunds", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id")
+		}).
+		Where("session_id = ?", sessionId).
+		Order("id").
+		Find(&adjustments).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return adjustments, nil
+}
@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"context"
+	"parking_lot_service/internal/repo/models"
+)
+
+// GetAuditEntries retrieves the audit entries matching the filter, newest first. Only entries with an ID below
+// the cursor of the filter are returned, so the ID of the last entry of a page is the cursor of the next one.
+func (s *impl) GetAuditEntries(ctx context.Context, filter *AuditEntryFilter) ([]*models.AuditEntry, error) {
+	var entries []*models.AuditEntry
+
+	query := s.db.WithContext(ctx)
+	if filter.Entity != "" {
+		query = query.Where("entity = ?", filter.Entity)
+	}
+	if filter.EntityId != "" {
+		query = query.Where("entity_id = ?", filter.EntityId)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.RequestId != "" {
+		query = query.Where("request_id = ?", filter.RequestId)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if filter.BeforeId > 0 {
+		query = query.Where("id < ?", filter.BeforeId)
+	}
+
+	err := query.
+		Order("id DESC").
+		Limit(filter.Limit).
+		Find(&entries).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetAuditChain retrieves up to limit audit entries following the entry with the given ID in the order they
+// were written, from the first entry for ID zero.
+func (s *impl) GetAuditChain(ctx context.Context, afterId uint, limit int) ([]*models.AuditEntry, error) {
+	var entries []*models.AuditEntry
+
+	err := s.db.
+		WithContext(ctx).
+		Where("id > ?", afterId).
+		Order("id").
+		Limit(limit).
+		Find(&entries).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,132 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"parking_lot_service/internal/repo/models"
+)
+
+// ErrCapacityBelowOccupancy is returned when a parking space would be resized below the number
+// of vehicles currently parked in it without allowing the space to go over capacity.
+var ErrCapacityBelowOccupancy = errors.New("capacity is below the number of parked vehicles")
+
+// GetParkingSpace retrieves the parking space of a parking lot for a vehicle type.
+func (s *impl) GetParkingSpace(ctx context.Context, parkingLotId, vehicleTypeId int) (*models.ParkingSpace, error) {
+	var parkingSpace models.ParkingSpace
+
+	err := s.db.
+		WithContext(ctx).
+		Where("parking_lot_id = ? AND vehicle_type_id = ?", parkingLotId, vehicleTypeId).
+		First(&parkingSpace).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &parkingSpace, nil
+}
+
+// ResizeParkingSpace sets the capacity of a parking lot for a vehicle type, creating the parking space if needed.
+// Growing adds numbered spots, shrinking removes free spots. Shrinking below the number of parked vehicles fails
+// with ErrCapacityBelowOccupancy unless allowOverCapacity is set, in which case the missing removals are recorded
+// as pending: the free spots become negative and every spot freed afterwards is removed until the pool fits.
+func (s *impl) ResizeParkingSpace(ctx context.Context, parkingLotId, vehicleTypeId, totalSpots int,
+	allowOverCapacity bool) (*models.ParkingSpace, error) {
+
+	var parkingSpace *models.ParkingSpace
+
+	err := s.
+		transaction(ctx, func(tx *gorm.DB) error {
+			var (
+				vehicleType models.VehicleType
+				err         error
+			)
+
+			// Lock the parking space so vehicles cannot park or leave while it is being resized
+			parkingSpace, err = lockParkingSpace(tx, parkingLotId, vehicleTypeId)
+			if err != nil {
+				return err
+			}
+
+			err = tx.
+				Where("id = ?", vehicleTypeId).
+				First(&vehicleType).
+				Error
+			if err != nil {
+				return err
+			}
+
+			delta := totalSpots - parkingSpace.TotalSpots
+			if delta > 0 {
+				// Cancel pending removals first, the spots they refer to are still marked in the lot
+				cancelled := min(delta, parkingSpace.PendingRemovals)
+				parkingSpace.PendingRemovals -= cancelled
+
+				err = createNumberedSpots(tx, parkingLotId, &vehicleType, delta-cancelled)
+				if err != nil {
+					return err
+				}
+			}
+
+			if delta < 0 {
+				var freeSpotIds []uint
+				err = tx.
+					Model(&models.Spot{}).
+					Where("parking_lot_id = ? AND vehicle_type_id = ? AND occupied = ?", parkingLotId, vehicleTypeId, false).
+					Order("level DESC, label DESC").
+					Limit(-delta).
+					Pluck("id", &freeSpotIds).
+					Error
+				if err != nil {
+					return err
+				}
+
+				missing := -delta - len(freeSpotIds)
+				if missing > 0 && !allowOverCapacity {
+					return ErrCapacityBelowOccupancy
+				}
+				parkingSpace.PendingRemovals += missing
+
+				if len(freeSpotIds) > 0 {
+					err = tx.
+						Where("id IN ?", freeSpotIds).
+						Delete(&models.Spot{}).
+						Error
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			var occupiedSpots int64
+			err = tx.
+				Model(&models.Spot{}).
+				Where("parking_lot_id = ? AND vehicle_type_id = ? AND occupied = ?", parkingLotId, vehicleTypeId, true).
+				Count(&occupiedSpots).
+				Error
+			if err != nil {
+				return err
+			}
+
+			parkingSpace.TotalSpots = totalSpots
+			parkingSpace.AvailableSpots = totalSpots - int(occupiedSpots)
+
+			return tx.
+				Model(&models.ParkingSpace{}).
+				Where("id = ?", parkingSpace.ID).
+				Updates(map[string]interface{}{
+					"total_spots":      parkingSpace.TotalSpots,
+					"available_spots":  parkingSpace.AvailableSpots,
+					"pending_removals": parkingSpace.PendingRemovals,
+				}).
+				Error
+		})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parkingSpace, nil
+}
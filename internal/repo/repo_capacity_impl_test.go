@@ -0,0 +1,106 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"parking_lot_service/internal/repo/models"
+	"strings"
+	"testing"
+)
+
+// stubRows makes the dry-run queries of a database read the given parking space and free spot IDs.
+func stubRows(t *testing.T, db *gorm.DB, parkingSpace *models.ParkingSpace, freeSpotIds []uint) {
+	t.Helper()
+	err := db.Callback().Query().After("gorm:query").Register("test:stub_rows", func(db *gorm.DB) {
+		switch dest := db.Statement.Dest.(type) {
+		case *models.ParkingSpace:
+			*dest = *parkingSpace
+		case *[]uint:
+			if db.Statement.Table == "spots" {
+				*dest = freeSpotIds
+			}
+		}
+	})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+}
+
+func TestSpotChanges_LockParkingSpaceFirst(t *testing.T) {
+	const lock = `FROM "parking_spaces" WHERE parking_lot_id = 1 AND vehicle_type_id = 2`
+
+	tests := []struct {
+		name   string
+		run    func(r ParkingLotRepo) error
+		change string // Statement changing the spots, which must come after the lock
+	}{
+		{
+			name: "growing",
+			run: func(r ParkingLotRepo) error {
+				_, err := r.ResizeParkingSpace(context.Background(), 1, 2, 8, false)
+				return err
+			},
+			change: `INSERT INTO "spots"`,
+		},
+		{
+			name: "shrinking",
+			run: func(r ParkingLotRepo) error {
+				_, err := r.ResizeParkingSpace(context.Background(), 1, 2, 3, false)
+				return err
+			},
+			change: `DELETE FROM "spots" WHERE id IN (7,8)`,
+		},
+		{
+			name: "shrinking below occupancy",
+			run: func(r ParkingLotRepo) error {
+				_, err := r.ResizeParkingSpace(context.Background(), 1, 2, 1, true)
+				return err
+			},
+			change: `DELETE FROM "spots" WHERE id IN (7,8)`,
+		},
+		{
+			name: "adding a spot",
+			run: func(r ParkingLotRepo) error {
+				return r.CreateSpot(context.Background(), &models.Spot{ParkingLotId: 1, VehicleTypeId: 2, Label: "B-1"})
+			},
+			change: `INSERT INTO "spots"`,
+		},
+		{
+			name: "removing a spot",
+			run: func(r ParkingLotRepo) error {
+				err := r.DeleteSpot(context.Background(), &models.Spot{ID: 7, ParkingLotId: 1, VehicleTypeId: 2})
+				if errors.Is(err, ErrSpotOccupied) {
+					return nil // A dry run deletes no row
+				}
+				return err
+			},
+			change: `DELETE FROM "spots" WHERE id = 7 AND occupied = false`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Changing spots takes a transaction, which the fake connection begins
+			db, log := openFakeDB(t, &fakeConn{}, true, TenantScope{})
+			stubRows(t, db, &models.ParkingSpace{ID: 1, ParkingLotId: 1, VehicleTypeId: 2, TotalSpots: 5,
+				AvailableSpots: 2}, []uint{7, 8})
+
+			if err := tt.run(NewParkingLotRepo(db)); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+
+			locked, changed := -1, -1
+			for i, statement := range log.statements {
+				if locked < 0 && strings.Contains(statement, lock) && strings.HasSuffix(statement, "FOR UPDATE") {
+					locked = i
+				}
+				if changed < 0 && strings.HasPrefix(statement, tt.change) {
+					changed = i
+				}
+			}
+			if locked < 0 || changed < 0 || locked > changed {
+				t.Errorf("statements = %q, want the parking space locked before %s", log.statements, tt.change)
+			}
+		})
+	}
+}
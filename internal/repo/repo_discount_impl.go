@@ -0,0 +1,208 @@
+package repo
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"parking_lot_service/internal/repo/models"
+)
+
+// GetMerchants retrieves the merchants of a parking lot ordered by name.
+func (s *impl) GetMerchants(ctx context.Context, parkingLotId int) ([]*models.Merchant, error) {
+	var merchants []*models.Merchant
+
+	err := s.db.
+		WithContext(ctx).
+		Where("parking_lot_id = ?", parkingLotId).
+		Order("name").
+		Find(&merchants).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return merchants, nil
+}
+
+// GetMerchantById retrieves a single merchant by its ID.
+func (s *impl) GetMerchantById(ctx context.Context, merchantId uint) (*models.Merchant, error) {
+	var merchant models.Merchant
+
+	err := s.db.
+		WithContext(ctx).
+		Where("id = ?", merchantId).
+		First(&merchant).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &merchant, nil
+}
+
+// CreateMerchant registers a merchant with a parking lot.
+func (s *impl) CreateMerchant(ctx context.Context, merchant *models.Merchant) error {
+	return s.db.
+		WithContext(ctx).
+		Create(merchant).
+		Error
+}
+
+// GetDiscountRules retrieves the discount rules of a parking lot ordered by name.
+func (s *impl) GetDiscountRules(ctx context.Context, parkingLotId int) ([]*models.DiscountRule, error) {
+	var discountRules []*models.DiscountRule
+
+	err := s.db.
+		WithContext(ctx).
+		Where("parking_lot_id = ?", parkingLotId).
+		Order("name").
+		Find(&discountRules).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return discountRules, nil
+}
+
+// GetDiscountRuleById retrieves a single discount rule by its ID.
+func (s *impl) GetDiscountRuleById(ctx context.Context, discountRuleId uint) (*models.DiscountRule, error) {
+	var discountRule models.DiscountRule
+
+	err := s.db.
+		WithContext(ctx).
+		Where("id = ?", discountRuleId).
+		First(&discountRule).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &discountRule, nil
+}
+
+// GetDiscountRuleByCode retrieves the discount rule of a parking lot with the given discount code.
+func (s *impl) GetDiscountRuleByCode(ctx context.Context, parkingLotId int, code string) (*models.DiscountRule, error) {
+	var discountRule models.DiscountRule
+
+	err := s.db.
+		WithContext(ctx).
+		Where("parking_lot_id = ? AND code = ?", parkingLotId, code).
+		First(&discountRule).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &discountRule, nil
+}
+
+// CreateDiscountRule adds a discount rule to a parking lot.
+func (s *impl) CreateDiscountRule(ctx context.Context, discountRule *models.DiscountRule) error {
+	return s.db.
+		WithContext(ctx).
+		Create(discountRule).
+		Error
+}
+
+// DeleteDiscountRule deletes a discount rule. Validations already issued keep their copy of the rule.
+func (s *impl) DeleteDiscountRule(ctx context.Context, discountRuleId uint) error {
+	res := s.db.
+		WithContext(ctx).
+		Where("id = ?", discountRuleId).
+		Delete(&models.DiscountRule{})
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CountDiscountRulesByParkingLotId counts the merchant validations and discount codes of a parking lot.
+func (s *impl) CountDiscountRulesByParkingLotId(ctx context.Context, parkingLotId int) (int64, error) {
+	var count int64
+
+	err := s.db.
+		WithContext(ctx).
+		Model(&models.DiscountRule{}).
+		Where("parking_lot_id = ?", parkingLotId).
+		Count(&count).
+		Error
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// CountValidationsByParkingLotId counts the validations applied to the parking sessions of a parking lot.
+func (s *impl) CountValidationsByParkingLotId(ctx context.Context, parkingLotId int) (int64, error) {
+	var count int64
+
+	err := s.db.
+		WithContext(ctx).
+		Model(&models.Validation{}).
+		Where("session_id IN (?)", s.db.
+			WithContext(ctx).
+			Model(&models.ParkingSession{}).
+			Select("id").
+			Where("parking_lot_id = ?", parkingLotId)).
+		Count(&count).
+		Error
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// CreateValidation applies a discount rule to a parking session. It returns gorm.ErrDuplicatedKey when the
+// rule has already been applied to the session.
+func (s *impl) CreateValidation(ctx context.Context, validation *models.Validation) error {
+	return s.db.
+		WithContext(ctx).
+		Create(validation).
+		Error
+}
+
+// GetValidations retrieves the validations of a parking session in the order they were issued.
+func (s *impl) GetValidations(ctx context.Context, sessionId uint) ([]*models.Validation, error) {
+	var validations []*models.Validation
+
+	err := s.db.
+		WithContext(ctx).
+		Where("session_id = ?", sessionId).
+		Order("id").
+		Find(&validations).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return validations, nil
+}
+
+// SaveValidations stores the validations of a closed parking session with the amounts they took off its fare.
+// Validations without an ID are created.
+func (s *impl) SaveValidations(ctx context.Context, validations []*models.Validation) error {
+	for _, validation := range validations {
+		err := s.db.
+			WithContext(ctx).
+			Save(validation).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
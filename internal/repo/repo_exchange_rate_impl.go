@@ -0,0 +1,53 @@
+package repo
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"parking_lot_service/internal/repo/models"
+)
+
+// GetExchangeRates retrieves the configured exchange rates ordered by currency pair.
+func (s *impl) GetExchangeRates(ctx context.Context) ([]*models.ExchangeRate, error) {
+	var exchangeRates []*models.ExchangeRate
+
+	err := s.db.
+		WithContext(ctx).
+		Order("base_currency, quote_currency").
+		Find(&exchangeRates).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return exchangeRates, nil
+}
+
+// SaveExchangeRate adds the exchange rate of a currency pair, or replaces the rate when the pair already has one.
+func (s *impl) SaveExchangeRate(ctx context.Context, exchangeRate *models.ExchangeRate) error {
+	return s.db.
+		WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "base_currency"}, {Name: "quote_currency"}},
+			DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+		}).
+		Create(exchangeRate).
+		Error
+}
+
+// DeleteExchangeRate removes the exchange rate of a currency pair.
+func (s *impl) DeleteExchangeRate(ctx context.Context, exchangeRateId uint) error {
+	res := s.db.
+		WithContext(ctx).
+		Where("id = ?", exchangeRateId).
+		Delete(&models.ExchangeRate{})
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package repo
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"parking_lot_service/internal/repo/models"
+)
+
+// GetHolidays retrieves the public holidays of a parking lot ordered by date, optionally only those between
+// two local dates, both inclusive. An empty date disables the corresponding bound.
+func (s *impl) GetHolidays(ctx context.Context, parkingLotId int, fromDate, toDate string) ([]*models.Holiday, error) {
+	var holidays []*models.Holiday
+
+	query := s.db.
+		WithContext(ctx).
+		Where("parking_lot_id = ?", parkingLotId)
+	if fromDate != "" {
+		query = query.Where("date >= ?", fromDate)
+	}
+	if toDate != "" {
+		query = query.Where("date <= ?", toDate)
+	}
+
+	err := query.
+		Order("date").
+		Find(&holidays).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return holidays, nil
+}
+
+// GetHolidayById retrieves a single public holiday by its ID.
+func (s *impl) GetHolidayById(ctx context.Context, holidayId uint) (*models.Holiday, error) {
+	var holiday models.Holiday
+
+	err := s.db.
+		WithContext(ctx).
+		Where("id = ?", holidayId).
+		First(&holiday).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &holiday, nil
+}
+
+// CreateHoliday adds a public holiday to the calendar of a parking lot. It returns gorm.ErrDuplicatedKey when
+// the lot already has a holiday on that date.
+func (s *impl) CreateHoliday(ctx context.Context, holiday *models.Holiday) error {
+	return s.db.
+		WithContext(ctx).
+		Create(holiday).
+		Error
+}
+
+// DeleteHoliday removes a public holiday from the calendar of its parking lot.
+func (s *impl) DeleteHoliday(ctx context.Context, holidayId uint) error {
+	res := s.db.
+		WithContext(ctx).
+		Where("id = ?", holidayId).
+		Delete(&models.Holiday{})
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
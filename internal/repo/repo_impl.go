@@ -3,168 +3,374 @@ package repo
 import (
 	"context"
 	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"parking_lot_service/internal/money"
 	"parking_lot_service/internal/repo/models"
+	"time"
 )
 
-// SeedParkingSpace seeds the database with initial parking space records if none exist.
-// It checks for existing records, and if none are found, it inserts predefined parking spaces.
+// SeedParkingSpace seeds the database with the initial vehicle types, parking lots, parking spaces, tariffs and spots.
+// Every table is seeded independently and only while it is still empty, so databases created by an older
+// release only receive the records of the tables that were added since. Parking spaces are only seeded
+// together with a fresh lot catalogue; lots seeded next to legacy spaces get the IDs those spaces reference.
 func (s *impl) SeedParkingSpace(ctx context.Context) error {
+	// Insert all records in a single transaction
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		if err := seedVehicleTypes(tx); err != nil {
+			return err
+		}
+		if err := seedParkingLots(tx); err != nil {
+			return err
+		}
+		if err := seedTariffs(tx); err != nil {
+			return err
+		}
+		return seedSpots(tx)
+	})
+}
+
+func seedVehicleTypes(tx *gorm.DB) error {
 	var count int64
-	err := s.db.
-		WithContext(ctx).
-		Model(&models.ParkingSpace{}).
+	err := tx.
+		Model(&models.VehicleType{}).
 		Count(&count).
 		Error
 
 	if err != nil {
-		return fmt.Errorf("error counting existing parking spaces: %w", err)
+		return fmt.Errorf("error counting existing vehicle types: %w", err)
 	}
 
 	if count > 0 {
 		return nil // Skip seeding if records already exist
 	}
 
+	vehicleTypes := []*models.VehicleType{
+		{Code: "MOTORCYCLES_SCOOTERS", DisplayName: "Motorcycles/Scooters", SizeClass: models.SizeClassSmall},
+		{Code: "CARS_SUVS", DisplayName: "Cars/SUVs", SizeClass: models.SizeClassMedium},
+		{Code: "BUSES_TRUCKS", DisplayName: "Buses/Trucks", SizeClass: models.SizeClassLarge},
+	}
+
+	for _, vehicleType := range vehicleTypes {
+		err = tx.
+			Create(vehicleType).
+			Error
+		if err != nil {
+			return fmt.Errorf("error creating vehicle type: %w", err)
+		}
+	}
+	return nil
+}
+
+func seedParkingLots(tx *gorm.DB) error {
+	var lotCount, spaceCount int64
+	err := tx.
+		Model(&models.ParkingLot{}).
+		Count(&lotCount).
+		Error
+
+	if err != nil {
+		return fmt.Errorf("error counting existing parking lots: %w", err)
+	}
+
+	if lotCount > 0 {
+		return nil // Skip seeding if records already exist
+	}
+
+	lotA := &models.ParkingLot{Name: "Parking Lot A", Timezone: "UTC", Status: models.ParkingLotStatusActive}
+	lotB := &models.ParkingLot{Name: "Parking Lot B", Timezone: "UTC", Status: models.ParkingLotStatusActive}
+	for _, lot := range []*models.ParkingLot{lotA, lotB} {
+		err = tx.
+			Create(lot).
+			Error
+		if err != nil {
+			return fmt.Errorf("error creating parking lot: %w", err)
+		}
+	}
+
+	err = tx.
+		Model(&models.ParkingSpace{}).
+		Count(&spaceCount).
+		Error
+
+	if err != nil {
+		return fmt.Errorf("error counting existing parking spaces: %w", err)
+	}
+
+	if spaceCount > 0 {
+		return nil // Legacy parking spaces already reference the seeded lots
+	}
+
+	vehicleTypes, err := seededVehicleTypeIds(tx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		motorcycles = vehicleTypes["MOTORCYCLES_SCOOTERS"]
+		cars        = vehicleTypes["CARS_SUVS"]
+		buses       = vehicleTypes["BUSES_TRUCKS"]
+	)
+
 	parkingSpaces := []models.ParkingSpace{
 		// Parking Lot A
-		{ParkingLotId: models.ParkingLotA, VehicleTypeId: models.MotorcyclesAndScooters, AvailableSpots: 50},
-		{ParkingLotId: models.ParkingLotA, VehicleTypeId: models.CarsAndSUVs, AvailableSpots: 30},
-		{ParkingLotId: models.ParkingLotA, VehicleTypeId: models.BusesAndTrucks, AvailableSpots: 20},
+		{ParkingLotId: lotA.ID, VehicleTypeId: motorcycles, AvailableSpots: 50, TotalSpots: 50},
+		{ParkingLotId: lotA.ID, VehicleTypeId: cars, AvailableSpots: 30, TotalSpots: 30},
+		{ParkingLotId: lotA.ID, VehicleTypeId: buses, AvailableSpots: 20, TotalSpots: 20},
 		// Parking Lot B
-		{ParkingLotId: models.ParkingLotB, VehicleTypeId: models.MotorcyclesAndScooters, AvailableSpots: 100},
-		{ParkingLotId: models.ParkingLotB, VehicleTypeId: models.CarsAndSUVs, AvailableSpots: 80},
-		{ParkingLotId: models.ParkingLotB, VehicleTypeId: models.BusesAndTrucks, AvailableSpots: 40},
+		{ParkingLotId: lotB.ID, VehicleTypeId: motorcycles, AvailableSpots: 100, TotalSpots: 100},
+		{ParkingLotId: lotB.ID, VehicleTypeId: cars, AvailableSpots: 80, TotalSpots: 80},
+		{ParkingLotId: lotB.ID, VehicleTypeId: buses, AvailableSpots: 40, TotalSpots: 40},
 	}
 
-	// Insert all parking spaces in a single transaction
-	tx := s.db.WithContext(ctx).Begin()
 	for _, space := range parkingSpaces {
+		if space.VehicleTypeId == 0 {
+			continue // The seeded vehicle type has been removed by an administrator
+		}
 		err = tx.
 			Create(&space).
 			Error
 		if err != nil {
-			tx.Rollback()
 			return fmt.Errorf("error creating parking space: %w", err)
 		}
 	}
+	return nil
+}
+
+// seedTariffs creates the original tariffs of the seeded lots, effective since the beginning of time
+// so that vehicles parked before the tariffs table existed are priced as before.
+func seedTariffs(tx *gorm.DB) error {
+	var count int64
+	err := tx.
+		Model(&models.Tariff{}).
+		Count(&count).
+		Error
+
+	if err != nil {
+		return fmt.Errorf("error counting existing tariffs: %w", err)
+	}
 
+	if count > 0 {
+		return nil // Skip seeding if records already exist
+	}
+
+	var parkingLots []*models.ParkingLot
 	err = tx.
-		Commit().
+		Where("name IN ?", []string{"Parking Lot A", "Parking Lot B"}).
+		Find(&parkingLots).
 		Error
 
 	if err != nil {
-		return fmt.Errorf("error committing parking spaces: %w", err)
+		return fmt.Errorf("error fetching seeded parking lots: %w", err)
 	}
 
-	return nil
-}
+	lots := make(map[string]int, len(parkingLots))
+	for _, lot := range parkingLots {
+		lots[lot.Name] = lot.ID
+	}
 
-// GetParkingSpaces retrieves all parking spaces from the database.
-func (s *impl) GetParkingSpaces(ctx context.Context) ([]*models.ParkingSpace, error) {
-	var parkingSpaces []*models.ParkingSpace
+	vehicleTypes, err := seededVehicleTypeIds(tx)
+	if err != nil {
+		return err
+	}
 
-	// Query the database to fetch all parking spaces
-	res := s.db.
-		WithContext(ctx).
-		Find(&parkingSpaces)
+	var (
+		lotA        = lots["Parking Lot A"]
+		lotB        = lots["Parking Lot B"]
+		motorcycles = vehicleTypes["MOTORCYCLES_SCOOTERS"]
+		cars        = vehicleTypes["CARS_SUVS"]
+		buses       = vehicleTypes["BUSES_TRUCKS"]
+		since       = time.Unix(0, 0).UTC()
+	)
 
-	if res.Error != nil {
-		return nil, res.Error
+	// Rates are in the minor units of the default currency
+	tariffs := []models.Tariff{
+		// Parking Lot A
+		{ParkingLotId: lotA, VehicleTypeId: motorcycles, HourlyRate: 500, EffectiveFrom: since},
+		{ParkingLotId: lotA, VehicleTypeId: cars, HourlyRate: 2050, EffectiveFrom: since},
+		{ParkingLotId: lotA, VehicleTypeId: buses, HourlyRate: 5000, DayRate: 50000, DayRateHours: 24, EffectiveFrom: since},
+		// Parking Lot B
+		{ParkingLotId: lotB, VehicleTypeId: motorcycles, HourlyRate: 1050, EffectiveFrom: since},
+		{ParkingLotId: lotB, VehicleTypeId: cars, FirstHourRate: 5000, AdditionalHourRate: 2500, EffectiveFrom: since},
+		{ParkingLotId: lotB, VehicleTypeId: buses, HourlyRate: 10000, EffectiveFrom: since},
 	}
 
-	return parkingSpaces, nil
+	for _, tariff := range tariffs {
+		if tariff.ParkingLotId == 0 || tariff.VehicleTypeId == 0 {
+			continue // The seeded lot or vehicle type has been removed by an administrator
+		}
+		tariff.Currency = money.DefaultCurrency
+		err = tx.
+			Create(&tariff).
+			Error
+		if err != nil {
+			return fmt.Errorf("error creating tariff: %w", err)
+		}
+	}
+	return nil
 }
 
-// GetFreeParkingSpaceById retrieves free parking spaces for a given parking lot ID.
-func (s *impl) GetFreeParkingSpaceById(ctx context.Context, parkingLotId int) ([]*models.ParkingSpace, error) {
+// seedSpots creates numbered spots for every parking space that has none yet, which covers the seeded lots as
+// well as parking spaces that predate individual spots. Vehicles parked before spots existed are put on the
+// new spots in order of arrival.
+func seedSpots(tx *gorm.DB) error {
 	var parkingSpaces []*models.ParkingSpace
-
-	// Query parking spaces where ParkingLotId matches
-	err := s.db.WithContext(ctx).
-		Where("parking_lot_id = ?", parkingLotId).
+	err := tx.
+		Where(`NOT EXISTS (
+			SELECT 1 FROM spots
+			WHERE spots.parking_lot_id = parking_spaces.parking_lot_id
+			AND spots.vehicle_type_id = parking_spaces.vehicle_type_id)`).
 		Find(&parkingSpaces).
 		Error
 
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("error fetching parking spaces without spots: %w", err)
 	}
 
-	return parkingSpaces, nil
-}
+	for _, parkingSpace := range parkingSpaces {
+		var (
+			vehicleType     models.VehicleType
+			spots           []*models.Spot
+			parkingSessions []*models.ParkingSession
+		)
 
-// SaveParkedVehicle saves a parked vehicle record to the database.
-func (s *impl) SaveParkedVehicle(ctx context.Context, vehicleDetail *models.ParkedVehicle) error {
-	err := s.
-		db.
-		WithContext(ctx).
-		Create(&vehicleDetail).
-		Error
-	if err != nil {
-		return err
+		err = tx.
+			Where("id = ?", parkingSpace.VehicleTypeId).
+			First(&vehicleType).
+			Error
+		if err != nil {
+			return fmt.Errorf("error fetching vehicle type of parking space: %w", err)
+		}
+
+		err = createNumberedSpots(tx, parkingSpace.ParkingLotId, &vehicleType, parkingSpace.TotalSpots)
+		if err != nil {
+			return fmt.Errorf("error creating spots: %w", err)
+		}
+
+		err = tx.
+			Where("parking_lot_id = ? AND vehicle_type_id = ?", parkingSpace.ParkingLotId, parkingSpace.VehicleTypeId).
+			Order("level, label").
+			Find(&spots).
+			Error
+		if err != nil {
+			return fmt.Errorf("error fetching spots: %w", err)
+		}
+
+		err = tx.
+			Where("parking_lot_id = ? AND vehicle_type_id = ? AND status = ? AND spot_id IS NULL",
+				parkingSpace.ParkingLotId, parkingSpace.VehicleTypeId, models.ParkingSessionStatusOpen).
+			Order("entry_time").
+			Find(&parkingSessions).
+			Error
+		if err != nil {
+			return fmt.Errorf("error fetching parking sessions: %w", err)
+		}
+
+		for i, parkingSession := range parkingSessions {
+			if i >= len(spots) {
+				break
+			}
+			err = tx.
+				Model(&models.Spot{}).
+				Where("id = ?", spots[i].ID).
+				Update("occupied", true).
+				Error
+			if err != nil {
+				return fmt.Errorf("error occupying spot: %w", err)
+			}
+
+			err = tx.
+				Model(&models.ParkingSession{}).
+				Where("id = ?", parkingSession.ID).
+				Update("spot_id", spots[i].ID).
+				Error
+			if err != nil {
+				return fmt.Errorf("error assigning spot to parking session: %w", err)
+			}
+		}
 	}
 	return nil
 }
 
-// GetAvailableParkingSpotsByParkingLotIdAndVehicleId counts available parking spots by parking lot ID and vehicle type ID.
-func (s *impl) GetAvailableParkingSpotsByParkingLotIdAndVehicleId(ctx context.Context,
-	parkingLotId, vehicleId int) (int, error) {
-	resp := models.ParkingSpace{}
-	err := s.db.WithContext(ctx).
-		Model(&models.ParkingSpace{}).
-		Where("parking_lot_id = ? AND vehicle_type_id = ?", parkingLotId, vehicleId).
-		First(&resp).
+// seededVehicleTypeIds maps the codes of the seeded vehicle types to their IDs.
+func seededVehicleTypeIds(tx *gorm.DB) (map[string]int, error) {
+	var vehicleTypes []*models.VehicleType
+	err := tx.
+		Where("code IN ?", []string{"MOTORCYCLES_SCOOTERS", "CARS_SUVS", "BUSES_TRUCKS"}).
+		Find(&vehicleTypes).
 		Error
 
 	if err != nil {
-		return 0, err
+		return nil, fmt.Errorf("error fetching seeded vehicle types: %w", err)
 	}
-	return resp.AvailableSpots, nil
-}
-
-// UpdateParkingSpace updates the available spots of a parking space in the database.
-func (s *impl) UpdateParkingSpace(ctx context.Context, parkingSpace *models.ParkingSpace) error {
-	err := s.
-		db.
-		WithContext(ctx).
-		Model(&models.ParkingSpace{}).
-		Where("parking_lot_id = ? AND vehicle_type_id = ?", parkingSpace.ParkingLotId, parkingSpace.VehicleTypeId).
-		Updates(map[string]interface{}{
-			"available_spots": parkingSpace.AvailableSpots,
-		}).
-		Error
 
-	if err != nil {
-		return err
+	ids := make(map[string]int, len(vehicleTypes))
+	for _, vehicleType := range vehicleTypes {
+		ids[vehicleType.Code] = vehicleType.ID
 	}
-	return nil
+	return ids, nil
 }
 
-// GetParkedVehicle checks if a parked vehicle exists in the database and returns it.
-func (s *impl) GetParkedVehicle(ctx context.Context, vehicleNumber string) (*models.ParkedVehicle, error) {
-	var existingVehicle models.ParkedVehicle
+// GetFreeParkingSpaceById retrieves free parking spaces for a given parking lot ID.
+func (s *impl) GetFreeParkingSpaceById(ctx context.Context, parkingLotId int) ([]*models.ParkingSpace, error) {
+	var parkingSpaces []*models.ParkingSpace
 
-	// Check if the vehicle exists
+	// Query parking spaces where ParkingLotId matches
 	err := s.db.WithContext(ctx).
-		Where("vehicle_number = ?", vehicleNumber).
-		First(&existingVehicle).
+		Where("parking_lot_id = ?", parkingLotId).
+		Find(&parkingSpaces).
 		Error
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &existingVehicle, nil
+	return parkingSpaces, nil
 }
 
-// DeleteParkedVehicle deletes the specified parked vehicle from the database.
-func (s *impl) DeleteParkedVehicle(ctx context.Context, parkedVehicle *models.ParkedVehicle) error {
-	// Delete the vehicle
-	err := s.db.WithContext(ctx).
-		Delete(parkedVehicle).
+// LockParkingSpaces locks the parking spaces of a parking lot for the given vehicle types, in the order of the
+// vehicle types so that concurrent callers cannot deadlock. Reservations and pass products are booked against a
+// locked parking space, so the spots held in it can be counted until the transaction ends.
+func (s *impl) LockParkingSpaces(ctx context.Context, parkingLotId int, vehicleTypeIds []int) error {
+	var parkingSpaces []*models.ParkingSpace
+
+	return s.db.
+		WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("parking_lot_id = ? AND vehicle_type_id IN ?", parkingLotId, vehicleTypeIds).
+		Order("vehicle_type_id").
+		Find(&parkingSpaces).
 		Error
+}
 
-	if err != nil {
-		return err
+// DecrementAvailableSpots takes one spot of a parking space in a single conditional update, so concurrent
+// callers can never take more spots than are free. Spots held for reservations are not taken, it reports
+// false when the parking space has no free spot beyond heldSpots.
+func (s *impl) DecrementAvailableSpots(ctx context.Context, parkingLotId, vehicleTypeId, heldSpots int) (bool, error) {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.ParkingSpace{}).
+		Where("parking_lot_id = ? AND vehicle_type_id = ? AND available_spots > ?",
+			parkingLotId, vehicleTypeId, heldSpots).
+		Update("available_spots", gorm.Expr("available_spots - 1"))
+
+	if res.Error != nil {
+		return false, res.Error
 	}
+	return res.RowsAffected == 1, nil
+}
 
-	return nil
+// IncrementAvailableSpots gives one spot back to a parking space in a single conditional update.
+// It reports false when all spots of the parking space are already free.
+func (s *impl) IncrementAvailableSpots(ctx context.Context, parkingLotId, vehicleTypeId int) (bool, error) {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.ParkingSpace{}).
+		Where("parking_lot_id = ? AND vehicle_type_id = ? AND available_spots < total_spots", parkingLotId, vehicleTypeId).
+		Update("available_spots", gorm.Expr("available_spots + 1"))
+
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
 }
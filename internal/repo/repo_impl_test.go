@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// newFakeConnRepo returns a repo that executes its statements on conn, and the log of the statements. Its
+// queries fail.
+func newFakeConnRepo(t *testing.T, conn *fakeConn) (ParkingLotRepo, *statementLog) {
+	t.Helper()
+
+	db, log := openFakeDB(t, conn, false, TenantScope{})
+	return NewParkingLotRepo(db), log
+}
+
+// The service relies on these updates to never oversell a parking space or a spot, whatever the number of
+// concurrent requests: each is a single conditional statement, and only the caller whose statement changed
+// the row wins.
+func TestTakingSpots_IsConditional(t *testing.T) {
+	tests := []struct {
+		name      string
+		run       func(r ParkingLotRepo) (bool, error)
+		update    string
+		condition string // Condition that makes the update safe under concurrency
+	}{
+		{
+			name: "DecrementAvailableSpots",
+			run: func(r ParkingLotRepo) (bool, error) {
+				return r.DecrementAvailableSpots(context.Background(), 1, 2, 3)
+			},
+			update:    `UPDATE "parking_spaces" SET "available_spots"=available_spots - 1`,
+			condition: `WHERE parking_lot_id = 1 AND vehicle_type_id = 2 AND available_spots > 3`,
+		},
+		{
+			name: "IncrementAvailableSpots",
+			run: func(r ParkingLotRepo) (bool, error) {
+				return r.IncrementAvailableSpots(context.Background(), 1, 2)
+			},
+			update:    `UPDATE "parking_spaces" SET "available_spots"=available_spots + 1`,
+			condition: `WHERE parking_lot_id = 1 AND vehicle_type_id = 2 AND available_spots < total_spots`,
+		},
+		{
+			name: "OccupySpot",
+			run: func(r ParkingLotRepo) (bool, error) {
+				return r.OccupySpot(context.Background(), 7)
+			},
+			update:    `UPDATE "spots" SET "occupied"=true,"usage_count"=usage_count + 1`,
+			condition: `WHERE id = 7 AND occupied = false`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The caller whose update changed the row took the spot, any other lost the race
+			for rowsAffected, want := range []bool{false, true} {
+				r, log := newFakeConnRepo(t, &fakeConn{rowsAffected: int64(rowsAffected)})
+
+				got, err := tt.run(r)
+				if err != nil || got != want {
+					t.Errorf("with %d rows affected = %v, %v, want %v", rowsAffected, got, err, want)
+				}
+				if len(log.statements) != 1 || !strings.HasPrefix(log.statements[0], tt.update) ||
+					!strings.HasSuffix(log.statements[0], tt.condition) {
+					t.Errorf("statements = %q, want %s ... %s", log.statements, tt.update, tt.condition)
+				}
+			}
+		})
+	}
+}
+
+func TestLockParkingSpaces_LocksInVehicleTypeOrder(t *testing.T) {
+	r, log := newDryRunRepo(t)
+
+	if err := r.LockParkingSpaces(context.Background(), 1, []int{4, 2}); err != nil {
+		t.Fatalf("LockParkingSpaces() error = %v", err)
+	}
+	want := `SELECT * FROM "parking_spaces" WHERE parking_lot_id = 1 AND vehicle_type_id IN (4,2) ` +
+		`ORDER BY vehicle_type_id FOR UPDATE`
+	if len(log.statements) != 1 || log.statements[0] != want {
+		t.Errorf("statements = %q, want %s", log.statements, want)
+	}
+}
@@ -0,0 +1,158 @@
+package repo
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"parking_lot_service/internal/repo/models"
+	"time"
+)
+
+// GetInvoiceById retrieves a single invoice by its ID with its payments and refunds in the order they were made.
+func (s *impl) GetInvoiceById(ctx context.Context, invoiceId uint) (*models.Invoice, error) {
+	var invoice models.Invoice
+
+	err := s.db.
+		WithContext(ctx).
+		Preload("Payments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id")
+		}).
+		Where("id = ?", invoiceId).
+		First(&invoice).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &invoice, nil
+}
+
+// GetOpenInvoice retrieves the pending or partially paid invoice of a parking session.
+func (s *impl) GetOpenInvoice(ctx context.Context, sessionId uint) (*models.Invoice, error) {
+	var invoice models.Invoice
+
+	err := s.db.
+		WithContext(ctx).
+		Where("session_id = ? AND status IN ?", sessionId,
+			[]models.InvoiceStatus{models.InvoiceStatusPending, models.InvoiceStatusPartiallyPaid}).
+		Order("id DESC").
+		First(&invoice).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &invoice, nil
+}
+
+// GetSessionInvoices retrieves the invoices of a parking session with their payments and refunds, oldest first.
+func (s *impl) GetSessionInvoices(ctx context.Context, sessionId uint) ([]*models.Invoice, error) {
+	var invoices []*models.Invoice
+
+	err := s.db.
+		WithContext(ctx).
+		Preload("Payments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id")
+		}).
+		Where("session_id = ?", sessionId).
+		Order("id").
+		Find(&invoices).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return invoices, nil
+}
+
+// CreateInvoice adds a pending invoice for the stay of a parking session.
+func (s *impl) CreateInvoice(ctx context.Context, invoice *models.Invoice) error {
+	invoice.Status = models.InvoiceStatusPending
+	return s.db.
+		WithContext(ctx).
+		Create(invoice).
+		Error
+}
+
+// VoidInvoice cancels a pending invoice before it is replaced. It returns gorm.ErrRecordNotFound when the
+// invoice has been paid in part, settled or voided by a concurrent request.
+func (s *impl) VoidInvoice(ctx context.Context, invoiceId uint) error {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.Invoice{}).
+		Where("id = ? AND status = ?", invoiceId, models.InvoiceStatusPending).
+		Update("status", models.InvoiceStatusVoid)
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// PayInvoice adds a payment to what has been paid of a pending or partially paid invoice, the invoice is
+// paid once nothing is left to pay. It reports false when the invoice is no longer open or the payment is
+// more than what is left to pay, because of a concurrent payment.
+func (s *impl) PayInvoice(ctx context.Context, invoiceId uint, amount int64, at time.Time) (bool, error) {
+	const balance = "amount_minor - (paid_amount_minor - refunded_amount_minor)"
+
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.Invoice{}).
+		Where("id = ? AND status IN ? AND "+balance+" >= ?", invoiceId,
+			[]models.InvoiceStatus{models.InvoiceStatusPending, models.InvoiceStatusPartiallyPaid}, amount).
+		Updates(map[string]interface{}{
+			"paid_amount_minor": gorm.Expr("paid_amount_minor + ?", amount),
+			"status": gorm.Expr("CASE WHEN "+balance+" = ? THEN ? ELSE ? END",
+				amount, models.InvoiceStatusPaid, models.InvoiceStatusPartiallyPaid),
+			"settled_at": gorm.Expr("CASE WHEN "+balance+" = ? THEN ?::timestamptz ELSE NULL END", amount, at),
+		})
+
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// BillInvoiceToAccount settles a pending or partially paid invoice by billing what is left to pay to the
+// account of the customer. It reports false when the invoice is no longer open.
+func (s *impl) BillInvoiceToAccount(ctx context.Context, invoiceId uint, accountReference string,
+	at time.Time) (bool, error) {
+
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.Invoice{}).
+		Where("id = ? AND status IN ?", invoiceId,
+			[]models.InvoiceStatus{models.InvoiceStatusPending, models.InvoiceStatusPartiallyPaid}).
+		Updates(map[string]interface{}{
+			"status":            models.InvoiceStatusAccountBilled,
+			"account_reference": accountReference,
+			"settled_at":        at,
+		})
+
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// RefundInvoice adds a refund to what has been paid back of an invoice.
+func (s *impl) RefundInvoice(ctx context.Context, invoiceId uint, amount int64) error {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.Invoice{}).
+		Where("id = ?", invoiceId).
+		Update("refunded_amount_minor", gorm.Expr("refunded_amount_minor + ?", amount))
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"parking_lot_service/internal/repo/models"
+	"time"
+)
+
+// AddOutboxEvent writes an event to the outbox. Called through the repo of a transaction, the event is only
+// published if the transaction commits.
+func (s *impl) AddOutboxEvent(ctx context.Context, event *models.OutboxEvent) error {
+	return s.db.
+		WithContext(ctx).
+		Create(event).
+		Error
+}
+
+// ClaimOutboxEvents leases up to limit undelivered events due at now, oldest first, and counts the delivery
+// attempt. Events leased by another dispatcher are skipped rather than waited for.
+func (s *impl) ClaimOutboxEvents(ctx context.Context, now time.Time, lease time.Duration,
+	limit int) ([]*models.OutboxEvent, error) {
+
+	var events []*models.OutboxEvent
+
+	err := s.
+		transaction(ctx, func(tx *gorm.DB) error {
+			err := tx.
+				Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("delivered_at IS NULL AND next_attempt_at <= ?", now).
+				Order("id").
+				Limit(limit).
+				Find(&events).
+				Error
+			if err != nil || len(events) == 0 {
+				return err
+			}
+
+			ids := make([]uint, 0, len(events))
+			for _, event := range events {
+				event.Attempts++
+				event.NextAttemptAt = now.Add(lease)
+				ids = append(ids, event.ID)
+			}
+			return tx.
+				Model(&models.OutboxEvent{}).
+				Where("id IN ?", ids).
+				Updates(map[string]interface{}{
+					"attempts":        gorm.Expr("attempts + 1"),
+					"next_attempt_at": now.Add(lease),
+				}).
+				Error
+		})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// MarkOutboxEventDelivered records that an event was delivered, it is not claimed again.
+func (s *impl) MarkOutboxEventDelivered(ctx context.Context, eventId uint, deliveredAt time.Time) error {
+	return s.db.
+		WithContext(ctx).
+		Model(&models.OutboxEvent{}).
+		Where("id = ? AND delivered_at IS NULL", eventId).
+		Updates(map[string]interface{}{
+			"delivered_at": deliveredAt,
+			"last_error":   "",
+		}).
+		Error
+}
+
+// RetryOutboxEvent records the failed delivery of an event and when it is due again.
+func (s *impl) RetryOutboxEvent(ctx context.Context, eventId uint, nextAttemptAt time.Time, lastError string) error {
+	return s.db.
+		WithContext(ctx).
+		Model(&models.OutboxEvent{}).
+		Where("id = ? AND delivered_at IS NULL", eventId).
+		Updates(map[string]interface{}{
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastError,
+		}).
+		Error
+}
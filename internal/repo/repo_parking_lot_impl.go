@@ -0,0 +1,237 @@
+package repo
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"parking_lot_service/internal/repo/models"
+)
+
+// GetParkingLots retrieves all parking lots ordered by their ID.
+func (s *impl) GetParkingLots(ctx context.Context) ([]*models.ParkingLot, error) {
+	var parkingLots []*models.ParkingLot
+
+	err := s.db.
+		WithContext(ctx).
+		Order("id").
+		Find(&parkingLots).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parkingLots, nil
+}
+
+// GetParkingLotById retrieves a single parking lot by its ID.
+func (s *impl) GetParkingLotById(ctx context.Context, parkingLotId int) (*models.ParkingLot, error) {
+	var parkingLot models.ParkingLot
+
+	err := s.db.
+		WithContext(ctx).
+		Where("id = ?", parkingLotId).
+		First(&parkingLot).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &parkingLot, nil
+}
+
+// CreateParkingLot inserts a new parking lot into the catalogue.
+func (s *impl) CreateParkingLot(ctx context.Context, parkingLot *models.ParkingLot) error {
+	return s.db.
+		WithContext(ctx).
+		Create(parkingLot).
+		Error
+}
+
+// UpdateParkingLot updates the metadata of an existing parking lot.
+func (s *impl) UpdateParkingLot(ctx context.Context, parkingLot *models.ParkingLot) error {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.ParkingLot{}).
+		Where("id = ?", parkingLot.ID).
+		Updates(map[string]interface{}{
+			"name":                      parkingLot.Name,
+			"address":                   parkingLot.Address,
+			"timezone":                  parkingLot.Timezone,
+			"status":                    parkingLot.Status,
+			"allocation_strategy":       parkingLot.AllocationStrategy,
+			"reservation_grace_minutes": parkingLot.ReservationGraceMinutes,
+			"max_discount_percent":      parkingLot.MaxDiscountPercent,
+			"currency":                  parkingLot.Currency,
+			"locale":                    parkingLot.Locale,
+		})
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdatePenaltyPolicy replaces the lost-ticket and overstay penalties of a parking lot.
+func (s *impl) UpdatePenaltyPolicy(ctx context.Context, parkingLot *models.ParkingLot) error {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.ParkingLot{}).
+		Where("id = ?", parkingLot.ID).
+		Updates(map[string]interface{}{
+			"lost_ticket_fee_minor":    parkingLot.LostTicketFee,
+			"lost_ticket_full_day":     parkingLot.LostTicketFullDay,
+			"max_stay_days":            parkingLot.MaxStayDays,
+			"overstay_surcharge_minor": parkingLot.OverstaySurcharge,
+		})
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteParkingLot deletes a parking lot together with its parking space, spot, upsize rule, tariff,
+// reservation, pass, discount rule, merchant and holiday records. Validations are kept with the parking sessions they
+// were applied to.
+func (s *impl) DeleteParkingLot(ctx context.Context, parkingLotId int) error {
+	return s.
+		transaction(ctx, func(tx *gorm.DB) error {
+			err := tx.
+				Where("parking_lot_id = ?", parkingLotId).
+				Delete(&models.ParkingSpace{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.
+				Where("parking_lot_id = ?", parkingLotId).
+				Delete(&models.Spot{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.
+				Where("parking_lot_id = ?", parkingLotId).
+				Delete(&models.UpsizeRule{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.
+				Where("tariff_id IN (?)", tx.
+					Model(&models.Tariff{}).
+					Select("id").
+					Where("parking_lot_id = ?", parkingLotId)).
+				Delete(&models.TariffBand{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.
+				Where("parking_lot_id = ?", parkingLotId).
+				Delete(&models.Tariff{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.
+				Where("parking_lot_id = ?", parkingLotId).
+				Delete(&models.Reservation{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.
+				Where("pass_id IN (?)", tx.
+					Model(&models.Pass{}).
+					Select("id").
+					Where("parking_lot_id = ?", parkingLotId)).
+				Delete(&models.PassVehicle{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.
+				Where("parking_lot_id = ?", parkingLotId).
+				Delete(&models.Pass{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.
+				Where("parking_lot_id = ?", parkingLotId).
+				Delete(&models.PassProduct{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.
+				Where("parking_lot_id = ?", parkingLotId).
+				Delete(&models.DiscountRule{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.
+				Where("parking_lot_id = ?", parkingLotId).
+				Delete(&models.Merchant{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.
+				Where("parking_lot_id = ?", parkingLotId).
+				Delete(&models.Holiday{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			res := tx.
+				Where("id = ?", parkingLotId).
+				Delete(&models.ParkingLot{})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+			return nil
+		})
+}
+
+// CountParkedVehiclesByParkingLotId counts the vehicles currently parked in a parking lot.
+func (s *impl) CountParkedVehiclesByParkingLotId(ctx context.Context, parkingLotId int) (int64, error) {
+	var count int64
+
+	err := s.db.
+		WithContext(ctx).
+		Model(&models.ParkingSession{}).
+		Where("parking_lot_id = ? AND status = ?", parkingLotId, models.ParkingSessionStatusOpen).
+		Count(&count).
+		Error
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,282 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"parking_lot_service/internal/repo/models"
+	"time"
+)
+
+var (
+	// ErrPassCapacity is returned when the pass products of a pool would reserve more spots than the pool has.
+	ErrPassCapacity = errors.New("reserved spots exceed the spots of the pool")
+	// ErrPassProductInUse is returned when a pass product with passes that are still valid would be deleted.
+	ErrPassProductInUse = errors.New("pass product has valid passes")
+)
+
+// GetPassProducts retrieves the pass products of a parking lot ordered by name.
+func (s *impl) GetPassProducts(ctx context.Context, parkingLotId int) ([]*models.PassProduct, error) {
+	var passProducts []*models.PassProduct
+
+	err := s.db.
+		WithContext(ctx).
+		Where("parking_lot_id = ?", parkingLotId).
+		Order("name").
+		Find(&passProducts).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return passProducts, nil
+}
+
+// GetPassProductById retrieves a single pass product by its ID.
+func (s *impl) GetPassProductById(ctx context.Context, passProductId uint) (*models.PassProduct, error) {
+	var passProduct models.PassProduct
+
+	err := s.db.
+		WithContext(ctx).
+		Where("id = ?", passProductId).
+		First(&passProduct).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &passProduct, nil
+}
+
+// CreatePassProduct adds a pass product to a parking lot. It returns gorm.ErrRecordNotFound when the lot has no
+// parking space for the vehicle type and ErrPassCapacity when the products of the pool would reserve more spots
+// than it has.
+func (s *impl) CreatePassProduct(ctx context.Context, passProduct *models.PassProduct) error {
+	return s.
+		transaction(ctx, func(tx *gorm.DB) error {
+			var parkingSpace models.ParkingSpace
+
+			err := tx.
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("parking_lot_id = ? AND vehicle_type_id = ?", passProduct.ParkingLotId, passProduct.VehicleTypeId).
+				First(&parkingSpace).
+				Error
+			if err != nil {
+				return err
+			}
+
+			var reservedSpots int
+			err = tx.
+				Model(&models.PassProduct{}).
+				Select("COALESCE(SUM(reserved_spots), 0)").
+				Where("parking_lot_id = ? AND vehicle_type_id = ?", passProduct.ParkingLotId, passProduct.VehicleTypeId).
+				Scan(&reservedSpots).
+				Error
+			if err != nil {
+				return err
+			}
+			if reservedSpots+passProduct.ReservedSpots > parkingSpace.TotalSpots {
+				return ErrPassCapacity
+			}
+
+			return tx.
+				Create(passProduct).
+				Error
+		})
+}
+
+// DeletePassProduct deletes a pass product together with its expired and cancelled passes.
+// It returns ErrPassProductInUse when one of its passes is still valid.
+func (s *impl) DeletePassProduct(ctx context.Context, passProductId uint) error {
+	return s.
+		transaction(ctx, func(tx *gorm.DB) error {
+			var validPasses int64
+
+			err := tx.
+				Model(&models.Pass{}).
+				Where("pass_product_id = ? AND status = ? AND valid_to > ?",
+					passProductId, models.PassStatusActive, time.Now()).
+				Count(&validPasses).
+				Error
+			if err != nil {
+				return err
+			}
+			if validPasses > 0 {
+				return ErrPassProductInUse
+			}
+
+			err = tx.
+				Where("pass_id IN (?)", tx.
+					Model(&models.Pass{}).
+					Select("id").
+					Where("pass_product_id = ?", passProductId)).
+				Delete(&models.PassVehicle{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.
+				Where("pass_product_id = ?", passProductId).
+				Delete(&models.Pass{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			res := tx.
+				Where("id = ?", passProductId).
+				Delete(&models.PassProduct{})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+			return nil
+		})
+}
+
+// CountPassProductsByParkingLotId counts the pass products of a parking lot.
+func (s *impl) CountPassProductsByParkingLotId(ctx context.Context, parkingLotId int) (int64, error) {
+	var count int64
+
+	err := s.db.
+		WithContext(ctx).
+		Model(&models.PassProduct{}).
+		Where("parking_lot_id = ?", parkingLotId).
+		Count(&count).
+		Error
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetPassById retrieves a single pass by its ID together with its registered vehicles.
+func (s *impl) GetPassById(ctx context.Context, passId uint) (*models.Pass, error) {
+	var pass models.Pass
+
+	err := s.db.
+		WithContext(ctx).
+		Preload("Vehicles").
+		Where("id = ?", passId).
+		First(&pass).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &pass, nil
+}
+
+// CreatePass saves a sold pass together with its registered vehicles.
+func (s *impl) CreatePass(ctx context.Context, pass *models.Pass) error {
+	return s.db.
+		WithContext(ctx).
+		Create(pass).
+		Error
+}
+
+// ReplacePassVehicles replaces the vehicles registered on a pass.
+func (s *impl) ReplacePassVehicles(ctx context.Context, passId uint, vehicles []models.PassVehicle) error {
+	return s.
+		transaction(ctx, func(tx *gorm.DB) error {
+			err := tx.
+				Where("pass_id = ?", passId).
+				Delete(&models.PassVehicle{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			for i := range vehicles {
+				vehicles[i].PassId = passId
+			}
+			return tx.
+				Create(&vehicles).
+				Error
+		})
+}
+
+// CancelPass cancels an active pass, it stops covering parking from the given time.
+// It returns gorm.ErrRecordNotFound when the pass is not active.
+func (s *impl) CancelPass(ctx context.Context, passId uint, at time.Time) error {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.Pass{}).
+		Where("id = ? AND status = ?", passId, models.PassStatusActive).
+		Updates(map[string]interface{}{
+			"status":   models.PassStatusCancelled,
+			"valid_to": gorm.Expr("LEAST(valid_to, ?)", at),
+		})
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetActivePass retrieves the active pass a vehicle is registered on for a vehicle type in a parking lot at the
+// given time. When the vehicle is registered on several passes, the one valid the longest is returned.
+func (s *impl) GetActivePass(ctx context.Context, parkingLotId, vehicleTypeId int, vehicleNumber string,
+	at time.Time) (*models.Pass, error) {
+
+	var pass models.Pass
+
+	err := s.db.
+		WithContext(ctx).
+		Joins("JOIN pass_vehicles ON pass_vehicles.pass_id = passes.id").
+		Where("passes.parking_lot_id = ? AND passes.vehicle_type_id = ? AND pass_vehicles.vehicle_number = ?",
+			parkingLotId, vehicleTypeId, vehicleNumber).
+		Where("passes.status = ? AND passes.valid_from <= ? AND passes.valid_to > ?",
+			models.PassStatusActive, at, at).
+		Order("passes.valid_to DESC").
+		First(&pass).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &pass, nil
+}
+
+// GetPassHolds counts the reserved spots of every pass product that are not taken by one of its pass holders.
+// A zero parking lot ID returns the holds of all lots.
+func (s *impl) GetPassHolds(ctx context.Context, parkingLotId int) ([]*models.PassHold, error) {
+	var holds []*models.PassHold
+
+	query := s.db.
+		WithContext(ctx).
+		Model(&models.PassProduct{}).
+		Select("pass_products.id AS pass_product_id, pass_products.parking_lot_id, pass_products.vehicle_type_id, "+
+			"GREATEST(pass_products.reserved_spots - COUNT(parking_sessions.id), 0) AS held_spots").
+		Joins("LEFT JOIN passes ON passes.pass_product_id = pass_products.id").
+		Joins("LEFT JOIN parking_sessions ON parking_sessions.pass_id = passes.id AND parking_sessions.status = ?",
+			models.ParkingSessionStatusOpen).
+		Where("pass_products.reserved_spots > 0")
+	if parkingLotId > 0 {
+		query = query.Where("pass_products.parking_lot_id = ?", parkingLotId)
+	}
+
+	err := query.
+		Group("pass_products.id").
+		Scan(&holds).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return holds, nil
+}
@@ -0,0 +1,76 @@
+package repo
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"parking_lot_service/internal/repo/models"
+)
+
+// GetPaymentById retrieves a single payment or refund by its ID.
+func (s *impl) GetPaymentById(ctx context.Context, paymentId uint) (*models.Payment, error) {
+	var payment models.Payment
+
+	err := s.db.
+		WithContext(ctx).
+		Where("id = ?", paymentId).
+		First(&payment).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+// CreatePayment records a payment or refund before it is sent to its gateway.
+func (s *impl) CreatePayment(ctx context.Context, payment *models.Payment) error {
+	return s.db.
+		WithContext(ctx).
+		Create(payment).
+		Error
+}
+
+// CompletePayment records the answer of the gateway to a pending payment or refund, with the status, gateway
+// reference and message of the payment. It returns gorm.ErrRecordNotFound when the payment is not pending.
+func (s *impl) CompletePayment(ctx context.Context, payment *models.Payment) error {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.Payment{}).
+		Where("id = ? AND status = ?", payment.ID, models.PaymentStatusPending).
+		Updates(map[string]interface{}{
+			"status":    payment.Status,
+			"reference": payment.Reference,
+			"message":   payment.Message,
+		})
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RefundPayment adds a refund to what has been paid back of a successful charge, the charge is refunded once
+// all of it has been paid back. It reports false when the refund is more than what is left of the charge,
+// because of a concurrent refund.
+func (s *impl) RefundPayment(ctx context.Context, paymentId uint, amount int64) (bool, error) {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.Payment{}).
+		Where("id = ? AND kind = ? AND status IN ? AND amount_minor - refunded_amount_minor >= ?", paymentId,
+			models.PaymentKindCharge,
+			[]models.PaymentStatus{models.PaymentStatusSucceeded, models.PaymentStatusPartiallyRefunded}, amount).
+		Updates(map[string]interface{}{
+			"refunded_amount_minor": gorm.Expr("refunded_amount_minor + ?", amount),
+			"status": gorm.Expr("CASE WHEN amount_minor - refunded_amount_minor = ? THEN ? ELSE ? END",
+				amount, models.PaymentStatusRefunded, models.PaymentStatusPartiallyRefunded),
+		})
+
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
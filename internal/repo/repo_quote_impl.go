@@ -0,0 +1,31 @@
+package repo
+
+import (
+	"context"
+	"parking_lot_service/internal/repo/models"
+)
+
+// CreateFareQuote records the fare quoted to a parking session.
+func (s *impl) CreateFareQuote(ctx context.Context, quote *models.FareQuote) error {
+	return s.db.
+		WithContext(ctx).
+		Create(quote).
+		Error
+}
+
+// GetFareQuoteById retrieves a single fare quote by its ID.
+func (s *impl) GetFareQuoteById(ctx context.Context, quoteId uint) (*models.FareQuote, error) {
+	var quote models.FareQuote
+
+	err := s.db.
+		WithContext(ctx).
+		Where("id = ?", quoteId).
+		First(&quote).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &quote, nil
+}
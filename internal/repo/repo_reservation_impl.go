@@ -0,0 +1,191 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"parking_lot_service/internal/repo/models"
+	"time"
+)
+
+var (
+	// ErrReservationCapacity is returned when the holds of a reservation window would exceed the spots of its pool.
+	ErrReservationCapacity = errors.New("no capacity left for the reservation window")
+	// ErrReservationNotBooked is returned when a reservation is no longer booked, it was checked in, cancelled
+	// or marked as a no-show in the meantime.
+	ErrReservationNotBooked = errors.New("reservation is not booked")
+)
+
+// GetReservationById retrieves a single reservation by its ID.
+func (s *impl) GetReservationById(ctx context.Context, reservationId uint) (*models.Reservation, error) {
+	var reservation models.Reservation
+
+	err := s.db.
+		WithContext(ctx).
+		Where("id = ?", reservationId).
+		First(&reservation).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &reservation, nil
+}
+
+// CreateReservation books a reservation. It returns gorm.ErrRecordNotFound when the parking lot has no parking
+// space for the vehicle type and ErrReservationCapacity when the pool has no spot left to hold for the window.
+func (s *impl) CreateReservation(ctx context.Context, reservation *models.Reservation) error {
+	return s.
+		transaction(ctx, func(tx *gorm.DB) error {
+			if err := checkReservationCapacity(tx, reservation); err != nil {
+				return err
+			}
+
+			reservation.Status = models.ReservationStatusBooked
+			return tx.
+				Create(reservation).
+				Error
+		})
+}
+
+// UpdateReservation moves a booked reservation to another window or vehicle. It returns ErrReservationCapacity
+// when the pool has no spot left to hold for the new window and ErrReservationNotBooked when the reservation
+// is no longer booked.
+func (s *impl) UpdateReservation(ctx context.Context, reservation *models.Reservation) error {
+	return s.
+		transaction(ctx, func(tx *gorm.DB) error {
+			if err := checkReservationCapacity(tx, reservation); err != nil {
+				return err
+			}
+
+			res := tx.
+				Model(&models.Reservation{}).
+				Where("id = ? AND status = ?", reservation.ID, models.ReservationStatusBooked).
+				Updates(map[string]interface{}{
+					"vehicle_number": reservation.VehicleNumber,
+					"start_time":     reservation.StartTime,
+					"end_time":       reservation.EndTime,
+					"hold_until":     reservation.HoldUntil,
+				})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return ErrReservationNotBooked
+			}
+			return nil
+		})
+}
+
+// CancelReservation cancels a booked reservation and releases its hold.
+// It returns ErrReservationNotBooked when the reservation is no longer booked.
+func (s *impl) CancelReservation(ctx context.Context, reservationId uint) error {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.Reservation{}).
+		Where("id = ? AND status = ?", reservationId, models.ReservationStatusBooked).
+		Update("status", models.ReservationStatusCancelled)
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrReservationNotBooked
+	}
+	return nil
+}
+
+// CheckInReservation converts a booked reservation into the parking session of the arrived vehicle.
+// It reports false when the reservation is no longer booked.
+func (s *impl) CheckInReservation(ctx context.Context, reservationId, sessionId uint) (bool, error) {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.Reservation{}).
+		Where("id = ? AND status = ?", reservationId, models.ReservationStatusBooked).
+		Updates(map[string]interface{}{
+			"status":     models.ReservationStatusCheckedIn,
+			"session_id": sessionId,
+		})
+
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// GetReservationHolds counts the spots held for booked reservations at the given time per parking lot and
+// vehicle type. A zero parking lot ID counts the holds of all lots.
+func (s *impl) GetReservationHolds(ctx context.Context, parkingLotId int, at time.Time) ([]*models.SpotHold, error) {
+	var holds []*models.SpotHold
+
+	query := s.db.
+		WithContext(ctx).
+		Model(&models.Reservation{}).
+		Select("parking_lot_id, vehicle_type_id, COUNT(*) AS held_spots").
+		Where("status = ? AND start_time <= ? AND hold_until > ?", models.ReservationStatusBooked, at, at)
+	if parkingLotId > 0 {
+		query = query.Where("parking_lot_id = ?", parkingLotId)
+	}
+
+	err := query.
+		Group("parking_lot_id, vehicle_type_id").
+		Scan(&holds).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return holds, nil
+}
+
+// MarkNoShowReservations marks the booked reservations whose hold has passed as no-shows.
+// It returns the number of reservations marked.
+func (s *impl) MarkNoShowReservations(ctx context.Context, at time.Time) (int64, error) {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.Reservation{}).
+		Where("status = ? AND hold_until <= ?", models.ReservationStatusBooked, at).
+		Update("status", models.ReservationStatusNoShow)
+
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	return res.RowsAffected, nil
+}
+
+// checkReservationCapacity locks the parking space of a reservation and makes sure one more spot can be held
+// for its window. Every other booked reservation whose hold overlaps the window is counted against the pool,
+// even when those holds do not overlap each other, so the check errs on the side of turning bookings away.
+func checkReservationCapacity(tx *gorm.DB, reservation *models.Reservation) error {
+	var parkingSpace models.ParkingSpace
+
+	err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("parking_lot_id = ? AND vehicle_type_id = ?", reservation.ParkingLotId, reservation.VehicleTypeId).
+		First(&parkingSpace).
+		Error
+	if err != nil {
+		return err
+	}
+
+	var overlapping int64
+	err = tx.
+		Model(&models.Reservation{}).
+		Where("parking_lot_id = ? AND vehicle_type_id = ? AND status = ?",
+			reservation.ParkingLotId, reservation.VehicleTypeId, models.ReservationStatusBooked).
+		Where("start_time < ? AND hold_until > ? AND id <> ?",
+			reservation.HoldUntil, reservation.StartTime, reservation.ID).
+		Count(&overlapping).
+		Error
+	if err != nil {
+		return err
+	}
+
+	if overlapping >= int64(parkingSpace.TotalSpots) {
+		return ErrReservationCapacity
+	}
+	return nil
+}
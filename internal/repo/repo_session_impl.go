@@ -0,0 +1,197 @@
+package repo
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"parking_lot_service/internal/repo/models"
+)
+
+// OpenParkingSession saves the parking session of a vehicle that has just been parked. Opening a second
+// session for a vehicle that is still parked fails with gorm.ErrDuplicatedKey.
+func (s *impl) OpenParkingSession(ctx context.Context, parkingSession *models.ParkingSession) error {
+	parkingSession.Status = models.ParkingSessionStatusOpen
+	return s.db.
+		WithContext(ctx).
+		Create(parkingSession).
+		Error
+}
+
+// GetParkingSessionByTicket retrieves the parking session a ticket was issued for.
+func (s *impl) GetParkingSessionByTicket(ctx context.Context, ticketNumber string) (*models.ParkingSession, error) {
+	var parkingSession models.ParkingSession
+
+	err := s.db.
+		WithContext(ctx).
+		Where("ticket_number = ?", ticketNumber).
+		First(&parkingSession).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &parkingSession, nil
+}
+
+// GetParkingSessionById retrieves a single parking session by its ID.
+func (s *impl) GetParkingSessionById(ctx context.Context, sessionId uint) (*models.ParkingSession, error) {
+	var parkingSession models.ParkingSession
+
+	err := s.db.
+		WithContext(ctx).
+		Where("id = ?", sessionId).
+		First(&parkingSession).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &parkingSession, nil
+}
+
+// CloseParkingSession records the exit time and fares of a parking session and closes it.
+// It returns gorm.ErrRecordNotFound when the session has already been closed by a concurrent request.
+func (s *impl) CloseParkingSession(ctx context.Context, parkingSession *models.ParkingSession) error {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.ParkingSession{}).
+		Where("id = ? AND status = ?", parkingSession.ID, models.ParkingSessionStatusOpen).
+		Updates(map[string]interface{}{
+			"exit_time":        parkingSession.ExitTime,
+			"currency":         parkingSession.Currency,
+			"gross_fare_minor": parkingSession.GrossFare,
+			"fare_minor":       parkingSession.Fare,
+			"status":           models.ParkingSessionStatusClosed,
+		})
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	parkingSession.Status = models.ParkingSessionStatusClosed
+	return nil
+}
+
+// PayParkingSession records that the stay of an open parking session has been paid up to its PaidAt, with the
+// fares paid so far. It returns gorm.ErrRecordNotFound when the session has been closed, or paid up to the same
+// or a later time by a concurrent request.
+func (s *impl) PayParkingSession(ctx context.Context, parkingSession *models.ParkingSession) error {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.ParkingSession{}).
+		Where("id = ? AND status = ? AND (paid_at IS NULL OR paid_at < ?)", parkingSession.ID,
+			models.ParkingSessionStatusOpen, parkingSession.PaidAt).
+		Updates(map[string]interface{}{
+			"paid_at":          parkingSession.PaidAt,
+			"currency":         parkingSession.Currency,
+			"gross_fare_minor": parkingSession.GrossFare,
+			"fare_minor":       parkingSession.Fare,
+		})
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// AdjustSessionFare changes the fare of a closed parking session from one amount to another. It reports false
+// when the session is not closed or its fare has been changed by a concurrent request.
+func (s *impl) AdjustSessionFare(ctx context.Context, sessionId uint, from, to int64) (bool, error) {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.ParkingSession{}).
+		Where("id = ? AND status = ? AND fare_minor = ?", sessionId, models.ParkingSessionStatusClosed, from).
+		Update("fare_minor", to)
+
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// CreateLostTicketExit records the identity checked for a vehicle leaving without its ticket.
+func (s *impl) CreateLostTicketExit(ctx context.Context, lostTicketExit *models.LostTicketExit) error {
+	return s.db.
+		WithContext(ctx).
+		Create(lostTicketExit).
+		Error
+}
+
+// GetParkingSessions retrieves the parking sessions matching the filter, newest first. Only sessions with
+// an ID below the cursor of the filter are returned, so the ID of the last session of a page is the cursor
+// of the next one.
+func (s *impl) GetParkingSessions(ctx context.Context, filter *ParkingSessionFilter) ([]*models.ParkingSession, error) {
+	var parkingSessions []*models.ParkingSession
+
+	query := s.db.WithContext(ctx)
+	if filter.VehicleNumber != "" {
+		query = query.Where("vehicle_number = ?", filter.VehicleNumber)
+	}
+	if filter.ParkingLotId > 0 {
+		query = query.Where("parking_lot_id = ?", filter.ParkingLotId)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.EnteredFrom != nil {
+		query = query.Where("entry_time >= ?", *filter.EnteredFrom)
+	}
+	if filter.EnteredTo != nil {
+		query = query.Where("entry_time < ?", *filter.EnteredTo)
+	}
+	if filter.BeforeId > 0 {
+		query = query.Where("id < ?", filter.BeforeId)
+	}
+
+	err := query.
+		Order("id DESC").
+		Limit(filter.Limit).
+		Find(&parkingSessions).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parkingSessions, nil
+}
+
+// GetRevenueByCurrency adds up the fares of the closed parking sessions per currency, ordered by currency.
+func (s *impl) GetRevenueByCurrency(ctx context.Context, filter *RevenueFilter) ([]*models.RevenueTotal, error) {
+	var totals []*models.RevenueTotal
+
+	query := s.db.
+		WithContext(ctx).
+		Model(&models.ParkingSession{}).
+		Select("currency, COUNT(*) AS sessions, COALESCE(SUM(gross_fare_minor), 0) AS gross_fare, "+
+			"COALESCE(SUM(fare_minor), 0) AS fare").
+		Where("status = ?", models.ParkingSessionStatusClosed)
+	if filter.ParkingLotId > 0 {
+		query = query.Where("parking_lot_id = ?", filter.ParkingLotId)
+	}
+	if filter.ExitedFrom != nil {
+		query = query.Where("exit_time >= ?", *filter.ExitedFrom)
+	}
+	if filter.ExitedTo != nil {
+		query = query.Where("exit_time < ?", *filter.ExitedTo)
+	}
+
+	err := query.
+		Group("currency").
+		Order("currency").
+		Scan(&totals).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
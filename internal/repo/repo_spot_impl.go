@@ -0,0 +1,359 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"parking_lot_service/internal/repo/models"
+)
+
+// ErrSpotOccupied is returned when a spot that is occupied by a vehicle would be deleted.
+var ErrSpotOccupied = errors.New("spot is occupied")
+
+// GetFreeSpots retrieves the free spots of a parking lot across all vehicle types.
+func (s *impl) GetFreeSpots(ctx context.Context, parkingLotId int) ([]*models.Spot, error) {
+	var spots []*models.Spot
+
+	err := s.db.
+		WithContext(ctx).
+		Where("parking_lot_id = ? AND occupied = ?", parkingLotId, false).
+		Find(&spots).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return spots, nil
+}
+
+// OccupySpot marks a spot as occupied and counts the use, only if the spot is still free.
+// It returns false when a concurrent request has taken the spot in the meantime.
+func (s *impl) OccupySpot(ctx context.Context, spotId uint) (bool, error) {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.Spot{}).
+		Where("id = ? AND occupied = ?", spotId, false).
+		Updates(map[string]interface{}{
+			"occupied":    true,
+			"usage_count": gorm.Expr("usage_count + 1"),
+		})
+
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// ReleaseSpot frees a spot when its vehicle leaves. If the parking space of the spot is waiting for spots to
+// be removed after it was resized below its occupancy, the spot is deleted instead of being freed.
+func (s *impl) ReleaseSpot(ctx context.Context, spotId uint) error {
+	return s.
+		transaction(ctx, func(tx *gorm.DB) error {
+			var spot models.Spot
+
+			err := tx.
+				Where("id = ?", spotId).
+				First(&spot).
+				Error
+			if err != nil {
+				return err
+			}
+
+			res := tx.
+				Model(&models.ParkingSpace{}).
+				Where("parking_lot_id = ? AND vehicle_type_id = ? AND pending_removals > 0",
+					spot.ParkingLotId, spot.VehicleTypeId).
+				Update("pending_removals", gorm.Expr("pending_removals - 1"))
+			if res.Error != nil {
+				return res.Error
+			}
+
+			if res.RowsAffected == 1 {
+				return tx.
+					Delete(&spot).
+					Error
+			}
+
+			return tx.
+				Model(&models.Spot{}).
+				Where("id = ?", spot.ID).
+				Update("occupied", false).
+				Error
+		})
+}
+
+// GetSpots retrieves the spots of a parking lot ordered by level and label, optionally filtered by
+// vehicle type. A zero vehicle type ID disables the filter.
+func (s *impl) GetSpots(ctx context.Context, parkingLotId, vehicleTypeId int) ([]*models.Spot, error) {
+	var spots []*models.Spot
+
+	query := s.db.
+		WithContext(ctx).
+		Where("parking_lot_id = ?", parkingLotId)
+	if vehicleTypeId > 0 {
+		query = query.Where("vehicle_type_id = ?", vehicleTypeId)
+	}
+
+	err := query.
+		Order("level, label").
+		Find(&spots).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return spots, nil
+}
+
+// GetSpotById retrieves a single spot by its ID.
+func (s *impl) GetSpotById(ctx context.Context, spotId uint) (*models.Spot, error) {
+	var spot models.Spot
+
+	err := s.db.
+		WithContext(ctx).
+		Where("id = ?", spotId).
+		First(&spot).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &spot, nil
+}
+
+// CreateSpot adds a free spot to a parking lot and grows the parking space of its vehicle type by one,
+// creating the parking space if the lot had no spots for the vehicle type yet.
+func (s *impl) CreateSpot(ctx context.Context, spot *models.Spot) error {
+	return s.
+		transaction(ctx, func(tx *gorm.DB) error {
+			parkingSpace, err := lockParkingSpace(tx, spot.ParkingLotId, spot.VehicleTypeId)
+			if err != nil {
+				return err
+			}
+
+			spot.Occupied = false
+			err = tx.
+				Create(spot).
+				Error
+			if err != nil {
+				return err
+			}
+
+			return tx.
+				Model(&models.ParkingSpace{}).
+				Where("id = ?", parkingSpace.ID).
+				Updates(map[string]interface{}{
+					"total_spots":     gorm.Expr("total_spots + 1"),
+					"available_spots": gorm.Expr("available_spots + 1"),
+				}).
+				Error
+		})
+}
+
+// UpdateSpot updates the location, label, flags and entrance distance of a spot. Its parking space pool, size class
+// and occupancy are left unchanged.
+func (s *impl) UpdateSpot(ctx context.Context, spot *models.Spot) error {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.Spot{}).
+		Where("id = ?", spot.ID).
+		Updates(map[string]interface{}{
+			"level":             spot.Level,
+			"zone":              spot.Zone,
+			"label":             spot.Label,
+			"covered":           spot.Covered,
+			"handicap":          spot.Handicap,
+			"ev_charger":        spot.EVCharger,
+			"entrance_distance": spot.EntranceDistance,
+		})
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteSpot removes a free spot and shrinks the parking space of its vehicle type by one.
+// It returns ErrSpotOccupied when a vehicle is parked on the spot.
+func (s *impl) DeleteSpot(ctx context.Context, spot *models.Spot) error {
+	return s.
+		transaction(ctx, func(tx *gorm.DB) error {
+			parkingSpace, err := lockParkingSpace(tx, spot.ParkingLotId, spot.VehicleTypeId)
+			if err != nil {
+				return err
+			}
+
+			res := tx.
+				Where("id = ? AND occupied = ?", spot.ID, false).
+				Delete(&models.Spot{})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return ErrSpotOccupied
+			}
+
+			return tx.
+				Model(&models.ParkingSpace{}).
+				Where("id = ?", parkingSpace.ID).
+				Updates(map[string]interface{}{
+					"total_spots":     gorm.Expr("total_spots - 1"),
+					"available_spots": gorm.Expr("available_spots - 1"),
+				}).
+				Error
+		})
+}
+
+// GetSpotAvailability counts the free spots per parking lot and vehicle type. A zero parking lot ID
+// counts the spots of all lots.
+func (s *impl) GetSpotAvailability(ctx context.Context, parkingLotId int) ([]*models.SpotAvailability, error) {
+	var availability []*models.SpotAvailability
+
+	query := s.db.
+		WithContext(ctx).
+		Model(&models.Spot{}).
+		Select("parking_lot_id, vehicle_type_id, COUNT(*) AS free_spots").
+		Where("occupied = ?", false)
+	if parkingLotId > 0 {
+		query = query.Where("parking_lot_id = ?", parkingLotId)
+	}
+
+	err := query.
+		Group("parking_lot_id, vehicle_type_id").
+		Scan(&availability).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return availability, nil
+}
+
+// ReconcileParkingSpaces repairs drift between the spots and the spot counters of the parking spaces.
+// Spots marked occupied without an open parking session are freed first, then the total and available spots
+// of every parking space are recomputed from its spots and pending removals.
+func (s *impl) ReconcileParkingSpaces(ctx context.Context) error {
+	return s.
+		transaction(ctx, func(tx *gorm.DB) error {
+			err := tx.
+				Model(&models.Spot{}).
+				Where("occupied = ?", true).
+				Where("id NOT IN (?)", tx.
+					Model(&models.ParkingSession{}).
+					Select("spot_id").
+					Where("status = ? AND spot_id IS NOT NULL", models.ParkingSessionStatusOpen)).
+				Update("occupied", false).
+				Error
+			if err != nil {
+				return fmt.Errorf("error freeing orphaned spots: %w", err)
+			}
+
+			// Built through the model rather than as raw SQL so the corrections are recorded in the audit log
+			err = tx.
+				Model(&models.ParkingSpace{}).
+				Where(`EXISTS (
+					SELECT 1 FROM spots
+					WHERE spots.parking_lot_id = parking_spaces.parking_lot_id
+					AND spots.vehicle_type_id = parking_spaces.vehicle_type_id
+				)`).
+				Updates(map[string]interface{}{
+					"total_spots": gorm.Expr(`(
+						SELECT COUNT(*) FROM spots
+						WHERE spots.parking_lot_id = parking_spaces.parking_lot_id
+						AND spots.vehicle_type_id = parking_spaces.vehicle_type_id
+					) - pending_removals`),
+					"available_spots": gorm.Expr(`(
+						SELECT COUNT(*) FROM spots
+						WHERE spots.parking_lot_id = parking_spaces.parking_lot_id
+						AND spots.vehicle_type_id = parking_spaces.vehicle_type_id
+						AND NOT spots.occupied
+					) - pending_removals`),
+				}).
+				Error
+			if err != nil {
+				return fmt.Errorf("error recomputing spot counters: %w", err)
+			}
+			return nil
+		})
+}
+
+// lockParkingSpace locks the parking space of a parking lot for a vehicle type, creating an empty one if needed.
+func lockParkingSpace(tx *gorm.DB, parkingLotId, vehicleTypeId int) (*models.ParkingSpace, error) {
+	var parkingSpace models.ParkingSpace
+
+	err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("parking_lot_id = ? AND vehicle_type_id = ?", parkingLotId, vehicleTypeId).
+		First(&parkingSpace).
+		Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The parking space belongs to the tenant of its lot
+		parkingSpace = models.ParkingSpace{ParkingLotId: parkingLotId, VehicleTypeId: vehicleTypeId}
+		err = tx.
+			Model(&models.ParkingLot{}).
+			Select("tenant_id").
+			Where("id = ?", parkingLotId).
+			Scan(&parkingSpace.TenantId).
+			Error
+		if err == nil {
+			err = tx.
+				Create(&parkingSpace).
+				Error
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &parkingSpace, nil
+}
+
+// createNumberedSpots adds count free spots to a parking lot for a vehicle type. The spots are labelled with
+// the vehicle type code and a running number, skipping numbers whose label is already taken in the lot.
+func createNumberedSpots(tx *gorm.DB, parkingLotId int, vehicleType *models.VehicleType, count int) error {
+	var labels []string
+	err := tx.
+		Model(&models.Spot{}).
+		Where("parking_lot_id = ?", parkingLotId).
+		Pluck("label", &labels).
+		Error
+	if err != nil {
+		return err
+	}
+
+	taken := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		taken[label] = true
+	}
+
+	spots := make([]*models.Spot, 0, count)
+	for number := 1; len(spots) < count; number++ {
+		label := fmt.Sprintf("%s-%03d", vehicleType.Code, number)
+		if taken[label] {
+			continue
+		}
+		spots = append(spots, &models.Spot{
+			ParkingLotId:  parkingLotId,
+			VehicleTypeId: vehicleType.ID,
+			Label:         label,
+			SizeClass:     vehicleType.SizeClass,
+		})
+	}
+
+	if len(spots) == 0 {
+		return nil
+	}
+	return tx.
+		CreateInBatches(spots, 100).
+		Error
+}
@@ -0,0 +1,228 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"parking_lot_service/internal/repo/models"
+	"time"
+)
+
+// ErrTariffOverlap is returned when a tariff version would overlap another version of the same
+// parking lot and vehicle type that cannot be closed automatically.
+var ErrTariffOverlap = errors.New("tariff overlaps an existing version")
+
+// GetTariffs retrieves the tariff versions with their time bands ordered by effective date, optionally filtered
+// by parking lot and vehicle type. A zero ID disables the corresponding filter.
+func (s *impl) GetTariffs(ctx context.Context, parkingLotId, vehicleTypeId int) ([]*models.Tariff, error) {
+	var tariffs []*models.Tariff
+
+	query := s.db.
+		WithContext(ctx).
+		Preload("Bands")
+	if parkingLotId > 0 {
+		query = query.Where("parking_lot_id = ?", parkingLotId)
+	}
+	if vehicleTypeId > 0 {
+		query = query.Where("vehicle_type_id = ?", vehicleTypeId)
+	}
+
+	err := query.
+		Order("parking_lot_id, vehicle_type_id, effective_from").
+		Find(&tariffs).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tariffs, nil
+}
+
+// GetTariffById retrieves a single tariff version by its ID together with its time bands.
+func (s *impl) GetTariffById(ctx context.Context, tariffId uint) (*models.Tariff, error) {
+	var tariff models.Tariff
+
+	err := s.db.
+		WithContext(ctx).
+		Preload("Bands").
+		Where("id = ?", tariffId).
+		First(&tariff).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &tariff, nil
+}
+
+// GetTariffEffectiveAt retrieves the tariff version of a parking lot and vehicle type that was effective at the given time,
+// together with its time bands.
+func (s *impl) GetTariffEffectiveAt(ctx context.Context, parkingLotId, vehicleTypeId int,
+	at time.Time) (*models.Tariff, error) {
+	var tariff models.Tariff
+
+	err := s.db.
+		WithContext(ctx).
+		Preload("Bands").
+		Where("parking_lot_id = ? AND vehicle_type_id = ?", parkingLotId, vehicleTypeId).
+		Where("effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)", at, at).
+		Order("effective_from DESC").
+		First(&tariff).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &tariff, nil
+}
+
+// CreateTariff inserts a new tariff version with its time bands. The currently open ended version that started earlier is
+// closed at the effective date of the new one; any other overlap is rejected with ErrTariffOverlap.
+func (s *impl) CreateTariff(ctx context.Context, tariff *models.Tariff) error {
+	return s.
+		transaction(ctx, func(tx *gorm.DB) error {
+			var versions []*models.Tariff
+
+			// Lock the existing versions so concurrent edits of the same tariff are serialised
+			err := tx.
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("parking_lot_id = ? AND vehicle_type_id = ?", tariff.ParkingLotId, tariff.VehicleTypeId).
+				Find(&versions).
+				Error
+			if err != nil {
+				return err
+			}
+
+			for _, version := range versions {
+				if !tariffsOverlap(version, tariff) {
+					continue
+				}
+				if version.EffectiveTo != nil || !version.EffectiveFrom.Before(tariff.EffectiveFrom) {
+					return ErrTariffOverlap
+				}
+
+				err = tx.
+					Model(&models.Tariff{}).
+					Where("id = ?", version.ID).
+					Update("effective_to", tariff.EffectiveFrom).
+					Error
+				if err != nil {
+					return err
+				}
+			}
+
+			return tx.
+				Create(tariff).
+				Error
+		})
+}
+
+// UpdateTariffRates updates the currency, rates, rounding, grace periods, quote validity and daily cap of an existing tariff version and
+// replaces its time bands, its effective dates are left unchanged.
+func (s *impl) UpdateTariffRates(ctx context.Context, tariff *models.Tariff) error {
+	return s.
+		transaction(ctx, func(tx *gorm.DB) error {
+			res := tx.
+				Model(&models.Tariff{}).
+				Where("id = ?", tariff.ID).
+				Updates(map[string]interface{}{
+					"currency":                   tariff.Currency,
+					"hourly_rate_minor":          tariff.HourlyRate,
+					"day_rate_minor":             tariff.DayRate,
+					"day_rate_hours":             tariff.DayRateHours,
+					"first_hour_rate_minor":      tariff.FirstHourRate,
+					"additional_hour_rate_minor": tariff.AdditionalHourRate,
+					"rounding_minutes":           tariff.RoundingMinutes,
+					"entry_grace_minutes":        tariff.EntryGraceMinutes,
+					"exit_grace_minutes":         tariff.ExitGraceMinutes,
+					"quote_validity_minutes":     tariff.QuoteValidityMinutes,
+					"daily_cap_minor":            tariff.DailyCap,
+				})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+
+			err := tx.
+				Where("tariff_id = ?", tariff.ID).
+				Delete(&models.TariffBand{}).
+				Error
+			if err != nil {
+				return err
+			}
+			if len(tariff.Bands) == 0 {
+				return nil
+			}
+
+			for i := range tariff.Bands {
+				tariff.Bands[i].TariffId = tariff.ID
+			}
+			return tx.
+				Create(&tariff.Bands).
+				Error
+		})
+}
+
+// DeleteTariff deletes a tariff version together with its time bands. A version that was closed by the deleted one is extended
+// to the end of the deleted version again, so no gap is left behind.
+func (s *impl) DeleteTariff(ctx context.Context, tariff *models.Tariff) error {
+	return s.
+		transaction(ctx, func(tx *gorm.DB) error {
+			err := tx.
+				Where("tariff_id = ?", tariff.ID).
+				Delete(&models.TariffBand{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			res := tx.
+				Where("id = ?", tariff.ID).
+				Delete(&models.Tariff{})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+
+			return tx.
+				Model(&models.Tariff{}).
+				Where("parking_lot_id = ? AND vehicle_type_id = ?", tariff.ParkingLotId, tariff.VehicleTypeId).
+				Where("effective_to = ?", tariff.EffectiveFrom).
+				Update("effective_to", tariff.EffectiveTo).
+				Error
+		})
+}
+
+// tariffsOverlap reports whether the effective periods of two tariff versions intersect.
+func tariffsOverlap(a, b *models.Tariff) bool {
+	startsBeforeEndOf := func(x, y *models.Tariff) bool {
+		return y.EffectiveTo == nil || x.EffectiveFrom.Before(*y.EffectiveTo)
+	}
+	return startsBeforeEndOf(a, b) && startsBeforeEndOf(b, a)
+}
+
+// CountTariffsByParkingLotId counts the tariff versions of a parking lot.
+func (s *impl) CountTariffsByParkingLotId(ctx context.Context, parkingLotId int) (int64, error) {
+	var count int64
+
+	err := s.db.
+		WithContext(ctx).
+		Model(&models.Tariff{}).
+		Where("parking_lot_id = ?", parkingLotId).
+		Count(&count).
+		Error
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
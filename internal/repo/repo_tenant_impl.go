@@ -0,0 +1,66 @@
+package repo
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"parking_lot_service/internal/repo/models"
+)
+
+// GetTenants retrieves the tenants ordered by their ID.
+func (s *impl) GetTenants(ctx context.Context) ([]*models.Tenant, error) {
+	var tenants []*models.Tenant
+
+	err := s.db.
+		WithContext(ctx).
+		Order("id").
+		Find(&tenants).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tenants, nil
+}
+
+// GetTenantById retrieves a single tenant by its ID.
+func (s *impl) GetTenantById(ctx context.Context, tenantId uint) (*models.Tenant, error) {
+	var tenant models.Tenant
+
+	err := s.db.
+		WithContext(ctx).
+		Where("id = ?", tenantId).
+		First(&tenant).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &tenant, nil
+}
+
+// CreateTenant inserts a new tenant. It returns gorm.ErrDuplicatedKey when the name is taken.
+func (s *impl) CreateTenant(ctx context.Context, tenant *models.Tenant) error {
+	return s.db.
+		WithContext(ctx).
+		Create(tenant).
+		Error
+}
+
+// UpdateTenant renames an existing tenant.
+func (s *impl) UpdateTenant(ctx context.Context, tenant *models.Tenant) error {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.Tenant{}).
+		Where("id = ?", tenant.ID).
+		Update("name", tenant.Name)
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
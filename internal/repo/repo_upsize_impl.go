@@ -0,0 +1,59 @@
+package repo
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"parking_lot_service/internal/repo/models"
+)
+
+// GetUpsizeRules retrieves the upsize rules of a parking lot ordered by vehicle type and priority.
+func (s *impl) GetUpsizeRules(ctx context.Context, parkingLotId int) ([]*models.UpsizeRule, error) {
+	var rules []*models.UpsizeRule
+
+	err := s.db.
+		WithContext(ctx).
+		Where("parking_lot_id = ?", parkingLotId).
+		Order("vehicle_type_id, priority").
+		Find(&rules).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// ReplaceUpsizePolicy replaces the upsize rules and the upsize pricing of a parking lot.
+func (s *impl) ReplaceUpsizePolicy(ctx context.Context, parkingLotId int, pricing models.UpsizePricing,
+	rules []*models.UpsizeRule) error {
+
+	return s.
+		transaction(ctx, func(tx *gorm.DB) error {
+			res := tx.
+				Model(&models.ParkingLot{}).
+				Where("id = ?", parkingLotId).
+				Update("upsize_pricing", pricing)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+
+			err := tx.
+				Where("parking_lot_id = ?", parkingLotId).
+				Delete(&models.UpsizeRule{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			if len(rules) == 0 {
+				return nil
+			}
+			return tx.
+				Create(rules).
+				Error
+		})
+}
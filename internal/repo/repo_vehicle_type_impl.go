@@ -0,0 +1,133 @@
+package repo
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"parking_lot_service/internal/repo/models"
+)
+
+// GetVehicleTypes retrieves all registered vehicle types ordered by size class.
+func (s *impl) GetVehicleTypes(ctx context.Context) ([]*models.VehicleType, error) {
+	var vehicleTypes []*models.VehicleType
+
+	err := s.db.
+		WithContext(ctx).
+		Order("size_class, id").
+		Find(&vehicleTypes).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return vehicleTypes, nil
+}
+
+// GetVehicleTypeById retrieves a single vehicle type by its ID.
+func (s *impl) GetVehicleTypeById(ctx context.Context, vehicleTypeId int) (*models.VehicleType, error) {
+	var vehicleType models.VehicleType
+
+	err := s.db.
+		WithContext(ctx).
+		Where("id = ?", vehicleTypeId).
+		First(&vehicleType).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &vehicleType, nil
+}
+
+// CreateVehicleType registers a new vehicle type.
+func (s *impl) CreateVehicleType(ctx context.Context, vehicleType *models.VehicleType) error {
+	return s.db.
+		WithContext(ctx).
+		Create(vehicleType).
+		Error
+}
+
+// UpdateVehicleType updates an existing vehicle type.
+func (s *impl) UpdateVehicleType(ctx context.Context, vehicleType *models.VehicleType) error {
+	res := s.db.
+		WithContext(ctx).
+		Model(&models.VehicleType{}).
+		Where("id = ?", vehicleType.ID).
+		Updates(map[string]interface{}{
+			"code":               vehicleType.Code,
+			"display_name":       vehicleType.DisplayName,
+			"size_class":         vehicleType.SizeClass,
+			"allow_larger_spots": vehicleType.AllowLargerSpots,
+		})
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteVehicleType deletes a vehicle type together with the upsize rules that refer to it.
+func (s *impl) DeleteVehicleType(ctx context.Context, vehicleTypeId int) error {
+	return s.
+		transaction(ctx, func(tx *gorm.DB) error {
+			err := tx.
+				Where("vehicle_type_id = ? OR overflow_vehicle_type_id = ?", vehicleTypeId, vehicleTypeId).
+				Delete(&models.UpsizeRule{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			res := tx.
+				Where("id = ?", vehicleTypeId).
+				Delete(&models.VehicleType{})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+			return nil
+		})
+}
+
+// CountParkingSpacesByVehicleTypeId counts the parking space pools configured for a vehicle type across all lots.
+func (s *impl) CountParkingSpacesByVehicleTypeId(ctx context.Context, vehicleTypeId int) (int64, error) {
+	var count int64
+
+	err := s.db.
+		WithContext(ctx).
+		Model(&models.ParkingSpace{}).
+		Where("vehicle_type_id = ?", vehicleTypeId).
+		Count(&count).
+		Error
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// CountParkingSessionsByVehicleTypeId counts the parking sessions, open or closed, of vehicles of a vehicle type or
+// parked in its pool across all lots.
+func (s *impl) CountParkingSessionsByVehicleTypeId(ctx context.Context, vehicleTypeId int) (int64, error) {
+	var count int64
+
+	err := s.db.
+		WithContext(ctx).
+		Model(&models.ParkingSession{}).
+		Where("vehicle_type_id = ? OR spot_vehicle_type_id = ?", vehicleTypeId, vehicleTypeId).
+		Count(&count).
+		Error
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"parking_lot_service/internal/auth"
+	"parking_lot_service/internal/repo/models"
+	"reflect"
+)
+
+// tenantPaths lead from the foreign keys of rows without a tenant of their own to the tenant of the row they
+// belong to. The first foreign key a model has decides its tenant.
+var tenantPaths = []struct {
+	field    string
+	subquery string
+}{
+	{field: "ParkingLotId", subquery: "SELECT id FROM parking_lots WHERE tenant_id = ?"},
+	{field: "SessionId", subquery: "SELECT id FROM parking_sessions WHERE tenant_id = ?"},
+	{field: "TariffId", subquery: "SELECT id FROM tariffs WHERE tenant_id = ?"},
+	{field: "InvoiceId", subquery: "SELECT invoices.id FROM invoices " +
+		"JOIN parking_lots ON parking_lots.id = invoices.parking_lot_id WHERE parking_lots.tenant_id = ?"},
+	{field: "PassId", subquery: "SELECT passes.id FROM passes " +
+		"JOIN parking_lots ON parking_lots.id = passes.parking_lot_id WHERE parking_lots.tenant_id = ?"},
+}
+
+var tenantType = reflect.TypeOf(models.Tenant{})
+
+// TenantScope is a GORM plugin keeping the callers of a tenant to the rows of their tenant. The tenant is
+// taken from the principal of the statement context, so it applies to every query of the repo, which passes
+// the context of the request on. Queries, updates and deletes are narrowed down to the rows of the tenant, and
+// rows created for a tenant are stamped with it.
+//
+// Rows with a tenant column are matched on it, other rows by the row they belong to, e.g. the lot of a spot.
+// Vehicle types and exchange rates are shared by all tenants. Platform admins and work done without a
+// principal, like migrations and background jobs, are not scoped.
+type TenantScope struct{}
+
+// Name returns the name the plugin is registered by.
+func (TenantScope) Name() string {
+	return "tenant_scope"
+}
+
+// Initialize registers the callbacks of the plugin.
+func (TenantScope) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register("tenant_scope:query", scopeToTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("tenant_scope:row", scopeToTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant_scope:update", scopeToTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("tenant_scope:delete", scopeToTenant); err != nil {
+		return err
+	}
+	return callbacks.Create().Before("gorm:create").Register("tenant_scope:create", assignTenant)
+}
+
+// scopeToTenant narrows a statement down to the rows of the tenant of its caller.
+func scopeToTenant(db *gorm.DB) {
+	tenantId, ok := auth.TenantFromContext(db.Statement.Context)
+	if !ok || db.Error != nil {
+		return
+	}
+	if condition := tenantCondition(db.Statement, tenantId); condition != nil {
+		db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{condition}})
+	}
+}
+
+// assignTenant stamps rows created by the caller of a tenant with the tenant. An upsert only updates a row it
+// conflicts with if the row is the tenant's.
+func assignTenant(db *gorm.DB) {
+	tenantId, ok := auth.TenantFromContext(db.Statement.Context)
+	if !ok || db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	if db.Statement.Schema.LookUpField("TenantId") != nil {
+		db.Statement.SetColumn("TenantId", tenantId, true)
+	}
+
+	if c, ok := db.Statement.Clauses["ON CONFLICT"]; ok {
+		if onConflict, ok := c.Expression.(clause.OnConflict); ok && !onConflict.DoNothing {
+			if condition := tenantCondition(db.Statement, tenantId); condition != nil {
+				onConflict.Where.Exprs = append(onConflict.Where.Exprs, condition)
+				db.Statement.AddClause(onConflict)
+			}
+		}
+	}
+}
+
+// tenantCondition returns the condition matching the rows of a tenant in the table of a statement, or nil for
+// tables shared by all tenants.
+func tenantCondition(stmt *gorm.Statement, tenantId uint) clause.Expression {
+	if stmt.Schema == nil {
+		return nil
+	}
+	column := func(name string) clause.Column {
+		return clause.Column{Table: clause.CurrentTable, Name: name}
+	}
+
+	if stmt.Schema.ModelType == tenantType {
+		return clause.Eq{Column: column(stmt.Schema.PrioritizedPrimaryField.DBName), Value: tenantId}
+	}
+	if field := stmt.Schema.LookUpField("TenantId"); field != nil {
+		return clause.Eq{Column: column(field.DBName), Value: tenantId}
+	}
+	for _, path := range tenantPaths {
+		if field := stmt.Schema.LookUpField(path.field); field != nil {
+			return clause.Expr{SQL: "? IN (" + path.subquery + ")", Vars: []any{column(field.DBName), tenantId}}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package repo
+
+import (
+	"context"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"parking_lot_service/internal/auth"
+	"parking_lot_service/internal/repo/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+// statementLog records the SQL of the statements run through it.
+type statementLog struct {
+	logger.Interface
+	statements []string
+}
+
+func (l *statementLog) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	l.statements = append(l.statements, sql)
+}
+
+// newDryRunRepo returns a repo that builds its statements without running them, and the log of the statements.
+func newDryRunRepo(t *testing.T) (ParkingLotRepo, *statementLog) {
+	t.Helper()
+
+	db, log := newDryRunDB(t, TenantScope{})
+	return NewParkingLotRepo(db), log
+}
+
+// newDryRunDB returns a database with the given plugins that builds its statements without running them, and
+// the log of the statements.
+func newDryRunDB(t *testing.T, plugins ...gorm.Plugin) (*gorm.DB, *statementLog) {
+	t.Helper()
+
+	log := &statementLog{Interface: logger.Discard}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true, // Transactions need a connection
+		Logger:                 log,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	for _, plugin := range plugins {
+		if err = db.Use(plugin); err != nil {
+			t.Fatalf("Use(%s) error = %v", plugin.Name(), err)
+		}
+	}
+	return db, log
+}
+
+func TestTenantScope(t *testing.T) {
+	const otherLots = `IN (SELECT id FROM parking_lots WHERE tenant_id = 2)`
+
+	var (
+		tenant   = auth.NewContext(context.Background(), &auth.Principal{Role: auth.RoleLotManager, TenantID: 2})
+		platform = auth.NewContext(context.Background(), &auth.Principal{Role: auth.RoleAdmin})
+	)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		run  func(ctx context.Context, r ParkingLotRepo) error
+		want string // Tenant condition the statement must have, empty for an unscoped statement
+	}{
+		{
+			name: "Read of a lot",
+			ctx:  tenant,
+			run: func(ctx context.Context, r ParkingLotRepo) error {
+				_, err := r.GetParkingLotById(ctx, 7)
+				return err
+			},
+			want: `"parking_lots"."tenant_id" = 2`,
+		},
+		{
+			name: "Read of a session by ticket",
+			ctx:  tenant,
+			run: func(ctx context.Context, r ParkingLotRepo) error {
+				_, err := r.GetParkingSessionByTicket(ctx, "T-1")
+				return err
+			},
+			want: `"parking_sessions"."tenant_id" = 2`,
+		},
+		{
+			name: "Read of a tariff",
+			ctx:  tenant,
+			run: func(ctx context.Context, r ParkingLotRepo) error {
+				_, err := r.GetTariffById(ctx, 3)
+				return err
+			},
+			want: `"tariffs"."tenant_id" = 2`,
+		},
+		{
+			name: "Read of the tenants",
+			ctx:  tenant,
+			run: func(ctx context.Context, r ParkingLotRepo) error {
+				_, err := r.GetTenants(ctx)
+				return err
+			},
+			want: `"tenants"."id" = 2`,
+		},
+		{
+			name: "Read of a payment through its invoice",
+			ctx:  tenant,
+			run: func(ctx context.Context, r ParkingLotRepo) error {
+				_, err := r.GetPaymentById(ctx, 5)
+				return err
+			},
+			want: `"payments"."invoice_id" IN (SELECT invoices.id FROM invoices`,
+		},
+		{
+			name: "Counting of spots",
+			ctx:  tenant,
+			run: func(ctx context.Context, r ParkingLotRepo) error {
+				_, err := r.GetSpotAvailability(ctx, 0)
+				return err
+			},
+			want: `"spots"."parking_lot_id" ` + otherLots,
+		},
+		{
+			name: "Update of a parking space",
+			ctx:  tenant,
+			run: func(ctx context.Context, r ParkingLotRepo) error {
+				_, err := r.DecrementAvailableSpots(ctx, 7, 1, 0)
+				return err
+			},
+			want: `"parking_spaces"."tenant_id" = 2`,
+		},
+		{
+			name: "Update of a lot",
+			ctx:  tenant,
+			run: func(ctx context.Context, r ParkingLotRepo) error {
+				return r.UpdateParkingLot(ctx, &models.ParkingLot{ID: 7, Name: "Mall"})
+			},
+			want: `"parking_lots"."tenant_id" = 2`,
+		},
+		{
+			name: "Delete of a holiday",
+			ctx:  tenant,
+			run: func(ctx context.Context, r ParkingLotRepo) error {
+				return r.DeleteHoliday(ctx, 4)
+			},
+			want: `"holidays"."parking_lot_id" ` + otherLots,
+		},
+		{
+			name: "Creation of a lot of another tenant",
+			ctx:  tenant,
+			run: func(ctx context.Context, r ParkingLotRepo) error {
+				return r.CreateParkingLot(ctx, &models.ParkingLot{TenantId: 1, Name: "Mall"})
+			},
+			want: `VALUES (2,'Mall',`, // The tenant is the first column
+		},
+		{
+			name: "Read of shared exchange rates",
+			ctx:  tenant,
+			run: func(ctx context.Context, r ParkingLotRepo) error {
+				_, err := r.GetExchangeRates(ctx)
+				return err
+			},
+		},
+		{
+			name: "Read of the lots by a platform admin",
+			ctx:  platform,
+			run: func(ctx context.Context, r ParkingLotRepo) error {
+				_, err := r.GetParkingLots(ctx)
+				return err
+			},
+		},
+		{
+			name: "Read of the lots without a principal",
+			ctx:  context.Background(),
+			run: func(ctx context.Context, r ParkingLotRepo) error {
+				_, err := r.GetParkingLots(ctx)
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, log := newDryRunRepo(t)
+			_ = tt.run(tt.ctx, r) // Statements are not run, their results are empty
+
+			if len(log.statements) == 0 {
+				t.Fatalf("no statement was built")
+			}
+			for _, sql := range log.statements {
+				scoped := strings.Contains(sql, "tenant_id") || strings.Contains(sql, `"tenants"."id"`)
+				switch {
+				case tt.want != "" && !strings.Contains(sql, tt.want):
+					t.Errorf("statement %s is not scoped by %s", sql, tt.want)
+				case tt.want == "" && scoped:
+					t.Errorf("statement %s is scoped to a tenant", sql)
+				}
+			}
+		})
+	}
+}
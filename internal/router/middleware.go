@@ -0,0 +1,81 @@
+package router
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"parking_lot_service/internal/audit"
+	"parking_lot_service/internal/auth"
+	"parking_lot_service/internal/genericresponse"
+)
+
+// maxRequestIDLength is the longest request ID taken over from a caller, longer IDs are replaced.
+const maxRequestIDLength = 100
+
+// requestID identifies every request by the X-Request-Id header of the caller, or else by a random ID, and
+// returns the ID in the X-Request-Id header of the response.
+func requestID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			id := c.Request().Header.Get(echo.HeaderXRequestID)
+			if id == "" || len(id) > maxRequestIDLength {
+				random := make([]byte, 16)
+				if _, err := rand.Read(random); err != nil {
+					return err
+				}
+				id = hex.EncodeToString(random)
+			}
+
+			c.Response().Header().Set(echo.HeaderXRequestID, id)
+			return next(c)
+		}
+	}
+}
+
+// recordOperation passes the route and the ID of the request on in the request context, the changes the
+// request makes are recorded in the audit log under them.
+func recordOperation() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			operation := audit.Operation{
+				Name:      c.Request().Method + " " + c.Path(),
+				RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+			}
+			c.SetRequest(c.Request().WithContext(audit.NewContext(c.Request().Context(), operation)))
+			return next(c)
+		}
+	}
+}
+
+// authorize authenticates the caller of a route and checks its role is granted the permission of the route.
+// The principal is passed on in the request context, the services use it to keep callers to their lots.
+func authorize(authenticator *auth.Authenticator, permissions map[string]auth.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal, err := authenticator.Authenticate(c.Request())
+			if err != nil {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="parking-lot"`)
+				return c.JSON(http.StatusUnauthorized, &genericresponse.GenericResponse{
+					StatusCode: http.StatusUnauthorized,
+					Message:    err.Error(),
+				})
+			}
+
+			// Paths without a route and routes left out of the matrix are not found
+			permission, ok := permissions[c.Request().Method+" "+c.Path()]
+			if !ok {
+				return echo.ErrNotFound
+			}
+			if !principal.Can(permission) {
+				return c.JSON(http.StatusForbidden, &genericresponse.GenericResponse{
+					StatusCode: http.StatusForbidden,
+					Message:    "Role " + string(principal.Role) + " does not have permission " + string(permission),
+				})
+			}
+
+			c.SetRequest(c.Request().WithContext(auth.NewContext(c.Request().Context(), principal)))
+			return next(c)
+		}
+	}
+}
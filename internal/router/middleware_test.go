@@ -0,0 +1,126 @@
+package router
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"parking_lot_service/internal/audit"
+	"parking_lot_service/internal/auth"
+	"parking_lot_service/internal/handler"
+	"strings"
+	"testing"
+)
+
+func TestMapRoutes_EveryRouteHasAPermission(t *testing.T) {
+	e := echo.New()
+	NewRouter(handler.NewParkingLotHandler(nil), auth.NewAuthenticator(nil, nil, "")).MapRoutes(e)
+
+	routes := map[string]bool{}
+	for _, route := range e.Routes() {
+		// Routes the group adds to answer paths it has no route for are not handlers
+		if !strings.HasPrefix(route.Path, "/parking-lot/") || route.Method == echo.RouteNotFound {
+			continue
+		}
+		key := route.Method + " " + route.Path
+		routes[key] = true
+		if _, ok := routePermissions[key]; !ok {
+			t.Errorf("route %s has no permission", key)
+		}
+	}
+	for key := range routePermissions {
+		if !routes[key] {
+			t.Errorf("permission of %s has no route", key)
+		}
+	}
+}
+
+func Test_authorize(t *testing.T) {
+	authenticator := auth.NewAuthenticator([]auth.APIKey{
+		{Name: "kiosk-1", Role: auth.RoleKiosk, ParkingLotIDs: []int{1}, Hash: auth.HashAPIKey("kiosk-secret")},
+	}, nil, "")
+
+	e := echo.New()
+	group := e.Group("/parking-lot", authorize(authenticator, map[string]auth.Permission{
+		http.MethodPost + " /parking-lot/park-vehicle":      auth.PermissionParkVehicles,
+		http.MethodPost + " /parking-lot/lost-ticket-exits": auth.PermissionLostTicketExits,
+	}))
+	principalOf := func(c echo.Context) error {
+		principal, _ := auth.FromContext(c.Request().Context())
+		return c.String(http.StatusOK, principal.Subject)
+	}
+	group.POST("/park-vehicle", principalOf)
+	group.POST("/lost-ticket-exits", principalOf)
+	group.POST("/passes", principalOf)
+
+	tests := []struct {
+		name     string
+		path     string
+		apiKey   string
+		wantCode int
+		wantBody string
+	}{
+		{name: "Granted", path: "/parking-lot/park-vehicle", apiKey: "kiosk-secret", wantCode: http.StatusOK,
+			wantBody: "kiosk-1"},
+		{name: "Not granted", path: "/parking-lot/lost-ticket-exits", apiKey: "kiosk-secret",
+			wantCode: http.StatusForbidden},
+		{name: "Without credentials", path: "/parking-lot/park-vehicle", wantCode: http.StatusUnauthorized},
+		{name: "Unknown API key", path: "/parking-lot/park-vehicle", apiKey: "guessed",
+			wantCode: http.StatusUnauthorized},
+		{name: "Route without a permission", path: "/parking-lot/passes", apiKey: "kiosk-secret",
+			wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			if tt.apiKey != "" {
+				req.Header.Set(auth.APIKeyHeader, tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode || (tt.wantBody != "" && rec.Body.String() != tt.wantBody) {
+				t.Errorf("POST %s = %d %s, want %d %s", tt.path, rec.Code, rec.Body.String(), tt.wantCode, tt.wantBody)
+			}
+		})
+	}
+}
+
+func Test_recordOperation(t *testing.T) {
+	e := echo.New()
+	group := e.Group("/parking-lot", requestID(), recordOperation())
+	group.POST("/spots/:id", func(c echo.Context) error {
+		operation, _ := audit.FromContext(c.Request().Context())
+		return c.String(http.StatusOK, operation.Name+" "+operation.RequestID)
+	})
+
+	tests := []struct {
+		name      string
+		requestID string
+		wantID    string // Empty for a generated ID
+	}{
+		{name: "ID of the caller", requestID: "gate-7-0042", wantID: "gate-7-0042"},
+		{name: "Generated ID"},
+		{name: "ID of the caller too long", requestID: strings.Repeat("x", maxRequestIDLength+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/parking-lot/spots/3", nil)
+			if tt.requestID != "" {
+				req.Header.Set(echo.HeaderXRequestID, tt.requestID)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(echo.HeaderXRequestID)
+			switch {
+			case tt.wantID != "" && id != tt.wantID:
+				t.Errorf("request ID = %q, want %q", id, tt.wantID)
+			case tt.wantID == "" && (len(id) != 32 || id == tt.requestID):
+				t.Errorf("request ID = %q, want a generated ID", id)
+			}
+			if want := "POST /parking-lot/spots/:id " + id; rec.Body.String() != want {
+				t.Errorf("operation = %q, want %q", rec.Body.String(), want)
+			}
+		})
+	}
+}
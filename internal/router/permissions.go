@@ -0,0 +1,101 @@
+package router
+
+import (
+	"net/http"
+	"parking_lot_service/internal/auth"
+)
+
+// routePermissions is the permission a caller needs for each route of the /parking-lot group, by method and
+// path as registered. A route missing here is not served, so every new route must be added.
+var routePermissions = map[string]auth.Permission{
+	http.MethodGet + " /parking-lot/free-parking-spaces": auth.PermissionViewLots,
+	http.MethodGet + " /parking-lot/parking-space":       auth.PermissionViewLots,
+	http.MethodPost + " /parking-lot/park-vehicle":       auth.PermissionParkVehicles,
+	http.MethodGet + " /parking-lot/quote":               auth.PermissionExitVehicles,
+	http.MethodPost + " /parking-lot/pay-ticket":         auth.PermissionExitVehicles,
+	http.MethodPost + " /parking-lot/un-park-vehicle":    auth.PermissionExitVehicles,
+	http.MethodPost + " /parking-lot/lost-ticket-exits":  auth.PermissionLostTicketExits,
+	http.MethodGet + " /parking-lot/sessions":            auth.PermissionViewSessions,
+
+	// Parking lot catalogue
+	http.MethodGet + " /parking-lot/lots":                     auth.PermissionViewLots,
+	http.MethodPost + " /parking-lot/lots":                    auth.PermissionAdministerLots,
+	http.MethodGet + " /parking-lot/lots/:id":                 auth.PermissionViewLots,
+	http.MethodPut + " /parking-lot/lots/:id":                 auth.PermissionConfigureLots,
+	http.MethodDelete + " /parking-lot/lots/:id":              auth.PermissionAdministerLots,
+	http.MethodGet + " /parking-lot/lots/:id/capacity":        auth.PermissionViewLots,
+	http.MethodPut + " /parking-lot/lots/:id/capacity":        auth.PermissionConfigureLots,
+	http.MethodGet + " /parking-lot/lots/:id/upsize-policy":   auth.PermissionViewLots,
+	http.MethodPut + " /parking-lot/lots/:id/upsize-policy":   auth.PermissionConfigureLots,
+	http.MethodGet + " /parking-lot/lots/:id/penalty-policy":  auth.PermissionViewLots,
+	http.MethodPut + " /parking-lot/lots/:id/penalty-policy":  auth.PermissionManagePricing,
+	http.MethodGet + " /parking-lot/lots/:id/spots":           auth.PermissionViewLots,
+	http.MethodPost + " /parking-lot/lots/:id/spots":          auth.PermissionConfigureLots,
+	http.MethodGet + " /parking-lot/lots/:id/pass-products":   auth.PermissionViewLots,
+	http.MethodPost + " /parking-lot/lots/:id/pass-products":  auth.PermissionManagePricing,
+	http.MethodGet + " /parking-lot/lots/:id/merchants":       auth.PermissionViewLots,
+	http.MethodPost + " /parking-lot/lots/:id/merchants":      auth.PermissionManagePricing,
+	http.MethodGet + " /parking-lot/lots/:id/discount-rules":  auth.PermissionViewLots,
+	http.MethodPost + " /parking-lot/lots/:id/discount-rules": auth.PermissionManagePricing,
+	http.MethodGet + " /parking-lot/lots/:id/holidays":        auth.PermissionViewLots,
+	http.MethodPost + " /parking-lot/lots/:id/holidays":       auth.PermissionConfigureLots,
+
+	// Spots
+	http.MethodPut + " /parking-lot/spots/:id":    auth.PermissionConfigureLots,
+	http.MethodDelete + " /parking-lot/spots/:id": auth.PermissionConfigureLots,
+
+	// Reservations
+	http.MethodPost + " /parking-lot/reservations":       auth.PermissionManageReservations,
+	http.MethodGet + " /parking-lot/reservations/:id":    auth.PermissionManageReservations,
+	http.MethodPut + " /parking-lot/reservations/:id":    auth.PermissionManageReservations,
+	http.MethodDelete + " /parking-lot/reservations/:id": auth.PermissionManageReservations,
+
+	// Passes
+	http.MethodDelete + " /parking-lot/pass-products/:id": auth.PermissionManagePricing,
+	http.MethodPost + " /parking-lot/passes":              auth.PermissionManagePasses,
+	http.MethodGet + " /parking-lot/passes/:id":           auth.PermissionManagePasses,
+	http.MethodPut + " /parking-lot/passes/:id/vehicles":  auth.PermissionManagePasses,
+	http.MethodDelete + " /parking-lot/passes/:id":        auth.PermissionManagePasses,
+
+	// Discounts
+	http.MethodDelete + " /parking-lot/discount-rules/:id":      auth.PermissionManagePricing,
+	http.MethodPost + " /parking-lot/merchants/:id/validations": auth.PermissionValidateTickets,
+
+	// Vehicle type registry
+	http.MethodGet + " /parking-lot/vehicle-types":        auth.PermissionViewLots,
+	http.MethodPost + " /parking-lot/vehicle-types":       auth.PermissionAdministerLots,
+	http.MethodGet + " /parking-lot/vehicle-types/:id":    auth.PermissionViewLots,
+	http.MethodPut + " /parking-lot/vehicle-types/:id":    auth.PermissionAdministerLots,
+	http.MethodDelete + " /parking-lot/vehicle-types/:id": auth.PermissionAdministerLots,
+
+	// Tariff versions
+	http.MethodGet + " /parking-lot/tariffs":         auth.PermissionViewLots,
+	http.MethodPost + " /parking-lot/tariffs":        auth.PermissionManagePricing,
+	http.MethodGet + " /parking-lot/tariffs/:id":     auth.PermissionViewLots,
+	http.MethodPut + " /parking-lot/tariffs/:id":     auth.PermissionManagePricing,
+	http.MethodDelete + " /parking-lot/tariffs/:id":  auth.PermissionManagePricing,
+	http.MethodDelete + " /parking-lot/holidays/:id": auth.PermissionConfigureLots,
+
+	// Exchange rates and reports
+	http.MethodGet + " /parking-lot/exchange-rates":        auth.PermissionViewFinance,
+	http.MethodPut + " /parking-lot/exchange-rates":        auth.PermissionManageRates,
+	http.MethodDelete + " /parking-lot/exchange-rates/:id": auth.PermissionManageRates,
+	http.MethodGet + " /parking-lot/reports/revenue":       auth.PermissionViewFinance,
+
+	// Invoices and payments
+	http.MethodGet + " /parking-lot/invoices/:id":                  auth.PermissionViewInvoices,
+	http.MethodPost + " /parking-lot/invoices/:id/payments":        auth.PermissionPayInvoices,
+	http.MethodPost + " /parking-lot/invoices/:id/account-billing": auth.PermissionBillAccounts,
+	http.MethodPost + " /parking-lot/payments/:id/refunds":         auth.PermissionRefundPayments,
+	http.MethodGet + " /parking-lot/sessions/:id/adjustments":      auth.PermissionViewFinance,
+	http.MethodPost + " /parking-lot/sessions/:id/adjustments":     auth.PermissionAdjustFares,
+
+	// Tenants
+	http.MethodGet + " /parking-lot/tenants":     auth.PermissionManageTenants,
+	http.MethodPost + " /parking-lot/tenants":    auth.PermissionManageTenants,
+	http.MethodGet + " /parking-lot/tenants/:id": auth.PermissionManageTenants,
+	http.MethodPut + " /parking-lot/tenants/:id": auth.PermissionManageTenants,
+
+	// Audit log
+	http.MethodGet + " /parking-lot/audit-log": auth.PermissionViewAuditLog,
+}
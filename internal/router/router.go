@@ -1,6 +1,7 @@
 package router
 
 import (
+	"parking_lot_service/internal/auth"
 	"parking_lot_service/internal/handler"
 
 	"github.com/labstack/echo/v4"
@@ -12,10 +13,12 @@ type Router interface {
 
 type impl struct {
 	parkingLotHandler handler.ParkingLotHandler
+	authenticator     *auth.Authenticator
 }
 
-func NewRouter(parkingLotHandler handler.ParkingLotHandler) Router {
+func NewRouter(parkingLotHandler handler.ParkingLotHandler, authenticator *auth.Authenticator) Router {
 	return &impl{
 		parkingLotHandler: parkingLotHandler,
+		authenticator:     authenticator,
 	}
 }
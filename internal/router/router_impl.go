@@ -7,11 +7,97 @@ import (
 )
 
 func (r *impl) MapRoutes(e *echo.Echo) {
-	parkingLot := e.Group("/parking-lot")
+	parkingLot := e.Group("/parking-lot", requestID(), authorize(r.authenticator, routePermissions), recordOperation())
 	parkingLot.GET("/free-parking-spaces", r.parkingLotHandler.GetFreeParkingSpaces)
 	parkingLot.GET("/parking-space", r.parkingLotHandler.GetParkingSpaceByParkingLotId)
 	parkingLot.POST("/park-vehicle", r.parkingLotHandler.ParkVehicle)
+	parkingLot.GET("/quote", r.parkingLotHandler.GetQuote)
+	parkingLot.POST("/pay-ticket", r.parkingLotHandler.PayTicket)
 	parkingLot.POST("/un-park-vehicle", r.parkingLotHandler.UnParkVehicle)
+	parkingLot.POST("/lost-ticket-exits", r.parkingLotHandler.LostTicketExit)
+	parkingLot.GET("/sessions", r.parkingLotHandler.GetParkingSessions)
+
+	// Parking lot catalogue
+	parkingLot.GET("/lots", r.parkingLotHandler.GetParkingLots)
+	parkingLot.POST("/lots", r.parkingLotHandler.CreateParkingLot)
+	parkingLot.GET("/lots/:id", r.parkingLotHandler.GetParkingLotById)
+	parkingLot.PUT("/lots/:id", r.parkingLotHandler.UpdateParkingLot)
+	parkingLot.DELETE("/lots/:id", r.parkingLotHandler.DeleteParkingLot)
+	parkingLot.GET("/lots/:id/capacity", r.parkingLotHandler.GetParkingLotCapacity)
+	parkingLot.PUT("/lots/:id/capacity", r.parkingLotHandler.UpdateParkingLotCapacity)
+	parkingLot.GET("/lots/:id/upsize-policy", r.parkingLotHandler.GetUpsizePolicy)
+	parkingLot.PUT("/lots/:id/upsize-policy", r.parkingLotHandler.UpdateUpsizePolicy)
+	parkingLot.GET("/lots/:id/penalty-policy", r.parkingLotHandler.GetPenaltyPolicy)
+	parkingLot.PUT("/lots/:id/penalty-policy", r.parkingLotHandler.UpdatePenaltyPolicy)
+	parkingLot.GET("/lots/:id/spots", r.parkingLotHandler.GetSpots)
+	parkingLot.POST("/lots/:id/spots", r.parkingLotHandler.CreateSpot)
+	parkingLot.GET("/lots/:id/pass-products", r.parkingLotHandler.GetPassProducts)
+	parkingLot.POST("/lots/:id/pass-products", r.parkingLotHandler.CreatePassProduct)
+	parkingLot.GET("/lots/:id/merchants", r.parkingLotHandler.GetMerchants)
+	parkingLot.POST("/lots/:id/merchants", r.parkingLotHandler.CreateMerchant)
+	parkingLot.GET("/lots/:id/discount-rules", r.parkingLotHandler.GetDiscountRules)
+	parkingLot.POST("/lots/:id/discount-rules", r.parkingLotHandler.CreateDiscountRule)
+	parkingLot.GET("/lots/:id/holidays", r.parkingLotHandler.GetHolidays)
+	parkingLot.POST("/lots/:id/holidays", r.parkingLotHandler.CreateHoliday)
+
+	// Spots
+	parkingLot.PUT("/spots/:id", r.parkingLotHandler.UpdateSpot)
+	parkingLot.DELETE("/spots/:id", r.parkingLotHandler.DeleteSpot)
+
+	// Reservations
+	parkingLot.POST("/reservations", r.parkingLotHandler.CreateReservation)
+	parkingLot.GET("/reservations/:id", r.parkingLotHandler.GetReservationById)
+	parkingLot.PUT("/reservations/:id", r.parkingLotHandler.UpdateReservation)
+	parkingLot.DELETE("/reservations/:id", r.parkingLotHandler.CancelReservation)
+
+	// Passes
+	parkingLot.DELETE("/pass-products/:id", r.parkingLotHandler.DeletePassProduct)
+	parkingLot.POST("/passes", r.parkingLotHandler.CreatePass)
+	parkingLot.GET("/passes/:id", r.parkingLotHandler.GetPassById)
+	parkingLot.PUT("/passes/:id/vehicles", r.parkingLotHandler.UpdatePassVehicles)
+	parkingLot.DELETE("/passes/:id", r.parkingLotHandler.CancelPass)
+
+	// Discounts
+	parkingLot.DELETE("/discount-rules/:id", r.parkingLotHandler.DeleteDiscountRule)
+	parkingLot.POST("/merchants/:id/validations", r.parkingLotHandler.ValidateTicket)
+
+	// Vehicle type registry
+	parkingLot.GET("/vehicle-types", r.parkingLotHandler.GetVehicleTypes)
+	parkingLot.POST("/vehicle-types", r.parkingLotHandler.CreateVehicleType)
+	parkingLot.GET("/vehicle-types/:id", r.parkingLotHandler.GetVehicleTypeById)
+	parkingLot.PUT("/vehicle-types/:id", r.parkingLotHandler.UpdateVehicleType)
+	parkingLot.DELETE("/vehicle-types/:id", r.parkingLotHandler.DeleteVehicleType)
+
+	// Tariff versions
+	parkingLot.GET("/tariffs", r.parkingLotHandler.GetTariffs)
+	parkingLot.POST("/tariffs", r.parkingLotHandler.CreateTariff)
+	parkingLot.GET("/tariffs/:id", r.parkingLotHandler.GetTariffById)
+	parkingLot.PUT("/tariffs/:id", r.parkingLotHandler.UpdateTariff)
+	parkingLot.DELETE("/tariffs/:id", r.parkingLotHandler.DeleteTariff)
+	parkingLot.DELETE("/holidays/:id", r.parkingLotHandler.DeleteHoliday)
+
+	// Exchange rates and reports
+	parkingLot.GET("/exchange-rates", r.parkingLotHandler.GetExchangeRates)
+	parkingLot.PUT("/exchange-rates", r.parkingLotHandler.SaveExchangeRate)
+	parkingLot.DELETE("/exchange-rates/:id", r.parkingLotHandler.DeleteExchangeRate)
+	parkingLot.GET("/reports/revenue", r.parkingLotHandler.GetRevenueReport)
+
+	// Invoices and payments
+	parkingLot.GET("/invoices/:id", r.parkingLotHandler.GetInvoiceById)
+	parkingLot.POST("/invoices/:id/payments", r.parkingLotHandler.PayInvoice)
+	parkingLot.POST("/invoices/:id/account-billing", r.parkingLotHandler.BillInvoiceToAccount)
+	parkingLot.POST("/payments/:id/refunds", r.parkingLotHandler.RefundPayment)
+	parkingLot.GET("/sessions/:id/adjustments", r.parkingLotHandler.GetFareAdjustments)
+	parkingLot.POST("/sessions/:id/adjustments", r.parkingLotHandler.AdjustFare)
+
+	// Tenants
+	parkingLot.GET("/tenants", r.parkingLotHandler.GetTenants)
+	parkingLot.POST("/tenants", r.parkingLotHandler.CreateTenant)
+	parkingLot.GET("/tenants/:id", r.parkingLotHandler.GetTenantById)
+	parkingLot.PUT("/tenants/:id", r.parkingLotHandler.UpdateTenant)
+
+	// Audit log
+	parkingLot.GET("/audit-log", r.parkingLotHandler.GetAuditEntries)
 
 	// Swagger endpoint
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"parking_lot_service/internal/audit"
+	"time"
+)
+
+// Job is a piece of background work that runs at a fixed interval.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Start runs every job in its own goroutine, once right away and then on each tick of its interval,
+// until ctx is cancelled. A failed run is logged and the job is tried again on its next tick. The changes
+// a job makes are recorded in the audit log under the name of the job.
+func Start(ctx context.Context, jobs ...Job) {
+	for _, job := range jobs {
+		go run(ctx, job)
+	}
+}
+
+func run(ctx context.Context, job Job) {
+	ctx = audit.NewContext(ctx, audit.Operation{Name: "job " + job.Name})
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job.Run(ctx); err != nil {
+			log.Printf("scheduler: job %s failed: %v", job.Name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package allocation
+
+import (
+	"fmt"
+	"parking_lot_service/internal/repo/models"
+	"sort"
+)
+
+// Names of the built-in allocation strategies, as stored on a parking lot.
+const (
+	NearestToEntrance = "nearest-to-entrance"
+	LevelByLevel      = "level-by-level"
+	SpreadForWear     = "spread-for-wear"
+	SmallestFitting   = "smallest-fitting"
+)
+
+// DefaultStrategy is used for parking lots that do not configure a strategy.
+const DefaultStrategy = LevelByLevel
+
+// AllocationStrategy decides which free spot of a parking lot a vehicle is assigned to.
+type AllocationStrategy interface {
+	// Name returns the name the strategy is configured with.
+	Name() string
+	// Choose picks a spot for a vehicle of the given type out of the free spots of a lot,
+	// it returns nil when none of the spots may be used by the vehicle.
+	Choose(vehicleType *models.VehicleType, freeSpots []*models.Spot) *models.Spot
+}
+
+var strategies = map[string]AllocationStrategy{
+	NearestToEntrance: nearestToEntrance{},
+	LevelByLevel:      levelByLevel{},
+	SpreadForWear:     spreadForWear{},
+	SmallestFitting:   smallestFitting{},
+}
+
+// New returns the strategy with the given name, an empty name selects the DefaultStrategy.
+func New(name string) (AllocationStrategy, error) {
+	if name == "" {
+		name = DefaultStrategy
+	}
+	strategy, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown allocation strategy %q", name)
+	}
+	return strategy, nil
+}
+
+// Names returns the names of the built-in strategies in alphabetical order.
+func Names() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ownPool returns the spots that belong to the parking space pool of the vehicle type.
+func ownPool(vehicleType *models.VehicleType, freeSpots []*models.Spot) []*models.Spot {
+	var spots []*models.Spot
+	for _, spot := range freeSpots {
+		if spot.VehicleTypeId == vehicleType.ID {
+			spots = append(spots, spot)
+		}
+	}
+	return spots
+}
+
+// first returns the spot that sorts first according to less, or nil if there are no spots.
+func first(spots []*models.Spot, less func(a, b *models.Spot) bool) *models.Spot {
+	var best *models.Spot
+	for _, spot := range spots {
+		if best == nil || less(spot, best) {
+			best = spot
+		}
+	}
+	return best
+}
+
+// byLevelAndLabel orders spots from the lowest level up and by label within a level,
+// it breaks ties for every strategy so the choice is deterministic.
+func byLevelAndLabel(a, b *models.Spot) bool {
+	if a.Level != b.Level {
+		return a.Level < b.Level
+	}
+	return a.Label < b.Label
+}
@@ -0,0 +1,138 @@
+package allocation
+
+import (
+	"parking_lot_service/internal/repo/models"
+	"testing"
+)
+
+var (
+	motorcycles = &models.VehicleType{ID: 1, Code: "MOTORCYCLES_SCOOTERS", SizeClass: models.SizeClassSmall,
+		AllowLargerSpots: true}
+	cars = &models.VehicleType{ID: 2, Code: "CARS_SUVS", SizeClass: models.SizeClassMedium}
+)
+
+func spot(id uint, vehicleType *models.VehicleType, level int, label string) *models.Spot {
+	return &models.Spot{ID: id, VehicleTypeId: vehicleType.ID, Level: level, Label: label,
+		SizeClass: vehicleType.SizeClass}
+}
+
+func TestStrategies_Choose(t *testing.T) {
+	var (
+		farCar      = spot(1, cars, 0, "C-001")
+		nearCar     = spot(2, cars, 1, "C-002")
+		upperCar    = spot(3, cars, 2, "C-003")
+		usedCar     = spot(4, cars, 0, "C-000")
+		motorcycle  = spot(5, motorcycles, 1, "M-001")
+		largeSpot   = &models.Spot{ID: 6, VehicleTypeId: 3, Level: 0, Label: "B-001", SizeClass: models.SizeClassLarge}
+		carSpots    = []*models.Spot{farCar, nearCar, upperCar, usedCar}
+		allFreeSpot = append([]*models.Spot{motorcycle, largeSpot}, carSpots...)
+	)
+	farCar.EntranceDistance = 120
+	nearCar.EntranceDistance = 15
+	upperCar.EntranceDistance = 40
+	usedCar.EntranceDistance = 80
+	usedCar.UsageCount = 7
+	farCar.UsageCount = 3
+	nearCar.UsageCount = 3
+
+	tests := []struct {
+		name        string
+		strategy    string
+		vehicleType *models.VehicleType
+		freeSpots   []*models.Spot
+		want        *models.Spot
+	}{
+		{
+			name:        "Nearest to entrance picks the shortest walk",
+			strategy:    NearestToEntrance,
+			vehicleType: cars,
+			freeSpots:   allFreeSpot,
+			want:        nearCar,
+		},
+		{
+			name:        "Level by level picks the lowest level and label",
+			strategy:    LevelByLevel,
+			vehicleType: cars,
+			freeSpots:   allFreeSpot,
+			want:        usedCar,
+		},
+		{
+			name:        "Spread for wear picks the least used spot",
+			strategy:    SpreadForWear,
+			vehicleType: cars,
+			freeSpots:   allFreeSpot,
+			want:        upperCar,
+		},
+		{
+			name:        "Smallest fitting prefers the vehicle's own pool",
+			strategy:    SmallestFitting,
+			vehicleType: motorcycles,
+			freeSpots:   allFreeSpot,
+			want:        motorcycle,
+		},
+		{
+			name:        "Smallest fitting takes the smallest larger spot when the pool is full",
+			strategy:    SmallestFitting,
+			vehicleType: motorcycles,
+			freeSpots:   append([]*models.Spot{largeSpot}, carSpots...),
+			want:        usedCar,
+		},
+		{
+			name:        "Smallest fitting never takes larger spots for vehicles that may not use them",
+			strategy:    SmallestFitting,
+			vehicleType: cars,
+			freeSpots:   []*models.Spot{motorcycle, largeSpot},
+			want:        nil,
+		},
+		{
+			name:        "Other strategies stay within the vehicle's own pool",
+			strategy:    NearestToEntrance,
+			vehicleType: motorcycles,
+			freeSpots:   carSpots,
+			want:        nil,
+		},
+		{
+			name:        "No free spots",
+			strategy:    LevelByLevel,
+			vehicleType: cars,
+			freeSpots:   nil,
+			want:        nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := New(tt.strategy)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := strategy.Choose(tt.vehicleType, tt.freeSpots); got != tt.want {
+				t.Errorf("Choose() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "", want: DefaultStrategy},
+		{name: SmallestFitting, want: SmallestFitting},
+		{name: "random", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Name() != tt.want {
+				t.Errorf("New() = %s, want %s", got.Name(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package allocation
+
+import "parking_lot_service/internal/repo/models"
+
+// levelByLevel fills the lot from the lowest level up, so upper levels can be closed off
+// while the lot is quiet.
+type levelByLevel struct{}
+
+func (levelByLevel) Name() string {
+	return LevelByLevel
+}
+
+func (levelByLevel) Choose(vehicleType *models.VehicleType, freeSpots []*models.Spot) *models.Spot {
+	return first(ownPool(vehicleType, freeSpots), byLevelAndLabel)
+}
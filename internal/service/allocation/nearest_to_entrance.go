@@ -0,0 +1,20 @@
+package allocation
+
+import "parking_lot_service/internal/repo/models"
+
+// nearestToEntrance assigns the spot with the shortest walk from the entrance, so short stays
+// do not have to search the whole lot.
+type nearestToEntrance struct{}
+
+func (nearestToEntrance) Name() string {
+	return NearestToEntrance
+}
+
+func (nearestToEntrance) Choose(vehicleType *models.VehicleType, freeSpots []*models.Spot) *models.Spot {
+	return first(ownPool(vehicleType, freeSpots), func(a, b *models.Spot) bool {
+		if a.EntranceDistance != b.EntranceDistance {
+			return a.EntranceDistance < b.EntranceDistance
+		}
+		return byLevelAndLabel(a, b)
+	})
+}
@@ -0,0 +1,33 @@
+package allocation
+
+import "parking_lot_service/internal/repo/models"
+
+// smallestFitting assigns a spot of the vehicle's own pool and only falls back to the smallest larger
+// spot once that pool is full, for vehicle types that are allowed to use larger spots.
+type smallestFitting struct{}
+
+func (smallestFitting) Name() string {
+	return SmallestFitting
+}
+
+func (smallestFitting) Choose(vehicleType *models.VehicleType, freeSpots []*models.Spot) *models.Spot {
+	if spot := first(ownPool(vehicleType, freeSpots), byLevelAndLabel); spot != nil {
+		return spot
+	}
+	if !vehicleType.AllowLargerSpots {
+		return nil
+	}
+
+	var larger []*models.Spot
+	for _, spot := range freeSpots {
+		if spot.SizeClass > vehicleType.SizeClass {
+			larger = append(larger, spot)
+		}
+	}
+	return first(larger, func(a, b *models.Spot) bool {
+		if a.SizeClass != b.SizeClass {
+			return a.SizeClass < b.SizeClass
+		}
+		return byLevelAndLabel(a, b)
+	})
+}
@@ -0,0 +1,19 @@
+package allocation
+
+import "parking_lot_service/internal/repo/models"
+
+// spreadForWear assigns the least used spot, so markings and surfaces wear evenly.
+type spreadForWear struct{}
+
+func (spreadForWear) Name() string {
+	return SpreadForWear
+}
+
+func (spreadForWear) Choose(vehicleType *models.VehicleType, freeSpots []*models.Spot) *models.Spot {
+	return first(ownPool(vehicleType, freeSpots), func(a, b *models.Spot) bool {
+		if a.UsageCount != b.UsageCount {
+			return a.UsageCount < b.UsageCount
+		}
+		return byLevelAndLabel(a, b)
+	})
+}
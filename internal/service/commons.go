@@ -1,2 +1 @@
 package service
-
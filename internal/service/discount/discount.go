@@ -0,0 +1,98 @@
+package discount
+
+import (
+	"math"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo/models"
+)
+
+// FareFunc returns the fare of the stay being discounted when its first hours are free.
+type FareFunc func(freeHours float64) money.Money
+
+// Apply works out the reduction every validation of a stay gives on its gross fare and stores it in the
+// Amount of the validation, in the currency of the fare. Validations that are not applied get zero, as do
+// validations whose amounts are in another currency than the fare.
+//
+// Each reduction is capped by the maximum amount of its rule and by the gross fare. Stackable validations add
+// up, a validation that does not stack is applied on its own, so the largest of the stacked total and the best
+// single non-stackable reduction wins. The sum of all reductions never exceeds maxPercent of the gross fare,
+// the stacked reductions are cut in the order of the validations to stay within it. Percentages of the fare
+// are rounded down to a minor unit, so a discount never takes off more than it says.
+func Apply(validations []*models.Validation, grossFare money.Money, fareAfterFreeHours FareFunc, maxPercent int) {
+	limit := grossFare.MulRatio(int64(maxPercent), 100, money.Down).Min(grossFare)
+
+	var (
+		stacked = money.Zero(grossFare.Currency)
+		best    *models.Validation
+	)
+	for _, validation := range validations {
+		validation.Amount = reduction(validation, grossFare, fareAfterFreeHours).Amount
+		if validation.Stackable {
+			stacked = stacked.Add(money.New(validation.Amount, grossFare.Currency))
+		} else if best == nil || validation.Amount > best.Amount {
+			best = validation
+		}
+	}
+	stacked = stacked.Min(limit)
+
+	if best != nil && best.Amount >= stacked.Amount {
+		for _, validation := range validations {
+			if validation != best {
+				validation.Amount = 0
+			}
+		}
+		if best.Amount > limit.Amount {
+			best.Amount = limit.Amount
+		}
+		return
+	}
+
+	remaining := limit.Amount
+	for _, validation := range validations {
+		if !validation.Stackable {
+			validation.Amount = 0
+			continue
+		}
+		if validation.Amount > remaining {
+			validation.Amount = remaining
+		}
+		remaining -= validation.Amount
+	}
+}
+
+// reduction returns the amount a validation takes off the gross fare on its own.
+func reduction(validation *models.Validation, grossFare money.Money, fareAfterFreeHours FareFunc) money.Money {
+	amount := money.Zero(grossFare.Currency)
+	if (validation.FixedAmount != 0 || validation.MaxAmount != 0) && validation.Currency != grossFare.Currency {
+		return amount
+	}
+
+	switch validation.Kind {
+	case models.DiscountKindPercentage:
+		// Percentages are kept to hundredths of a percent
+		basisPoints := int64(math.Round(validation.Value * 100))
+		amount = grossFare.MulRatio(basisPoints, 10000, money.Down)
+	case models.DiscountKindFixedAmount:
+		amount = money.New(validation.FixedAmount, grossFare.Currency)
+	case models.DiscountKindFreeHours:
+		amount = grossFare.Sub(fareAfterFreeHours(validation.Value))
+	}
+
+	if validation.MaxAmount > 0 {
+		amount = amount.Min(money.New(validation.MaxAmount, grossFare.Currency))
+	}
+	amount = amount.Min(grossFare)
+	if amount.IsNegative() {
+		return money.Zero(grossFare.Currency)
+	}
+	return amount
+}
+
+// Net returns the fare left to pay once the amounts of the validations are taken off the gross fare.
+func Net(grossFare money.Money, validations []*models.Validation) money.Money {
+	net := grossFare
+	for _, validation := range validations {
+		net = net.Sub(money.New(validation.Amount, grossFare.Currency))
+	}
+	return net
+}
@@ -0,0 +1,136 @@
+package discount
+
+import (
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo/models"
+	"reflect"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	// A stay of 5 hours at 20.00 per hour
+	grossFare := money.New(10000, "INR")
+	fareAfterFreeHours := func(freeHours float64) money.Money {
+		if freeHours >= 5 {
+			return money.Zero("INR")
+		}
+		return money.New(int64((5-freeHours)*2000), "INR")
+	}
+
+	tests := []struct {
+		name        string
+		validations []*models.Validation
+		maxPercent  int
+		want        []int64 // Minor units
+	}{
+		{
+			name: "Percentage of the gross fare",
+			validations: []*models.Validation{
+				{Kind: models.DiscountKindPercentage, Value: 15},
+			},
+			maxPercent: 100,
+			want:       []int64{1500},
+		},
+		{
+			name: "Free hours are priced by the tariff",
+			validations: []*models.Validation{
+				{Kind: models.DiscountKindFreeHours, Value: 2},
+			},
+			maxPercent: 100,
+			want:       []int64{4000},
+		},
+		{
+			name: "Rule cap limits the reduction",
+			validations: []*models.Validation{
+				{Kind: models.DiscountKindPercentage, Value: 50, MaxAmount: 3000, Currency: "INR"},
+			},
+			maxPercent: 100,
+			want:       []int64{3000},
+		},
+		{
+			name: "Fixed amount never exceeds the fare",
+			validations: []*models.Validation{
+				{Kind: models.DiscountKindFixedAmount, FixedAmount: 25000, Currency: "INR"},
+			},
+			maxPercent: 100,
+			want:       []int64{10000},
+		},
+		{
+			name: "Stackable discounts add up",
+			validations: []*models.Validation{
+				{Kind: models.DiscountKindFreeHours, Value: 1, Stackable: true},
+				{Kind: models.DiscountKindFixedAmount, FixedAmount: 1000, Currency: "INR", Stackable: true},
+			},
+			maxPercent: 100,
+			want:       []int64{2000, 1000},
+		},
+		{
+			name: "Best non-stackable discount wins over a smaller stack",
+			validations: []*models.Validation{
+				{Kind: models.DiscountKindFixedAmount, FixedAmount: 1000, Currency: "INR", Stackable: true},
+				{Kind: models.DiscountKindPercentage, Value: 30},
+				{Kind: models.DiscountKindFixedAmount, FixedAmount: 2500, Currency: "INR"},
+				{Kind: models.DiscountKindPercentage, Value: 10, Stackable: true},
+			},
+			maxPercent: 100,
+			want:       []int64{0, 3000, 0, 0},
+		},
+		{
+			name: "Larger stack wins over a non-stackable discount",
+			validations: []*models.Validation{
+				{Kind: models.DiscountKindFreeHours, Value: 2, Stackable: true},
+				{Kind: models.DiscountKindPercentage, Value: 30},
+				{Kind: models.DiscountKindFixedAmount, FixedAmount: 500, Currency: "INR", Stackable: true},
+			},
+			maxPercent: 100,
+			want:       []int64{4000, 0, 500},
+		},
+		{
+			name: "Lot cap cuts the stack in order",
+			validations: []*models.Validation{
+				{Kind: models.DiscountKindFreeHours, Value: 2, Stackable: true},
+				{Kind: models.DiscountKindPercentage, Value: 25, Stackable: true},
+			},
+			maxPercent: 50,
+			want:       []int64{4000, 1000},
+		},
+		{
+			name: "Lot cap limits a non-stackable discount",
+			validations: []*models.Validation{
+				{Kind: models.DiscountKindFreeHours, Value: 5},
+			},
+			maxPercent: 80,
+			want:       []int64{8000},
+		},
+		{
+			name: "Amount in another currency gives nothing",
+			validations: []*models.Validation{
+				{Kind: models.DiscountKindFixedAmount, FixedAmount: 1000, Currency: "EUR"},
+			},
+			maxPercent: 100,
+			want:       []int64{0},
+		},
+		{
+			name: "Unknown kind gives nothing",
+			validations: []*models.Validation{
+				{Kind: "loyalty", Value: 10},
+			},
+			maxPercent: 100,
+			want:       []int64{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Apply(tt.validations, grossFare, fareAfterFreeHours, tt.maxPercent)
+
+			got := make([]int64, len(tt.validations))
+			for i, validation := range tt.validations {
+				got[i] = validation.Amount
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() amounts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
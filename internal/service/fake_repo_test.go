@@ -0,0 +1,1466 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"parking_lot_service/internal/repo"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/allocation"
+	"sort"
+	"sync"
+	"time"
+)
+
+// fakeRepo is an in-memory repo.ParkingLotRepo for service tests. Every method takes the same lock,
+// which gives it the atomicity of the single statements the GORM implementation issues. Methods the
+// tests do not need fall through to the embedded nil interface and panic.
+type fakeRepo struct {
+	repo.ParkingLotRepo
+
+	mu            *sync.Mutex
+	tenants       map[uint]*models.Tenant
+	parkingLots   map[int]*models.ParkingLot
+	vehicleTypes  map[int]*models.VehicleType
+	parkingSpaces map[[2]int]*models.ParkingSpace
+	openSessions  map[string]*models.ParkingSession
+	sessions      map[uint]*models.ParkingSession
+	lastSessionId *uint
+	spots         map[uint]*models.Spot
+	upsizeRules   []*models.UpsizeRule
+	reservations  map[uint]*models.Reservation
+	passProducts  map[uint]*models.PassProduct
+	passes        map[uint]*models.Pass
+	merchants     map[uint]*models.Merchant
+	discountRules map[uint]*models.DiscountRule
+	validations   map[uint]*models.Validation
+	holidays      []*models.Holiday
+	tariffs       []*models.Tariff
+	exchangeRates []*models.ExchangeRate
+	invoices      map[uint]*models.Invoice
+	payments      map[uint]*models.Payment
+	fareQuotes    map[uint]*models.FareQuote
+	adjustments   map[uint]*models.FareAdjustment
+	lostTickets   []*models.LostTicketExit
+	auditEntries  []*models.AuditEntry // In the order they were written
+	outboxEvents  map[uint]*models.OutboxEvent
+
+	// undo collects the compensating actions of the enclosing WithTx call, it is nil outside of one
+	undo *[]func()
+
+	// lockHook runs when parking spaces are locked, like a concurrent request committing right before the lock
+	// is granted
+	lockHook func()
+}
+
+// newFakeRepo returns a fake repo with one active lot whose single vehicle type pool has the given capacity.
+func newFakeRepo(totalSpots int) *fakeRepo {
+	spots := make(map[uint]*models.Spot, totalSpots)
+	for i := 1; i <= totalSpots; i++ {
+		spots[uint(i)] = &models.Spot{ID: uint(i), ParkingLotId: 1, VehicleTypeId: 1,
+			Label: fmt.Sprintf("CARS_SUVS-%03d", i), SizeClass: models.SizeClassMedium}
+	}
+
+	return &fakeRepo{
+		mu: &sync.Mutex{},
+		tenants: map[uint]*models.Tenant{
+			models.DefaultTenantId: {ID: models.DefaultTenantId, Name: "Default"},
+		},
+		parkingLots: map[int]*models.ParkingLot{
+			1: {ID: 1, TenantId: models.DefaultTenantId, Name: "Parking Lot A", Timezone: "UTC",
+				Status: models.ParkingLotStatusActive, AllocationStrategy: allocation.DefaultStrategy,
+				MaxDiscountPercent: 100, Currency: "INR", Locale: "en-IN"},
+		},
+		vehicleTypes: map[int]*models.VehicleType{
+			1: {ID: 1, Code: "CARS_SUVS", DisplayName: "Cars/SUVs", SizeClass: models.SizeClassMedium},
+		},
+		parkingSpaces: map[[2]int]*models.ParkingSpace{
+			{1, 1}: {ID: 1, ParkingLotId: 1, VehicleTypeId: 1, AvailableSpots: totalSpots, TotalSpots: totalSpots},
+		},
+		openSessions:  map[string]*models.ParkingSession{},
+		sessions:      map[uint]*models.ParkingSession{},
+		lastSessionId: new(uint),
+		spots:         spots,
+		reservations:  map[uint]*models.Reservation{},
+		passProducts:  map[uint]*models.PassProduct{},
+		passes:        map[uint]*models.Pass{},
+		merchants:     map[uint]*models.Merchant{},
+		discountRules: map[uint]*models.DiscountRule{},
+		validations:   map[uint]*models.Validation{},
+		invoices:      map[uint]*models.Invoice{},
+		payments:      map[uint]*models.Payment{},
+		fareQuotes:    map[uint]*models.FareQuote{},
+		adjustments:   map[uint]*models.FareAdjustment{},
+		outboxEvents:  map[uint]*models.OutboxEvent{},
+		tariffs: []*models.Tariff{
+			{ID: 1, ParkingLotId: 1, VehicleTypeId: 1, Currency: "INR", HourlyRate: 2000, EffectiveFrom: time.Unix(0, 0)},
+		},
+	}
+}
+
+// WithTx applies changes immediately and reverts them when fn fails, like a rolled back transaction.
+func (f *fakeRepo) WithTx(_ context.Context, fn func(txRepo repo.ParkingLotRepo) error) error {
+	var undo []func()
+	tx := *f
+	tx.undo = &undo
+
+	if err := fn(&tx); err != nil {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+		return err
+	}
+	return nil
+}
+
+func (f *fakeRepo) onRollback(action func()) {
+	if f.undo != nil {
+		*f.undo = append(*f.undo, action)
+	}
+}
+
+func (f *fakeRepo) GetParkingLotById(_ context.Context, parkingLotId int) (*models.ParkingLot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parkingLot, ok := f.parkingLots[parkingLotId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return parkingLot, nil
+}
+
+func (f *fakeRepo) GetParkingLots(_ context.Context) ([]*models.ParkingLot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parkingLots := make([]*models.ParkingLot, 0, len(f.parkingLots))
+	for _, parkingLot := range f.parkingLots {
+		parkingLots = append(parkingLots, parkingLot)
+	}
+	sort.Slice(parkingLots, func(i, j int) bool { return parkingLots[i].ID < parkingLots[j].ID })
+	return parkingLots, nil
+}
+
+func (f *fakeRepo) CreateParkingLot(_ context.Context, parkingLot *models.ParkingLot) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parkingLot.ID = len(f.parkingLots) + 1
+	f.parkingLots[parkingLot.ID] = parkingLot
+	return nil
+}
+
+func (f *fakeRepo) UpdateParkingLot(_ context.Context, parkingLot *models.ParkingLot) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored, ok := f.parkingLots[parkingLot.ID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	stored.Name = parkingLot.Name
+	stored.Address = parkingLot.Address
+	stored.Currency = parkingLot.Currency
+	stored.Locale = parkingLot.Locale
+	return nil
+}
+
+func (f *fakeRepo) CountTariffsByParkingLotId(_ context.Context, parkingLotId int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var count int64
+	for _, tariff := range f.tariffs {
+		if tariff.ParkingLotId == parkingLotId {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeRepo) CountPassProductsByParkingLotId(_ context.Context, parkingLotId int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var count int64
+	for _, passProduct := range f.passProducts {
+		if passProduct.ParkingLotId == parkingLotId {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeRepo) CountDiscountRulesByParkingLotId(_ context.Context, parkingLotId int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var count int64
+	for _, discountRule := range f.discountRules {
+		if discountRule.ParkingLotId == parkingLotId {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeRepo) CountValidationsByParkingLotId(_ context.Context, parkingLotId int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var count int64
+	for _, validation := range f.validations {
+		if parkingSession, ok := f.sessions[validation.SessionId]; ok && parkingSession.ParkingLotId == parkingLotId {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeRepo) UpdatePenaltyPolicy(_ context.Context, parkingLot *models.ParkingLot) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored, ok := f.parkingLots[parkingLot.ID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	stored.LostTicketFee = parkingLot.LostTicketFee
+	stored.LostTicketFullDay = parkingLot.LostTicketFullDay
+	stored.MaxStayDays = parkingLot.MaxStayDays
+	stored.OverstaySurcharge = parkingLot.OverstaySurcharge
+	return nil
+}
+
+func (f *fakeRepo) GetVehicleTypeById(_ context.Context, vehicleTypeId int) (*models.VehicleType, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	vehicleType, ok := f.vehicleTypes[vehicleTypeId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return vehicleType, nil
+}
+
+func (f *fakeRepo) CreateVehicleType(_ context.Context, vehicleType *models.VehicleType) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.vehicleTypeCodeTaken(vehicleType) {
+		return gorm.ErrDuplicatedKey
+	}
+	vehicleType.ID = len(f.vehicleTypes) + 1
+	typeCopy := *vehicleType
+	f.vehicleTypes[vehicleType.ID] = &typeCopy
+	return nil
+}
+
+func (f *fakeRepo) UpdateVehicleType(_ context.Context, vehicleType *models.VehicleType) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.vehicleTypes[vehicleType.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	if f.vehicleTypeCodeTaken(vehicleType) {
+		return gorm.ErrDuplicatedKey
+	}
+	typeCopy := *vehicleType
+	f.vehicleTypes[vehicleType.ID] = &typeCopy
+	return nil
+}
+
+// vehicleTypeCodeTaken reports whether another vehicle type has the code of vehicleType, like the unique index.
+func (f *fakeRepo) vehicleTypeCodeTaken(vehicleType *models.VehicleType) bool {
+	for _, other := range f.vehicleTypes {
+		if other.ID != vehicleType.ID && other.Code == vehicleType.Code {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeRepo) DeleteVehicleType(_ context.Context, vehicleTypeId int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.vehicleTypes[vehicleTypeId]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(f.vehicleTypes, vehicleTypeId)
+	return nil
+}
+
+func (f *fakeRepo) CountParkingSpacesByVehicleTypeId(_ context.Context, vehicleTypeId int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var count int64
+	for key := range f.parkingSpaces {
+		if key[1] == vehicleTypeId {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeRepo) CountParkingSessionsByVehicleTypeId(_ context.Context, vehicleTypeId int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var count int64
+	for _, parkingSession := range f.sessions {
+		if parkingSession.VehicleTypeId == vehicleTypeId || parkingSession.SpotVehicleTypeId == vehicleTypeId {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeRepo) GetParkingSpace(_ context.Context, parkingLotId, vehicleTypeId int) (*models.ParkingSpace, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parkingSpace, ok := f.parkingSpaces[[2]int{parkingLotId, vehicleTypeId}]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	spaceCopy := *parkingSpace
+	return &spaceCopy, nil
+}
+
+func (f *fakeRepo) DecrementAvailableSpots(_ context.Context, parkingLotId, vehicleTypeId, heldSpots int) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parkingSpace, ok := f.parkingSpaces[[2]int{parkingLotId, vehicleTypeId}]
+	if !ok || parkingSpace.AvailableSpots <= heldSpots {
+		return false, nil
+	}
+	parkingSpace.AvailableSpots--
+	f.onRollback(func() { parkingSpace.AvailableSpots++ })
+	return true, nil
+}
+
+func (f *fakeRepo) IncrementAvailableSpots(_ context.Context, parkingLotId, vehicleTypeId int) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parkingSpace, ok := f.parkingSpaces[[2]int{parkingLotId, vehicleTypeId}]
+	if !ok || parkingSpace.AvailableSpots >= parkingSpace.TotalSpots {
+		return false, nil
+	}
+	parkingSpace.AvailableSpots++
+	f.onRollback(func() { parkingSpace.AvailableSpots-- })
+	return true, nil
+}
+
+func (f *fakeRepo) GetFreeSpots(_ context.Context, parkingLotId int) ([]*models.Spot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var freeSpots []*models.Spot
+	for _, spot := range f.sortedSpots() {
+		if spot.ParkingLotId == parkingLotId && !spot.Occupied {
+			spotCopy := *spot
+			freeSpots = append(freeSpots, &spotCopy)
+		}
+	}
+	return freeSpots, nil
+}
+
+func (f *fakeRepo) OccupySpot(_ context.Context, spotId uint) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	spot, ok := f.spots[spotId]
+	if !ok || spot.Occupied {
+		return false, nil
+	}
+	spot.Occupied = true
+	spot.UsageCount++
+	f.onRollback(func() {
+		spot.Occupied = false
+		spot.UsageCount--
+	})
+	return true, nil
+}
+
+// ReleaseSpot frees a spot, or removes it while its parking space has pending removals.
+func (f *fakeRepo) ReleaseSpot(_ context.Context, spotId uint) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	spot, ok := f.spots[spotId]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	parkingSpace, ok := f.parkingSpaces[[2]int{spot.ParkingLotId, spot.VehicleTypeId}]
+	if ok && parkingSpace.PendingRemovals > 0 {
+		parkingSpace.PendingRemovals--
+		delete(f.spots, spotId)
+		f.onRollback(func() {
+			parkingSpace.PendingRemovals++
+			f.spots[spotId] = spot
+		})
+		return nil
+	}
+
+	spot.Occupied = false
+	f.onRollback(func() { spot.Occupied = true })
+	return nil
+}
+
+// ResizeParkingSpace sets the capacity of a parking space like the GORM implementation: growing cancels pending
+// removals before numbered spots are added, shrinking removes free spots and records the missing removals as
+// pending when allowOverCapacity is set.
+func (f *fakeRepo) ResizeParkingSpace(_ context.Context, parkingLotId, vehicleTypeId, totalSpots int,
+	allowOverCapacity bool) (*models.ParkingSpace, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	vehicleType, ok := f.vehicleTypes[vehicleTypeId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	key := [2]int{parkingLotId, vehicleTypeId}
+	parkingSpace, ok := f.parkingSpaces[key]
+	if !ok {
+		parkingSpace = &models.ParkingSpace{ID: uint(len(f.parkingSpaces) + 1),
+			TenantId: f.parkingLots[parkingLotId].TenantId, ParkingLotId: parkingLotId, VehicleTypeId: vehicleTypeId}
+	}
+	pendingRemovals := parkingSpace.PendingRemovals
+
+	var added, removed []*models.Spot
+	delta := totalSpots - parkingSpace.TotalSpots
+	if delta > 0 {
+		cancelled := min(delta, pendingRemovals)
+		pendingRemovals -= cancelled
+
+		taken := map[string]bool{}
+		for _, spot := range f.spots {
+			if spot.ParkingLotId == parkingLotId {
+				taken[spot.Label] = true
+			}
+		}
+		var nextId uint
+		for _, spot := range f.spots {
+			nextId = max(nextId, spot.ID)
+		}
+		for number := 1; len(added) < delta-cancelled; number++ {
+			label := fmt.Sprintf("%s-%03d", vehicleType.Code, number)
+			if taken[label] {
+				continue
+			}
+			nextId++
+			added = append(added, &models.Spot{ID: nextId, ParkingLotId: parkingLotId, VehicleTypeId: vehicleTypeId,
+				Label: label, SizeClass: vehicleType.SizeClass})
+		}
+	}
+
+	if delta < 0 {
+		for _, spot := range f.sortedSpots() {
+			if spot.ParkingLotId == parkingLotId && spot.VehicleTypeId == vehicleTypeId && !spot.Occupied {
+				removed = append(removed, spot)
+			}
+		}
+		sort.SliceStable(removed, func(i, j int) bool {
+			if removed[i].Level != removed[j].Level {
+				return removed[i].Level > removed[j].Level
+			}
+			return removed[i].Label > removed[j].Label
+		})
+		removed = removed[:min(len(removed), -delta)]
+
+		missing := -delta - len(removed)
+		if missing > 0 && !allowOverCapacity {
+			return nil, repo.ErrCapacityBelowOccupancy
+		}
+		pendingRemovals += missing
+	}
+
+	for _, spot := range added {
+		f.spots[spot.ID] = spot
+	}
+	for _, spot := range removed {
+		delete(f.spots, spot.ID)
+	}
+
+	occupied := 0
+	for _, spot := range f.spots {
+		if spot.ParkingLotId == parkingLotId && spot.VehicleTypeId == vehicleTypeId && spot.Occupied {
+			occupied++
+		}
+	}
+
+	saved, existed := *parkingSpace, ok
+	parkingSpace.TotalSpots = totalSpots
+	parkingSpace.AvailableSpots = totalSpots - occupied
+	parkingSpace.PendingRemovals = pendingRemovals
+	f.parkingSpaces[key] = parkingSpace
+	f.onRollback(func() {
+		for _, spot := range added {
+			delete(f.spots, spot.ID)
+		}
+		for _, spot := range removed {
+			f.spots[spot.ID] = spot
+		}
+		if !existed {
+			delete(f.parkingSpaces, key)
+		}
+		*parkingSpace = saved
+	})
+
+	spaceCopy := *parkingSpace
+	return &spaceCopy, nil
+}
+
+// sortedSpots returns the spots ordered by ID.
+func (f *fakeRepo) sortedSpots() []*models.Spot {
+	spots := make([]*models.Spot, 0, len(f.spots))
+	for _, spot := range f.spots {
+		spots = append(spots, spot)
+	}
+	sort.Slice(spots, func(i, j int) bool { return spots[i].ID < spots[j].ID })
+	return spots
+}
+
+func (f *fakeRepo) GetSpotById(_ context.Context, spotId uint) (*models.Spot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	spot, ok := f.spots[spotId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	spotCopy := *spot
+	return &spotCopy, nil
+}
+
+// DeleteSpot removes a free spot and shrinks its parking space by one, like the GORM implementation.
+func (f *fakeRepo) DeleteSpot(_ context.Context, spot *models.Spot) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored, ok := f.spots[spot.ID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	if stored.Occupied {
+		return repo.ErrSpotOccupied
+	}
+	parkingSpace := f.parkingSpaces[[2]int{stored.ParkingLotId, stored.VehicleTypeId}]
+	delete(f.spots, stored.ID)
+	parkingSpace.TotalSpots--
+	parkingSpace.AvailableSpots--
+	f.onRollback(func() {
+		f.spots[stored.ID] = stored
+		parkingSpace.TotalSpots++
+		parkingSpace.AvailableSpots++
+	})
+	return nil
+}
+
+// ReconcileParkingSpaces frees the occupied spots without an open session and recomputes the counters of every
+// parking space with spots, like the GORM implementation.
+func (f *fakeRepo) ReconcileParkingSpaces(_ context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	taken := map[uint]bool{}
+	for _, parkingSession := range f.openSessions {
+		if parkingSession.SpotId != nil {
+			taken[*parkingSession.SpotId] = true
+		}
+	}
+
+	totals, free := map[[2]int]int{}, map[[2]int]int{}
+	for _, spot := range f.spots {
+		if spot.Occupied && !taken[spot.ID] {
+			spot.Occupied = false
+		}
+		key := [2]int{spot.ParkingLotId, spot.VehicleTypeId}
+		totals[key]++
+		if !spot.Occupied {
+			free[key]++
+		}
+	}
+	for key, parkingSpace := range f.parkingSpaces {
+		if total, ok := totals[key]; ok {
+			parkingSpace.TotalSpots = total - parkingSpace.PendingRemovals
+			parkingSpace.AvailableSpots = free[key] - parkingSpace.PendingRemovals
+		}
+	}
+	return nil
+}
+
+// occupiedSpots counts the spots currently marked as occupied.
+func (f *fakeRepo) occupiedSpots() int {
+	var occupied int
+	for _, spot := range f.spots {
+		if spot.Occupied {
+			occupied++
+		}
+	}
+	return occupied
+}
+
+func (f *fakeRepo) OpenParkingSession(_ context.Context, parkingSession *models.ParkingSession) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.openSessions[parkingSession.VehicleNumber]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	*f.lastSessionId++
+	parkingSession.ID = *f.lastSessionId
+	parkingSession.Status = models.ParkingSessionStatusOpen
+	f.openSessions[parkingSession.VehicleNumber] = parkingSession
+	f.sessions[parkingSession.ID] = parkingSession
+	f.onRollback(func() {
+		delete(f.openSessions, parkingSession.VehicleNumber)
+		delete(f.sessions, parkingSession.ID)
+	})
+	return nil
+}
+
+func (f *fakeRepo) GetParkingSessionByTicket(_ context.Context, ticketNumber string) (*models.ParkingSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, parkingSession := range f.sessions {
+		if parkingSession.TicketNumber == ticketNumber {
+			sessionCopy := *parkingSession
+			return &sessionCopy, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeRepo) GetParkingSessionById(_ context.Context, sessionId uint) (*models.ParkingSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parkingSession, ok := f.sessions[sessionId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	sessionCopy := *parkingSession
+	return &sessionCopy, nil
+}
+
+func (f *fakeRepo) CreateLostTicketExit(_ context.Context, lostTicketExit *models.LostTicketExit) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	lostTicketExit.ID = uint(len(f.lostTickets) + 1)
+	exitCopy := *lostTicketExit
+	f.lostTickets = append(f.lostTickets, &exitCopy)
+	return nil
+}
+
+func (f *fakeRepo) CloseParkingSession(_ context.Context, parkingSession *models.ParkingSession) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored, ok := f.openSessions[parkingSession.VehicleNumber]
+	if !ok || stored.ID != parkingSession.ID {
+		return gorm.ErrRecordNotFound
+	}
+	previous := *stored
+	stored.ExitTime = parkingSession.ExitTime
+	stored.Currency = parkingSession.Currency
+	stored.GrossFare = parkingSession.GrossFare
+	stored.Fare = parkingSession.Fare
+	stored.Status = models.ParkingSessionStatusClosed
+	delete(f.openSessions, stored.VehicleNumber)
+	f.onRollback(func() {
+		*stored = previous
+		f.openSessions[stored.VehicleNumber] = stored
+	})
+	return nil
+}
+
+func (f *fakeRepo) PayParkingSession(_ context.Context, parkingSession *models.ParkingSession) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored, ok := f.openSessions[parkingSession.VehicleNumber]
+	if !ok || stored.ID != parkingSession.ID || (stored.PaidAt != nil && !stored.PaidAt.Before(*parkingSession.PaidAt)) {
+		return gorm.ErrRecordNotFound
+	}
+	previous := *stored
+	stored.PaidAt = parkingSession.PaidAt
+	stored.Currency = parkingSession.Currency
+	stored.GrossFare = parkingSession.GrossFare
+	stored.Fare = parkingSession.Fare
+	f.onRollback(func() {
+		*stored = previous
+	})
+	return nil
+}
+
+func (f *fakeRepo) GetParkingSessions(_ context.Context, filter *repo.ParkingSessionFilter) ([]*models.ParkingSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var parkingSessions []*models.ParkingSession
+	for id := *f.lastSessionId; id > 0 && len(parkingSessions) < filter.Limit; id-- {
+		parkingSession, ok := f.sessions[id]
+		if ok && (filter.BeforeId == 0 || id < filter.BeforeId) &&
+			(filter.VehicleNumber == "" || parkingSession.VehicleNumber == filter.VehicleNumber) &&
+			(filter.Status == "" || parkingSession.Status == filter.Status) {
+			parkingSessions = append(parkingSessions, parkingSession)
+		}
+	}
+	return parkingSessions, nil
+}
+
+func (f *fakeRepo) GetTariffEffectiveAt(_ context.Context, parkingLotId, vehicleTypeId int,
+	at time.Time) (*models.Tariff, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, tariff := range f.tariffs {
+		if tariff.ParkingLotId == parkingLotId && tariff.VehicleTypeId == vehicleTypeId &&
+			!tariff.EffectiveFrom.After(at) && (tariff.EffectiveTo == nil || tariff.EffectiveTo.After(at)) {
+			return tariff, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// CreateTariff closes the open ended version that started earlier at the start of the new one and rejects any
+// other overlap, like the repo.
+func (f *fakeRepo) CreateTariff(_ context.Context, tariff *models.Tariff) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var closing []*models.Tariff
+	for _, version := range f.tariffs {
+		if version.ParkingLotId != tariff.ParkingLotId || version.VehicleTypeId != tariff.VehicleTypeId {
+			continue
+		}
+		overlaps := (tariff.EffectiveTo == nil || version.EffectiveFrom.Before(*tariff.EffectiveTo)) &&
+			(version.EffectiveTo == nil || tariff.EffectiveFrom.Before(*version.EffectiveTo))
+		if !overlaps {
+			continue
+		}
+		if version.EffectiveTo != nil || !version.EffectiveFrom.Before(tariff.EffectiveFrom) {
+			return repo.ErrTariffOverlap
+		}
+		closing = append(closing, version)
+	}
+
+	for _, version := range closing {
+		effectiveTo := tariff.EffectiveFrom
+		version.EffectiveTo = &effectiveTo
+	}
+	tariff.ID = uint(len(f.tariffs) + 1)
+	tariffCopy := *tariff
+	f.tariffs = append(f.tariffs, &tariffCopy)
+	return nil
+}
+
+func (f *fakeRepo) GetUpsizeRules(_ context.Context, parkingLotId int) ([]*models.UpsizeRule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var rules []*models.UpsizeRule
+	for _, rule := range f.upsizeRules {
+		if rule.ParkingLotId == parkingLotId {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (f *fakeRepo) ReplaceUpsizePolicy(_ context.Context, parkingLotId int, pricing models.UpsizePricing,
+	rules []*models.UpsizeRule) error {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parkingLot, ok := f.parkingLots[parkingLotId]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	parkingLot.UpsizePricing = pricing
+
+	kept := make([]*models.UpsizeRule, 0, len(f.upsizeRules)+len(rules))
+	for _, rule := range f.upsizeRules {
+		if rule.ParkingLotId != parkingLotId {
+			kept = append(kept, rule)
+		}
+	}
+	for i, rule := range rules {
+		rule.ID = uint(len(f.upsizeRules) + i + 1)
+		kept = append(kept, rule)
+	}
+	f.upsizeRules = kept
+	return nil
+}
+
+func (f *fakeRepo) GetReservationById(_ context.Context, reservationId uint) (*models.Reservation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	reservation, ok := f.reservations[reservationId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	reservationCopy := *reservation
+	return &reservationCopy, nil
+}
+
+func (f *fakeRepo) CheckInReservation(_ context.Context, reservationId, sessionId uint) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	reservation, ok := f.reservations[reservationId]
+	if !ok || reservation.Status != models.ReservationStatusBooked {
+		return false, nil
+	}
+	reservation.Status = models.ReservationStatusCheckedIn
+	reservation.SessionId = &sessionId
+	f.onRollback(func() {
+		reservation.Status = models.ReservationStatusBooked
+		reservation.SessionId = nil
+	})
+	return true, nil
+}
+
+func (f *fakeRepo) GetReservationHolds(_ context.Context, parkingLotId int, at time.Time) ([]*models.SpotHold, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	heldSpots := make(map[int]int)
+	for _, reservation := range f.reservations {
+		if reservation.ParkingLotId == parkingLotId && reservation.Status == models.ReservationStatusBooked &&
+			!reservation.StartTime.After(at) && reservation.HoldUntil.After(at) {
+			heldSpots[reservation.VehicleTypeId]++
+		}
+	}
+
+	var holds []*models.SpotHold
+	for vehicleTypeId, held := range heldSpots {
+		holds = append(holds, &models.SpotHold{ParkingLotId: parkingLotId, VehicleTypeId: vehicleTypeId,
+			HeldSpots: held})
+	}
+	return holds, nil
+}
+
+func (f *fakeRepo) GetPassById(_ context.Context, passId uint) (*models.Pass, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parkingPass, ok := f.passes[passId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return parkingPass, nil
+}
+
+func (f *fakeRepo) GetPassProductById(_ context.Context, passProductId uint) (*models.PassProduct, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	passProduct, ok := f.passProducts[passProductId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return passProduct, nil
+}
+
+func (f *fakeRepo) GetActivePass(_ context.Context, parkingLotId, vehicleTypeId int, vehicleNumber string,
+	at time.Time) (*models.Pass, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, parkingPass := range f.passes {
+		if parkingPass.ParkingLotId != parkingLotId || parkingPass.VehicleTypeId != vehicleTypeId ||
+			parkingPass.Status != models.PassStatusActive || parkingPass.ValidFrom.After(at) || !parkingPass.ValidTo.After(at) {
+			continue
+		}
+		for _, vehicle := range parkingPass.Vehicles {
+			if vehicle.VehicleNumber == vehicleNumber {
+				return parkingPass, nil
+			}
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeRepo) GetPassHolds(_ context.Context, parkingLotId int) ([]*models.PassHold, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var holds []*models.PassHold
+	for _, passProduct := range f.passProducts {
+		if passProduct.ParkingLotId != parkingLotId || passProduct.ReservedSpots == 0 {
+			continue
+		}
+		held := passProduct.ReservedSpots
+		for _, parkingSession := range f.openSessions {
+			if parkingSession.PassId != nil && f.passes[*parkingSession.PassId].PassProductId == passProduct.ID {
+				held--
+			}
+		}
+		holds = append(holds, &models.PassHold{PassProductId: passProduct.ID, ParkingLotId: parkingLotId,
+			VehicleTypeId: passProduct.VehicleTypeId, HeldSpots: max(held, 0)})
+	}
+	return holds, nil
+}
+
+func (f *fakeRepo) GetMerchantById(_ context.Context, merchantId uint) (*models.Merchant, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	merchant, ok := f.merchants[merchantId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return merchant, nil
+}
+
+func (f *fakeRepo) GetDiscountRuleById(_ context.Context, discountRuleId uint) (*models.DiscountRule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	discountRule, ok := f.discountRules[discountRuleId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return discountRule, nil
+}
+
+func (f *fakeRepo) GetDiscountRuleByCode(_ context.Context, parkingLotId int, code string) (*models.DiscountRule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, discountRule := range f.discountRules {
+		if discountRule.ParkingLotId == parkingLotId && discountRule.Code != nil && *discountRule.Code == code {
+			return discountRule, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeRepo) CreateValidation(_ context.Context, validation *models.Validation) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, stored := range f.validations {
+		if stored.SessionId == validation.SessionId && stored.DiscountRuleId == validation.DiscountRuleId {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	validation.ID = uint(len(f.validations) + 1)
+	validationCopy := *validation
+	f.validations[validation.ID] = &validationCopy
+	return nil
+}
+
+func (f *fakeRepo) GetValidations(_ context.Context, sessionId uint) ([]*models.Validation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var validations []*models.Validation
+	for id := uint(1); id <= uint(len(f.validations)); id++ {
+		if validation := f.validations[id]; validation.SessionId == sessionId {
+			validationCopy := *validation
+			validations = append(validations, &validationCopy)
+		}
+	}
+	return validations, nil
+}
+
+func (f *fakeRepo) SaveValidations(_ context.Context, validations []*models.Validation) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, validation := range validations {
+		if validation.ID == 0 {
+			validation.ID = uint(len(f.validations) + 1)
+			id := validation.ID
+			f.onRollback(func() {
+				delete(f.validations, id)
+			})
+		} else {
+			previous := f.validations[validation.ID]
+			f.onRollback(func() {
+				f.validations[previous.ID] = previous
+			})
+		}
+		validationCopy := *validation
+		f.validations[validation.ID] = &validationCopy
+	}
+	return nil
+}
+
+func (f *fakeRepo) GetHolidays(_ context.Context, parkingLotId int, fromDate, toDate string) ([]*models.Holiday, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var holidays []*models.Holiday
+	for _, holiday := range f.holidays {
+		if holiday.ParkingLotId == parkingLotId && holiday.Date >= fromDate && holiday.Date <= toDate {
+			holidays = append(holidays, holiday)
+		}
+	}
+	return holidays, nil
+}
+
+func (f *fakeRepo) GetRevenueByCurrency(_ context.Context, filter *repo.RevenueFilter) ([]*models.RevenueTotal, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	byCurrency := map[string]*models.RevenueTotal{}
+	for _, session := range f.sessions {
+		switch {
+		case session.Status != models.ParkingSessionStatusClosed,
+			filter.ParkingLotId > 0 && session.ParkingLotId != filter.ParkingLotId,
+			filter.ExitedFrom != nil && session.ExitTime.Before(*filter.ExitedFrom),
+			filter.ExitedTo != nil && !session.ExitTime.Before(*filter.ExitedTo):
+			continue
+		}
+		total, ok := byCurrency[session.Currency]
+		if !ok {
+			total = &models.RevenueTotal{Currency: session.Currency}
+			byCurrency[session.Currency] = total
+		}
+		total.Sessions++
+		total.GrossFare += *session.GrossFare
+		total.Fare += *session.Fare
+	}
+
+	totals := make([]*models.RevenueTotal, 0, len(byCurrency))
+	for _, total := range byCurrency {
+		totals = append(totals, total)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		return totals[i].Currency < totals[j].Currency
+	})
+	return totals, nil
+}
+
+func (f *fakeRepo) GetExchangeRates(_ context.Context) ([]*models.ExchangeRate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.exchangeRates, nil
+}
+
+func (f *fakeRepo) GetInvoiceById(_ context.Context, invoiceId uint) (*models.Invoice, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	invoice, ok := f.invoices[invoiceId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	invoiceCopy := *invoice
+	invoiceCopy.Payments = nil
+	for id := uint(1); id <= uint(len(f.payments)); id++ {
+		if found := f.payments[id]; found.InvoiceId == invoiceId {
+			invoiceCopy.Payments = append(invoiceCopy.Payments, *found)
+		}
+	}
+	return &invoiceCopy, nil
+}
+
+func (f *fakeRepo) GetOpenInvoice(_ context.Context, sessionId uint) (*models.Invoice, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, invoice := range f.invoices {
+		if invoice.SessionId == sessionId && (invoice.Status == models.InvoiceStatusPending ||
+			invoice.Status == models.InvoiceStatusPartiallyPaid) {
+			invoiceCopy := *invoice
+			return &invoiceCopy, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeRepo) CreateInvoice(_ context.Context, invoice *models.Invoice) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	invoice.ID = uint(len(f.invoices) + 1)
+	invoice.Status = models.InvoiceStatusPending
+	invoiceCopy := *invoice
+	f.invoices[invoice.ID] = &invoiceCopy
+	id := invoice.ID
+	f.onRollback(func() {
+		delete(f.invoices, id)
+	})
+	return nil
+}
+
+func (f *fakeRepo) VoidInvoice(_ context.Context, invoiceId uint) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	invoice, ok := f.invoices[invoiceId]
+	if !ok || invoice.Status != models.InvoiceStatusPending {
+		return gorm.ErrRecordNotFound
+	}
+	f.updateInvoice(invoice, func() {
+		invoice.Status = models.InvoiceStatusVoid
+	})
+	return nil
+}
+
+func (f *fakeRepo) PayInvoice(_ context.Context, invoiceId uint, amount int64, at time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	invoice, ok := f.invoices[invoiceId]
+	if !ok || (invoice.Status != models.InvoiceStatusPending && invoice.Status != models.InvoiceStatusPartiallyPaid) ||
+		invoice.Balance() < amount {
+		return false, nil
+	}
+	f.updateInvoice(invoice, func() {
+		invoice.Status = models.InvoiceStatusPartiallyPaid
+		if invoice.Balance() == amount {
+			invoice.Status = models.InvoiceStatusPaid
+			invoice.SettledAt = &at
+		}
+		invoice.PaidAmount += amount
+	})
+	return true, nil
+}
+
+func (f *fakeRepo) BillInvoiceToAccount(_ context.Context, invoiceId uint, accountReference string,
+	at time.Time) (bool, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	invoice, ok := f.invoices[invoiceId]
+	if !ok || (invoice.Status != models.InvoiceStatusPending && invoice.Status != models.InvoiceStatusPartiallyPaid) {
+		return false, nil
+	}
+	f.updateInvoice(invoice, func() {
+		invoice.Status = models.InvoiceStatusAccountBilled
+		invoice.AccountReference = &accountReference
+		invoice.SettledAt = &at
+	})
+	return true, nil
+}
+
+func (f *fakeRepo) RefundInvoice(_ context.Context, invoiceId uint, amount int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	invoice, ok := f.invoices[invoiceId]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	f.updateInvoice(invoice, func() {
+		invoice.RefundedAmount += amount
+	})
+	return nil
+}
+
+// updateInvoice changes a stored invoice and restores it when the enclosing WithTx call fails.
+func (f *fakeRepo) updateInvoice(invoice *models.Invoice, change func()) {
+	previous := *invoice
+	change()
+	f.onRollback(func() {
+		*invoice = previous
+	})
+}
+
+func (f *fakeRepo) GetPaymentById(_ context.Context, paymentId uint) (*models.Payment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	found, ok := f.payments[paymentId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	paymentCopy := *found
+	return &paymentCopy, nil
+}
+
+func (f *fakeRepo) CreatePayment(_ context.Context, created *models.Payment) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	created.ID = uint(len(f.payments) + 1)
+	paymentCopy := *created
+	f.payments[created.ID] = &paymentCopy
+	id := created.ID
+	f.onRollback(func() {
+		delete(f.payments, id)
+	})
+	return nil
+}
+
+func (f *fakeRepo) CompletePayment(_ context.Context, completed *models.Payment) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored, ok := f.payments[completed.ID]
+	if !ok || stored.Status != models.PaymentStatusPending {
+		return gorm.ErrRecordNotFound
+	}
+	previous := *stored
+	stored.Status = completed.Status
+	stored.Reference = completed.Reference
+	stored.Message = completed.Message
+	f.onRollback(func() {
+		*stored = previous
+	})
+	return nil
+}
+
+func (f *fakeRepo) RefundPayment(_ context.Context, paymentId uint, amount int64) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored, ok := f.payments[paymentId]
+	if !ok || stored.Kind != models.PaymentKindCharge || stored.Amount-stored.RefundedAmount < amount ||
+		(stored.Status != models.PaymentStatusSucceeded && stored.Status != models.PaymentStatusPartiallyRefunded) {
+		return false, nil
+	}
+	previous := *stored
+	stored.RefundedAmount += amount
+	stored.Status = models.PaymentStatusPartiallyRefunded
+	if stored.RefundedAmount == stored.Amount {
+		stored.Status = models.PaymentStatusRefunded
+	}
+	f.onRollback(func() {
+		*stored = previous
+	})
+	return true, nil
+}
+
+func (f *fakeRepo) AdjustSessionFare(_ context.Context, sessionId uint, from, to int64) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored, ok := f.sessions[sessionId]
+	if !ok || stored.Status != models.ParkingSessionStatusClosed || stored.Fare == nil || *stored.Fare != from {
+		return false, nil
+	}
+	previous := *stored
+	stored.Fare = &to
+	f.onRollback(func() {
+		*stored = previous
+	})
+	return true, nil
+}
+
+func (f *fakeRepo) GetSessionInvoices(_ context.Context, sessionId uint) ([]*models.Invoice, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var invoices []*models.Invoice
+	for id := uint(1); id <= uint(len(f.invoices)); id++ {
+		invoice := f.invoices[id]
+		if invoice.SessionId != sessionId {
+			continue
+		}
+		invoiceCopy := *invoice
+		invoiceCopy.Payments = nil
+		for paymentId := uint(1); paymentId <= uint(len(f.payments)); paymentId++ {
+			if found := f.payments[paymentId]; found.InvoiceId == id {
+				invoiceCopy.Payments = append(invoiceCopy.Payments, *found)
+			}
+		}
+		invoices = append(invoices, &invoiceCopy)
+	}
+	return invoices, nil
+}
+
+func (f *fakeRepo) CreateFareAdjustment(_ context.Context, adjustment *models.FareAdjustment) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	adjustment.ID = uint(len(f.adjustments) + 1)
+	adjustment.CreatedAt = time.Now()
+	adjustmentCopy := *adjustment
+	f.adjustments[adjustment.ID] = &adjustmentCopy
+	id := adjustment.ID
+	f.onRollback(func() {
+		delete(f.adjustments, id)
+	})
+	return nil
+}
+
+func (f *fakeRepo) GetFareAdjustmentById(_ context.Context, adjustmentId uint) (*models.FareAdjustment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	adjustment, ok := f.adjustments[adjustmentId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return f.withRefunds(adjustment), nil
+}
+
+func (f *fakeRepo) GetFareAdjustments(_ context.Context, sessionId uint) ([]*models.FareAdjustment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var adjustments []*models.FareAdjustment
+	for id := uint(1); id <= uint(len(f.adjustments)); id++ {
+		if adjustment := f.adjustments[id]; adjustment.SessionId == sessionId {
+			adjustments = append(adjustments, f.withRefunds(adjustment))
+		}
+	}
+	return adjustments, nil
+}
+
+// withRefunds copies a stored fare adjustment with the refunds made for it, f.mu must be held.
+func (f *fakeRepo) withRefunds(adjustment *models.FareAdjustment) *models.FareAdjustment {
+	adjustmentCopy := *adjustment
+	adjustmentCopy.Refunds = nil
+	for id := uint(1); id <= uint(len(f.payments)); id++ {
+		if found := f.payments[id]; found.AdjustmentId != nil && *found.AdjustmentId == adjustment.ID {
+			adjustmentCopy.Refunds = append(adjustmentCopy.Refunds, *found)
+		}
+	}
+	return &adjustmentCopy
+}
+
+func (f *fakeRepo) GetTenants(_ context.Context) ([]*models.Tenant, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tenants := make([]*models.Tenant, 0, len(f.tenants))
+	for _, tenant := range f.tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].ID < tenants[j].ID })
+	return tenants, nil
+}
+
+func (f *fakeRepo) GetTenantById(_ context.Context, tenantId uint) (*models.Tenant, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tenant, ok := f.tenants[tenantId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return tenant, nil
+}
+
+func (f *fakeRepo) CreateTenant(_ context.Context, tenant *models.Tenant) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tenant.ID = uint(len(f.tenants) + 1)
+	f.tenants[tenant.ID] = tenant
+	return nil
+}
+
+func (f *fakeRepo) UpdateTenant(_ context.Context, tenant *models.Tenant) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored, ok := f.tenants[tenant.ID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	stored.Name = tenant.Name
+	return nil
+}
+
+func (f *fakeRepo) GetAuditEntries(_ context.Context, filter *repo.AuditEntryFilter) ([]*models.AuditEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var entries []*models.AuditEntry
+	for i := len(f.auditEntries) - 1; i >= 0 && len(entries) < filter.Limit; i-- {
+		entry := f.auditEntries[i]
+		if filter.BeforeId > 0 && entry.ID >= filter.BeforeId {
+			continue
+		}
+		if filter.Entity != "" && entry.Entity != filter.Entity {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (f *fakeRepo) GetAuditChain(_ context.Context, afterId uint, limit int) ([]*models.AuditEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var entries []*models.AuditEntry
+	for _, entry := range f.auditEntries {
+		if entry.ID > afterId && len(entries) < limit {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (f *fakeRepo) AddOutboxEvent(_ context.Context, event *models.OutboxEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	event.ID = uint(len(f.outboxEvents) + 1)
+	eventCopy := *event
+	f.outboxEvents[event.ID] = &eventCopy
+	id := event.ID
+	f.onRollback(func() {
+		delete(f.outboxEvents, id)
+	})
+	return nil
+}
+
+// publishedEvents returns the outbox events in the order they were written.
+func (f *fakeRepo) publishedEvents() []*models.OutboxEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	published := make([]*models.OutboxEvent, 0, len(f.outboxEvents))
+	for _, event := range f.outboxEvents {
+		published = append(published, event)
+	}
+	sort.Slice(published, func(i, j int) bool { return published[i].ID < published[j].ID })
+	return published
+}
+
+func (f *fakeRepo) CreateFareQuote(_ context.Context, quote *models.FareQuote) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	quote.ID = uint(len(f.fareQuotes) + 1)
+	quoteCopy := *quote
+	f.fareQuotes[quote.ID] = &quoteCopy
+	return nil
+}
+
+func (f *fakeRepo) GetFareQuoteById(_ context.Context, quoteId uint) (*models.FareQuote, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	quote, ok := f.fareQuotes[quoteId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	quoteCopy := *quote
+	return &quoteCopy, nil
+}
+
+func (f *fakeRepo) LockParkingSpaces(context.Context, int, []int) error {
+	if f.lockHook != nil {
+		f.lockHook()
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package fare
+
+import (
+	"parking_lot_service/internal/repo/models"
+	"sort"
+	"time"
+)
+
+// DateLayout is the layout of the local dates in a holiday calendar.
+const DateLayout = "2006-01-02"
+
+// Calendar holds the public holidays of a parking lot keyed by their local date in DateLayout.
+type Calendar map[string]bool
+
+// Period is a part of a stay that is charged, [From, To).
+type Period struct {
+	From time.Time
+	To   time.Time
+}
+
+// Segment is a part of a stay charged under a single window of a tariff band, or under the standard rates of
+// the tariff when Band is nil.
+type Segment struct {
+	Band   *models.TariffBand
+	Window time.Time // Start of the band window the segment falls into, zero for the standard rates
+	From   time.Time
+	To     time.Time
+}
+
+// window is one occurrence of a tariff band on a local day.
+type window struct {
+	band  *models.TariffBand
+	order int // Position of the band in the tariff, breaks ties between bands of the same priority
+	start time.Time
+	end   time.Time
+}
+
+// Split divides a period of a stay into segments by the windows of the tariff bands that apply to it.
+// Band windows are laid out on the local days of loc, so they follow the lot's wall clock across daylight
+// saving changes. Where windows overlap the band with the highest priority wins, time outside of every
+// window is charged at the standard rates. Consecutive time in the same window is a single segment.
+func Split(bands []models.TariffBand, period Period, loc *time.Location, holidays Calendar) []Segment {
+	if !period.From.Before(period.To) {
+		return nil
+	}
+
+	windows := bandWindows(bands, period, loc, holidays)
+	boundaries := []time.Time{period.From, period.To}
+	for _, w := range windows {
+		boundaries = append(boundaries, w.start, w.end)
+	}
+	sort.Slice(boundaries, func(i, j int) bool {
+		return boundaries[i].Before(boundaries[j])
+	})
+
+	var segments []Segment
+	for i := 0; i+1 < len(boundaries); i++ {
+		from, to := boundaries[i], boundaries[i+1]
+		if !from.Before(to) {
+			continue
+		}
+
+		segment := Segment{From: from, To: to}
+		if w := winner(windows, from); w != nil {
+			segment.Band = w.band
+			segment.Window = w.start
+		}
+
+		last := len(segments) - 1
+		if last >= 0 && segments[last].Band == segment.Band && segments[last].Window.Equal(segment.Window) {
+			segments[last].To = to
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	return segments
+}
+
+// Skip drops the first d of charged time from a stay, as if that part of it were free.
+func Skip(periods []Period, d time.Duration) []Period {
+	var remaining []Period
+	for _, period := range periods {
+		length := period.To.Sub(period.From)
+		if d >= length {
+			d -= length
+			continue
+		}
+		remaining = append(remaining, Period{From: period.From.Add(d), To: period.To})
+		d = 0
+	}
+	return remaining
+}
+
+// Clip returns the parts of the periods that fall within [from, to).
+func Clip(periods []Period, from, to time.Time) []Period {
+	var clipped []Period
+	for _, period := range periods {
+		start, end := period.From, period.To
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if start.Before(end) {
+			clipped = append(clipped, Period{From: start, To: end})
+		}
+	}
+	return clipped
+}
+
+// bandWindows returns the windows of the bands that intersect the period, clipped to it.
+func bandWindows(bands []models.TariffBand, period Period, loc *time.Location, holidays Calendar) []window {
+	var (
+		windows []window
+		local   = period.From.In(loc)
+		// Start a day early, the window of the previous evening may run into the period
+		day = time.Date(local.Year(), local.Month(), local.Day()-1, 0, 0, 0, 0, loc)
+	)
+	for day.Before(period.To) {
+		for i := range bands {
+			band := &bands[i]
+			if !appliesOn(band.Days, day, holidays) {
+				continue
+			}
+
+			start := time.Date(day.Year(), day.Month(), day.Day(), 0, band.StartMinute, 0, 0, loc)
+			end := time.Date(day.Year(), day.Month(), day.Day(), 0, band.EndMinute, 0, 0, loc)
+			if band.EndMinute <= band.StartMinute {
+				end = time.Date(day.Year(), day.Month(), day.Day()+1, 0, band.EndMinute, 0, 0, loc)
+			}
+			if start.Before(period.From) {
+				start = period.From
+			}
+			if end.After(period.To) {
+				end = period.To
+			}
+			if start.Before(end) {
+				windows = append(windows, window{band: band, order: i, start: start, end: end})
+			}
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc)
+	}
+	return windows
+}
+
+// appliesOn reports whether a band with the given days applies on the local day.
+func appliesOn(days models.BandDays, day time.Time, holidays Calendar) bool {
+	holiday := holidays[day.Format(DateLayout)]
+	weekend := day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+
+	switch days {
+	case models.BandDaysEveryDay:
+		return true
+	case models.BandDaysWeekdays:
+		return !weekend && !holiday
+	case models.BandDaysWeekends:
+		return weekend
+	case models.BandDaysHolidays:
+		return holiday
+	}
+	return false
+}
+
+// winner returns the window that charges the instant at, or nil when the standard rates apply.
+func winner(windows []window, at time.Time) *window {
+	var best *window
+	for i := range windows {
+		w := &windows[i]
+		if at.Before(w.start) || !at.Before(w.end) {
+			continue
+		}
+		if best == nil || w.band.Priority > best.band.Priority ||
+			w.band.Priority == best.band.Priority && w.order < best.order {
+			best = w
+		}
+	}
+	return best
+}
@@ -0,0 +1,152 @@
+package fare
+
+import (
+	"parking_lot_service/internal/repo/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSplit(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	// Friday, 5 January 2024 in Kolkata
+	friday := func(hour int) time.Time {
+		return time.Date(2024, time.January, 5, hour, 0, 0, 0, kolkata)
+	}
+
+	night := models.TariffBand{ID: 1, Name: "Night", Days: models.BandDaysEveryDay,
+		StartMinute: 22 * 60, EndMinute: 6 * 60, FlatRate: 50}
+	weekend := models.TariffBand{ID: 2, Name: "Weekend", Days: models.BandDaysWeekends, FlatRate: 80, Priority: 1}
+	office := models.TariffBand{ID: 3, Name: "Office hours", Days: models.BandDaysWeekdays,
+		StartMinute: 9 * 60, EndMinute: 17 * 60, HourlyRate: 30}
+	holiday := models.TariffBand{ID: 4, Name: "Holiday", Days: models.BandDaysHolidays, FlatRate: 40}
+
+	type segment struct {
+		band     string // Name of the band, empty for the standard rates
+		from, to time.Time
+	}
+	tests := []struct {
+		name     string
+		bands    []models.TariffBand
+		from     time.Time
+		to       time.Time
+		holidays Calendar
+		want     []segment
+	}{
+		{
+			name:  "No bands charges the standard rates",
+			bands: nil,
+			from:  friday(9),
+			to:    friday(12),
+			want:  []segment{{"", friday(9), friday(12)}},
+		},
+		{
+			name:  "Night band across midnight",
+			bands: []models.TariffBand{night},
+			from:  friday(20),
+			to:    friday(32),
+			want: []segment{
+				{"", friday(20), friday(22)},
+				{"Night", friday(22), friday(30)},
+				{"", friday(30), friday(32)},
+			},
+		},
+		{
+			name:  "Stay starting within the previous evening's window",
+			bands: []models.TariffBand{night},
+			from:  friday(2),
+			to:    friday(7),
+			want: []segment{
+				{"Night", friday(2), friday(6)},
+				{"", friday(6), friday(7)},
+			},
+		},
+		{
+			name:  "Weekend outranks the night band",
+			bands: []models.TariffBand{night, weekend},
+			from:  friday(20),
+			to:    friday(34),
+			want: []segment{
+				{"", friday(20), friday(22)},
+				{"Night", friday(22), friday(24)},
+				{"Weekend", friday(24), friday(34)},
+			},
+		},
+		{
+			name:     "Holiday replaces the weekday band",
+			bands:    []models.TariffBand{office, holiday},
+			from:     friday(10),
+			to:       friday(12),
+			holidays: Calendar{"2024-01-05": true},
+			want:     []segment{{"Holiday", friday(10), friday(12)}},
+		},
+		{
+			name:  "Bands follow the lot's time zone",
+			bands: []models.TariffBand{office},
+			from:  time.Date(2024, time.January, 5, 3, 0, 0, 0, time.UTC), // 08:30 in Kolkata
+			to:    time.Date(2024, time.January, 5, 4, 30, 0, 0, time.UTC),
+			want: []segment{
+				{"", time.Date(2024, time.January, 5, 3, 0, 0, 0, time.UTC), friday(9)},
+				{"Office hours", friday(9), time.Date(2024, time.January, 5, 4, 30, 0, 0, time.UTC)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Split(tt.bands, Period{From: tt.from, To: tt.to}, kolkata, tt.holidays)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Split() = %+v, want %d segments", got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				var band string
+				if got[i].Band != nil {
+					band = got[i].Band.Name
+				}
+				if band != want.band || !got[i].From.Equal(want.from) || !got[i].To.Equal(want.to) {
+					t.Errorf("Split()[%d] = %q %v - %v, want %q %v - %v",
+						i, band, got[i].From, got[i].To, want.band, want.from, want.to)
+				}
+			}
+		})
+	}
+}
+
+func TestSkip(t *testing.T) {
+	start := time.Date(2024, time.January, 5, 9, 0, 0, 0, time.UTC)
+	periods := []Period{
+		{From: start, To: start.Add(time.Hour)},
+		{From: start.Add(3 * time.Hour), To: start.Add(5 * time.Hour)},
+	}
+
+	got := Skip(periods, 90*time.Minute)
+	if len(got) != 1 || !got[0].From.Equal(start.Add(210*time.Minute)) || !got[0].To.Equal(start.Add(5*time.Hour)) {
+		t.Errorf("Skip() = %+v, want the last 90 minutes", got)
+	}
+	if got := Skip(periods, 3*time.Hour); len(got) != 0 {
+		t.Errorf("Skip() = %+v, want nothing left", got)
+	}
+}
+
+func TestClip(t *testing.T) {
+	start := time.Date(2024, time.January, 5, 9, 0, 0, 0, time.UTC)
+	periods := []Period{
+		{From: start, To: start.Add(2 * time.Hour)},
+		{From: start.Add(3 * time.Hour), To: start.Add(5 * time.Hour)},
+	}
+
+	got := Clip(periods, start.Add(time.Hour), start.Add(4*time.Hour))
+	want := []Period{
+		{From: start.Add(time.Hour), To: start.Add(2 * time.Hour)},
+		{From: start.Add(3 * time.Hour), To: start.Add(4 * time.Hour)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Clip() = %+v, want %+v", got, want)
+	}
+	if got := Clip(periods, start.Add(2*time.Hour), start.Add(3*time.Hour)); len(got) != 0 {
+		t.Errorf("Clip() = %+v, want nothing between the periods", got)
+	}
+}
@@ -1,24 +1,33 @@
 package model
 
 import (
-	"parking_lot_service/internal/repo/models"
+	"encoding/json"
+	"parking_lot_service/internal/money"
 	"time"
 )
 
 // FreeSpotsResponse represents the response structure for free parking spots in parking lot.
 type FreeSpotsResponse struct {
-	ParkingLotID                    int `json:"parkingLotId"`
-	FreeSpotsForMotorcyclesScooters int `json:"freeSpotsForMotorcyclesScooters"`
-	FreeSpotsForCarsSUVs            int `json:"freeSpotsForCarsSUVs"`
-	FreeSpotsForBusesTrucks         int `json:"freeSpotsForBusesTrucks"`
+	ParkingLotID   int                     `json:"parkingLotId"`
+	ParkingLotName string                  `json:"parkingLotName"`
+	FreeSpots      []*VehicleTypeFreeSpots `json:"freeSpots"`
+}
+
+// VehicleTypeFreeSpots represents the free parking spots of a single vehicle type in a parking lot.
+type VehicleTypeFreeSpots struct {
+	VehicleID   int    `json:"vehicleId"`
+	Code        string `json:"code"`
+	DisplayName string `json:"displayName"`
+	FreeSpots   int    `json:"freeSpots"`
 }
 
 // ParkVehicleRequest represents the request structure for parking a vehicle.
 type ParkVehicleRequest struct {
-	ParkingLotID  models.ParkingLot  `json:"parking_lot_id" binding:"required"`
-	VehicleID     models.VehicleType `json:"vehicle_id" binding:"required"`
-	VehicleNumber string             `json:"vehicle_number" binding:"required"`
-	VehicleName   string             `json:"vehicle_name"`
+	ParkingLotID  int    `json:"parking_lot_id" binding:"required"`
+	VehicleID     int    `json:"vehicle_id" binding:"required"`
+	VehicleNumber string `json:"vehicle_number" binding:"required"`
+	VehicleName   string `json:"vehicle_name"`
+	ReservationID uint   `json:"reservation_id"` // Optional, checks the vehicle in to its reservation
 }
 
 // ParkVehicleResponse represents the response structure after successfully parking a vehicle.
@@ -28,39 +37,748 @@ type ParkVehicleResponse struct {
 
 // ParkingTicket represents the details of a parking ticket.
 type ParkingTicket struct {
-	VehicleNumber string    `json:"vehicle_number"`
-	ParkingLot    string    `json:"parking_lot"`
-	VehicleID     int       `json:"vehicle_id"`
-	EntryTime     time.Time `json:"entry_time"`
+	TicketNumber  string        `json:"ticket_number"`
+	VehicleNumber string        `json:"vehicle_number"`
+	ParkingLot    string        `json:"parking_lot"`
+	VehicleID     int           `json:"vehicle_id"`
+	EntryTime     time.Time     `json:"entry_time"`
+	Spot          *SpotLocation `json:"spot"`
 }
 
-// UnParkVehicleRequest represents the request structure for unparking a vehicle.
+// UnParkVehicleRequest represents the request structure for unparking a vehicle. The parking lot and
+// vehicle type are taken from the parking session the ticket was issued for.
 type UnParkVehicleRequest struct {
-	ParkingLotID  models.ParkingLot  `json:"parking_lot_id" binding:"required"`
-	VehicleNumber string             `json:"vehicle_number" binding:"required"`
-	VehicleID     models.VehicleType `json:"vehicle_id" binding:"required"`
+	TicketNumber string `json:"ticket_number" binding:"required"`
+	DiscountCode string `json:"discount_code"` // Optional discount code of the parking lot
+	QuoteID      uint   `json:"quote_id"`      // Optional quote of the ticket, its fare holds while the quote is valid
 }
 
-// UnParkVehicleResponse represents the response structure after successfully unparking a vehicle.
+// UnParkVehicleResponse represents the response structure after unparking a vehicle. A stay with a fare left to
+// pay is not let out: the receipt comes with the pending invoice of the fare, and the vehicle exits once the
+// invoice is paid or billed to an account and it is unparked again.
 type UnParkVehicleResponse struct {
-	Parking ParkingReceipt `json:"parking_receipt"`
+	Parking ParkingReceipt   `json:"parking_receipt"`
+	Exited  bool             `json:"exited"`
+	Invoice *InvoiceResponse `json:"invoice,omitempty"`
 }
 
-// ParkingReceipt represents the receipt details after unparking a vehicle.
+// ParkingReceipt represents the receipt details after unparking a vehicle. A stay of a pass holder is only
+// charged for the time outside the coverage of the pass. The gross fare is reduced by the discount lines,
+// the total fare is the net amount paid. The fare lines itemise the gross fare by tariff band, the penalty
+// lines the lost-ticket and overstay penalties added to it, which discounts do not take off. When the ticket
+// was paid at a pay station before leaving, the prepaid fare was paid then and the receipt only charges the
+// time past the exit grace period. Amounts are in the currency of the parking lot, the formatted amounts are
+// written for the locale of the lot.
 type ParkingReceipt struct {
-	VehicleNumber string  `json:"vehicle_number"`
-	TotalFare     float64 `json:"total_fare"`
-	From          string  `json:"from"`
-	To            string  `json:"to"`
-	VehicleID     int     `json:"vehicle_id"`
-	ParkingLotID  int     `json:"parking_lot_id"`
-}
-
-// Tariff represents the tariff details for different vehicle types in a parking lot.
-type Tariff struct {
-	HourlyRate            float64
-	DayRate               float64
-	FirstHourRate         float64
-	AdditionalHourRate    float64
-	MaxDurationForDayRate time.Duration
+	TicketNumber         string         `json:"ticket_number"`
+	VehicleNumber        string         `json:"vehicle_number"`
+	Currency             string         `json:"currency"`
+	Locale               string         `json:"locale"`
+	FareLines            []FareLine     `json:"fare_lines"`
+	Penalties            []PenaltyLine  `json:"penalties"`
+	GrossFare            money.Money    `json:"gross_fare"`
+	FormattedGrossFare   string         `json:"formatted_gross_fare"`
+	Discounts            []DiscountLine `json:"discounts"`
+	TotalFare            money.Money    `json:"total_fare"`
+	FormattedTotalFare   string         `json:"formatted_total_fare"`
+	PrepaidFare          *money.Money   `json:"prepaid_fare,omitempty"`
+	FormattedPrepaidFare string         `json:"formatted_prepaid_fare,omitempty"`
+	From                 string         `json:"from"`
+	To                   string         `json:"to"`
+	VehicleID            int            `json:"vehicle_id"`
+	ParkingLotID         int            `json:"parking_lot_id"`
+	ParkingLot           string         `json:"parking_lot"`
+	PassID               *uint          `json:"pass_id,omitempty"`
+	CoveredByPass        bool           `json:"covered_by_pass"` // True when the whole stay was covered by the pass
+}
+
+// PayTicketRequest represents the request structure for paying a ticket at a pay station before leaving. With
+// a gateway, the invoice of the stay is paid through it in full, otherwise it is left to be paid.
+type PayTicketRequest struct {
+	TicketNumber string `json:"ticket_number" binding:"required"`
+	DiscountCode string `json:"discount_code"` // Optional discount code of the parking lot
+	Gateway      string `json:"gateway"`       // Optional payment gateway, e.g. "cash"
+	Token        string `json:"token"`         // Identifies how the customer pays at the gateway, e.g. a card token
+	QuoteID      uint   `json:"quote_id"`      // Optional quote of the ticket, its fare holds while the quote is valid
+}
+
+// QuoteQuery represents the request structure for quoting the fare of an open parking session, found by its
+// ticket number or by the number of the parked vehicle.
+type QuoteQuery struct {
+	TicketNumber  string
+	VehicleNumber string
+	DiscountCode  string // Optional discount code of the parking lot
+}
+
+// QuoteResponse represents the fare a parked vehicle owes when leaving now. Paying or unparking with the ID
+// of the quote before ValidUntil charges the quoted fare, however much longer the vehicle stays in the meantime.
+type QuoteResponse struct {
+	QuoteID    uint           `json:"quote_id"`
+	Parking    ParkingReceipt `json:"parking_receipt"`
+	QuotedAt   time.Time      `json:"quoted_at"`
+	ValidUntil time.Time      `json:"valid_until"`
+}
+
+// PayTicketResponse represents the response structure after paying a ticket. Once the invoice is settled, the
+// vehicle can leave without paying more until ExitBy.
+type PayTicketResponse struct {
+	Parking ParkingReceipt   `json:"parking_receipt"`
+	Invoice *InvoiceResponse `json:"invoice,omitempty"`
+	ExitBy  string           `json:"exit_by,omitempty"`
+}
+
+// FareLine represents the part of a stay charged under one tariff band window, or at the standard rates.
+type FareLine struct {
+	Name            string      `json:"name"`
+	From            string      `json:"from"`
+	To              string      `json:"to"`
+	Amount          money.Money `json:"amount"`
+	FormattedAmount string      `json:"formatted_amount,omitempty"`
+}
+
+// PenaltyLine represents one penalty on a parking receipt.
+type PenaltyLine struct {
+	Name            string      `json:"name"`
+	Kind            string      `json:"kind"` // "lost-ticket", "lost-ticket-full-day" or "overstay"
+	Amount          money.Money `json:"amount"`
+	FormattedAmount string      `json:"formatted_amount,omitempty"`
+}
+
+// DiscountLine represents one discount on a parking receipt.
+type DiscountLine struct {
+	Name            string      `json:"name"`
+	Kind            string      `json:"kind"`
+	MerchantID      *uint       `json:"merchant_id,omitempty"` // Merchant that validated the ticket, empty for discount codes
+	Amount          money.Money `json:"amount"`
+	FormattedAmount string      `json:"formatted_amount,omitempty"`
+}
+
+// ParkingLotRequest represents the request structure for creating or updating a parking lot.
+type ParkingLotRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Address  string `json:"address"`
+	Timezone string `json:"timezone"` // IANA time zone name, defaults to UTC
+	Status   string `json:"status"`   // "active" or "closed", defaults to active
+	// Strategy that picks the spot of an arriving vehicle: "nearest-to-entrance", "level-by-level",
+	// "spread-for-wear" or "smallest-fitting", defaults to level-by-level
+	AllocationStrategy string `json:"allocation_strategy"`
+	// Minutes a reservation's spot is held after its start time before it is a no-show, defaults to 15
+	ReservationGraceMinutes *int `json:"reservation_grace_minutes"`
+	// Share of a fare that discounts may take off in total, between 1 and 100, defaults to 100
+	MaxDiscountPercent *int `json:"max_discount_percent"`
+	// ISO 4217 code of the currency fares are charged in, defaults to INR. It cannot change once the lot has
+	// tariffs, pass products, discount rules, validations or penalty fees.
+	Currency string `json:"currency"`
+	// BCP 47 tag of the locale amounts on receipts are formatted for, e.g. en-US, defaults to en-IN
+	Locale string `json:"locale"`
+	// Tenant the lot belongs to. It defaults to the tenant of the caller, or to the default tenant for platform
+	// admins, and cannot change once the lot exists.
+	TenantID uint `json:"tenant_id"`
+}
+
+// ParkingLotResponse represents a parking lot in the catalogue.
+type ParkingLotResponse struct {
+	ID                      int       `json:"id"`
+	TenantID                uint      `json:"tenant_id"`
+	Name                    string    `json:"name"`
+	Address                 string    `json:"address"`
+	Timezone                string    `json:"timezone"`
+	Status                  string    `json:"status"`
+	AllocationStrategy      string    `json:"allocation_strategy"`
+	ReservationGraceMinutes int       `json:"reservation_grace_minutes"`
+	MaxDiscountPercent      int       `json:"max_discount_percent"`
+	Currency                string    `json:"currency"`
+	Locale                  string    `json:"locale"`
+	CreatedAt               time.Time `json:"created_at"`
+	UpdatedAt               time.Time `json:"updated_at"`
+}
+
+// VehicleTypeRequest represents the request structure for registering or updating a vehicle type.
+type VehicleTypeRequest struct {
+	Code             string `json:"code" binding:"required"`
+	DisplayName      string `json:"display_name" binding:"required"`
+	SizeClass        int    `json:"size_class" binding:"required"` // 1 = small, 2 = medium, 3 = large, higher values are larger
+	AllowLargerSpots bool   `json:"allow_larger_spots"`
+}
+
+// VehicleTypeResponse represents a registered vehicle type.
+type VehicleTypeResponse struct {
+	ID               int    `json:"id"`
+	Code             string `json:"code"`
+	DisplayName      string `json:"display_name"`
+	SizeClass        int    `json:"size_class"`
+	AllowLargerSpots bool   `json:"allow_larger_spots"`
+}
+
+// TariffRatesRequest represents the rates of a tariff version. A tariff charges either an hourly rate,
+// optionally switching to a day rate after DayRateHours, or a first hour rate followed by an additional hour rate.
+type TariffRatesRequest struct {
+	HourlyRate         money.Money `json:"hourly_rate"`
+	DayRate            money.Money `json:"day_rate"`
+	DayRateHours       int         `json:"day_rate_hours"`
+	FirstHourRate      money.Money `json:"first_hour_rate"`
+	AdditionalHourRate money.Money `json:"additional_hour_rate"`
+	RoundingMinutes    int         `json:"rounding_minutes"`    // 1, 15 or 60 (default), partial units are charged in full
+	EntryGraceMinutes  int         `json:"entry_grace_minutes"` // Stays up to this long are free
+	ExitGraceMinutes   int         `json:"exit_grace_minutes"`  // Time to leave after paying at a pay station
+	DailyCap           money.Money `json:"daily_cap"`           // Most charged for every 24 hours from entry, omitted for no cap
+	// QuoteValidityMinutes is how long a quoted fare can be paid at, 10 by default and at most 60
+	QuoteValidityMinutes int `json:"quote_validity_minutes"`
+	// Bands charge time windows of the lot's local day at their own rate instead
+	Bands []TariffBandRequest `json:"bands"`
+}
+
+// TariffBandRequest represents a time band of a tariff version. A band charges either a flat rate once for every
+// window a stay falls into, or an hourly rate for every started hour within a window.
+type TariffBandRequest struct {
+	Name       string      `json:"name" binding:"required"`
+	Days       string      `json:"days" binding:"required"`  // "every-day", "weekdays", "weekends" or "holidays"
+	Start      string      `json:"start" binding:"required"` // Local time of day as HH:MM
+	End        string      `json:"end" binding:"required"`   // Before the start to run past midnight, equal to it for the whole day
+	FlatRate   money.Money `json:"flat_rate"`
+	HourlyRate money.Money `json:"hourly_rate"`
+	Priority   int         `json:"priority"` // The band with the highest priority applies where bands overlap
+}
+
+// TariffRequest represents the request structure for creating a new tariff version.
+type TariffRequest struct {
+	ParkingLotID  int        `json:"parking_lot_id" binding:"required"`
+	VehicleID     int        `json:"vehicle_id" binding:"required"`
+	EffectiveFrom *time.Time `json:"effective_from"` // Defaults to now, must be in the future when set
+	EffectiveTo   *time.Time `json:"effective_to"`   // Open ended when omitted
+	TariffRatesRequest
+}
+
+// TariffResponse represents a tariff version.
+type TariffResponse struct {
+	ID                   uint                 `json:"id"`
+	ParkingLotID         int                  `json:"parking_lot_id"`
+	VehicleID            int                  `json:"vehicle_id"`
+	HourlyRate           money.Money          `json:"hourly_rate"`
+	DayRate              money.Money          `json:"day_rate"`
+	DayRateHours         int                  `json:"day_rate_hours"`
+	FirstHourRate        money.Money          `json:"first_hour_rate"`
+	AdditionalHourRate   money.Money          `json:"additional_hour_rate"`
+	RoundingMinutes      int                  `json:"rounding_minutes"`
+	EntryGraceMinutes    int                  `json:"entry_grace_minutes"`
+	ExitGraceMinutes     int                  `json:"exit_grace_minutes"`
+	DailyCap             money.Money          `json:"daily_cap"`
+	QuoteValidityMinutes int                  `json:"quote_validity_minutes"`
+	EffectiveFrom        time.Time            `json:"effective_from"`
+	EffectiveTo          *time.Time           `json:"effective_to"`
+	Bands                []TariffBandResponse `json:"bands"`
+}
+
+// TariffBandResponse represents a time band of a tariff version.
+type TariffBandResponse struct {
+	ID         uint        `json:"id"`
+	Name       string      `json:"name"`
+	Days       string      `json:"days"`
+	Start      string      `json:"start"`
+	End        string      `json:"end"`
+	FlatRate   money.Money `json:"flat_rate"`
+	HourlyRate money.Money `json:"hourly_rate"`
+	Priority   int         `json:"priority"`
+}
+
+// HolidayRequest represents the request structure for adding a public holiday to the calendar of a parking lot.
+type HolidayRequest struct {
+	Date string `json:"date" binding:"required"` // Local date as YYYY-MM-DD
+	Name string `json:"name" binding:"required"`
+}
+
+// HolidayResponse represents a public holiday of a parking lot.
+type HolidayResponse struct {
+	ID           uint   `json:"id"`
+	ParkingLotID int    `json:"parking_lot_id"`
+	Date         string `json:"date"`
+	Name         string `json:"name"`
+}
+
+// CapacityRequest represents the request structure for resizing the pool of a vehicle type in a parking lot.
+type CapacityRequest struct {
+	VehicleID  int `json:"vehicle_id" binding:"required"`
+	TotalSpots int `json:"total_spots"`
+	// AllowOverCapacity permits shrinking below the number of parked vehicles, the pool then
+	// accepts no new vehicles until enough have left instead of the request being rejected.
+	AllowOverCapacity bool `json:"allow_over_capacity"`
+}
+
+// CapacityResponse represents the capacity and occupancy of a vehicle type pool in a parking lot.
+type CapacityResponse struct {
+	ParkingLotID   int  `json:"parking_lot_id"`
+	VehicleID      int  `json:"vehicle_id"`
+	TotalSpots     int  `json:"total_spots"`
+	AvailableSpots int  `json:"available_spots"`
+	OccupiedSpots  int  `json:"occupied_spots"`
+	OverCapacity   bool `json:"over_capacity"`
+}
+
+// SpotLocation tells a driver where the spot assigned to their vehicle is. The vehicle type and size class
+// are those of the spot, which are larger than the vehicle's own when it overflowed into another pool.
+type SpotLocation struct {
+	SpotID    uint   `json:"spot_id"`
+	Level     int    `json:"level"`
+	Zone      string `json:"zone"`
+	Label     string `json:"label"`
+	VehicleID int    `json:"vehicle_id"`
+	SizeClass int    `json:"size_class"`
+}
+
+// SpotRequest represents the request structure for creating or updating a spot. The vehicle type of
+// a spot is fixed when it is created and ignored on updates.
+type SpotRequest struct {
+	VehicleID        int    `json:"vehicle_id"`
+	Level            int    `json:"level"`
+	Zone             string `json:"zone"`
+	Label            string `json:"label" binding:"required"`
+	Covered          bool   `json:"covered"`
+	Handicap         bool   `json:"handicap"`
+	EVCharger        bool   `json:"ev_charger"`
+	EntranceDistance int    `json:"entrance_distance"` // Walking distance from the entrance in meters
+}
+
+// SpotResponse represents a single spot of a parking lot.
+type SpotResponse struct {
+	ID               uint   `json:"id"`
+	ParkingLotID     int    `json:"parking_lot_id"`
+	VehicleID        int    `json:"vehicle_id"`
+	Level            int    `json:"level"`
+	Zone             string `json:"zone"`
+	Label            string `json:"label"`
+	SizeClass        int    `json:"size_class"`
+	Covered          bool   `json:"covered"`
+	Handicap         bool   `json:"handicap"`
+	EVCharger        bool   `json:"ev_charger"`
+	EntranceDistance int    `json:"entrance_distance"`
+	UsageCount       int    `json:"usage_count"`
+	Occupied         bool   `json:"occupied"`
+}
+
+// UpsizeRuleRequest lists the vehicle types whose spots a vehicle type may overflow into, in order of preference.
+type UpsizeRuleRequest struct {
+	VehicleID          int   `json:"vehicle_id" binding:"required"`
+	OverflowVehicleIDs []int `json:"overflow_vehicle_ids"`
+}
+
+// UpsizePolicyRequest represents the request structure for replacing the upsize policy of a parking lot.
+type UpsizePolicyRequest struct {
+	Pricing string               `json:"pricing"` // "vehicle-type" or "spot-class", defaults to vehicle-type
+	Rules   []*UpsizeRuleRequest `json:"rules"`
+}
+
+// UpsizePolicyResponse represents the upsize policy of a parking lot.
+type UpsizePolicyResponse struct {
+	ParkingLotID int                  `json:"parking_lot_id"`
+	Pricing      string               `json:"pricing"`
+	Rules        []*UpsizeRuleRequest `json:"rules"`
+}
+
+// ParkingSessionQuery represents the filters and the cursor of a parking session listing.
+type ParkingSessionQuery struct {
+	VehicleNumber string
+	ParkingLotID  int
+	Status        string     // "open" or "closed"
+	From          *time.Time // Inclusive lower bound of the entry time
+	To            *time.Time // Exclusive upper bound of the entry time
+	Cursor        string     // Next cursor of the previous page, empty for the first page
+	Limit         int
+}
+
+// ParkingSessionResponse represents one stay of a vehicle in a parking lot.
+type ParkingSessionResponse struct {
+	ID            uint         `json:"id"`
+	TicketNumber  string       `json:"ticket_number"`
+	VehicleNumber string       `json:"vehicle_number"`
+	ParkingLotID  int          `json:"parking_lot_id"`
+	VehicleID     int          `json:"vehicle_id"`
+	VehicleName   string       `json:"vehicle_name"`
+	SpotID        *uint        `json:"spot_id"`
+	SpotVehicleID int          `json:"spot_vehicle_id"`
+	EntryTime     time.Time    `json:"entry_time"`
+	PaidAt        *time.Time   `json:"paid_at"` // End of the paid part of the stay, set when an invoice was settled
+	ExitTime      *time.Time   `json:"exit_time"`
+	GrossFare     *money.Money `json:"gross_fare"` // Fare before discounts
+	Fare          *money.Money `json:"fare"`
+	Status        string       `json:"status"`
+}
+
+// ParkingSessionPage represents one page of a parking session listing, newest sessions first.
+type ParkingSessionPage struct {
+	Sessions   []*ParkingSessionResponse `json:"sessions"`
+	NextCursor string                    `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// ReservationRequest represents the request structure for booking a reservation.
+type ReservationRequest struct {
+	ParkingLotID  int       `json:"parking_lot_id" binding:"required"`
+	VehicleID     int       `json:"vehicle_id" binding:"required"`
+	VehicleNumber string    `json:"vehicle_number" binding:"required"`
+	StartTime     time.Time `json:"start_time" binding:"required"` // Start of the arrival window
+	EndTime       time.Time `json:"end_time" binding:"required"`   // End of the arrival window
+}
+
+// ReservationUpdateRequest represents the request structure for modifying a booked reservation.
+type ReservationUpdateRequest struct {
+	VehicleNumber string    `json:"vehicle_number" binding:"required"`
+	StartTime     time.Time `json:"start_time" binding:"required"`
+	EndTime       time.Time `json:"end_time" binding:"required"`
+}
+
+// ReservationResponse represents a reservation.
+type ReservationResponse struct {
+	ID            uint      `json:"id"`
+	ParkingLotID  int       `json:"parking_lot_id"`
+	VehicleID     int       `json:"vehicle_id"`
+	VehicleNumber string    `json:"vehicle_number"`
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+	HoldUntil     time.Time `json:"hold_until"` // Check-in deadline, the spot is released afterwards
+	Status        string    `json:"status"`     // "booked", "checked-in", "cancelled" or "no-show"
+	SessionID     *uint     `json:"session_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// PassProductRequest represents the request structure for adding a pass product to a parking lot.
+type PassProductRequest struct {
+	Name          string      `json:"name" binding:"required"`
+	VehicleID     int         `json:"vehicle_id" binding:"required"`
+	Period        string      `json:"period" binding:"required"`   // "weekly" or "monthly"
+	Coverage      string      `json:"coverage" binding:"required"` // "all-day", "weekdays" or "nights"
+	Price         money.Money `json:"price"`
+	ReservedSpots int         `json:"reserved_spots"` // Spots held back from walk-in vehicles for the pass holders
+}
+
+// PassProductResponse represents a pass product of a parking lot.
+type PassProductResponse struct {
+	ID            uint        `json:"id"`
+	ParkingLotID  int         `json:"parking_lot_id"`
+	VehicleID     int         `json:"vehicle_id"`
+	Name          string      `json:"name"`
+	Period        string      `json:"period"`
+	Coverage      string      `json:"coverage"`
+	Price         money.Money `json:"price"`
+	ReservedSpots int         `json:"reserved_spots"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// PassRequest represents the request structure for selling a pass.
+type PassRequest struct {
+	PassProductID  uint       `json:"pass_product_id" binding:"required"`
+	HolderName     string     `json:"holder_name" binding:"required"`
+	VehicleNumbers []string   `json:"vehicle_numbers" binding:"required"`
+	ValidFrom      *time.Time `json:"valid_from"` // Defaults to now
+}
+
+// PassVehiclesRequest represents the request structure for replacing the vehicles registered on a pass.
+type PassVehiclesRequest struct {
+	VehicleNumbers []string `json:"vehicle_numbers" binding:"required"`
+}
+
+// PassResponse represents a pass sold to a subscriber.
+type PassResponse struct {
+	ID             uint        `json:"id"`
+	PassProductID  uint        `json:"pass_product_id"`
+	ParkingLotID   int         `json:"parking_lot_id"`
+	VehicleID      int         `json:"vehicle_id"`
+	HolderName     string      `json:"holder_name"`
+	Price          money.Money `json:"price"`
+	ValidFrom      time.Time   `json:"valid_from"`
+	ValidTo        time.Time   `json:"valid_to"`
+	Status         string      `json:"status"` // "active" or "cancelled"
+	VehicleNumbers []string    `json:"vehicle_numbers"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+// MerchantRequest represents the request structure for registering a merchant with a parking lot.
+type MerchantRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// MerchantResponse represents a merchant of a parking lot.
+type MerchantResponse struct {
+	ID           uint      `json:"id"`
+	ParkingLotID int       `json:"parking_lot_id"`
+	Name         string    `json:"name"`
+	Active       bool      `json:"active"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// DiscountRuleRequest represents the request structure for adding a discount rule to a parking lot.
+// A rule is either issued by a merchant or applied with a discount code, exactly one of the two must be set.
+type DiscountRuleRequest struct {
+	Name       string      `json:"name" binding:"required"`
+	Kind       string      `json:"kind" binding:"required"` // "percentage", "fixed-amount" or "free-hours"
+	Value      float64     `json:"value"`                   // Percentage or free hours
+	Amount     money.Money `json:"amount"`                  // Amount taken off by a fixed-amount rule
+	MaxAmount  money.Money `json:"max_amount"`              // Cap of the reduction, omitted for no cap
+	Stackable  bool        `json:"stackable"`               // Whether the rule combines with other stackable discounts
+	MerchantID *uint       `json:"merchant_id"`
+	Code       string      `json:"code"`
+}
+
+// DiscountRuleResponse represents a discount rule of a parking lot.
+type DiscountRuleResponse struct {
+	ID           uint         `json:"id"`
+	ParkingLotID int          `json:"parking_lot_id"`
+	Name         string       `json:"name"`
+	Kind         string       `json:"kind"`
+	Value        float64      `json:"value"`
+	Amount       *money.Money `json:"amount,omitempty"`
+	MaxAmount    *money.Money `json:"max_amount,omitempty"`
+	Stackable    bool         `json:"stackable"`
+	MerchantID   *uint        `json:"merchant_id,omitempty"`
+	Code         *string      `json:"code,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// ValidationRequest represents the request structure for a merchant validating an open ticket.
+type ValidationRequest struct {
+	TicketNumber   string `json:"ticket_number" binding:"required"`
+	DiscountRuleID uint   `json:"discount_rule_id" binding:"required"`
+}
+
+// ValidationResponse represents a discount applied to a parking session.
+type ValidationResponse struct {
+	ID             uint         `json:"id"`
+	TicketNumber   string       `json:"ticket_number"`
+	DiscountRuleID uint         `json:"discount_rule_id"`
+	MerchantID     *uint        `json:"merchant_id,omitempty"`
+	Name           string       `json:"name"`
+	Kind           string       `json:"kind"`
+	Value          float64      `json:"value"`
+	Amount         *money.Money `json:"amount,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+// ExchangeRateRequest represents the request structure for setting the exchange rate of a currency pair.
+type ExchangeRateRequest struct {
+	BaseCurrency  string `json:"base_currency" binding:"required"`
+	QuoteCurrency string `json:"quote_currency" binding:"required"`
+	Rate          string `json:"rate" binding:"required"` // Decimal price of one base unit in the quote currency, e.g. "0.012"
+}
+
+// ExchangeRateResponse represents the exchange rate of a currency pair.
+type ExchangeRateResponse struct {
+	ID            uint      `json:"id"`
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Rate          string    `json:"rate"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// RevenueReportQuery represents the filters of a revenue report.
+type RevenueReportQuery struct {
+	ParkingLotID int
+	From         *time.Time // Inclusive lower bound of the exit time
+	To           *time.Time // Exclusive upper bound of the exit time
+	Currency     string     // Currency to convert the totals into, empty to keep them per currency
+}
+
+// RevenueReportResponse represents the fares of the closed parking sessions added up per currency, and
+// converted into a single currency when one was asked for.
+type RevenueReportResponse struct {
+	Totals    []RevenueTotal    `json:"totals"`
+	Converted *ConvertedRevenue `json:"converted,omitempty"`
+}
+
+// RevenueTotal represents the fares of the parking sessions closed in one currency.
+type RevenueTotal struct {
+	Currency  string      `json:"currency"`
+	Sessions  int64       `json:"sessions"`
+	GrossFare money.Money `json:"gross_fare"`
+	Fare      money.Money `json:"fare"`
+}
+
+// ConvertedRevenue represents the totals of a revenue report converted into one currency with the exchange
+// rates that were applied. Each total is converted on its own and rounded half up to a minor unit.
+type ConvertedRevenue struct {
+	Currency  string        `json:"currency"`
+	Sessions  int64         `json:"sessions"`
+	GrossFare money.Money   `json:"gross_fare"`
+	Fare      money.Money   `json:"fare"`
+	Rates     []AppliedRate `json:"rates"`
+}
+
+// AppliedRate represents the exchange rate a total was converted at.
+type AppliedRate struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Rate string `json:"rate"`
+}
+
+// InvoiceResponse represents the fare a parking session owes for its stay up to PricedAt, with the payments and
+// refunds made against it. The balance is what is left to pay.
+type InvoiceResponse struct {
+	ID               uint              `json:"id"`
+	TicketNumber     string            `json:"ticket_number"`
+	ParkingLotID     int               `json:"parking_lot_id"`
+	Status           string            `json:"status"`
+	GrossFare        money.Money       `json:"gross_fare"`
+	Amount           money.Money       `json:"amount"`
+	PaidAmount       money.Money       `json:"paid_amount"`
+	RefundedAmount   money.Money       `json:"refunded_amount"`
+	Balance          money.Money       `json:"balance"`
+	AccountReference *string           `json:"account_reference,omitempty"`
+	PricedAt         time.Time         `json:"priced_at"`
+	SettledAt        *time.Time        `json:"settled_at,omitempty"`
+	Payments         []PaymentResponse `json:"payments"`
+	CreatedAt        time.Time         `json:"created_at"`
+}
+
+// PaymentRequest represents the request structure for paying an invoice through a payment gateway. Without an
+// amount the balance of the invoice is paid, a smaller amount pays the invoice in part.
+type PaymentRequest struct {
+	Gateway string      `json:"gateway" binding:"required"` // e.g. "cash"
+	Amount  money.Money `json:"amount"`
+	Token   string      `json:"token"` // Identifies how the customer pays at the gateway, e.g. a card token
+}
+
+// AccountBillingRequest represents the request structure for billing what is left to pay of an invoice to the
+// account of the customer.
+type AccountBillingRequest struct {
+	AccountReference string `json:"account_reference" binding:"required"`
+}
+
+// RefundRequest represents the request structure for paying back a payment. Without an amount, all that has
+// not been paid back yet is refunded.
+type RefundRequest struct {
+	Amount money.Money `json:"amount"`
+	Reason string      `json:"reason"`
+}
+
+// PaymentResponse represents a payment of an invoice or a refund of such a payment.
+type PaymentResponse struct {
+	ID             uint         `json:"id"`
+	InvoiceID      uint         `json:"invoice_id"`
+	RefundOf       *uint        `json:"refund_of,omitempty"`     // Payment a refund pays back
+	AdjustmentID   *uint        `json:"adjustment_id,omitempty"` // Fare adjustment a refund was made for
+	Kind           string       `json:"kind"`
+	Gateway        string       `json:"gateway"`
+	Amount         money.Money  `json:"amount"`
+	RefundedAmount *money.Money `json:"refunded_amount,omitempty"`
+	Status         string       `json:"status"`
+	Reference      string       `json:"reference,omitempty"`
+	Message        string       `json:"message,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+// FareAdjustmentRequest represents the request structure for correcting or waiving the fare of a closed parking
+// session. What the new fare takes off the fare paid through payment gateways is refunded.
+type FareAdjustmentRequest struct {
+	Fare       money.Money `json:"fare"`                           // New fare of the session, omitted to waive it
+	Waive      bool        `json:"waive"`                          // Let the fare off in full
+	ReasonCode string      `json:"reason_code" binding:"required"` // e.g. "wrong-vehicle-type", see the reason codes
+	Note       string      `json:"note"`                           // Required for the reason code "other"
+	Operator   string      `json:"operator" binding:"required"`    // Who changes the fare
+}
+
+// FareAdjustmentResponse represents an entry of the ledger of fare adjustments of a parking session, with the
+// refunds made for it.
+type FareAdjustmentResponse struct {
+	ID           uint               `json:"id"`
+	SessionID    uint               `json:"session_id"`
+	ParkingLotID int                `json:"parking_lot_id"`
+	Kind         string             `json:"kind"`
+	ReasonCode   string             `json:"reason_code"`
+	Note         string             `json:"note,omitempty"`
+	Operator     string             `json:"operator"`
+	OriginalFare money.Money        `json:"original_fare"`
+	AdjustedFare money.Money        `json:"adjusted_fare"`
+	Refunds      []*PaymentResponse `json:"refunds"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
+// PenaltyPolicyRequest represents the request structure for replacing the penalties of a parking lot. Amounts
+// are in the currency of the lot, zero amounts charge nothing.
+type PenaltyPolicyRequest struct {
+	LostTicketFee     money.Money `json:"lost_ticket_fee"`      // Flat fee for leaving without the ticket
+	LostTicketFullDay bool        `json:"lost_ticket_full_day"` // A lost ticket pays at least the fare of a full day
+	MaxStayDays       int         `json:"max_stay_days"`        // Longest stay without a surcharge, 0 for no limit
+	OverstaySurcharge money.Money `json:"overstay_surcharge"`   // Charged for every started day past the longest stay
+}
+
+// PenaltyPolicyResponse represents the penalties of a parking lot.
+type PenaltyPolicyResponse struct {
+	ParkingLotID      int         `json:"parking_lot_id"`
+	LostTicketFee     money.Money `json:"lost_ticket_fee"`
+	LostTicketFullDay bool        `json:"lost_ticket_full_day"`
+	MaxStayDays       int         `json:"max_stay_days"`
+	OverstaySurcharge money.Money `json:"overstay_surcharge"`
+}
+
+// LostTicketExitRequest represents the request structure for letting a vehicle out whose driver lost the
+// ticket. The operator checks the identity of the driver and the registration papers of the vehicle first.
+type LostTicketExitRequest struct {
+	VehicleNumber        string `json:"vehicle_number" binding:"required"`
+	DriverName           string `json:"driver_name" binding:"required"`
+	DocumentType         string `json:"document_type" binding:"required"`   // "driving-licence", "passport" or "national-id"
+	DocumentNumber       string `json:"document_number" binding:"required"` // Only the last four characters are kept
+	RegistrationVerified bool   `json:"registration_verified"`              // The registration papers match the vehicle
+	Operator             string `json:"operator" binding:"required"`        // Who checked the identity
+}
+
+// TenantRequest represents the request structure for creating or renaming a tenant.
+type TenantRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// TenantResponse represents a property owner whose lots are run by the service.
+type TenantResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AuditEntryQuery represents the filters and the cursor of an audit log listing.
+type AuditEntryQuery struct {
+	Entity    string // Table of the changed rows, e.g. parking_sessions
+	EntityID  string
+	Actor     string
+	RequestID string
+	From      *time.Time // Inclusive lower bound of the time of the change
+	To        *time.Time // Exclusive upper bound of the time of the change
+	Cursor    string     // Next cursor of the previous page, empty for the first page
+	Limit     int
+}
+
+// AuditEntryResponse represents the change of one row recorded in the audit log.
+type AuditEntryResponse struct {
+	ID        uint            `json:"id"`
+	TenantID  uint            `json:"tenant_id"` // Zero for data shared by all tenants
+	Actor     string          `json:"actor"`
+	Role      string          `json:"role"`
+	Operation string          `json:"operation"` // Route or job that made the change
+	RequestID string          `json:"request_id"`
+	Action    string          `json:"action"` // create, upsert, update or delete
+	Entity    string          `json:"entity"`
+	EntityID  string          `json:"entity_id"`
+	Before    json.RawMessage `json:"before" swaggertype:"object"` // Null for created rows
+	After     json.RawMessage `json:"after" swaggertype:"object"`  // Null for deleted rows
+	CreatedAt time.Time       `json:"created_at"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+}
+
+// AuditEntryPage represents one page of an audit log listing, newest entries first.
+type AuditEntryPage struct {
+	Entries    []*AuditEntryResponse `json:"entries"`
+	NextCursor string                `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// AuditVerificationRequest represents the heads of the audit log chains recorded by an earlier verification.
+type AuditVerificationRequest struct {
+	Heads map[uint]string `json:"heads"` // Each must still be in the chain of its tenant, by tenant ID
+}
+
+// AuditVerificationResponse represents the outcome of walking the hash chains of the audit log.
+type AuditVerificationResponse struct {
+	Intact  bool `json:"intact"`
+	Checked int  `json:"checked"` // Entries found intact
+	// Hash of the last intact entry of the chain of every tenant by tenant ID, 0 for data shared by all tenants
+	Heads     map[uint]string `json:"heads"`
+	BrokenAt  *uint           `json:"broken_at,omitempty"` // First entry the chain breaks at
+	Truncated []uint          `json:"truncated,omitempty"` // Tenants whose chain ends before its recorded head
+	Reason    string          `json:"reason,omitempty"`
 }
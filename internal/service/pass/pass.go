@@ -0,0 +1,130 @@
+package pass
+
+import (
+	"parking_lot_service/internal/repo/models"
+	"time"
+)
+
+// Hours of the lot's local day between which a night pass covers parking.
+const (
+	NightStartHour = 20
+	NightEndHour   = 8
+)
+
+// ValidUntil returns the end of the validity of a pass of the given period issued at from.
+// It returns the zero time for an unknown period.
+func ValidUntil(period models.PassPeriod, from time.Time) time.Time {
+	switch period {
+	case models.PassPeriodWeekly:
+		return from.AddDate(0, 0, 7)
+	case models.PassPeriodMonthly:
+		return from.AddDate(0, 1, 0)
+	}
+	return time.Time{}
+}
+
+// Covered returns how much of the stay [from, to) falls within the hours the coverage applies to.
+// Days, weekdays and night hours are those of the given location. An unknown coverage covers nothing.
+func Covered(coverage models.PassCoverage, from, to time.Time, loc *time.Location) time.Duration {
+	if !from.Before(to) {
+		return 0
+	}
+	if coverage == models.PassCoverageAllDay {
+		return to.Sub(from)
+	}
+
+	var (
+		covered time.Duration
+		local   = from.In(loc)
+		day     = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	)
+	for day.Before(to) {
+		nextDay := time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc)
+		for _, window := range coveredWindows(coverage, day, nextDay) {
+			covered += overlap(window[0], window[1], from, to)
+		}
+		day = nextDay
+	}
+	return covered
+}
+
+// Uncovered returns the parts of the stay [from, to) that fall outside the hours the coverage applies to,
+// in chronological order. Days, weekdays and night hours are those of the given location.
+func Uncovered(coverage models.PassCoverage, from, to time.Time, loc *time.Location) [][2]time.Time {
+	if !from.Before(to) {
+		return nil
+	}
+	if coverage == models.PassCoverageAllDay {
+		return nil
+	}
+
+	var (
+		uncovered [][2]time.Time
+		start     = from
+		local     = from.In(loc)
+		day       = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	)
+	for day.Before(to) {
+		nextDay := time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc)
+		for _, window := range coveredWindows(coverage, day, nextDay) {
+			if overlap(window[0], window[1], from, to) == 0 {
+				continue
+			}
+			if window[0].After(start) {
+				uncovered = appendPart(uncovered, start, window[0])
+			}
+			if window[1].After(start) {
+				start = window[1]
+			}
+		}
+		day = nextDay
+	}
+	if start.Before(to) {
+		uncovered = appendPart(uncovered, start, to)
+	}
+	return uncovered
+}
+
+// appendPart appends [start, end) to parts, joining it to the last part when they touch.
+func appendPart(parts [][2]time.Time, start, end time.Time) [][2]time.Time {
+	if last := len(parts) - 1; last >= 0 && parts[last][1].Equal(start) {
+		parts[last][1] = end
+		return parts
+	}
+	return append(parts, [2]time.Time{start, end})
+}
+
+// coveredWindows returns the covered parts of the local day starting at day and ending at nextDay.
+func coveredWindows(coverage models.PassCoverage, day, nextDay time.Time) [][2]time.Time {
+	switch coverage {
+	case models.PassCoverageWeekdays:
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			return nil
+		}
+		return [][2]time.Time{{day, nextDay}}
+	case models.PassCoverageNights:
+		return [][2]time.Time{
+			{day, atHour(day, NightEndHour)},
+			{atHour(day, NightStartHour), nextDay},
+		}
+	}
+	return nil
+}
+
+func atHour(day time.Time, hour int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, day.Location())
+}
+
+// overlap returns the length of the intersection of [start, end) and [from, to).
+func overlap(start, end, from, to time.Time) time.Duration {
+	if from.After(start) {
+		start = from
+	}
+	if to.Before(end) {
+		end = to
+	}
+	if !start.Before(end) {
+		return 0
+	}
+	return end.Sub(start)
+}
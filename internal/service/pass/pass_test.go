@@ -0,0 +1,163 @@
+package pass
+
+import (
+	"parking_lot_service/internal/repo/models"
+	"testing"
+	"time"
+)
+
+func TestCovered(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	// Friday, 5 January 2024 in Kolkata
+	friday := func(hour, minute int) time.Time {
+		return time.Date(2024, time.January, 5, hour, minute, 0, 0, kolkata)
+	}
+
+	tests := []struct {
+		name     string
+		coverage models.PassCoverage
+		from     time.Time
+		to       time.Time
+		want     time.Duration
+	}{
+		{
+			name:     "All day covers the whole stay",
+			coverage: models.PassCoverageAllDay,
+			from:     friday(9, 0),
+			to:       friday(9, 0).Add(50 * time.Hour),
+			want:     50 * time.Hour,
+		},
+		{
+			name:     "Weekdays stops covering at midnight on Friday",
+			coverage: models.PassCoverageWeekdays,
+			from:     friday(22, 0),
+			to:       friday(22, 0).Add(4 * time.Hour),
+			want:     2 * time.Hour,
+		},
+		{
+			name:     "Weekdays covers nothing over the weekend",
+			coverage: models.PassCoverageWeekdays,
+			from:     friday(0, 0).AddDate(0, 0, 1),
+			to:       friday(0, 0).AddDate(0, 0, 3),
+			want:     0,
+		},
+		{
+			name:     "Nights covers the evening part of a stay",
+			coverage: models.PassCoverageNights,
+			from:     friday(18, 0),
+			to:       friday(22, 30),
+			want:     150 * time.Minute,
+		},
+		{
+			name:     "Nights covers the early morning part of a stay",
+			coverage: models.PassCoverageNights,
+			from:     friday(7, 0),
+			to:       friday(9, 0),
+			want:     time.Hour,
+		},
+		{
+			name:     "Nights covers a whole night across midnight",
+			coverage: models.PassCoverageNights,
+			from:     friday(19, 0),
+			to:       friday(9, 0).AddDate(0, 0, 1),
+			want:     12 * time.Hour,
+		},
+		{
+			name:     "Nights uses the hours of the lot's time zone",
+			coverage: models.PassCoverageNights,
+			from:     time.Date(2024, time.January, 5, 14, 30, 0, 0, time.UTC), // 20:00 in Kolkata
+			to:       time.Date(2024, time.January, 5, 15, 30, 0, 0, time.UTC),
+			want:     time.Hour,
+		},
+		{
+			name:     "Unknown coverage covers nothing",
+			coverage: "holidays",
+			from:     friday(9, 0),
+			to:       friday(10, 0),
+			want:     0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Covered(tt.coverage, tt.from, tt.to, kolkata); got != tt.want {
+				t.Errorf("Covered() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidUntil(t *testing.T) {
+	from := time.Date(2024, time.January, 31, 10, 0, 0, 0, time.UTC)
+
+	if got, want := ValidUntil(models.PassPeriodWeekly, from), from.AddDate(0, 0, 7); !got.Equal(want) {
+		t.Errorf("ValidUntil(weekly) = %v, want %v", got, want)
+	}
+	if got, want := ValidUntil(models.PassPeriodMonthly, from), from.AddDate(0, 1, 0); !got.Equal(want) {
+		t.Errorf("ValidUntil(monthly) = %v, want %v", got, want)
+	}
+	if got := ValidUntil("yearly", from); !got.IsZero() {
+		t.Errorf("ValidUntil(yearly) = %v, want the zero time", got)
+	}
+}
+
+func TestUncovered(t *testing.T) {
+	// Friday, 5 January 2024 in UTC
+	friday := func(hour int) time.Time {
+		return time.Date(2024, time.January, 5, hour, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		coverage models.PassCoverage
+		from     time.Time
+		to       time.Time
+		want     [][2]time.Time
+	}{
+		{
+			name:     "All day leaves nothing",
+			coverage: models.PassCoverageAllDay,
+			from:     friday(9),
+			to:       friday(18),
+			want:     nil,
+		},
+		{
+			name:     "Nights leaves the day between two nights",
+			coverage: models.PassCoverageNights,
+			from:     friday(6),
+			to:       friday(22),
+			want:     [][2]time.Time{{friday(8), friday(20)}},
+		},
+		{
+			name:     "Weekdays leaves the weekend",
+			coverage: models.PassCoverageWeekdays,
+			from:     friday(12),
+			to:       friday(12).AddDate(0, 0, 3),
+			want:     [][2]time.Time{{friday(24), friday(24).AddDate(0, 0, 2)}},
+		},
+		{
+			name:     "Unknown coverage leaves the whole stay",
+			coverage: "holidays",
+			from:     friday(9),
+			to:       friday(10),
+			want:     [][2]time.Time{{friday(9), friday(10)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Uncovered(tt.coverage, tt.from, tt.to, time.UTC)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Uncovered() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i][0].Equal(tt.want[i][0]) || !got[i][1].Equal(tt.want[i][1]) {
+					t.Errorf("Uncovered()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package payment
+
+import "context"
+
+// cash records payments handed over at the booth of the parking lot. The attendant takes the money, so every
+// charge and refund is approved.
+type cash struct{}
+
+// NewCash returns the gateway for payments in cash at the booth.
+func NewCash() PaymentGateway {
+	return cash{}
+}
+
+func (cash) Name() string {
+	return Cash
+}
+
+func (cash) Charge(_ context.Context, charge *Charge) (*Result, error) {
+	return &Result{Approved: true, Reference: "cash-" + charge.Reference}, nil
+}
+
+func (cash) Refund(_ context.Context, refund *Refund) (*Result, error) {
+	return &Result{Approved: true, Reference: "cash-" + refund.Reference}, nil
+}
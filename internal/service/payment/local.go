@@ -0,0 +1,47 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// Tokens the local gateway answers differently to, every other token is approved.
+const (
+	DeclineToken     = "decline"     // The charge is turned down
+	UnavailableToken = "unavailable" // The gateway cannot be reached
+)
+
+// local stands in for a card gateway in tests and local development. It approves every charge and refund
+// without moving money, except for the tokens that simulate a declined card or an outage.
+type local struct {
+	sequence atomic.Int64
+}
+
+// NewLocal returns a gateway that approves payments without moving money.
+func NewLocal() PaymentGateway {
+	return &local{}
+}
+
+func (*local) Name() string {
+	return Local
+}
+
+func (l *local) Charge(_ context.Context, charge *Charge) (*Result, error) {
+	switch charge.Token {
+	case DeclineToken:
+		return &Result{Message: "card declined"}, nil
+	case UnavailableToken:
+		return nil, fmt.Errorf("local gateway unavailable")
+	}
+	return &Result{Approved: true, Reference: l.reference()}, nil
+}
+
+func (l *local) Refund(_ context.Context, _ *Refund) (*Result, error) {
+	return &Result{Approved: true, Reference: l.reference()}, nil
+}
+
+// reference numbers the charges and refunds of the gateway.
+func (l *local) reference() string {
+	return fmt.Sprintf("local-%d", l.sequence.Add(1))
+}
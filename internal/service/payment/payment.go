@@ -0,0 +1,79 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"parking_lot_service/internal/money"
+	"sort"
+)
+
+// Names of the built-in payment gateways, as given when paying an invoice.
+const (
+	Cash  = "cash"
+	Local = "local"
+)
+
+// PaymentGateway collects the payments of invoices and pays refunds back. A gateway that cannot be reached
+// returns an error, a payment it turns down is returned as a result that is not approved.
+type PaymentGateway interface {
+	// Name returns the name payments through the gateway are recorded with.
+	Name() string
+	// Charge collects an amount, the token identifies how the customer pays, e.g. a card token.
+	Charge(ctx context.Context, charge *Charge) (*Result, error)
+	// Refund pays back part or all of a payment collected by the gateway.
+	Refund(ctx context.Context, refund *Refund) (*Result, error)
+}
+
+// Charge asks a gateway to collect an amount. The reference is unique per payment, so a gateway can tell a
+// retried charge from a new one.
+type Charge struct {
+	Reference string
+	Amount    money.Money
+	Token     string
+}
+
+// Refund asks a gateway to pay back an amount of a payment, identified by the reference the gateway returned
+// when it was charged.
+type Refund struct {
+	Reference        string
+	PaymentReference string
+	Amount           money.Money
+}
+
+// Result is the answer of a gateway to a charge or refund, with the reference the gateway keeps it under.
+type Result struct {
+	Approved  bool
+	Reference string
+	Message   string // Why the gateway turned the charge or refund down
+}
+
+// Gateways holds the gateways invoices can be paid through by name.
+type Gateways map[string]PaymentGateway
+
+// NewGateways registers gateways under their names, a later gateway replaces an earlier one of the same name.
+func NewGateways(gateways ...PaymentGateway) Gateways {
+	registry := make(Gateways, len(gateways))
+	for _, gateway := range gateways {
+		registry[gateway.Name()] = gateway
+	}
+	return registry
+}
+
+// Get returns the gateway with the given name.
+func (g Gateways) Get(name string) (PaymentGateway, error) {
+	gateway, ok := g[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown payment gateway %q", name)
+	}
+	return gateway, nil
+}
+
+// Names returns the names of the registered gateways in alphabetical order.
+func (g Gateways) Names() []string {
+	names := make([]string, 0, len(g))
+	for name := range g {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,71 @@
+package payment
+
+import (
+	"context"
+	"parking_lot_service/internal/money"
+	"reflect"
+	"testing"
+)
+
+func TestGateways_Get(t *testing.T) {
+	gateways := NewGateways(NewCash(), NewLocal())
+
+	if got := gateways.Names(); !reflect.DeepEqual(got, []string{Cash, Local}) {
+		t.Errorf("Names() = %v, want %v", got, []string{Cash, Local})
+	}
+	gateway, err := gateways.Get(Cash)
+	if err != nil || gateway.Name() != Cash {
+		t.Errorf("Get(%q) = %v, %v", Cash, gateway, err)
+	}
+	if _, err := gateways.Get("card"); err == nil {
+		t.Errorf("Get(%q) returned no error", "card")
+	}
+}
+
+func TestGateways_Charge(t *testing.T) {
+	amount := money.New(2500, "INR")
+	tests := []struct {
+		name         string
+		gateway      PaymentGateway
+		token        string
+		wantApproved bool
+		wantErr      bool
+	}{
+		{name: "cash is always approved", gateway: NewCash(), token: DeclineToken, wantApproved: true},
+		{name: "local approves a token", gateway: NewLocal(), token: "tok_visa", wantApproved: true},
+		{name: "local declines", gateway: NewLocal(), token: DeclineToken},
+		{name: "local is unavailable", gateway: NewLocal(), token: UnavailableToken, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.gateway.Charge(context.Background(),
+				&Charge{Reference: "payment-1", Amount: amount, Token: tt.token})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Charge() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if result.Approved != tt.wantApproved {
+				t.Errorf("Charge() approved = %v, want %v", result.Approved, tt.wantApproved)
+			}
+			if result.Approved && result.Reference == "" {
+				t.Errorf("Charge() approved without a reference")
+			}
+			if !result.Approved && result.Message == "" {
+				t.Errorf("Charge() declined without a message")
+			}
+		})
+	}
+}
+
+func TestLocal_References(t *testing.T) {
+	gateway := NewLocal()
+	charged, _ := gateway.Charge(context.Background(), &Charge{Reference: "payment-1"})
+	refunded, _ := gateway.Refund(context.Background(), &Refund{Reference: "payment-2",
+		PaymentReference: charged.Reference})
+	if charged.Reference == refunded.Reference {
+		t.Errorf("charge and refund share the reference %q", charged.Reference)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"parking_lot_service/internal/repo"
 	"parking_lot_service/internal/service/model"
+	"parking_lot_service/internal/service/payment"
 )
 
 type ParkingLotService interface {
@@ -11,12 +12,82 @@ type ParkingLotService interface {
 	GetFreeParkingSpaceById(ctx context.Context, parkingLotId int) (*model.FreeSpotsResponse, error)
 	ParkVehicle(ctx context.Context, req *model.ParkVehicleRequest) (*model.ParkVehicleResponse, error)
 	UnParkVehicle(ctx context.Context, req *model.UnParkVehicleRequest) (*model.UnParkVehicleResponse, error)
+	GetParkingSessions(ctx context.Context, req *model.ParkingSessionQuery) (*model.ParkingSessionPage, error)
+	GetParkingLots(ctx context.Context) ([]*model.ParkingLotResponse, error)
+	GetParkingLotById(ctx context.Context, parkingLotId int) (*model.ParkingLotResponse, error)
+	CreateParkingLot(ctx context.Context, req *model.ParkingLotRequest) (*model.ParkingLotResponse, error)
+	UpdateParkingLot(ctx context.Context, parkingLotId int, req *model.ParkingLotRequest) (*model.ParkingLotResponse, error)
+	DeleteParkingLot(ctx context.Context, parkingLotId int) error
+	GetVehicleTypes(ctx context.Context) ([]*model.VehicleTypeResponse, error)
+	GetVehicleTypeById(ctx context.Context, vehicleTypeId int) (*model.VehicleTypeResponse, error)
+	CreateVehicleType(ctx context.Context, req *model.VehicleTypeRequest) (*model.VehicleTypeResponse, error)
+	UpdateVehicleType(ctx context.Context, vehicleTypeId int, req *model.VehicleTypeRequest) (*model.VehicleTypeResponse, error)
+	DeleteVehicleType(ctx context.Context, vehicleTypeId int) error
+	GetTariffs(ctx context.Context, parkingLotId, vehicleTypeId int) ([]*model.TariffResponse, error)
+	GetTariffById(ctx context.Context, tariffId uint) (*model.TariffResponse, error)
+	CreateTariff(ctx context.Context, req *model.TariffRequest) (*model.TariffResponse, error)
+	UpdateTariff(ctx context.Context, tariffId uint, req *model.TariffRatesRequest) (*model.TariffResponse, error)
+	DeleteTariff(ctx context.Context, tariffId uint) error
+	GetParkingLotCapacity(ctx context.Context, parkingLotId int) ([]*model.CapacityResponse, error)
+	UpdateParkingLotCapacity(ctx context.Context, parkingLotId int, req *model.CapacityRequest) (*model.CapacityResponse, error)
+	GetSpots(ctx context.Context, parkingLotId, vehicleTypeId int) ([]*model.SpotResponse, error)
+	CreateSpot(ctx context.Context, parkingLotId int, req *model.SpotRequest) (*model.SpotResponse, error)
+	UpdateSpot(ctx context.Context, spotId uint, req *model.SpotRequest) (*model.SpotResponse, error)
+	DeleteSpot(ctx context.Context, spotId uint) error
+	ReconcileParkingSpaces(ctx context.Context) error
+	GetUpsizePolicy(ctx context.Context, parkingLotId int) (*model.UpsizePolicyResponse, error)
+	UpdateUpsizePolicy(ctx context.Context, parkingLotId int, req *model.UpsizePolicyRequest) (*model.UpsizePolicyResponse, error)
+	CreateReservation(ctx context.Context, req *model.ReservationRequest) (*model.ReservationResponse, error)
+	GetReservationById(ctx context.Context, reservationId uint) (*model.ReservationResponse, error)
+	UpdateReservation(ctx context.Context, reservationId uint, req *model.ReservationUpdateRequest) (*model.ReservationResponse, error)
+	CancelReservation(ctx context.Context, reservationId uint) error
+	MarkNoShowReservations(ctx context.Context) error
+	GetPassProducts(ctx context.Context, parkingLotId int) ([]*model.PassProductResponse, error)
+	CreatePassProduct(ctx context.Context, parkingLotId int, req *model.PassProductRequest) (*model.PassProductResponse, error)
+	DeletePassProduct(ctx context.Context, passProductId uint) error
+	CreatePass(ctx context.Context, req *model.PassRequest) (*model.PassResponse, error)
+	GetPassById(ctx context.Context, passId uint) (*model.PassResponse, error)
+	UpdatePassVehicles(ctx context.Context, passId uint, req *model.PassVehiclesRequest) (*model.PassResponse, error)
+	CancelPass(ctx context.Context, passId uint) error
+	GetMerchants(ctx context.Context, parkingLotId int) ([]*model.MerchantResponse, error)
+	CreateMerchant(ctx context.Context, parkingLotId int, req *model.MerchantRequest) (*model.MerchantResponse, error)
+	GetDiscountRules(ctx context.Context, parkingLotId int) ([]*model.DiscountRuleResponse, error)
+	CreateDiscountRule(ctx context.Context, parkingLotId int, req *model.DiscountRuleRequest) (*model.DiscountRuleResponse, error)
+	DeleteDiscountRule(ctx context.Context, discountRuleId uint) error
+	ValidateTicket(ctx context.Context, merchantId uint, req *model.ValidationRequest) (*model.ValidationResponse, error)
+	GetHolidays(ctx context.Context, parkingLotId int) ([]*model.HolidayResponse, error)
+	CreateHoliday(ctx context.Context, parkingLotId int, req *model.HolidayRequest) (*model.HolidayResponse, error)
+	DeleteHoliday(ctx context.Context, holidayId uint) error
+	PayTicket(ctx context.Context, req *model.PayTicketRequest) (*model.PayTicketResponse, error)
+	GetExchangeRates(ctx context.Context) ([]*model.ExchangeRateResponse, error)
+	SaveExchangeRate(ctx context.Context, req *model.ExchangeRateRequest) (*model.ExchangeRateResponse, error)
+	DeleteExchangeRate(ctx context.Context, exchangeRateId uint) error
+	GetRevenueReport(ctx context.Context, req *model.RevenueReportQuery) (*model.RevenueReportResponse, error)
+	GetInvoiceById(ctx context.Context, invoiceId uint) (*model.InvoiceResponse, error)
+	PayInvoice(ctx context.Context, invoiceId uint, req *model.PaymentRequest) (*model.InvoiceResponse, error)
+	BillInvoiceToAccount(ctx context.Context, invoiceId uint, req *model.AccountBillingRequest) (*model.InvoiceResponse, error)
+	RefundPayment(ctx context.Context, paymentId uint, req *model.RefundRequest) (*model.PaymentResponse, error)
+	GetQuote(ctx context.Context, req *model.QuoteQuery) (*model.QuoteResponse, error)
+	AdjustFare(ctx context.Context, sessionId uint, req *model.FareAdjustmentRequest) (*model.FareAdjustmentResponse, error)
+	GetFareAdjustments(ctx context.Context, sessionId uint) ([]*model.FareAdjustmentResponse, error)
+	GetPenaltyPolicy(ctx context.Context, parkingLotId int) (*model.PenaltyPolicyResponse, error)
+	UpdatePenaltyPolicy(ctx context.Context, parkingLotId int, req *model.PenaltyPolicyRequest) (
+		*model.PenaltyPolicyResponse, error)
+	LostTicketExit(ctx context.Context, req *model.LostTicketExitRequest) (*model.UnParkVehicleResponse, error)
+	GetTenants(ctx context.Context) ([]*model.TenantResponse, error)
+	GetTenantById(ctx context.Context, tenantId uint) (*model.TenantResponse, error)
+	CreateTenant(ctx context.Context, req *model.TenantRequest) (*model.TenantResponse, error)
+	UpdateTenant(ctx context.Context, tenantId uint, req *model.TenantRequest) (*model.TenantResponse, error)
+	GetAuditEntries(ctx context.Context, req *model.AuditEntryQuery) (*model.AuditEntryPage, error)
+	VerifyAuditLog(ctx context.Context, req *model.AuditVerificationRequest) (*model.AuditVerificationResponse, error)
 }
 
 type impl struct {
 	parkingLotRepo repo.ParkingLotRepo
+	gateways       payment.Gateways
 }
 
-func NewParkingLotService(parkingLotRepo repo.ParkingLotRepo) ParkingLotService {
-	return &impl{parkingLotRepo: parkingLotRepo}
+// NewParkingLotService returns the service, invoices can be paid through the given payment gateways.
+func NewParkingLotService(parkingLotRepo repo.ParkingLotRepo, gateways ...payment.PaymentGateway) ParkingLotService {
+	return &impl{parkingLotRepo: parkingLotRepo, gateways: payment.NewGateways(gateways...)}
 }
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"strings"
+)
+
+// AdjustFare corrects or waives the fare of a closed parking session and appends the change to the ledger of
+// fare adjustments. What the session paid through payment gateways over the new fare is then refunded, newest
+// payment first. A refund that does not go through is listed as failed and can be made again by refunding its
+// payment, what was billed to an account is corrected with the account.
+func (s *impl) AdjustFare(ctx context.Context, sessionId uint, req *model.FareAdjustmentRequest) (
+	*model.FareAdjustmentResponse, error) {
+
+	parkingSession, err := s.getParkingSession(ctx, sessionId)
+	if err != nil {
+		return nil, err
+	}
+	if parkingSession.Status != models.ParkingSessionStatusClosed || parkingSession.Fare == nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Only the fare of a closed parking session can be adjusted",
+		}
+	}
+
+	adjustment := &models.FareAdjustment{
+		SessionId:    parkingSession.ID,
+		ParkingLotId: parkingSession.ParkingLotId,
+		Kind:         models.FareAdjustmentKindAdjust,
+		ReasonCode:   models.AdjustmentReason(strings.TrimSpace(req.ReasonCode)),
+		Note:         strings.TrimSpace(req.Note),
+		Operator:     strings.TrimSpace(req.Operator),
+		Currency:     parkingSession.Currency,
+		OriginalFare: *parkingSession.Fare,
+		AdjustedFare: req.Fare.Amount,
+	}
+	if req.Waive {
+		adjustment.Kind = models.FareAdjustmentKindWaive
+	}
+
+	switch {
+	case !adjustment.ReasonCode.Valid():
+		codes := make([]string, 0, len(models.AdjustmentReasons))
+		for _, reason := range models.AdjustmentReasons {
+			codes = append(codes, string(reason))
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Reason code must be one of " + strings.Join(codes, ", "),
+		}
+	case adjustment.ReasonCode == models.AdjustmentReasonOther && adjustment.Note == "":
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Note is required for the reason code other",
+		}
+	case len(adjustment.Note) > 255:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Note must be at most 255 characters",
+		}
+	case adjustment.Operator == "" || len(adjustment.Operator) > 100:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Operator is required and must be at most 100 characters",
+		}
+	case req.Waive && !req.Fare.IsZero():
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "A waived fare cannot have an amount",
+		}
+	case !req.Waive && req.Fare.Currency != parkingSession.Currency:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Fare must be in the currency of the session, " + parkingSession.Currency,
+		}
+	case req.Fare.IsNegative():
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Fare cannot be negative",
+		}
+	case adjustment.AdjustedFare == adjustment.OriginalFare:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Adjusted fare is the same as the fare of the session",
+		}
+	}
+
+	invoices, err := s.parkingLotRepo.GetSessionInvoices(ctx, parkingSession.ID)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	// The fare only changes from the amount it was read at, a concurrent adjustment is not overwritten
+	err = s.parkingLotRepo.WithTx(ctx, func(txRepo repo.ParkingLotRepo) error {
+		adjusted, err := txRepo.AdjustSessionFare(ctx, parkingSession.ID, adjustment.OriginalFare,
+			adjustment.AdjustedFare)
+		if err != nil {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    "Unable to adjust fare",
+			}
+		}
+		if !adjusted {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusConflict,
+				Message:    "Fare of the session has been adjusted by a concurrent request",
+			}
+		}
+		err = txRepo.CreateFareAdjustment(ctx, adjustment)
+		if err != nil {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    "Unable to record fare adjustment",
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.refundAdjustment(ctx, adjustment, invoices)
+
+	adjustment, err = s.parkingLotRepo.GetFareAdjustmentById(ctx, adjustment.ID)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	return toFareAdjustmentResponse(adjustment), nil
+}
+
+// GetFareAdjustments lists the ledger of fare adjustments of a parking session in the order they were made.
+func (s *impl) GetFareAdjustments(ctx context.Context, sessionId uint) ([]*model.FareAdjustmentResponse, error) {
+	_, err := s.getParkingSession(ctx, sessionId)
+	if err != nil {
+		return nil, err
+	}
+
+	adjustments, err := s.parkingLotRepo.GetFareAdjustments(ctx, sessionId)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	resp := make([]*model.FareAdjustmentResponse, 0, len(adjustments))
+	for _, adjustment := range adjustments {
+		resp = append(resp, toFareAdjustmentResponse(adjustment))
+	}
+	return resp, nil
+}
+
+// refundAdjustment pays back what the payments of the invoices of a session come to over the adjusted fare,
+// from the newest payment to the oldest. Each refund is recorded against the adjustment, failed or not.
+func (s *impl) refundAdjustment(ctx context.Context, adjustment *models.FareAdjustment, invoices []*models.Invoice) {
+	var charges []*models.Payment
+	paid := int64(0)
+	for _, invoice := range invoices {
+		for i := range invoice.Payments {
+			charge := &invoice.Payments[i]
+			if charge.Kind != models.PaymentKindCharge || charge.Currency != adjustment.Currency ||
+				(charge.Status != models.PaymentStatusSucceeded && charge.Status != models.PaymentStatusPartiallyRefunded) {
+				continue
+			}
+			charges = append(charges, charge)
+			paid += charge.Amount - charge.RefundedAmount
+		}
+	}
+
+	due := paid - adjustment.AdjustedFare
+	for i := len(charges) - 1; i >= 0 && due > 0; i-- {
+		charge := charges[i]
+		gateway, err := s.gateways.Get(charge.Gateway)
+		if err != nil {
+			continue
+		}
+
+		amount := min(due, charge.Amount-charge.RefundedAmount)
+		_, err = s.refundCharge(ctx, charge, gateway, &models.Payment{
+			InvoiceId:    charge.InvoiceId,
+			RefundOf:     &charge.ID,
+			AdjustmentId: &adjustment.ID,
+			Kind:         models.PaymentKindRefund,
+			Gateway:      gateway.Name(),
+			Currency:     charge.Currency,
+			Amount:       amount,
+			Status:       models.PaymentStatusPending,
+			Message:      "Fare adjustment: " + string(adjustment.ReasonCode),
+		})
+		if err == nil {
+			due -= amount
+		}
+	}
+}
+
+// getParkingSession resolves a parking session by its ID.
+func (s *impl) getParkingSession(ctx context.Context, sessionId uint) (*models.ParkingSession, error) {
+	parkingSession, err := s.parkingLotRepo.GetParkingSessionById(ctx, sessionId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "parking session not found",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	if err = checkParkingLotAccess(ctx, parkingSession.ParkingLotId); err != nil {
+		return nil, err
+	}
+	return parkingSession, nil
+}
+
+func toFareAdjustmentResponse(adjustment *models.FareAdjustment) *model.FareAdjustmentResponse {
+	refunds := make([]*model.PaymentResponse, 0, len(adjustment.Refunds))
+	for i := range adjustment.Refunds {
+		refunds = append(refunds, toPaymentResponse(&adjustment.Refunds[i]))
+	}
+	return &model.FareAdjustmentResponse{
+		ID:           adjustment.ID,
+		SessionID:    adjustment.SessionId,
+		ParkingLotID: adjustment.ParkingLotId,
+		Kind:         string(adjustment.Kind),
+		ReasonCode:   string(adjustment.ReasonCode),
+		Note:         adjustment.Note,
+		Operator:     adjustment.Operator,
+		OriginalFare: money.New(adjustment.OriginalFare, adjustment.Currency),
+		AdjustedFare: money.New(adjustment.AdjustedFare, adjustment.Currency),
+		Refunds:      refunds,
+		CreatedAt:    adjustment.CreatedAt,
+	}
+}
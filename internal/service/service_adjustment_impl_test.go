@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"parking_lot_service/internal/service/payment"
+	"testing"
+)
+
+// exitPaidInCashAndLocal unparks a vehicle whose fare of 40.00 INR was paid 10.00 INR in cash and the rest
+// through the local gateway, and returns its session.
+func exitPaidInCashAndLocal(t *testing.T, fake *fakeRepo, svc ParkingLotService) *models.ParkingSession {
+	t.Helper()
+	unparked := parkForInvoice(t, fake, svc)
+	_, err := svc.PayInvoice(context.Background(), unparked.Invoice.ID, &model.PaymentRequest{
+		Gateway: payment.Cash, Amount: inr(1000),
+	})
+	if err != nil {
+		t.Fatalf("PayInvoice() in cash error = %v", err)
+	}
+	_, err = svc.PayInvoice(context.Background(), unparked.Invoice.ID, &model.PaymentRequest{Gateway: payment.Local})
+	if err != nil {
+		t.Fatalf("PayInvoice() through the local gateway error = %v", err)
+	}
+
+	exited, err := svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{
+		TicketNumber: unparked.Parking.TicketNumber,
+	})
+	if err != nil || !exited.Exited {
+		t.Fatalf("UnParkVehicle() after payment = %+v, %v, want an exit", exited, err)
+	}
+	return fake.sessions[1]
+}
+
+func TestAdjustFare_RefundsWhatWasPaidOverTheFare(t *testing.T) {
+	fake := newFakeRepo(1)
+	svc := NewParkingLotService(fake, payment.NewCash(), payment.NewLocal())
+	parkingSession := exitPaidInCashAndLocal(t, fake, svc)
+
+	// Priced as a car, the vehicle was a motorcycle
+	adjusted, err := svc.AdjustFare(context.Background(), parkingSession.ID, &model.FareAdjustmentRequest{
+		Fare: inr(500), ReasonCode: string(models.AdjustmentReasonWrongVehicleType), Operator: "supervisor-1",
+	})
+	if err != nil {
+		t.Fatalf("AdjustFare() error = %v", err)
+	}
+	if adjusted.Kind != string(models.FareAdjustmentKindAdjust) || adjusted.OriginalFare != inr(4000) ||
+		adjusted.AdjustedFare != inr(500) || adjusted.Operator != "supervisor-1" {
+		t.Errorf("AdjustFare() = %+v, want 40.00 INR adjusted to 5.00 INR by supervisor-1", adjusted)
+	}
+	if got := inr(*fake.sessions[1].Fare); got != inr(500) {
+		t.Errorf("session fare = %v, want 5.00 INR", got)
+	}
+
+	// The newest payment is refunded first
+	wantRefunds := []struct {
+		gateway string
+		amount  money.Money
+	}{
+		{gateway: payment.Local, amount: inr(3000)},
+		{gateway: payment.Cash, amount: inr(500)},
+	}
+	if len(adjusted.Refunds) != len(wantRefunds) {
+		t.Fatalf("AdjustFare() refunds = %d, want %d", len(adjusted.Refunds), len(wantRefunds))
+	}
+	for i, want := range wantRefunds {
+		refund := adjusted.Refunds[i]
+		if refund.Gateway != want.gateway || refund.Amount != want.amount ||
+			refund.Status != string(models.PaymentStatusSucceeded) ||
+			refund.AdjustmentID == nil || *refund.AdjustmentID != adjusted.ID {
+			t.Errorf("AdjustFare() refund %d = %+v, want %v refunded through %s", i, refund, want.amount, want.gateway)
+		}
+	}
+
+	waived, err := svc.AdjustFare(context.Background(), parkingSession.ID, &model.FareAdjustmentRequest{
+		Waive: true, ReasonCode: string(models.AdjustmentReasonGoodwill), Operator: "supervisor-2",
+	})
+	if err != nil {
+		t.Fatalf("AdjustFare() waive error = %v", err)
+	}
+	if waived.Kind != string(models.FareAdjustmentKindWaive) || waived.OriginalFare != inr(500) ||
+		!waived.AdjustedFare.IsZero() || len(waived.Refunds) != 1 || waived.Refunds[0].Amount != inr(500) {
+		t.Errorf("AdjustFare() waive = %+v, want 5.00 INR waived and refunded", waived)
+	}
+	if got := fake.invoices[1].RefundedAmount; got != 4000 {
+		t.Errorf("invoice refunded amount = %d, want 4000", got)
+	}
+
+	ledger, err := svc.GetFareAdjustments(context.Background(), parkingSession.ID)
+	if err != nil {
+		t.Fatalf("GetFareAdjustments() error = %v", err)
+	}
+	if len(ledger) != 2 || ledger[0].ID != adjusted.ID || ledger[1].ID != waived.ID || len(ledger[0].Refunds) != 2 {
+		t.Errorf("GetFareAdjustments() = %+v, want the adjustment and the waiver with their refunds", ledger)
+	}
+}
+
+func TestAdjustFare_Errors(t *testing.T) {
+	fake := newFakeRepo(1)
+	svc := NewParkingLotService(fake, payment.NewCash(), payment.NewLocal())
+
+	unparked := parkForInvoice(t, fake, svc)
+	_, err := svc.AdjustFare(context.Background(), 1, &model.FareAdjustmentRequest{
+		Waive: true, ReasonCode: string(models.AdjustmentReasonGoodwill), Operator: "supervisor-1",
+	})
+	wantErrorStatus(t, "AdjustFare() of an open session", err, http.StatusConflict)
+
+	payAndExit(t, svc, unparked)
+	tests := []struct {
+		name string
+		req  *model.FareAdjustmentRequest
+	}{
+		{
+			name: "Unknown reason code",
+			req:  &model.FareAdjustmentRequest{Fare: inr(500), ReasonCode: "typo", Operator: "supervisor-1"},
+		},
+		{
+			name: "Reason code other without a note",
+			req:  &model.FareAdjustmentRequest{Fare: inr(500), ReasonCode: "other", Operator: "supervisor-1"},
+		},
+		{
+			name: "Without an operator",
+			req:  &model.FareAdjustmentRequest{Fare: inr(500), ReasonCode: "goodwill"},
+		},
+		{
+			name: "Waiver with an amount",
+			req:  &model.FareAdjustmentRequest{Fare: inr(500), Waive: true, ReasonCode: "goodwill", Operator: "supervisor-1"},
+		},
+		{
+			name: "Fare in another currency",
+			req:  &model.FareAdjustmentRequest{Fare: money.New(500, "USD"), ReasonCode: "goodwill", Operator: "supervisor-1"},
+		},
+		{
+			name: "Negative fare",
+			req:  &model.FareAdjustmentRequest{Fare: inr(-500), ReasonCode: "goodwill", Operator: "supervisor-1"},
+		},
+		{
+			name: "Unchanged fare",
+			req:  &model.FareAdjustmentRequest{Fare: inr(4000), ReasonCode: "goodwill", Operator: "supervisor-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.AdjustFare(context.Background(), 1, tt.req)
+			wantErrorStatus(t, "AdjustFare()", err, http.StatusBadRequest)
+		})
+	}
+	if len(fake.adjustments) != 0 || *fake.sessions[1].Fare != 4000 {
+		t.Errorf("rejected adjustments changed the fare to %d or the ledger", *fake.sessions[1].Fare)
+	}
+
+	_, err = svc.AdjustFare(context.Background(), 42, &model.FareAdjustmentRequest{
+		Waive: true, ReasonCode: "goodwill", Operator: "supervisor-1",
+	})
+	wantErrorStatus(t, "AdjustFare() of an unknown session", err, http.StatusNotFound)
+}
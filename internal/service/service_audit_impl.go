@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"parking_lot_service/internal/audit"
+	"parking_lot_service/internal/auth"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/repo"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+	auditChainPageSize   = 500 // Entries read at a time while verifying the log
+)
+
+func (s *impl) GetAuditEntries(ctx context.Context, req *model.AuditEntryQuery) (*model.AuditEntryPage, error) {
+	filter := &repo.AuditEntryFilter{
+		Entity:    strings.TrimSpace(req.Entity),
+		EntityId:  strings.TrimSpace(req.EntityID),
+		Actor:     strings.TrimSpace(req.Actor),
+		RequestId: strings.TrimSpace(req.RequestID),
+		From:      req.From,
+		To:        req.To,
+		Limit:     req.Limit,
+	}
+
+	switch {
+	case filter.Limit == 0:
+		filter.Limit = defaultAuditPageSize
+	case filter.Limit < 0 || filter.Limit > maxAuditPageSize:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Limit must be between 1 and " + strconv.Itoa(maxAuditPageSize),
+		}
+	}
+
+	if req.Cursor != "" {
+		beforeId, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Invalid cursor",
+			}
+		}
+		filter.BeforeId = beforeId
+	}
+
+	// Fetch one entry more than requested to find out whether there is a next page
+	pageSize := filter.Limit
+	filter.Limit++
+
+	entries, err := s.parkingLotRepo.GetAuditEntries(ctx, filter)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	resp := &model.AuditEntryPage{Entries: make([]*model.AuditEntryResponse, 0, pageSize)}
+	if len(entries) > pageSize {
+		entries = entries[:pageSize]
+		resp.NextCursor = encodeCursor(entries[pageSize-1].ID)
+	}
+	for _, entry := range entries {
+		if auth.CanAccessTenant(ctx, entry.TenantId) {
+			resp.Entries = append(resp.Entries, toAuditEntryResponse(entry))
+		}
+	}
+	return resp, nil
+}
+
+// VerifyAuditLog walks the audit log from its first entry and checks the hash chain of every tenant, and that the
+// heads recorded by an earlier verification are still in their chains. Only platform admins can verify the log, it
+// holds the entries of every tenant.
+func (s *impl) VerifyAuditLog(ctx context.Context, req *model.AuditVerificationRequest) (*model.AuditVerificationResponse, error) {
+	if err := checkPlatform(ctx, "verify the audit log"); err != nil {
+		return nil, err
+	}
+
+	verifier := &audit.Verifier{}
+	verifier.Anchor(req.Heads)
+	resp := &model.AuditVerificationResponse{Intact: true}
+	for afterId := uint(0); resp.Intact; {
+		entries, err := s.parkingLotRepo.GetAuditChain(ctx, afterId, auditChainPageSize)
+		if err != nil {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    err.Error(),
+			}
+		}
+
+		for _, entry := range entries {
+			err = verifier.Check(entry)
+			var chainErr *audit.ChainError
+			if errors.As(err, &chainErr) {
+				resp.Intact = false
+				resp.BrokenAt = &chainErr.EntryID
+				resp.Reason = chainErr.Error()
+				break
+			}
+		}
+		if len(entries) < auditChainPageSize {
+			break
+		}
+		afterId = entries[len(entries)-1].ID
+	}
+
+	// Newest entries removed from a chain leave it intact but without its recorded head
+	if truncated := verifier.Truncated(); resp.Intact && len(truncated) > 0 {
+		resp.Intact = false
+		resp.Truncated = truncated
+		resp.Reason = "chain of tenant " + strconv.FormatUint(uint64(truncated[0]), 10) + " ends before its recorded head"
+	}
+
+	resp.Checked = verifier.Checked()
+	resp.Heads = verifier.Heads()
+	return resp, nil
+}
+
+func toAuditEntryResponse(entry *models.AuditEntry) *model.AuditEntryResponse {
+	return &model.AuditEntryResponse{
+		ID:        entry.ID,
+		TenantID:  entry.TenantId,
+		Actor:     entry.Actor,
+		Role:      entry.Role,
+		Operation: entry.Operation,
+		RequestID: entry.RequestId,
+		Action:    string(entry.Action),
+		Entity:    entry.Entity,
+		EntityID:  entry.EntityId,
+		Before:    auditRow(entry.Before),
+		After:     auditRow(entry.After),
+		CreatedAt: entry.CreatedAt,
+		PrevHash:  entry.PrevHash,
+		Hash:      entry.Hash,
+	}
+}
+
+// auditRow returns a row recorded in an audit entry as JSON, or nil for no row.
+func auditRow(row string) json.RawMessage {
+	if row == "" {
+		return nil
+	}
+	return json.RawMessage(row)
+}
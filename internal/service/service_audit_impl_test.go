@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"parking_lot_service/internal/audit"
+	"parking_lot_service/internal/auth"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// appendAuditEntries links n entries about the spots of lot 1 to the chain of the default tenant in the fake repo.
+func appendAuditEntries(fake *fakeRepo, n int) {
+	appendTenantAuditEntries(fake, models.DefaultTenantId, n)
+}
+
+// appendTenantAuditEntries links n entries about the spots of lot 1 to the chain of a tenant in the fake repo.
+func appendTenantAuditEntries(fake *fakeRepo, tenantId uint, n int) {
+	prevHash := ""
+	for _, entry := range fake.auditEntries {
+		if entry.TenantId == tenantId {
+			prevHash = entry.Hash
+		}
+	}
+	for i := 1; i <= n; i++ {
+		id := len(fake.auditEntries) + 1
+		entry := &models.AuditEntry{ID: uint(id), TenantId: tenantId, Actor: "attendant-1",
+			Role: string(auth.RoleAttendant), Operation: "PUT /parking-lot/spots/:id", RequestId: "req-" + strconv.Itoa(id),
+			Action: models.AuditActionUpdate, Entity: "spots", EntityId: "1", Before: `{"occupied":false}`,
+			After: `{"occupied":true}`, CreatedAt: time.Date(2026, 3, 1, 9, 0, id, 0, time.UTC), PrevHash: prevHash}
+		entry.Hash = audit.Hash(entry)
+		prevHash = entry.Hash
+		fake.auditEntries = append(fake.auditEntries, entry)
+	}
+}
+
+func TestVerifyAuditLog(t *testing.T) {
+	const entries = auditChainPageSize + 20
+
+	fake := newFakeRepo(1)
+	appendAuditEntries(fake, entries)
+	svc := NewParkingLotService(fake)
+
+	result, err := svc.VerifyAuditLog(context.Background(), &model.AuditVerificationRequest{})
+	if err != nil {
+		t.Fatalf("VerifyAuditLog() error = %v", err)
+	}
+	if !result.Intact || result.Checked != entries || result.Heads[models.DefaultTenantId] != fake.auditEntries[entries-1].Hash {
+		t.Errorf("VerifyAuditLog() of an intact log = %+v", result)
+	}
+
+	// An entry changed after it was written breaks the chain at the entry
+	fake.auditEntries[entries-10].After = `{"occupied":false}`
+	result, err = svc.VerifyAuditLog(context.Background(), &model.AuditVerificationRequest{})
+	if err != nil {
+		t.Fatalf("VerifyAuditLog() error = %v", err)
+	}
+	if result.Intact || result.BrokenAt == nil || *result.BrokenAt != entries-9 || result.Checked != entries-10 {
+		t.Errorf("VerifyAuditLog() of a changed entry = %+v, want broken at entry %d", result, entries-9)
+	}
+
+	// A removed entry breaks the chain at the entry after it
+	fake = newFakeRepo(1)
+	appendAuditEntries(fake, 5)
+	fake.auditEntries = append(fake.auditEntries[:2], fake.auditEntries[3:]...)
+	result, _ = NewParkingLotService(fake).VerifyAuditLog(context.Background(), &model.AuditVerificationRequest{})
+	if result.Intact || result.BrokenAt == nil || *result.BrokenAt != 4 {
+		t.Errorf("VerifyAuditLog() of a removed entry = %+v, want broken at entry 4", result)
+	}
+
+	// Every tenant has a chain of its own, interleaved in the log
+	fake = newFakeRepo(1)
+	appendTenantAuditEntries(fake, 1, 2)
+	appendTenantAuditEntries(fake, 2, 3)
+	appendTenantAuditEntries(fake, 1, 2)
+	result, err = NewParkingLotService(fake).VerifyAuditLog(context.Background(), &model.AuditVerificationRequest{})
+	if err != nil {
+		t.Fatalf("VerifyAuditLog() error = %v", err)
+	}
+	if !result.Intact || result.Checked != 7 || len(result.Heads) != 2 ||
+		result.Heads[1] != fake.auditEntries[6].Hash || result.Heads[2] != fake.auditEntries[4].Hash {
+		t.Errorf("VerifyAuditLog() of interleaved tenant chains = %+v", result)
+	}
+
+	// An entry linked to the chain of another tenant breaks the chain at the entry
+	fake.auditEntries[5].PrevHash = fake.auditEntries[4].Hash
+	fake.auditEntries[5].Hash = audit.Hash(fake.auditEntries[5])
+	result, _ = NewParkingLotService(fake).VerifyAuditLog(context.Background(), &model.AuditVerificationRequest{})
+	if result.Intact || result.BrokenAt == nil || *result.BrokenAt != 6 {
+		t.Errorf("VerifyAuditLog() of an entry in the chain of another tenant = %+v, want broken at entry 6", result)
+	}
+
+	// The log holds the entries of every tenant
+	tenantAdmin := auth.NewContext(context.Background(), &auth.Principal{Role: auth.RoleAdmin, TenantID: 1})
+	_, err = svc.VerifyAuditLog(tenantAdmin, &model.AuditVerificationRequest{})
+	wantErrorStatus(t, "VerifyAuditLog() by an admin of a tenant", err, http.StatusForbidden)
+}
+
+func TestVerifyAuditLog_RecordedHeadsCatchRemovedNewestEntries(t *testing.T) {
+	fake := newFakeRepo(1)
+	appendTenantAuditEntries(fake, 1, 3)
+	appendTenantAuditEntries(fake, 2, 2)
+	svc := NewParkingLotService(fake)
+
+	result, err := svc.VerifyAuditLog(context.Background(), &model.AuditVerificationRequest{})
+	if err != nil {
+		t.Fatalf("VerifyAuditLog() error = %v", err)
+	}
+	recorded := result.Heads
+
+	// Entries written since the heads were recorded leave them in their chains
+	appendTenantAuditEntries(fake, 1, 2)
+	result, err = svc.VerifyAuditLog(context.Background(), &model.AuditVerificationRequest{Heads: recorded})
+	if err != nil {
+		t.Fatalf("VerifyAuditLog() error = %v", err)
+	}
+	if !result.Intact || result.Checked != 7 {
+		t.Errorf("VerifyAuditLog() of a grown log = %+v", result)
+	}
+
+	// Removing the newest entries of a chain leaves it intact, but without its recorded head
+	fake.auditEntries = fake.auditEntries[:2]
+	result, err = svc.VerifyAuditLog(context.Background(), &model.AuditVerificationRequest{Heads: recorded})
+	if err != nil {
+		t.Fatalf("VerifyAuditLog() error = %v", err)
+	}
+	if result.Intact || result.BrokenAt != nil || len(result.Truncated) != 2 || result.Truncated[0] != 1 ||
+		result.Truncated[1] != 2 {
+		t.Errorf("VerifyAuditLog() of a cut short log = %+v, want tenants 1 and 2 truncated", result)
+	}
+	if result, _ = svc.VerifyAuditLog(context.Background(), &model.AuditVerificationRequest{}); !result.Intact {
+		t.Errorf("VerifyAuditLog() of a cut short log without recorded heads = %+v, want intact", result)
+	}
+}
+
+func TestGetAuditEntries_PagesNewestFirst(t *testing.T) {
+	fake := newFakeRepo(1)
+	appendAuditEntries(fake, 5)
+	svc := NewParkingLotService(fake)
+
+	ctx := auth.NewContext(context.Background(), &auth.Principal{Role: auth.RoleFinance, TenantID: 1})
+	page, err := svc.GetAuditEntries(ctx, &model.AuditEntryQuery{Entity: "spots", Limit: 3})
+	if err != nil {
+		t.Fatalf("GetAuditEntries() error = %v", err)
+	}
+	if len(page.Entries) != 3 || page.Entries[0].ID != 5 || page.NextCursor == "" {
+		t.Fatalf("GetAuditEntries() first page = %+v", page)
+	}
+	if string(page.Entries[0].After) != `{"occupied":true}` || page.Entries[0].RequestID != "req-5" {
+		t.Errorf("GetAuditEntries() entry = %+v", page.Entries[0])
+	}
+
+	page, err = svc.GetAuditEntries(ctx, &model.AuditEntryQuery{Entity: "spots", Limit: 3, Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatalf("GetAuditEntries() error = %v", err)
+	}
+	if len(page.Entries) != 2 || page.Entries[0].ID != 2 || page.NextCursor != "" {
+		t.Errorf("GetAuditEntries() last page = %+v", page)
+	}
+
+	// Entries of another tenant are left out
+	other := auth.NewContext(context.Background(), &auth.Principal{Role: auth.RoleFinance, TenantID: 2})
+	if page, _ = svc.GetAuditEntries(other, &model.AuditEntryQuery{}); len(page.Entries) != 0 {
+		t.Errorf("GetAuditEntries() by another tenant = %+v, want none", page.Entries)
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/repo"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"time"
+)
+
+func (s *impl) GetParkingLotCapacity(ctx context.Context, parkingLotId int) ([]*model.CapacityResponse, error) {
+	if _, err := s.getParkingLot(ctx, parkingLotId); err != nil {
+		return nil, err
+	}
+
+	parkingSpaces, err := s.parkingLotRepo.GetFreeParkingSpaceById(ctx, parkingLotId)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	resp := make([]*model.CapacityResponse, 0, len(parkingSpaces))
+	for _, parkingSpace := range parkingSpaces {
+		resp = append(resp, toCapacityResponse(parkingSpace))
+	}
+	return resp, nil
+}
+
+func (s *impl) UpdateParkingLotCapacity(ctx context.Context, parkingLotId int,
+	req *model.CapacityRequest) (*model.CapacityResponse, error) {
+
+	if req.TotalSpots < 0 {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Total spots cannot be negative",
+		}
+	}
+	parkingLot, err := s.getParkingLot(ctx, parkingLotId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = s.getVehicleType(ctx, req.VehicleID); err != nil {
+		return nil, err
+	}
+
+	// Resize the parking space and publish the change in a single transaction
+	var parkingSpace *models.ParkingSpace
+	err = s.parkingLotRepo.WithTx(ctx, func(txRepo repo.ParkingLotRepo) error {
+		before, err := getParkingSpaceIfAny(ctx, txRepo, parkingLotId, req.VehicleID)
+		if err != nil {
+			return err
+		}
+
+		parkingSpace, err = txRepo.ResizeParkingSpace(ctx, parkingLotId, req.VehicleID, req.TotalSpots,
+			req.AllowOverCapacity)
+		if err != nil {
+			if errors.Is(err, repo.ErrCapacityBelowOccupancy) {
+				return &genericresponse.GenericResponse{
+					StatusCode: http.StatusConflict,
+					Message:    "More vehicles are parked than the requested capacity",
+				}
+			}
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    err.Error(),
+			}
+		}
+
+		return publishCapacityChange(ctx, txRepo, parkingLot, before, parkingSpace, time.Now())
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toCapacityResponse(parkingSpace), nil
+}
+
+func toCapacityResponse(parkingSpace *models.ParkingSpace) *model.CapacityResponse {
+	return &model.CapacityResponse{
+		ParkingLotID:   parkingSpace.ParkingLotId,
+		VehicleID:      parkingSpace.VehicleTypeId,
+		TotalSpots:     parkingSpace.TotalSpots,
+		AvailableSpots: max(parkingSpace.AvailableSpots, 0),
+		OccupiedSpots:  parkingSpace.OccupiedSpots(),
+		OverCapacity:   parkingSpace.AvailableSpots < 0,
+	}
+}
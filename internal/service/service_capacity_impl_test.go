@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"parking_lot_service/internal/service/payment"
+	"testing"
+)
+
+// parkVehicles parks n vehicles of type 1 in lot 1 and returns their tickets.
+func parkVehicles(t *testing.T, svc ParkingLotService, n int) []string {
+	t.Helper()
+	tickets := make([]string, 0, n)
+	for i := 1; i <= n; i++ {
+		parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+			ParkingLotID: 1, VehicleID: 1, VehicleNumber: fmt.Sprintf("KA-01-%04d", i),
+		})
+		if err != nil {
+			t.Fatalf("ParkVehicle() of vehicle %d error = %v", i, err)
+		}
+		tickets = append(tickets, parked.ParkingTicket.TicketNumber)
+	}
+	return tickets
+}
+
+// wantParkingSpace fails the test unless the parking space of lot 1 and vehicle type 1 has the given counters and
+// as many spots as it should have.
+func wantParkingSpace(t *testing.T, fake *fakeRepo, name string, total, available, pendingRemovals, spots int) {
+	t.Helper()
+	parkingSpace := fake.parkingSpaces[[2]int{1, 1}]
+	if parkingSpace.TotalSpots != total || parkingSpace.AvailableSpots != available ||
+		parkingSpace.PendingRemovals != pendingRemovals {
+		t.Errorf("%s parking space = %+v, want %d total, %d available and %d pending removals", name,
+			parkingSpace, total, available, pendingRemovals)
+	}
+	if len(fake.spots) != spots {
+		t.Errorf("%s spots = %d, want %d", name, len(fake.spots), spots)
+	}
+}
+
+func TestUpdateParkingLotCapacity_Grows(t *testing.T) {
+	fake := newFakeRepo(2)
+	svc := NewParkingLotService(fake)
+	parkVehicles(t, svc, 1)
+
+	resp, err := svc.UpdateParkingLotCapacity(context.Background(), 1, &model.CapacityRequest{VehicleID: 1, TotalSpots: 4})
+	if err != nil {
+		t.Fatalf("UpdateParkingLotCapacity() error = %v", err)
+	}
+	if resp.TotalSpots != 4 || resp.AvailableSpots != 3 || resp.OccupiedSpots != 1 || resp.OverCapacity {
+		t.Errorf("UpdateParkingLotCapacity() = %+v, want 4 spots with 1 occupied", resp)
+	}
+	wantParkingSpace(t, fake, "grown", 4, 3, 0, 4)
+
+	// New spots are numbered after the labels already taken
+	for _, id := range []uint{3, 4} {
+		if spot := fake.spots[id]; spot == nil || spot.Label != fmt.Sprintf("CARS_SUVS-%03d", id) || spot.Occupied {
+			t.Errorf("added spot %d = %+v, want a free numbered spot", id, spot)
+		}
+	}
+}
+
+func TestUpdateParkingLotCapacity_BelowOccupancyConflicts(t *testing.T) {
+	fake := newFakeRepo(3)
+	svc := NewParkingLotService(fake)
+	parkVehicles(t, svc, 2)
+
+	_, err := svc.UpdateParkingLotCapacity(context.Background(), 1, &model.CapacityRequest{VehicleID: 1, TotalSpots: 1})
+	wantErrorStatus(t, "UpdateParkingLotCapacity() below occupancy", err, http.StatusConflict)
+	wantParkingSpace(t, fake, "rejected", 3, 1, 0, 3)
+	if got := len(fake.publishedEvents()); got != 2 {
+		t.Errorf("events after a rejected resize = %d, want only the 2 VehicleParked", got)
+	}
+
+	// Shrinking down to the occupancy removes the free spot only
+	resp, err := svc.UpdateParkingLotCapacity(context.Background(), 1, &model.CapacityRequest{VehicleID: 1, TotalSpots: 2})
+	if err != nil {
+		t.Fatalf("UpdateParkingLotCapacity() to the occupancy error = %v", err)
+	}
+	if resp.AvailableSpots != 0 || resp.OverCapacity {
+		t.Errorf("UpdateParkingLotCapacity() = %+v, want a full pool", resp)
+	}
+	wantParkingSpace(t, fake, "shrunk", 2, 0, 0, 2)
+	if _, ok := fake.spots[3]; ok {
+		t.Errorf("free spot 3 was not removed")
+	}
+}
+
+func TestUpdateParkingLotCapacity_OverCapacityDrainsOnUnPark(t *testing.T) {
+	fake := newFakeRepo(3)
+	svc := NewParkingLotService(fake, payment.NewLocal())
+	tickets := parkVehicles(t, svc, 3)
+
+	resp, err := svc.UpdateParkingLotCapacity(context.Background(), 1, &model.CapacityRequest{
+		VehicleID: 1, TotalSpots: 1, AllowOverCapacity: true,
+	})
+	if err != nil {
+		t.Fatalf("UpdateParkingLotCapacity() over capacity error = %v", err)
+	}
+	if resp.TotalSpots != 1 || resp.AvailableSpots != 0 || resp.OccupiedSpots != 3 || !resp.OverCapacity {
+		t.Errorf("UpdateParkingLotCapacity() = %+v, want 3 vehicles in a pool of 1", resp)
+	}
+	// No spot is free, both removals wait for vehicles to leave
+	wantParkingSpace(t, fake, "over capacity", 1, -2, 2, 3)
+
+	_, err = svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0004",
+	})
+	if err == nil {
+		t.Errorf("ParkVehicle() over capacity error = nil")
+	}
+
+	// Every vehicle leaving removes its spot until the pool fits, the last one frees its spot
+	wants := []struct{ available, pendingRemovals, spots int }{{-1, 1, 2}, {0, 0, 1}, {1, 0, 1}}
+	for i, ticket := range tickets {
+		unparked, err := svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{TicketNumber: ticket})
+		if err != nil {
+			t.Fatalf("UnParkVehicle() of vehicle %d error = %v", i+1, err)
+		}
+		payAndExit(t, svc, unparked)
+
+		want := wants[i]
+		wantParkingSpace(t, fake, fmt.Sprintf("vehicle %d left", i+1), 1, want.available, want.pendingRemovals, want.spots)
+	}
+	if spot, ok := fake.spots[3]; !ok || spot.Occupied {
+		t.Errorf("spot left = %+v, want the spot of the last vehicle free", spot)
+	}
+}
+
+func TestUpdateParkingLotCapacity_GrowCancelsPendingRemovals(t *testing.T) {
+	fake := newFakeRepo(3)
+	svc := NewParkingLotService(fake)
+	parkVehicles(t, svc, 3)
+
+	for _, totalSpots := range []int{1, 4} {
+		_, err := svc.UpdateParkingLotCapacity(context.Background(), 1, &model.CapacityRequest{
+			VehicleID: 1, TotalSpots: totalSpots, AllowOverCapacity: true,
+		})
+		if err != nil {
+			t.Fatalf("UpdateParkingLotCapacity() to %d error = %v", totalSpots, err)
+		}
+	}
+	// The spots that were to be removed stay, a single spot is added
+	wantParkingSpace(t, fake, "grown back", 4, 1, 0, 4)
+	if spot := fake.spots[4]; spot == nil || spot.Label != "CARS_SUVS-004" {
+		t.Errorf("added spot = %+v, want CARS_SUVS-004", spot)
+	}
+}
+
+func TestUpdateParkingLotCapacity_CreatesParkingSpace(t *testing.T) {
+	fake := newFakeRepo(1)
+	fake.vehicleTypes[2] = &models.VehicleType{ID: 2, Code: "BIKES", DisplayName: "Bikes",
+		SizeClass: models.SizeClassSmall}
+	svc := NewParkingLotService(fake)
+
+	resp, err := svc.UpdateParkingLotCapacity(context.Background(), 1, &model.CapacityRequest{VehicleID: 2, TotalSpots: 2})
+	if err != nil {
+		t.Fatalf("UpdateParkingLotCapacity() of a new pool error = %v", err)
+	}
+	if resp.VehicleID != 2 || resp.TotalSpots != 2 || resp.AvailableSpots != 2 {
+		t.Errorf("UpdateParkingLotCapacity() = %+v, want a pool of 2 free spots", resp)
+	}
+	if parkingSpace := fake.parkingSpaces[[2]int{1, 2}]; parkingSpace == nil ||
+		parkingSpace.TenantId != models.DefaultTenantId {
+		t.Errorf("parking space = %+v, want one of the tenant of the lot", parkingSpace)
+	}
+}
@@ -0,0 +1,458 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"parking_lot_service/internal/ticket"
+	"strings"
+)
+
+// defaultMaxDiscountPercent lets discounts take off the whole fare of a stay.
+const defaultMaxDiscountPercent = 100
+
+func (s *impl) GetMerchants(ctx context.Context, parkingLotId int) ([]*model.MerchantResponse, error) {
+	if _, err := s.getParkingLot(ctx, parkingLotId); err != nil {
+		return nil, err
+	}
+
+	merchants, err := s.parkingLotRepo.GetMerchants(ctx, parkingLotId)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	resp := make([]*model.MerchantResponse, 0, len(merchants))
+	for _, merchant := range merchants {
+		resp = append(resp, toMerchantResponse(merchant))
+	}
+	return resp, nil
+}
+
+func (s *impl) CreateMerchant(ctx context.Context, parkingLotId int,
+	req *model.MerchantRequest) (*model.MerchantResponse, error) {
+
+	if _, err := s.getParkingLot(ctx, parkingLotId); err != nil {
+		return nil, err
+	}
+
+	merchant := &models.Merchant{
+		ParkingLotId: parkingLotId,
+		Name:         strings.TrimSpace(req.Name),
+		Active:       true,
+	}
+	if merchant.Name == "" {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Merchant name is required",
+		}
+	}
+
+	err := s.parkingLotRepo.CreateMerchant(ctx, merchant)
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusConflict,
+				Message:    "Merchant with this name already exists in the parking lot",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	return toMerchantResponse(merchant), nil
+}
+
+func (s *impl) GetDiscountRules(ctx context.Context, parkingLotId int) ([]*model.DiscountRuleResponse, error) {
+	if _, err := s.getParkingLot(ctx, parkingLotId); err != nil {
+		return nil, err
+	}
+
+	discountRules, err := s.parkingLotRepo.GetDiscountRules(ctx, parkingLotId)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	resp := make([]*model.DiscountRuleResponse, 0, len(discountRules))
+	for _, discountRule := range discountRules {
+		resp = append(resp, toDiscountRuleResponse(discountRule))
+	}
+	return resp, nil
+}
+
+func (s *impl) CreateDiscountRule(ctx context.Context, parkingLotId int,
+	req *model.DiscountRuleRequest) (*model.DiscountRuleResponse, error) {
+
+	parkingLot, err := s.getParkingLot(ctx, parkingLotId)
+	if err != nil {
+		return nil, err
+	}
+
+	discountRule := &models.DiscountRule{
+		ParkingLotId: parkingLotId,
+		MerchantId:   req.MerchantID,
+		Name:         strings.TrimSpace(req.Name),
+		Kind:         models.DiscountKind(req.Kind),
+		Value:        req.Value,
+		FixedAmount:  req.Amount.Amount,
+		MaxAmount:    req.MaxAmount.Amount,
+		Currency:     parkingLot.Currency,
+		Stackable:    req.Stackable,
+	}
+	if code := strings.ToUpper(strings.TrimSpace(req.Code)); code != "" {
+		discountRule.Code = &code
+	}
+
+	switch {
+	case discountRule.Name == "":
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Discount rule name is required",
+		}
+	case (discountRule.MerchantId == nil) == (discountRule.Code == nil):
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "A discount rule needs either a merchant or a discount code",
+		}
+	case discountRule.Kind != models.DiscountKindPercentage && discountRule.Kind != models.DiscountKindFixedAmount &&
+		discountRule.Kind != models.DiscountKindFreeHours:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Discount kind must be percentage, fixed-amount or free-hours",
+		}
+	case discountRule.Kind == models.DiscountKindFixedAmount && !req.Amount.IsPositive():
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "A fixed-amount discount needs a positive amount",
+		}
+	case discountRule.Kind != models.DiscountKindFixedAmount && (discountRule.Value <= 0 ||
+		discountRule.Kind == models.DiscountKindPercentage && discountRule.Value > 100):
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Discount value must be positive, and at most 100 for a percentage",
+		}
+	case req.MaxAmount.IsNegative():
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Maximum amount cannot be negative",
+		}
+	case !inCurrency(parkingLot.Currency, req.Amount, req.MaxAmount):
+		// Fixed amounts and caps are in the currency of the fares they are taken off
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Discount amounts must be in the currency of the parking lot, " + parkingLot.Currency,
+		}
+	}
+
+	if discountRule.Kind == models.DiscountKindFixedAmount {
+		discountRule.Value = 0
+	} else {
+		discountRule.FixedAmount = 0
+	}
+
+	if discountRule.MerchantId != nil {
+		merchant, err := s.getMerchant(ctx, *discountRule.MerchantId)
+		if err != nil {
+			return nil, err
+		}
+		if merchant.ParkingLotId != parkingLotId {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Merchant belongs to another parking lot",
+			}
+		}
+	}
+
+	err = s.parkingLotRepo.CreateDiscountRule(ctx, discountRule)
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusConflict,
+				Message:    "Discount code already exists in the parking lot",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	return toDiscountRuleResponse(discountRule), nil
+}
+
+func (s *impl) DeleteDiscountRule(ctx context.Context, discountRuleId uint) error {
+	err := s.parkingLotRepo.DeleteDiscountRule(ctx, discountRuleId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "discount rule not found",
+			}
+		}
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	return nil
+}
+
+// ValidateTicket lets a merchant apply one of its discount rules to the open ticket of a customer.
+// The discount is taken off the fare when the vehicle leaves.
+func (s *impl) ValidateTicket(ctx context.Context, merchantId uint,
+	req *model.ValidationRequest) (*model.ValidationResponse, error) {
+
+	merchant, err := s.getMerchant(ctx, merchantId)
+	if err != nil {
+		return nil, err
+	}
+	if !merchant.Active {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusForbidden,
+			Message:    "Merchant is not active",
+		}
+	}
+
+	discountRule, err := s.getDiscountRule(ctx, req.DiscountRuleID)
+	if err != nil {
+		return nil, err
+	}
+	if discountRule.MerchantId == nil || *discountRule.MerchantId != merchant.ID {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusForbidden,
+			Message:    "Discount rule is not issued by this merchant",
+		}
+	}
+
+	ticketNumber := strings.TrimSpace(req.TicketNumber)
+	if !ticket.Valid(ticketNumber) {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Invalid ticket number",
+		}
+	}
+	parkingSession, err := s.parkingLotRepo.GetParkingSessionByTicket(ctx, ticketNumber)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "ticket not found",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	switch {
+	case parkingSession.Status != models.ParkingSessionStatusOpen:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Ticket has already been used",
+		}
+	case parkingSession.PaidAt != nil:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Ticket has already been paid",
+		}
+	case parkingSession.ParkingLotId != merchant.ParkingLotId:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Ticket is for another parking lot",
+		}
+	}
+
+	validation := validationFromRule(discountRule, parkingSession.ID)
+	err = s.parkingLotRepo.CreateValidation(ctx, validation)
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusConflict,
+				Message:    "Ticket has already been validated with this discount",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	return toValidationResponse(validation, parkingSession.TicketNumber), nil
+}
+
+// getMerchant fetches a merchant from the repo and maps a missing merchant to a 404 response.
+func (s *impl) getMerchant(ctx context.Context, merchantId uint) (*models.Merchant, error) {
+	merchant, err := s.parkingLotRepo.GetMerchantById(ctx, merchantId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "merchant not found",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	if err = checkParkingLotAccess(ctx, merchant.ParkingLotId); err != nil {
+		return nil, err
+	}
+	return merchant, nil
+}
+
+// getDiscountRule fetches a discount rule from the repo and maps a missing rule to a 404 response.
+func (s *impl) getDiscountRule(ctx context.Context, discountRuleId uint) (*models.DiscountRule, error) {
+	discountRule, err := s.parkingLotRepo.GetDiscountRuleById(ctx, discountRuleId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "discount rule not found",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	if err = checkParkingLotAccess(ctx, discountRule.ParkingLotId); err != nil {
+		return nil, err
+	}
+	return discountRule, nil
+}
+
+// getSessionDiscounts returns the validations of a parking session, together with the discount code given at
+// exit. The validation of the discount code is not stored yet.
+func (s *impl) getSessionDiscounts(ctx context.Context, parkingSession *models.ParkingSession,
+	discountCode string) ([]*models.Validation, error) {
+
+	validations, err := s.parkingLotRepo.GetValidations(ctx, parkingSession.ID)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	discountCode = strings.ToUpper(strings.TrimSpace(discountCode))
+	if discountCode == "" {
+		return validations, nil
+	}
+
+	discountRule, err := s.parkingLotRepo.GetDiscountRuleByCode(ctx, parkingSession.ParkingLotId, discountCode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "discount code not found",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	// The code has been taken off an earlier invoice of the session already
+	for _, validation := range validations {
+		if validation.DiscountRuleId == discountRule.ID {
+			return validations, nil
+		}
+	}
+	return append(validations, validationFromRule(discountRule, parkingSession.ID)), nil
+}
+
+// validationFromRule copies a discount rule onto a validation of a parking session.
+func validationFromRule(discountRule *models.DiscountRule, sessionId uint) *models.Validation {
+	return &models.Validation{
+		SessionId:      sessionId,
+		DiscountRuleId: discountRule.ID,
+		MerchantId:     discountRule.MerchantId,
+		Name:           discountRule.Name,
+		Kind:           discountRule.Kind,
+		Value:          discountRule.Value,
+		FixedAmount:    discountRule.FixedAmount,
+		MaxAmount:      discountRule.MaxAmount,
+		Currency:       discountRule.Currency,
+		Stackable:      discountRule.Stackable,
+	}
+}
+
+// toDiscountLines lists the validations that took something off a fare in the given currency as receipt lines.
+func toDiscountLines(validations []*models.Validation, currency string) []model.DiscountLine {
+	lines := make([]model.DiscountLine, 0, len(validations))
+	for _, validation := range validations {
+		if validation.Amount <= 0 {
+			continue
+		}
+		lines = append(lines, model.DiscountLine{
+			Name:       validation.Name,
+			Kind:       string(validation.Kind),
+			MerchantID: validation.MerchantId,
+			Amount:     money.New(validation.Amount, currency),
+		})
+	}
+	return lines
+}
+
+func toMerchantResponse(merchant *models.Merchant) *model.MerchantResponse {
+	return &model.MerchantResponse{
+		ID:           merchant.ID,
+		ParkingLotID: merchant.ParkingLotId,
+		Name:         merchant.Name,
+		Active:       merchant.Active,
+		CreatedAt:    merchant.CreatedAt,
+	}
+}
+
+func toDiscountRuleResponse(discountRule *models.DiscountRule) *model.DiscountRuleResponse {
+	return &model.DiscountRuleResponse{
+		ID:           discountRule.ID,
+		ParkingLotID: discountRule.ParkingLotId,
+		Name:         discountRule.Name,
+		Kind:         string(discountRule.Kind),
+		Value:        discountRule.Value,
+		Amount:       optionalMoney(discountRule.FixedAmount, discountRule.Currency),
+		MaxAmount:    optionalMoney(discountRule.MaxAmount, discountRule.Currency),
+		Stackable:    discountRule.Stackable,
+		MerchantID:   discountRule.MerchantId,
+		Code:         discountRule.Code,
+		CreatedAt:    discountRule.CreatedAt,
+	}
+}
+
+func toValidationResponse(validation *models.Validation, ticketNumber string) *model.ValidationResponse {
+	return &model.ValidationResponse{
+		ID:             validation.ID,
+		TicketNumber:   ticketNumber,
+		DiscountRuleID: validation.DiscountRuleId,
+		MerchantID:     validation.MerchantId,
+		Name:           validation.Name,
+		Kind:           string(validation.Kind),
+		Value:          validation.Value,
+		Amount:         optionalMoney(validation.FixedAmount, validation.Currency),
+		CreatedAt:      validation.CreatedAt,
+	}
+}
+
+// optionalMoney returns an amount of minor units of a currency, or nil for an amount that is not set.
+func optionalMoney(amount int64, currency string) *money.Money {
+	if amount == 0 {
+		return nil
+	}
+	m := money.New(amount, currency)
+	return &m
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"parking_lot_service/internal/service/payment"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newFakeRepoWithDiscounts returns a fake repo whose lot has two merchants, the first validating one free hour
+// and the second 50% off, and the discount code SAVE10 for a fixed 10 off. Only the free hour and the code stack.
+func newFakeRepoWithDiscounts(totalSpots int) *fakeRepo {
+	fake := newFakeRepo(totalSpots)
+	fake.merchants[1] = &models.Merchant{ID: 1, ParkingLotId: 1, Name: "Cinema", Active: true}
+	fake.merchants[2] = &models.Merchant{ID: 2, ParkingLotId: 1, Name: "Bistro", Active: true}
+
+	merchantId, otherMerchantId, code := uint(1), uint(2), "SAVE10"
+	fake.discountRules[1] = &models.DiscountRule{ID: 1, ParkingLotId: 1, MerchantId: &merchantId, Name: "Movie",
+		Kind: models.DiscountKindFreeHours, Value: 1, Stackable: true}
+	fake.discountRules[2] = &models.DiscountRule{ID: 2, ParkingLotId: 1, MerchantId: &otherMerchantId, Name: "Dinner",
+		Kind: models.DiscountKindPercentage, Value: 50}
+	fake.discountRules[3] = &models.DiscountRule{ID: 3, ParkingLotId: 1, Code: &code, Name: "Welcome",
+		Kind: models.DiscountKindFixedAmount, FixedAmount: 1000, Currency: "INR", Stackable: true}
+	return fake
+}
+
+func TestUnParkVehicle_DiscountsOnReceipt(t *testing.T) {
+	merchantId, otherMerchantId := uint(1), uint(2)
+
+	tests := []struct {
+		name          string
+		validations   map[uint]uint // Discount rule ID by merchant ID
+		discountCode  string
+		wantDiscounts []model.DiscountLine
+		wantFare      money.Money
+	}{
+		{
+			name:          "No discounts",
+			wantDiscounts: []model.DiscountLine{},
+			wantFare:      inr(6000),
+		},
+		{
+			name:         "Merchant validation stacks with the discount code",
+			validations:  map[uint]uint{1: 1},
+			discountCode: " save10 ",
+			wantDiscounts: []model.DiscountLine{
+				{Name: "Movie", Kind: "free-hours", MerchantID: &merchantId, Amount: inr(2000), FormattedAmount: "₹20.00"},
+				{Name: "Welcome", Kind: "fixed-amount", Amount: inr(1000), FormattedAmount: "₹10.00"},
+			},
+			wantFare: inr(3000),
+		},
+		{
+			name:         "Larger non-stackable validation replaces the stack",
+			validations:  map[uint]uint{1: 1, 2: 2},
+			discountCode: "SAVE10",
+			wantDiscounts: []model.DiscountLine{
+				{Name: "Dinner", Kind: "percentage", MerchantID: &otherMerchantId, Amount: inr(3000), FormattedAmount: "₹30.00"},
+			},
+			wantFare: inr(3000),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeRepoWithDiscounts(1)
+			svc := NewParkingLotService(fake, payment.NewLocal())
+
+			parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+				ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0001",
+			})
+			if err != nil {
+				t.Fatalf("ParkVehicle() error = %v", err)
+			}
+			fake.sessions[1].EntryTime = time.Now().Add(-3 * time.Hour)
+			ticketNumber := parked.ParkingTicket.TicketNumber
+
+			for _, merchant := range []uint{1, 2} {
+				discountRuleId, ok := tt.validations[merchant]
+				if !ok {
+					continue
+				}
+				_, err = svc.ValidateTicket(context.Background(), merchant, &model.ValidationRequest{
+					TicketNumber: ticketNumber, DiscountRuleID: discountRuleId,
+				})
+				if err != nil {
+					t.Fatalf("ValidateTicket() merchant %d error = %v", merchant, err)
+				}
+			}
+
+			unparked, err := svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{
+				TicketNumber: ticketNumber, DiscountCode: tt.discountCode,
+			})
+			if err != nil {
+				t.Fatalf("UnParkVehicle() error = %v", err)
+			}
+			receipt := unparked.Parking
+			if receipt.GrossFare != inr(6000) { // 3 hours * HourlyRate: 20
+				t.Errorf("UnParkVehicle() gross fare = %v, want 60.00 INR", receipt.GrossFare)
+			}
+			if !reflect.DeepEqual(receipt.Discounts, tt.wantDiscounts) {
+				t.Errorf("UnParkVehicle() discounts = %+v, want %+v", receipt.Discounts, tt.wantDiscounts)
+			}
+			if receipt.TotalFare != tt.wantFare {
+				t.Errorf("UnParkVehicle() total fare = %v, want %v", receipt.TotalFare, tt.wantFare)
+			}
+			payAndExit(t, svc, unparked)
+			if fare := fake.sessions[1].Fare; fare == nil || inr(*fare) != tt.wantFare {
+				t.Errorf("session fare = %v, want %v", fare, tt.wantFare)
+			}
+		})
+	}
+}
+
+func TestValidateTicket(t *testing.T) {
+	fake := newFakeRepoWithDiscounts(1)
+	svc := NewParkingLotService(fake, payment.NewLocal())
+
+	parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0001",
+	})
+	if err != nil {
+		t.Fatalf("ParkVehicle() error = %v", err)
+	}
+	ticketNumber := parked.ParkingTicket.TicketNumber
+
+	validate := func(merchantId, discountRuleId uint) error {
+		_, err := svc.ValidateTicket(context.Background(), merchantId, &model.ValidationRequest{
+			TicketNumber: ticketNumber, DiscountRuleID: discountRuleId,
+		})
+		return err
+	}
+	wantStatus := func(name string, err error, statusCode int) {
+		t.Helper()
+		var genericErr *genericresponse.GenericResponse
+		if !errors.As(err, &genericErr) || genericErr.StatusCode != statusCode {
+			t.Errorf("ValidateTicket() %s error = %v, want status %d", name, err, statusCode)
+		}
+	}
+
+	wantStatus("rule of another merchant", validate(1, 2), http.StatusForbidden)
+	wantStatus("discount code", validate(1, 3), http.StatusForbidden)
+	if err := validate(1, 1); err != nil {
+		t.Fatalf("ValidateTicket() error = %v", err)
+	}
+	wantStatus("twice", validate(1, 1), http.StatusConflict)
+
+	fake.merchants[2].Active = false
+	wantStatus("inactive merchant", validate(2, 2), http.StatusForbidden)
+
+	_, err = svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{TicketNumber: ticketNumber})
+	if err != nil {
+		t.Fatalf("UnParkVehicle() error = %v", err)
+	}
+	fake.merchants[2].Active = true
+	wantStatus("closed ticket", validate(2, 2), http.StatusConflict)
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"parking_lot_service/internal/events"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/repo"
+	"parking_lot_service/internal/repo/models"
+	"time"
+)
+
+// publishEvent writes an event of a lot to the outbox through the repo of a transaction, so it is published
+// with the change it describes and dropped with it on a rollback.
+func publishEvent(ctx context.Context, txRepo repo.ParkingLotRepo, eventType events.Type,
+	parkingLot *models.ParkingLot, occurredAt time.Time, data any) error {
+
+	event, err := events.New(eventType, parkingLot, occurredAt, data)
+	if err == nil {
+		err = txRepo.AddOutboxEvent(ctx, event)
+	}
+	if err != nil {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    "Unable to publish event",
+		}
+	}
+	return nil
+}
+
+// publishAvailabilityChange publishes LotFull or LotAvailable when a vehicle taking or freeing a spot of a parking
+// space, by the given change of its free spots, filled it up or gave it room again. The change locked the parking
+// space until the transaction ends, so reading it back sees no change of another transaction.
+func publishAvailabilityChange(ctx context.Context, txRepo repo.ParkingLotRepo, parkingLot *models.ParkingLot,
+	vehicleTypeId, change int, occurredAt time.Time) error {
+
+	parkingSpace, err := txRepo.GetParkingSpace(ctx, parkingLot.ID, vehicleTypeId)
+	if err != nil {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    "Unable to fetch parking space",
+		}
+	}
+
+	eventType, ok := events.AvailabilityChange(parkingSpace.AvailableSpots-change, parkingSpace)
+	if !ok {
+		return nil
+	}
+	return publishEvent(ctx, txRepo, eventType, parkingLot, occurredAt, &events.AvailabilityData{
+		VehicleTypeID:  vehicleTypeId,
+		TotalSpots:     parkingSpace.TotalSpots,
+		AvailableSpots: max(parkingSpace.AvailableSpots, 0),
+	})
+}
+
+// publishCapacityChange publishes CapacityChanged for a parking space whose capacity was set, and LotFull or
+// LotAvailable when that filled it up or gave it room. A parking space that did not exist before had no spots.
+func publishCapacityChange(ctx context.Context, txRepo repo.ParkingLotRepo, parkingLot *models.ParkingLot,
+	before, after *models.ParkingSpace, occurredAt time.Time) error {
+
+	if before == nil {
+		before = &models.ParkingSpace{}
+	}
+	err := publishEvent(ctx, txRepo, events.CapacityChanged, parkingLot, occurredAt, &events.CapacityChangedData{
+		VehicleTypeID:      after.VehicleTypeId,
+		PreviousTotalSpots: before.TotalSpots,
+		TotalSpots:         after.TotalSpots,
+		AvailableSpots:     max(after.AvailableSpots, 0),
+		OverCapacity:       after.AvailableSpots < 0,
+	})
+	if err != nil {
+		return err
+	}
+
+	eventType, ok := events.AvailabilityChange(before.AvailableSpots, after)
+	if !ok {
+		return nil
+	}
+	return publishEvent(ctx, txRepo, eventType, parkingLot, occurredAt, &events.AvailabilityData{
+		VehicleTypeID:  after.VehicleTypeId,
+		TotalSpots:     after.TotalSpots,
+		AvailableSpots: max(after.AvailableSpots, 0),
+	})
+}
+
+// getParkingSpaceIfAny reads a parking space in a transaction, nil if the lot has none for the vehicle type.
+func getParkingSpaceIfAny(ctx context.Context, txRepo repo.ParkingLotRepo,
+	parkingLotId, vehicleTypeId int) (*models.ParkingSpace, error) {
+
+	parkingSpace, err := txRepo.GetParkingSpace(ctx, parkingLotId, vehicleTypeId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    "Unable to fetch parking space",
+		}
+	}
+	return parkingSpace, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"parking_lot_service/internal/events"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"parking_lot_service/internal/service/payment"
+	"testing"
+	"time"
+)
+
+// eventTypes returns the types of events in order.
+func eventTypes(published []*models.OutboxEvent) []events.Type {
+	types := make([]events.Type, 0, len(published))
+	for _, event := range published {
+		types = append(types, events.Type(event.Type))
+	}
+	return types
+}
+
+func TestParkAndUnParkVehicle_PublishEvents(t *testing.T) {
+	fake := newFakeRepo(1)
+	svc := NewParkingLotService(fake, payment.NewLocal())
+
+	parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0001",
+	})
+	if err != nil {
+		t.Fatalf("ParkVehicle() error = %v", err)
+	}
+
+	// Taking the only spot fills the pool up
+	published := fake.publishedEvents()
+	if got := eventTypes(published); len(got) != 2 || got[0] != events.VehicleParked || got[1] != events.LotFull {
+		t.Fatalf("events after ParkVehicle() = %v, want VehicleParked and LotFull", got)
+	}
+	var parkedData events.VehicleParkedData
+	if err = json.Unmarshal([]byte(published[0].Payload), &parkedData); err != nil {
+		t.Fatalf("VehicleParked payload %s error = %v", published[0].Payload, err)
+	}
+	if parkedData.TicketNumber != parked.ParkingTicket.TicketNumber || parkedData.SpotID != 1 ||
+		published[0].ParkingLotId != 1 || published[0].TenantId != models.DefaultTenantId {
+		t.Errorf("VehicleParked = %+v with %+v, want the ticket and spot of lot 1", published[0], parkedData)
+	}
+
+	// A vehicle turned away changes nothing, and publishes nothing
+	_, err = svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0002",
+	})
+	if err == nil {
+		t.Fatalf("ParkVehicle() in a full lot error = nil")
+	}
+	if got := len(fake.publishedEvents()); got != 2 {
+		t.Errorf("events after a failed ParkVehicle() = %d, want 2", got)
+	}
+
+	// Invoicing the fare publishes nothing, the vehicle only leaves once it is paid
+	fake.sessions[1].EntryTime = time.Now().Add(-90 * time.Minute)
+	unparked, err := svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{
+		TicketNumber: parked.ParkingTicket.TicketNumber,
+	})
+	if err != nil {
+		t.Fatalf("UnParkVehicle() error = %v", err)
+	}
+	if got := len(fake.publishedEvents()); got != 2 {
+		t.Errorf("events after invoicing = %d, want 2", got)
+	}
+	payAndExit(t, svc, unparked)
+
+	published = fake.publishedEvents()
+	if got := eventTypes(published); len(got) != 4 || got[2] != events.VehicleUnparked || got[3] != events.LotAvailable {
+		t.Fatalf("events after UnParkVehicle() = %v, want VehicleUnparked and LotAvailable to follow", got)
+	}
+	var unparkedData events.VehicleUnparkedData
+	if err = json.Unmarshal([]byte(published[2].Payload), &unparkedData); err != nil {
+		t.Fatalf("VehicleUnparked payload %s error = %v", published[2].Payload, err)
+	}
+	if unparkedData.Fare != 4000 || unparkedData.Currency != "INR" || unparkedData.SpotID != 1 {
+		t.Errorf("VehicleUnparked data = %+v, want the fare of 40 INR and spot 1", unparkedData)
+	}
+	var availability events.AvailabilityData
+	if err = json.Unmarshal([]byte(published[3].Payload), &availability); err != nil {
+		t.Fatalf("LotAvailable payload %s error = %v", published[3].Payload, err)
+	}
+	if availability != (events.AvailabilityData{VehicleTypeID: 1, TotalSpots: 1, AvailableSpots: 1}) {
+		t.Errorf("LotAvailable data = %+v, want 1 of 1 spots free", availability)
+	}
+}
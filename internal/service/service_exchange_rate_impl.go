@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"strings"
+)
+
+func (s *impl) GetExchangeRates(ctx context.Context) ([]*model.ExchangeRateResponse, error) {
+	exchangeRates, err := s.parkingLotRepo.GetExchangeRates(ctx)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	resp := make([]*model.ExchangeRateResponse, 0, len(exchangeRates))
+	for _, exchangeRate := range exchangeRates {
+		resp = append(resp, toExchangeRateResponse(exchangeRate))
+	}
+	return resp, nil
+}
+
+func (s *impl) SaveExchangeRate(ctx context.Context, req *model.ExchangeRateRequest) (*model.ExchangeRateResponse, error) {
+	// Exchange rates are shared by every tenant
+	if err := checkPlatform(ctx, "change exchange rates"); err != nil {
+		return nil, err
+	}
+	exchangeRate := &models.ExchangeRate{
+		BaseCurrency:  strings.ToUpper(strings.TrimSpace(req.BaseCurrency)),
+		QuoteCurrency: strings.ToUpper(strings.TrimSpace(req.QuoteCurrency)),
+	}
+
+	switch {
+	case !money.ValidCurrency(exchangeRate.BaseCurrency) || !money.ValidCurrency(exchangeRate.QuoteCurrency):
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Currencies must be ISO 4217 currency codes",
+		}
+	case exchangeRate.BaseCurrency == exchangeRate.QuoteCurrency:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "An exchange rate needs two different currencies",
+		}
+	}
+
+	rate, err := money.ParseRate(req.Rate)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Rate must be a positive decimal with at most 10 decimals",
+		}
+	}
+	exchangeRate.Rate = rate.String()
+
+	err = s.parkingLotRepo.SaveExchangeRate(ctx, exchangeRate)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	return toExchangeRateResponse(exchangeRate), nil
+}
+
+func (s *impl) DeleteExchangeRate(ctx context.Context, exchangeRateId uint) error {
+	if err := checkPlatform(ctx, "change exchange rates"); err != nil {
+		return err
+	}
+	err := s.parkingLotRepo.DeleteExchangeRate(ctx, exchangeRateId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "exchange rate not found",
+			}
+		}
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	return nil
+}
+
+func toExchangeRateResponse(exchangeRate *models.ExchangeRate) *model.ExchangeRateResponse {
+	return &model.ExchangeRateResponse{
+		ID:            exchangeRate.ID,
+		BaseCurrency:  exchangeRate.BaseCurrency,
+		QuoteCurrency: exchangeRate.QuoteCurrency,
+		Rate:          exchangeRate.Rate,
+		UpdatedAt:     exchangeRate.UpdatedAt,
+	}
+}
@@ -5,15 +5,18 @@ import (
 	"errors"
 	"gorm.io/gorm"
 	"net/http"
+	"parking_lot_service/internal/auth"
 	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/repo/models"
 	"parking_lot_service/internal/service/model"
+	"time"
 )
 
 func (s *impl) GetFreeParkingSpaces(ctx context.Context) ([]*model.FreeSpotsResponse, error) {
 
-	resp, err := s.parkingLotRepo.GetParkingSpaces(ctx)
-	if err != nil || len(resp) == 0 {
-		if errors.Is(err, gorm.ErrRecordNotFound) || len(resp) == 0 {
+	parkingLots, err := s.parkingLotRepo.GetParkingLots(ctx)
+	if err != nil || len(parkingLots) == 0 {
+		if errors.Is(err, gorm.ErrRecordNotFound) || len(parkingLots) == 0 {
 			return nil, &genericresponse.GenericResponse{
 				StatusCode: http.StatusNotFound,
 				Message:    "Record Not Found",
@@ -24,72 +27,134 @@ func (s *impl) GetFreeParkingSpaces(ctx context.Context) ([]*model.FreeSpotsResp
 			Message:    err.Error(),
 		}
 	}
-	var freeSpotsResponses []*model.FreeSpotsResponse
 
-	for i := 1; i <= 2; i++ {
-		motorcycleSpots := 0
-		carSuvSpots := 0
-		busTruckSpots := 0
-		for _, parkingSpace := range resp {
-			if i == int(parkingSpace.ParkingLotId) {
-				switch parkingSpace.VehicleTypeId {
-				case 1: // Motorcycles/scooters
-					motorcycleSpots += parkingSpace.AvailableSpots
-				case 2: // Cars/SUVs
-					carSuvSpots += parkingSpace.AvailableSpots
-				case 3: // Buses/Trucks
-					busTruckSpots += parkingSpace.AvailableSpots
-				}
-			}
+	vehicleTypes, err := s.parkingLotRepo.GetVehicleTypes(ctx)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
 		}
-		freeSpotsResponses = append(freeSpotsResponses, &model.FreeSpotsResponse{
-			ParkingLotID:                    i,
-			FreeSpotsForMotorcyclesScooters: motorcycleSpots,
-			FreeSpotsForCarsSUVs:            carSuvSpots,
-			FreeSpotsForBusesTrucks:         busTruckSpots,
-		})
+	}
+
+	resp, err := s.parkingLotRepo.GetSpotAvailability(ctx, 0)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	holds, err := s.getSpotHolds(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group the availability by lot so every lot in the catalogue gets an entry, even without spots
+	availabilityByLot := make(map[int][]*models.SpotAvailability, len(parkingLots))
+	for _, availability := range resp {
+		availabilityByLot[availability.ParkingLotId] = append(availabilityByLot[availability.ParkingLotId], availability)
+	}
+	holdsByLot := make(map[int][]*models.SpotHold, len(parkingLots))
+	for _, hold := range holds {
+		holdsByLot[hold.ParkingLotId] = append(holdsByLot[hold.ParkingLotId], hold)
+	}
+
+	var freeSpotsResponses []*model.FreeSpotsResponse
+	for _, parkingLot := range parkingLots {
+		if !auth.CanAccessParkingLot(ctx, parkingLot.ID) {
+			continue
+		}
+		freeSpotsResponses = append(freeSpotsResponses,
+			toFreeSpotsResponse(parkingLot, vehicleTypes, availabilityByLot[parkingLot.ID], holdsByLot[parkingLot.ID]))
 	}
 	return freeSpotsResponses, nil
 }
 
 func (s *impl) GetFreeParkingSpaceById(ctx context.Context, parkingLotId int) (*model.FreeSpotsResponse, error) {
 
-	resp, err := s.parkingLotRepo.GetFreeParkingSpaceById(ctx, parkingLotId)
+	parkingLot, err := s.getParkingLot(ctx, parkingLotId)
+	if err != nil {
+		return nil, err
+	}
 
-	if err != nil || len(resp) == 0 {
-		if errors.Is(err, gorm.ErrRecordNotFound) || len(resp) == 0 {
-			return nil, &genericresponse.GenericResponse{
-				StatusCode: http.StatusNotFound,
-				Message:    "parking lot not found",
-			}
+	vehicleTypes, err := s.parkingLotRepo.GetVehicleTypes(ctx)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
 		}
+	}
+
+	resp, err := s.parkingLotRepo.GetSpotAvailability(ctx, parkingLotId)
+	if err != nil {
 		return nil, &genericresponse.GenericResponse{
 			StatusCode: http.StatusInternalServerError,
 			Message:    err.Error(),
 		}
 	}
 
-	var (
-		motorcycleSpots = 0
-		carSuvSpots     = 0
-		busTruckSpots   = 0
-	)
-
-	for _, parkingSpace := range resp {
-		switch parkingSpace.VehicleTypeId {
-		case 1: // Motorcycles/scooters
-			motorcycleSpots += parkingSpace.AvailableSpots
-		case 2: // Cars/SUVs
-			carSuvSpots += parkingSpace.AvailableSpots
-		case 3: // Buses/Trucks
-			busTruckSpots += parkingSpace.AvailableSpots
+	holds, err := s.getSpotHolds(ctx, parkingLotId)
+	if err != nil {
+		return nil, err
+	}
+
+	return toFreeSpotsResponse(parkingLot, vehicleTypes, resp, holds), nil
+}
+
+// getSpotHolds returns the spots currently held back from walk-in vehicles for reservations and pass holders.
+// A zero parking lot ID returns the holds of all lots.
+func (s *impl) getSpotHolds(ctx context.Context, parkingLotId int) ([]*models.SpotHold, error) {
+	holds, err := s.parkingLotRepo.GetReservationHolds(ctx, parkingLotId, time.Now())
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
 		}
 	}
 
+	passHolds, err := s.parkingLotRepo.GetPassHolds(ctx, parkingLotId)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	for _, passHold := range passHolds {
+		holds = append(holds, &models.SpotHold{
+			ParkingLotId:  passHold.ParkingLotId,
+			VehicleTypeId: passHold.VehicleTypeId,
+			HeldSpots:     passHold.HeldSpots,
+		})
+	}
+	return holds, nil
+}
+
+// toFreeSpotsResponse lists the free spots of a lot per registered vehicle type, less the spots held for
+// reservations and pass holders. Vehicle types without a free spot in the lot are listed with zero free spots.
+func toFreeSpotsResponse(parkingLot *models.ParkingLot, vehicleTypes []*models.VehicleType,
+	availability []*models.SpotAvailability, holds []*models.SpotHold) *model.FreeSpotsResponse {
+
+	freeSpotsByVehicleType := make(map[int]int, len(vehicleTypes))
+	for _, spotAvailability := range availability {
+		freeSpotsByVehicleType[spotAvailability.VehicleTypeId] += spotAvailability.FreeSpots
+	}
+	for _, hold := range holds {
+		freeSpotsByVehicleType[hold.VehicleTypeId] = max(freeSpotsByVehicleType[hold.VehicleTypeId]-hold.HeldSpots, 0)
+	}
+
+	freeSpots := make([]*model.VehicleTypeFreeSpots, 0, len(vehicleTypes))
+	for _, vehicleType := range vehicleTypes {
+		freeSpots = append(freeSpots, &model.VehicleTypeFreeSpots{
+			VehicleID:   vehicleType.ID,
+			Code:        vehicleType.Code,
+			DisplayName: vehicleType.DisplayName,
+			FreeSpots:   freeSpotsByVehicleType[vehicleType.ID],
+		})
+	}
+
 	return &model.FreeSpotsResponse{
-		ParkingLotID:                    parkingLotId,
-		FreeSpotsForMotorcyclesScooters: motorcycleSpots,
-		FreeSpotsForCarsSUVs:            carSuvSpots,
-		FreeSpotsForBusesTrucks:         busTruckSpots,
-	}, nil
+		ParkingLotID:   parkingLot.ID,
+		ParkingLotName: parkingLot.Name,
+		FreeSpots:      freeSpots,
+	}
 }
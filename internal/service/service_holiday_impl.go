@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/fare"
+	"parking_lot_service/internal/service/model"
+	"strings"
+	"time"
+)
+
+func (s *impl) GetHolidays(ctx context.Context, parkingLotId int) ([]*model.HolidayResponse, error) {
+	if _, err := s.getParkingLot(ctx, parkingLotId); err != nil {
+		return nil, err
+	}
+
+	holidays, err := s.parkingLotRepo.GetHolidays(ctx, parkingLotId, "", "")
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	resp := make([]*model.HolidayResponse, 0, len(holidays))
+	for _, holiday := range holidays {
+		resp = append(resp, toHolidayResponse(holiday))
+	}
+	return resp, nil
+}
+
+func (s *impl) CreateHoliday(ctx context.Context, parkingLotId int,
+	req *model.HolidayRequest) (*model.HolidayResponse, error) {
+
+	if _, err := s.getParkingLot(ctx, parkingLotId); err != nil {
+		return nil, err
+	}
+
+	holiday := &models.Holiday{
+		ParkingLotId: parkingLotId,
+		Date:         strings.TrimSpace(req.Date),
+		Name:         strings.TrimSpace(req.Name),
+	}
+	if _, err := time.Parse(fare.DateLayout, holiday.Date); err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Holiday date must be a date as YYYY-MM-DD",
+		}
+	}
+	if holiday.Name == "" {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Holiday name is required",
+		}
+	}
+
+	err := s.parkingLotRepo.CreateHoliday(ctx, holiday)
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusConflict,
+				Message:    "Parking lot already has a holiday on " + holiday.Date,
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	return toHolidayResponse(holiday), nil
+}
+
+func (s *impl) DeleteHoliday(ctx context.Context, holidayId uint) error {
+	holiday, err := s.parkingLotRepo.GetHolidayById(ctx, holidayId)
+	if err != nil {
+		return holidayError(err)
+	}
+	if err = checkParkingLotAccess(ctx, holiday.ParkingLotId); err != nil {
+		return err
+	}
+
+	err = s.parkingLotRepo.DeleteHoliday(ctx, holidayId)
+	if err != nil {
+		return holidayError(err)
+	}
+	return nil
+}
+
+// holidayError maps a missing holiday to a 404 response and any other repo error to a 500 response.
+func holidayError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    "holiday not found",
+		}
+	}
+	return &genericresponse.GenericResponse{
+		StatusCode: http.StatusInternalServerError,
+		Message:    err.Error(),
+	}
+}
+
+// getHolidayCalendar returns the public holidays of a parking lot on the local days of a stay, including the
+// day before it whose evening band window may run into the stay.
+func (s *impl) getHolidayCalendar(ctx context.Context, parkingLotId int, location *time.Location,
+	from, to time.Time) (fare.Calendar, error) {
+
+	holidays, err := s.parkingLotRepo.GetHolidays(ctx, parkingLotId,
+		from.In(location).AddDate(0, 0, -1).Format(fare.DateLayout), to.In(location).Format(fare.DateLayout))
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	calendar := make(fare.Calendar, len(holidays))
+	for _, holiday := range holidays {
+		calendar[holiday.Date] = true
+	}
+	return calendar, nil
+}
+
+func toHolidayResponse(holiday *models.Holiday) *model.HolidayResponse {
+	return &model.HolidayResponse{
+		ID:           holiday.ID,
+		ParkingLotID: holiday.ParkingLotId,
+		Date:         holiday.Date,
+		Name:         holiday.Name,
+	}
+}
@@ -0,0 +1,460 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"parking_lot_service/internal/service/payment"
+	"strings"
+	"time"
+)
+
+var (
+	// errPaymentDeclined rolls back a payment or refund the gateway turned down.
+	errPaymentDeclined = errors.New("payment declined")
+	// errGatewayUnavailable rolls back a payment or refund the gateway could not be asked for.
+	errGatewayUnavailable = errors.New("payment gateway unavailable")
+)
+
+func (s *impl) GetInvoiceById(ctx context.Context, invoiceId uint) (*model.InvoiceResponse, error) {
+	invoice, err := s.getInvoice(ctx, invoiceId)
+	if err != nil {
+		return nil, err
+	}
+	return toInvoiceResponse(invoice), nil
+}
+
+// PayInvoice pays an open invoice through a payment gateway, in full or in part.
+func (s *impl) PayInvoice(ctx context.Context, invoiceId uint, req *model.PaymentRequest) (
+	*model.InvoiceResponse, error) {
+
+	invoice, err := s.getInvoice(ctx, invoiceId)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice, err = s.chargeInvoice(ctx, invoice, req.Gateway, req.Amount, req.Token)
+	if err != nil {
+		return nil, err
+	}
+	return toInvoiceResponse(invoice), nil
+}
+
+// BillInvoiceToAccount settles an open invoice by billing what is left to pay to the account of the customer,
+// the vehicle may then leave without paying at the lot.
+func (s *impl) BillInvoiceToAccount(ctx context.Context, invoiceId uint, req *model.AccountBillingRequest) (
+	*model.InvoiceResponse, error) {
+
+	invoice, err := s.getInvoice(ctx, invoiceId)
+	if err != nil {
+		return nil, err
+	}
+	if err = invoiceClosedError(invoice); err != nil {
+		return nil, err
+	}
+
+	accountReference := strings.TrimSpace(req.AccountReference)
+	if accountReference == "" || len(accountReference) > 100 {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Account reference must be between 1 and 100 characters",
+		}
+	}
+
+	err = s.parkingLotRepo.WithTx(ctx, func(txRepo repo.ParkingLotRepo) error {
+		billed, err := txRepo.BillInvoiceToAccount(ctx, invoice.ID, accountReference, time.Now())
+		if err != nil {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    "Unable to bill invoice",
+			}
+		}
+		if !billed {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusConflict,
+				Message:    "Invoice has been settled or replaced by a concurrent request",
+			}
+		}
+		return s.settleParkingSession(ctx, txRepo, invoice.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invoice, err = s.getInvoice(ctx, invoice.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toInvoiceResponse(invoice), nil
+}
+
+// chargeInvoice charges an amount of an open invoice through a payment gateway, a zero amount charges its
+// balance. The invoice stays locked while the gateway is charged, so concurrent payments cannot pay more
+// than the balance. A charge that does not go through is recorded as a failed payment. It returns the invoice
+// with its payments.
+func (s *impl) chargeInvoice(ctx context.Context, invoice *models.Invoice, gatewayName string, amount money.Money,
+	token string) (*models.Invoice, error) {
+
+	if err := invoiceClosedError(invoice); err != nil {
+		return nil, err
+	}
+
+	gateway, err := s.gateways.Get(strings.TrimSpace(gatewayName))
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Unknown payment gateway, use one of " + strings.Join(s.gateways.Names(), ", "),
+		}
+	}
+
+	if amount.IsZero() {
+		amount = money.New(invoice.Balance(), invoice.Currency)
+	}
+	switch {
+	case amount.Currency != invoice.Currency:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Payments must be in the currency of the invoice, " + invoice.Currency,
+		}
+	case !amount.IsPositive():
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Amount must be more than zero",
+		}
+	case amount.Amount > invoice.Balance():
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Amount is more than the balance of the invoice",
+		}
+	}
+
+	charge := &models.Payment{
+		InvoiceId: invoice.ID,
+		Kind:      models.PaymentKindCharge,
+		Gateway:   gateway.Name(),
+		Currency:  amount.Currency,
+		Amount:    amount.Amount,
+		Status:    models.PaymentStatusPending,
+	}
+	err = s.parkingLotRepo.CreatePayment(ctx, charge)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	err = s.parkingLotRepo.WithTx(ctx, func(txRepo repo.ParkingLotRepo) error {
+		paid, err := txRepo.PayInvoice(ctx, invoice.ID, amount.Amount, time.Now())
+		if err != nil {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    "Unable to pay invoice",
+			}
+		}
+		if !paid {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusConflict,
+				Message:    "Invoice has been paid, settled or replaced by a concurrent request",
+			}
+		}
+
+		result, err := gateway.Charge(ctx, &payment.Charge{
+			Reference: paymentReference(charge.ID),
+			Amount:    amount,
+			Token:     token,
+		})
+		switch {
+		case err != nil:
+			charge.Message = err.Error()
+			return errGatewayUnavailable
+		case !result.Approved:
+			charge.Message = result.Message
+			return errPaymentDeclined
+		}
+
+		charge.Status = models.PaymentStatusSucceeded
+		charge.Reference = result.Reference
+		err = txRepo.CompletePayment(ctx, charge)
+		if err == nil {
+			err = s.settleParkingSession(ctx, txRepo, invoice.ID)
+		}
+		if err != nil {
+			// The charge went through but could not be recorded, pay it back
+			s.reverseCharge(ctx, gateway, charge, amount)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, s.failPayment(ctx, charge, err)
+	}
+
+	return s.getInvoice(ctx, invoice.ID)
+}
+
+// reverseCharge pays back a charge that was approved by its gateway, best effort.
+func (s *impl) reverseCharge(ctx context.Context, gateway payment.PaymentGateway, charge *models.Payment,
+	amount money.Money) {
+
+	_, _ = gateway.Refund(ctx, &payment.Refund{
+		Reference:        paymentReference(charge.ID) + "-reversal",
+		PaymentReference: charge.Reference,
+		Amount:           amount,
+	})
+}
+
+// failPayment records that a payment or refund did not go through and returns the error to answer with.
+func (s *impl) failPayment(ctx context.Context, failed *models.Payment, err error) error {
+	var genericErr *genericresponse.GenericResponse
+	if errors.As(err, &genericErr) {
+		failed.Message = genericErr.Message
+	}
+	failed.Status = models.PaymentStatusFailed
+	if completeErr := s.parkingLotRepo.CompletePayment(ctx, failed); completeErr != nil {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    completeErr.Error(),
+		}
+	}
+
+	switch {
+	case errors.Is(err, errPaymentDeclined):
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusPaymentRequired,
+			Message:    "Payment was declined: " + failed.Message,
+		}
+	case errors.Is(err, errGatewayUnavailable):
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadGateway,
+			Message:    "Payment gateway " + failed.Gateway + " could not be reached",
+		}
+	}
+	return err
+}
+
+// settleParkingSession pays the stay of the parking session of an invoice up to the time the invoice priced it
+// to, once the invoice has been paid in full or billed to an account. The fares of the invoice are added to
+// what the session paid before.
+func (s *impl) settleParkingSession(ctx context.Context, txRepo repo.ParkingLotRepo, invoiceId uint) error {
+	invoice, err := txRepo.GetInvoiceById(ctx, invoiceId)
+	if err != nil {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	if !invoice.Settled() {
+		return nil
+	}
+
+	parkingSession, err := txRepo.GetParkingSessionByTicket(ctx, invoice.TicketNumber)
+	if err != nil {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	grossFare, fare := invoice.GrossFare, invoice.Amount
+	if parkingSession.PaidAt != nil && parkingSession.GrossFare != nil && parkingSession.Fare != nil {
+		grossFare += *parkingSession.GrossFare
+		fare += *parkingSession.Fare
+	}
+	parkingSession.PaidAt = &invoice.PricedAt
+	parkingSession.Currency = invoice.Currency
+	parkingSession.GrossFare = &grossFare
+	parkingSession.Fare = &fare
+
+	err = txRepo.PayParkingSession(ctx, parkingSession)
+	if err != nil {
+		// The vehicle has left or its stay has been paid by a concurrent request
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusConflict,
+				Message:    "Ticket has already been paid or used",
+			}
+		}
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    "Unable to pay parking session",
+		}
+	}
+	return nil
+}
+
+// getPendingInvoice returns the pending invoice of a parking session, which pricing its stay again replaces,
+// or nil when it has none. A stay whose invoice has been paid in part is not priced again until the balance
+// has been paid or billed to an account.
+func (s *impl) getPendingInvoice(ctx context.Context, parkingSession *models.ParkingSession) (
+	*models.Invoice, error) {
+
+	invoice, err := s.parkingLotRepo.GetOpenInvoice(ctx, parkingSession.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	if invoice.Status == models.InvoiceStatusPartiallyPaid {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusPaymentRequired,
+			Message: fmt.Sprintf("Invoice %d has been paid in part, pay its balance or bill it to an account",
+				invoice.ID),
+		}
+	}
+	return invoice, nil
+}
+
+// issueInvoice invoices the priced stay of a parking session up to pricedAt in place of its pending invoice,
+// and records what every discount took off the fare.
+func (s *impl) issueInvoice(ctx context.Context, parkingSession *models.ParkingSession,
+	pendingInvoice *models.Invoice, stay *stayFare, pricedAt time.Time) (*models.Invoice, error) {
+
+	invoice := &models.Invoice{
+		SessionId:    parkingSession.ID,
+		TicketNumber: parkingSession.TicketNumber,
+		ParkingLotId: parkingSession.ParkingLotId,
+		Currency:     stay.netFare.Currency,
+		GrossFare:    stay.grossFare.Amount,
+		Amount:       stay.netFare.Amount,
+		PricedAt:     pricedAt,
+	}
+	err := s.parkingLotRepo.WithTx(ctx, func(txRepo repo.ParkingLotRepo) error {
+		err := s.voidPendingInvoice(ctx, txRepo, pendingInvoice)
+		if err != nil {
+			return err
+		}
+
+		err = txRepo.CreateInvoice(ctx, invoice)
+		if err != nil {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    "Unable to create invoice",
+			}
+		}
+
+		if len(stay.validations) > 0 {
+			err = txRepo.SaveValidations(ctx, stay.validations)
+			if err != nil {
+				return &genericresponse.GenericResponse{
+					StatusCode: http.StatusInternalServerError,
+					Message:    "Unable to save discounts",
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// voidPendingInvoice voids the pending invoice of a parking session, if it has one, once its stay has been
+// priced again.
+func (s *impl) voidPendingInvoice(ctx context.Context, txRepo repo.ParkingLotRepo,
+	pendingInvoice *models.Invoice) error {
+
+	if pendingInvoice == nil {
+		return nil
+	}
+	err := txRepo.VoidInvoice(ctx, pendingInvoice.ID)
+	if err != nil {
+		// The invoice has been paid by a concurrent request
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusConflict,
+				Message:    fmt.Sprintf("Invoice %d has been paid by a concurrent request", pendingInvoice.ID),
+			}
+		}
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    "Unable to void invoice",
+		}
+	}
+	return nil
+}
+
+// getInvoice resolves an invoice with its payments.
+func (s *impl) getInvoice(ctx context.Context, invoiceId uint) (*models.Invoice, error) {
+	invoice, err := s.parkingLotRepo.GetInvoiceById(ctx, invoiceId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "invoice not found",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	if err = checkParkingLotAccess(ctx, invoice.ParkingLotId); err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// invoiceClosedError explains why an invoice can no longer be paid, it returns nil for an open invoice.
+func invoiceClosedError(invoice *models.Invoice) error {
+	switch invoice.Status {
+	case models.InvoiceStatusPending, models.InvoiceStatusPartiallyPaid:
+		return nil
+	case models.InvoiceStatusVoid:
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Invoice has been replaced by a later invoice",
+		}
+	}
+	return &genericresponse.GenericResponse{
+		StatusCode: http.StatusConflict,
+		Message:    "Invoice has already been settled",
+	}
+}
+
+// paymentReference identifies a payment or refund at its gateway.
+func paymentReference(paymentId uint) string {
+	return fmt.Sprintf("payment-%d", paymentId)
+}
+
+func toInvoiceResponse(invoice *models.Invoice) *model.InvoiceResponse {
+	payments := make([]model.PaymentResponse, 0, len(invoice.Payments))
+	for i := range invoice.Payments {
+		payments = append(payments, *toPaymentResponse(&invoice.Payments[i]))
+	}
+	return &model.InvoiceResponse{
+		ID:               invoice.ID,
+		TicketNumber:     invoice.TicketNumber,
+		ParkingLotID:     invoice.ParkingLotId,
+		Status:           string(invoice.Status),
+		GrossFare:        money.New(invoice.GrossFare, invoice.Currency),
+		Amount:           money.New(invoice.Amount, invoice.Currency),
+		PaidAmount:       money.New(invoice.PaidAmount, invoice.Currency),
+		RefundedAmount:   money.New(invoice.RefundedAmount, invoice.Currency),
+		Balance:          money.New(invoiceBalance(invoice), invoice.Currency),
+		AccountReference: invoice.AccountReference,
+		PricedAt:         invoice.PricedAt,
+		SettledAt:        invoice.SettledAt,
+		Payments:         payments,
+		CreatedAt:        invoice.CreatedAt,
+	}
+}
+
+// invoiceBalance returns what is left to pay of an invoice, nothing once it is settled or void.
+func invoiceBalance(invoice *models.Invoice) int64 {
+	if invoice.Settled() || invoice.Status == models.InvoiceStatusVoid {
+		return 0
+	}
+	return invoice.Balance()
+}
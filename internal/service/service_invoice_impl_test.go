@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"parking_lot_service/internal/service/payment"
+	"testing"
+	"time"
+)
+
+// parkForInvoice parks a vehicle that entered 90 minutes ago, a stay of 40.00 INR, and unparks it once.
+func parkForInvoice(t *testing.T, fake *fakeRepo, svc ParkingLotService) *model.UnParkVehicleResponse {
+	t.Helper()
+	parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0001",
+	})
+	if err != nil {
+		t.Fatalf("ParkVehicle() error = %v", err)
+	}
+	fake.sessions[1].EntryTime = time.Now().Add(-90 * time.Minute)
+
+	unparked, err := svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{
+		TicketNumber: parked.ParkingTicket.TicketNumber,
+	})
+	if err != nil {
+		t.Fatalf("UnParkVehicle() error = %v", err)
+	}
+	return unparked
+}
+
+func wantErrorStatus(t *testing.T, name string, err error, statusCode int) {
+	t.Helper()
+	var genericErr *genericresponse.GenericResponse
+	if !errors.As(err, &genericErr) || genericErr.StatusCode != statusCode {
+		t.Errorf("%s error = %v, want status %d", name, err, statusCode)
+	}
+}
+
+func TestUnParkVehicle_ExitsOnceInvoiceIsPaid(t *testing.T) {
+	fake := newFakeRepo(1)
+	svc := NewParkingLotService(fake, payment.NewCash(), payment.NewLocal())
+
+	unparked := parkForInvoice(t, fake, svc)
+	if unparked.Exited || unparked.Invoice == nil {
+		t.Fatalf("UnParkVehicle() = %+v, want a pending invoice", unparked)
+	}
+	invoice := unparked.Invoice
+	if invoice.Status != string(models.InvoiceStatusPending) || invoice.Balance != inr(4000) {
+		t.Errorf("UnParkVehicle() invoice = %+v, want 40.00 INR pending", invoice)
+	}
+	if fake.sessions[1].Status != models.ParkingSessionStatusOpen || fake.occupiedSpots() != 1 {
+		t.Errorf("session status = %q, occupied spots = %d, want the vehicle to stay",
+			fake.sessions[1].Status, fake.occupiedSpots())
+	}
+
+	// Half of the fare is paid in cash at the booth
+	invoice, err := svc.PayInvoice(context.Background(), invoice.ID, &model.PaymentRequest{
+		Gateway: payment.Cash, Amount: inr(1500),
+	})
+	if err != nil {
+		t.Fatalf("PayInvoice() cash error = %v", err)
+	}
+	if invoice.Status != string(models.InvoiceStatusPartiallyPaid) || invoice.Balance != inr(2500) {
+		t.Errorf("PayInvoice() cash = %+v, want 25.00 INR left to pay", invoice)
+	}
+
+	// An invoice paid in part is not priced again and the vehicle does not exit
+	_, err = svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{
+		TicketNumber: unparked.Parking.TicketNumber,
+	})
+	wantErrorStatus(t, "UnParkVehicle() partially paid", err, http.StatusPaymentRequired)
+
+	_, err = svc.PayInvoice(context.Background(), invoice.ID, &model.PaymentRequest{
+		Gateway: payment.Local, Amount: inr(3000),
+	})
+	wantErrorStatus(t, "PayInvoice() more than the balance", err, http.StatusBadRequest)
+	_, err = svc.PayInvoice(context.Background(), invoice.ID, &model.PaymentRequest{
+		Gateway: payment.Local, Token: payment.DeclineToken,
+	})
+	wantErrorStatus(t, "PayInvoice() declined", err, http.StatusPaymentRequired)
+	_, err = svc.PayInvoice(context.Background(), invoice.ID, &model.PaymentRequest{
+		Gateway: payment.Local, Token: payment.UnavailableToken,
+	})
+	wantErrorStatus(t, "PayInvoice() gateway down", err, http.StatusBadGateway)
+
+	invoice, err = svc.PayInvoice(context.Background(), invoice.ID, &model.PaymentRequest{Gateway: payment.Local})
+	if err != nil {
+		t.Fatalf("PayInvoice() balance error = %v", err)
+	}
+	if invoice.Status != string(models.InvoiceStatusPaid) || invoice.PaidAmount != inr(4000) || invoice.SettledAt == nil {
+		t.Errorf("PayInvoice() balance = %+v, want 40.00 INR paid", invoice)
+	}
+	wantStatuses := []models.PaymentStatus{models.PaymentStatusSucceeded, models.PaymentStatusFailed,
+		models.PaymentStatusFailed, models.PaymentStatusSucceeded}
+	if len(invoice.Payments) != len(wantStatuses) {
+		t.Fatalf("PayInvoice() payments = %+v, want %d", invoice.Payments, len(wantStatuses))
+	}
+	for i, want := range wantStatuses {
+		if invoice.Payments[i].Status != string(want) {
+			t.Errorf("payment %d status = %q, want %q", i+1, invoice.Payments[i].Status, want)
+		}
+	}
+	_, err = svc.PayInvoice(context.Background(), invoice.ID, &model.PaymentRequest{Gateway: payment.Cash})
+	wantErrorStatus(t, "PayInvoice() settled", err, http.StatusConflict)
+
+	exited, err := svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{
+		TicketNumber: unparked.Parking.TicketNumber,
+	})
+	if err != nil {
+		t.Fatalf("UnParkVehicle() after payment error = %v", err)
+	}
+	if !exited.Exited || exited.Parking.PrepaidFare == nil || *exited.Parking.PrepaidFare != inr(4000) {
+		t.Errorf("UnParkVehicle() after payment = %+v, want an exit with 40.00 INR prepaid", exited)
+	}
+	if fare := fake.sessions[1].Fare; fare == nil || *fare != 4000 || fake.occupiedSpots() != 0 {
+		t.Errorf("session fare = %v, occupied spots = %d, want 4000 and a free spot", fare, fake.occupiedSpots())
+	}
+}
+
+func TestUnParkVehicle_PricesPendingInvoiceAgain(t *testing.T) {
+	fake := newFakeRepo(1)
+	svc := NewParkingLotService(fake, payment.NewLocal())
+
+	first := parkForInvoice(t, fake, svc)
+	fake.sessions[1].EntryTime = time.Now().Add(-150 * time.Minute)
+	second, err := svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{
+		TicketNumber: first.Parking.TicketNumber,
+	})
+	if err != nil {
+		t.Fatalf("UnParkVehicle() again error = %v", err)
+	}
+	if second.Invoice == nil || second.Invoice.ID == first.Invoice.ID || second.Invoice.Amount != inr(6000) {
+		t.Errorf("UnParkVehicle() again invoice = %+v, want a new invoice of 60.00 INR", second.Invoice)
+	}
+
+	_, err = svc.PayInvoice(context.Background(), first.Invoice.ID, &model.PaymentRequest{Gateway: payment.Local})
+	wantErrorStatus(t, "PayInvoice() replaced invoice", err, http.StatusConflict)
+	if got := fake.invoices[first.Invoice.ID].Status; got != models.InvoiceStatusVoid {
+		t.Errorf("first invoice status = %q, want %q", got, models.InvoiceStatusVoid)
+	}
+}
+
+func TestBillInvoiceToAccount(t *testing.T) {
+	fake := newFakeRepo(1)
+	svc := NewParkingLotService(fake, payment.NewCash())
+
+	unparked := parkForInvoice(t, fake, svc)
+	_, err := svc.BillInvoiceToAccount(context.Background(), unparked.Invoice.ID, &model.AccountBillingRequest{})
+	wantErrorStatus(t, "BillInvoiceToAccount() without an account", err, http.StatusBadRequest)
+
+	invoice, err := svc.BillInvoiceToAccount(context.Background(), unparked.Invoice.ID,
+		&model.AccountBillingRequest{AccountReference: "ACME-042"})
+	if err != nil {
+		t.Fatalf("BillInvoiceToAccount() error = %v", err)
+	}
+	if invoice.Status != string(models.InvoiceStatusAccountBilled) || invoice.Balance != inr(0) ||
+		invoice.AccountReference == nil || *invoice.AccountReference != "ACME-042" {
+		t.Errorf("BillInvoiceToAccount() = %+v, want the invoice billed to ACME-042", invoice)
+	}
+
+	exited, err := svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{
+		TicketNumber: unparked.Parking.TicketNumber,
+	})
+	if err != nil || !exited.Exited {
+		t.Fatalf("UnParkVehicle() after billing = %+v, %v, want an exit", exited, err)
+	}
+}
+
+func TestRefundPayment(t *testing.T) {
+	fake := newFakeRepo(1)
+	svc := NewParkingLotService(fake, payment.NewLocal())
+
+	unparked := parkForInvoice(t, fake, svc)
+	invoice, err := svc.PayInvoice(context.Background(), unparked.Invoice.ID,
+		&model.PaymentRequest{Gateway: payment.Local})
+	if err != nil {
+		t.Fatalf("PayInvoice() error = %v", err)
+	}
+	chargeId := invoice.Payments[0].ID
+
+	refund, err := svc.RefundPayment(context.Background(), chargeId, &model.RefundRequest{
+		Amount: inr(1000), Reason: "Barrier fault",
+	})
+	if err != nil {
+		t.Fatalf("RefundPayment() error = %v", err)
+	}
+	if refund.Kind != string(models.PaymentKindRefund) || refund.Status != string(models.PaymentStatusSucceeded) ||
+		refund.RefundOf == nil || *refund.RefundOf != chargeId || refund.Amount != inr(1000) {
+		t.Errorf("RefundPayment() = %+v, want a refund of 10.00 INR", refund)
+	}
+
+	_, err = svc.RefundPayment(context.Background(), chargeId, &model.RefundRequest{Amount: inr(3500)})
+	wantErrorStatus(t, "RefundPayment() more than left", err, http.StatusBadRequest)
+	_, err = svc.RefundPayment(context.Background(), refund.ID, &model.RefundRequest{})
+	wantErrorStatus(t, "RefundPayment() of a refund", err, http.StatusConflict)
+
+	// Without an amount, the rest of the payment is refunded
+	refund, err = svc.RefundPayment(context.Background(), chargeId, &model.RefundRequest{})
+	if err != nil || refund.Amount != inr(3000) {
+		t.Fatalf("RefundPayment() rest = %+v, %v, want 30.00 INR", refund, err)
+	}
+
+	invoice, err = svc.GetInvoiceById(context.Background(), invoice.ID)
+	if err != nil {
+		t.Fatalf("GetInvoiceById() error = %v", err)
+	}
+	if invoice.Status != string(models.InvoiceStatusPaid) || invoice.RefundedAmount != inr(4000) ||
+		invoice.Payments[0].Status != string(models.PaymentStatusRefunded) {
+		t.Errorf("GetInvoiceById() = %+v, want a paid invoice refunded in full", invoice)
+	}
+}
@@ -5,94 +5,298 @@ import (
 	"errors"
 	"gorm.io/gorm"
 	"net/http"
+	"parking_lot_service/internal/events"
 	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/repo"
 	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/allocation"
 	"parking_lot_service/internal/service/model"
+	"parking_lot_service/internal/ticket"
+	"slices"
 	"time"
 )
 
 func (s *impl) ParkVehicle(ctx context.Context, req *model.ParkVehicleRequest) (*model.ParkVehicleResponse, error) {
-	// Check available parking spots for the given parking lot and vehicle type
-	cnt, err := s.parkingLotRepo.GetAvailableParkingSpotsByParkingLotIdAndVehicleId(ctx,
-		int(req.ParkingLotID), int(req.VehicleID))
-
+	// Fetch the parking lot from the catalogue, only active lots accept new vehicles
+	parkingLot, err := s.getParkingLot(ctx, req.ParkingLotID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, &genericresponse.GenericResponse{
-				StatusCode: http.StatusNotFound,
-				Message:    "Record Not Found",
-			}
+		return nil, err
+	}
+	if parkingLot.Status != models.ParkingLotStatusActive {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Parking lot is closed",
 		}
+	}
+
+	vehicleType, err := s.getVehicleType(ctx, req.VehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy, err := allocation.New(parkingLot.AllocationStrategy)
+	if err != nil {
 		return nil, &genericresponse.GenericResponse{
 			StatusCode: http.StatusInternalServerError,
 			Message:    err.Error(),
 		}
 	}
 
-	// If there are available spots, update the parking space
-	if cnt > 0 {
-		// Decrease available spots by 1
-		updateParkingPayload := &models.ParkingSpace{
-			ParkingLotId:   req.ParkingLotID,
-			VehicleTypeId:  req.VehicleID,
-			AvailableSpots: cnt - 1,
+	overflowVehicleTypes, err := s.getOverflowVehicleTypes(ctx, req.ParkingLotID, vehicleType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue the ticket number the vehicle is unparked with
+	ticketNumber, err := ticket.NewNumber()
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    "Unable to issue a ticket",
 		}
-		// Update the parking space in the repository
-		err = s.parkingLotRepo.UpdateParkingSpace(ctx, updateParkingPayload)
+	}
+
+	var (
+		entryTime   = time.Now()
+		spot        *models.Spot
+		reservation *models.Reservation
+	)
+
+	if req.ReservationID != 0 {
+		reservation, err = s.getReservationForCheckIn(ctx, req, entryTime)
 		if err != nil {
-			return nil, &genericresponse.GenericResponse{
+			return nil, err
+		}
+	}
+
+	parkingPass, err := s.getActivePass(ctx, req.ParkingLotID, req.VehicleID, req.VehicleNumber, entryTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pools the vehicle may park in, its own and the overflow pools
+	poolVehicleTypeIds := []int{vehicleType.ID}
+	for _, overflowVehicleType := range overflowVehicleTypes {
+		poolVehicleTypeIds = append(poolVehicleTypeIds, overflowVehicleType.ID)
+	}
+
+	// Take a spot and open the parking session in a single transaction, a failed insert gives the spot back
+	err = s.parkingLotRepo.WithTx(ctx, func(txRepo repo.ParkingLotRepo) error {
+		// Reservations and pass products are booked against locked pools, so the holds counted once the pools
+		// are locked stay until the spot is taken
+		err := txRepo.LockParkingSpaces(ctx, req.ParkingLotID, poolVehicleTypeIds)
+		if err != nil {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    "Unable to lock parking spaces",
+			}
+		}
+
+		// Spots held for reservations and pass holders are not given to walk-in vehicles
+		heldSpots, err := s.getReservationHolds(ctx, txRepo, req.ParkingLotID, entryTime)
+		if err != nil {
+			return err
+		}
+		// A vehicle arriving within its window takes the spot held for it
+		if reservation != nil && !entryTime.Before(reservation.StartTime) {
+			heldSpots[reservation.VehicleTypeId]--
+		}
+		if err = s.addPassHolds(ctx, txRepo, heldSpots, req.ParkingLotID, parkingPass); err != nil {
+			return err
+		}
+
+		// Let the lot's allocation strategy pick one of the free spots for the vehicle
+		spot, err = s.claimSpot(ctx, txRepo, strategy, vehicleType, overflowVehicleTypes, heldSpots, req.ParkingLotID)
+		if err != nil {
+			return err
+		}
+
+		// Decrease the available spots of the pool the spot belongs to, only if there is a free spot left
+		reserved, err := txRepo.DecrementAvailableSpots(ctx, req.ParkingLotID, spot.VehicleTypeId,
+			heldSpots[spot.VehicleTypeId])
+		if err != nil {
+			return &genericresponse.GenericResponse{
 				StatusCode: http.StatusInternalServerError,
 				Message:    "Unable to update parking space",
 			}
 		}
-	} else {
-		// No available spots, return error response
-		return nil, &genericresponse.GenericResponse{
-			StatusCode: http.StatusNotFound,
-			Message:    "No Spots Available",
+		if !reserved {
+			return s.noSpotAvailableError(ctx, txRepo, req.ParkingLotID, req.VehicleID)
 		}
-	}
 
-	// Save the parked vehicle details
-	err = s.parkingLotRepo.SaveParkedVehicle(ctx, &models.ParkedVehicle{
-		VehicleNumber: req.VehicleNumber,
-		ParkingLotID:  req.ParkingLotID,
-		VehicleTypeId: req.VehicleID,
-		VehicleName:   req.VehicleName,
-		EntryTime:     time.Now(),
-	})
+		// Open the parking session of the vehicle
+		parkingSession := &models.ParkingSession{
+			TenantId:          parkingLot.TenantId,
+			TicketNumber:      ticketNumber,
+			VehicleNumber:     req.VehicleNumber,
+			ParkingLotId:      req.ParkingLotID,
+			VehicleTypeId:     req.VehicleID,
+			VehicleName:       req.VehicleName,
+			EntryTime:         entryTime,
+			SpotId:            &spot.ID,
+			SpotVehicleTypeId: spot.VehicleTypeId,
+		}
+		if parkingPass != nil {
+			parkingSession.PassId = &parkingPass.ID
+		}
+		err = txRepo.OpenParkingSession(ctx, parkingSession)
 
-	if err != nil {
-		// Handle duplicate key error (vehicle already parked)
-		if errors.Is(err, gorm.ErrDuplicatedKey) {
-			return nil, &genericresponse.GenericResponse{
-				StatusCode: http.StatusBadRequest,
-				Message:    "Vehicle already in parking space",
+		if err != nil {
+			// Handle duplicate key error (vehicle already parked)
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return &genericresponse.GenericResponse{
+					StatusCode: http.StatusBadRequest,
+					Message:    "Vehicle already in parking space",
+				}
+			}
+			// Handle other internal errors
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    err.Error(),
 			}
 		}
-		// Handle other internal errors
-		return nil, &genericresponse.GenericResponse{
-			StatusCode: http.StatusInternalServerError,
-			Message:    err.Error(),
+
+		// Convert the reservation into the session, unless it was cancelled or checked in concurrently
+		if reservation != nil {
+			checkedIn, err := txRepo.CheckInReservation(ctx, reservation.ID, parkingSession.ID)
+			if err != nil {
+				return &genericresponse.GenericResponse{
+					StatusCode: http.StatusInternalServerError,
+					Message:    "Unable to check in reservation",
+				}
+			}
+			if !checkedIn {
+				return reservationWriteError(repo.ErrReservationNotBooked)
+			}
+		}
+
+		// Tell the downstream systems about the vehicle, and about the pool of its spot if it is full now
+		parked := &events.VehicleParkedData{
+			TicketNumber:  ticketNumber,
+			VehicleNumber: req.VehicleNumber,
+			VehicleTypeID: req.VehicleID,
+			SpotID:        spot.ID,
+			SpotTypeID:    spot.VehicleTypeId,
+			EntryTime:     entryTime,
 		}
+		if reservation != nil {
+			parked.ReservationID = reservation.ID
+		}
+		if parkingPass != nil {
+			parked.PassID = parkingPass.ID
+		}
+		err = publishEvent(ctx, txRepo, events.VehicleParked, parkingLot, entryTime, parked)
+		if err != nil {
+			return err
+		}
+		return publishAvailabilityChange(ctx, txRepo, parkingLot, spot.VehicleTypeId, -1, entryTime)
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
 	// Prepare the response with parking ticket information
 	resp := &model.ParkVehicleResponse{
 		ParkingTicket: model.ParkingTicket{
+			TicketNumber:  ticketNumber,
 			VehicleNumber: req.VehicleNumber,
-			VehicleID:     int(req.VehicleID),
-			EntryTime:     time.Now(),
+			ParkingLot:    parkingLot.Name,
+			VehicleID:     req.VehicleID,
+			EntryTime:     entryTime,
+			Spot:          toSpotLocation(spot),
 		},
 	}
 
-	// Set the parking lot name based on the ParkingLotID
-	switch req.ParkingLotID {
-	case 1:
-		resp.ParkingTicket.ParkingLot = "Parking Lot A"
-	case 2:
-		resp.ParkingTicket.ParkingLot = "Parking Lot B"
+	return resp, nil
+}
+
+// claimSpot asks the strategy for a spot and occupies it. Once the vehicle type's own pool is full, the pools
+// of the overflow vehicle types are tried in order. Pools whose free spots are all held for reservations are
+// left out. A spot taken by a concurrent request in the meantime is dropped from the candidates and the
+// strategy is asked again.
+func (s *impl) claimSpot(ctx context.Context, txRepo repo.ParkingLotRepo, strategy allocation.AllocationStrategy,
+	vehicleType *models.VehicleType, overflowVehicleTypes []*models.VehicleType, heldSpots map[int]int,
+	parkingLotId int) (*models.Spot, error) {
+
+	freeSpots, err := txRepo.GetFreeSpots(ctx, parkingLotId)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    "Unable to fetch free spots",
+		}
 	}
 
-	return resp, nil
+	freeSpotsByPool := make(map[int]int)
+	for _, freeSpot := range freeSpots {
+		freeSpotsByPool[freeSpot.VehicleTypeId]++
+	}
+	freeSpots = slices.DeleteFunc(freeSpots, func(freeSpot *models.Spot) bool {
+		return freeSpotsByPool[freeSpot.VehicleTypeId] <= heldSpots[freeSpot.VehicleTypeId]
+	})
+
+	for {
+		spot := chooseSpot(strategy, vehicleType, overflowVehicleTypes, freeSpots)
+		if spot == nil {
+			return nil, s.noSpotAvailableError(ctx, txRepo, parkingLotId, vehicleType.ID)
+		}
+
+		claimed, err := txRepo.OccupySpot(ctx, spot.ID)
+		if err != nil {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    "Unable to assign a spot",
+			}
+		}
+		if claimed {
+			spot.Occupied = true
+			return spot, nil
+		}
+
+		freeSpots = slices.DeleteFunc(freeSpots, func(freeSpot *models.Spot) bool {
+			return freeSpot.ID == spot.ID
+		})
+	}
+}
+
+// chooseSpot picks a spot from the vehicle type's own pool and falls back to the overflow pools in order. Larger
+// spots are only taken through the upsize policy of the lot, never by a strategy of its own accord.
+func chooseSpot(strategy allocation.AllocationStrategy, vehicleType *models.VehicleType,
+	overflowVehicleTypes []*models.VehicleType, freeSpots []*models.Spot) *models.Spot {
+
+	for _, poolVehicleType := range append([]*models.VehicleType{vehicleType}, overflowVehicleTypes...) {
+		// Choose a spot of the pool itself, as if a vehicle of its type arrived
+		pool := *poolVehicleType
+		pool.AllowLargerSpots = false
+		if spot := strategy.Choose(&pool, freeSpots); spot != nil {
+			return spot
+		}
+	}
+	return nil
+}
+
+// noSpotAvailableError explains why no spot could be taken: either the lot has no parking space
+// for the vehicle type at all, or all of its spots are taken.
+func (s *impl) noSpotAvailableError(ctx context.Context, txRepo repo.ParkingLotRepo,
+	parkingLotId, vehicleTypeId int) error {
+
+	_, err := txRepo.GetParkingSpace(ctx, parkingLotId, vehicleTypeId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "Record Not Found",
+			}
+		}
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	// No available spots, return error response
+	return &genericresponse.GenericResponse{
+		StatusCode: http.StatusNotFound,
+		Message:    "No Spots Available",
+	}
 }
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/service/model"
+	"sync"
+	"testing"
+)
+
+// The fake repo gives each method the atomicity of the conditional update the GORM implementation issues, the
+// conditions themselves are tested in the repo package.
+func TestParkVehicle_ConcurrentRequestsNeverOversell(t *testing.T) {
+	const (
+		totalSpots = 50
+		requests   = 500
+	)
+
+	fake := newFakeRepo(totalSpots)
+	svc := NewParkingLotService(fake)
+
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		parked     int
+		rejected   int
+		unexpected []error
+		start      = make(chan struct{})
+	)
+
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+
+			_, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+				ParkingLotID:  1,
+				VehicleID:     1,
+				VehicleNumber: fmt.Sprintf("KA-01-%04d", i),
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			var genericErr *genericresponse.GenericResponse
+			switch {
+			case err == nil:
+				parked++
+			case errors.As(err, &genericErr) && genericErr.StatusCode == http.StatusNotFound &&
+				genericErr.Message == "No Spots Available":
+				rejected++
+			default:
+				unexpected = append(unexpected, err)
+			}
+		}(i)
+	}
+
+	close(start)
+	wg.Wait()
+
+	if len(unexpected) > 0 {
+		t.Fatalf("ParkVehicle() unexpected errors = %v", unexpected)
+	}
+	if parked != totalSpots {
+		t.Errorf("ParkVehicle() parked = %d, want %d", parked, totalSpots)
+	}
+	if rejected != requests-totalSpots {
+		t.Errorf("ParkVehicle() rejected = %d, want %d", rejected, requests-totalSpots)
+	}
+	if got := fake.parkingSpaces[[2]int{1, 1}].AvailableSpots; got != 0 {
+		t.Errorf("AvailableSpots = %d, want 0", got)
+	}
+	if got := len(fake.openSessions); got != totalSpots {
+		t.Errorf("open sessions = %d, want %d", got, totalSpots)
+	}
+	if got := fake.occupiedSpots(); got != totalSpots {
+		t.Errorf("occupied spots = %d, want %d", got, totalSpots)
+	}
+
+	// Every parked vehicle must have been given a spot of its own
+	assigned := make(map[uint]string, totalSpots)
+	for _, parkingSession := range fake.openSessions {
+		if parkingSession.SpotId == nil {
+			t.Fatalf("vehicle %s has no spot", parkingSession.VehicleNumber)
+		}
+		if other, ok := assigned[*parkingSession.SpotId]; ok {
+			t.Fatalf("spot %d assigned to %s and %s", *parkingSession.SpotId, other, parkingSession.VehicleNumber)
+		}
+		assigned[*parkingSession.SpotId] = parkingSession.VehicleNumber
+	}
+}
+
+func TestParkVehicle_FailedInsertReleasesSpot(t *testing.T) {
+	fake := newFakeRepo(10)
+	svc := NewParkingLotService(fake)
+	req := &model.ParkVehicleRequest{ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0001"}
+
+	if _, err := svc.ParkVehicle(context.Background(), req); err != nil {
+		t.Fatalf("ParkVehicle() first call error = %v", err)
+	}
+
+	_, err := svc.ParkVehicle(context.Background(), req)
+	var genericErr *genericresponse.GenericResponse
+	if !errors.As(err, &genericErr) || genericErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("ParkVehicle() second call error = %v, want status %d", err, http.StatusBadRequest)
+	}
+
+	if got := fake.parkingSpaces[[2]int{1, 1}].AvailableSpots; got != 9 {
+		t.Errorf("AvailableSpots = %d, want 9", got)
+	}
+	if got := fake.occupiedSpots(); got != 1 {
+		t.Errorf("occupied spots = %d, want 1", got)
+	}
+}
@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"parking_lot_service/internal/auth"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/allocation"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (s *impl) GetParkingLots(ctx context.Context) ([]*model.ParkingLotResponse, error) {
+	parkingLots, err := s.parkingLotRepo.GetParkingLots(ctx)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	resp := make([]*model.ParkingLotResponse, 0, len(parkingLots))
+	for _, parkingLot := range parkingLots {
+		if auth.CanAccessTenant(ctx, parkingLot.TenantId) && auth.CanAccessParkingLot(ctx, parkingLot.ID) {
+			resp = append(resp, toParkingLotResponse(parkingLot))
+		}
+	}
+	return resp, nil
+}
+
+func (s *impl) GetParkingLotById(ctx context.Context, parkingLotId int) (*model.ParkingLotResponse, error) {
+	parkingLot, err := s.getParkingLot(ctx, parkingLotId)
+	if err != nil {
+		return nil, err
+	}
+	return toParkingLotResponse(parkingLot), nil
+}
+
+func (s *impl) CreateParkingLot(ctx context.Context, req *model.ParkingLotRequest) (*model.ParkingLotResponse, error) {
+	parkingLot, err := parkingLotFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	parkingLot.TenantId, err = s.tenantOfNewParkingLot(ctx, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.parkingLotRepo.CreateParkingLot(ctx, parkingLot)
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusConflict,
+				Message:    "Parking lot with this name already exists",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	return toParkingLotResponse(parkingLot), nil
+}
+
+func (s *impl) UpdateParkingLot(ctx context.Context, parkingLotId int,
+	req *model.ParkingLotRequest) (*model.ParkingLotResponse, error) {
+
+	parkingLot, err := parkingLotFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	parkingLot.ID = parkingLotId
+
+	current, err := s.getParkingLot(ctx, parkingLotId)
+	if err != nil {
+		return nil, err
+	}
+	if req.TenantID != 0 && req.TenantID != current.TenantId {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Parking lot cannot move to another tenant",
+		}
+	}
+	if parkingLot.Currency != current.Currency {
+		if err = s.checkCurrencyUnused(ctx, current); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.parkingLotRepo.UpdateParkingLot(ctx, parkingLot)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "parking lot not found",
+			}
+		}
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusConflict,
+				Message:    "Parking lot with this name already exists",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	return s.GetParkingLotById(ctx, parkingLotId)
+}
+
+// checkCurrencyUnused refuses to change the currency of a parking lot once anything is priced in it. Tariffs,
+// passes, discounts and validations keep their amounts in the currency of the lot.
+func (s *impl) checkCurrencyUnused(ctx context.Context, parkingLot *models.ParkingLot) error {
+	priced := []struct {
+		name  string
+		count func(ctx context.Context, parkingLotId int) (int64, error)
+	}{
+		{name: "tariffs", count: s.parkingLotRepo.CountTariffsByParkingLotId},
+		{name: "pass products", count: s.parkingLotRepo.CountPassProductsByParkingLotId},
+		{name: "discount rules", count: s.parkingLotRepo.CountDiscountRulesByParkingLotId},
+		{name: "validations", count: s.parkingLotRepo.CountValidationsByParkingLotId},
+	}
+	for _, p := range priced {
+		cnt, err := p.count(ctx, parkingLot.ID)
+		if err != nil {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    err.Error(),
+			}
+		}
+		if cnt > 0 {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusConflict,
+				Message:    "Currency of a parking lot with " + p.name + " cannot be changed",
+			}
+		}
+	}
+
+	if parkingLot.LostTicketFee != 0 || parkingLot.OverstaySurcharge != 0 {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Currency of a parking lot with penalty fees cannot be changed",
+		}
+	}
+	return nil
+}
+
+func (s *impl) DeleteParkingLot(ctx context.Context, parkingLotId int) error {
+	_, err := s.getParkingLot(ctx, parkingLotId)
+	if err != nil {
+		return err
+	}
+
+	// A lot can only be removed once every vehicle has left it
+	cnt, err := s.parkingLotRepo.CountParkedVehiclesByParkingLotId(ctx, parkingLotId)
+	if err != nil {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	if cnt > 0 {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Parking lot still has parked vehicles",
+		}
+	}
+
+	err = s.parkingLotRepo.DeleteParkingLot(ctx, parkingLotId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "parking lot not found",
+			}
+		}
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	return nil
+}
+
+// getParkingLot fetches a parking lot the caller is scoped to from the repo and maps a missing lot to a 404
+// response. The lots of other tenants are missing to the caller.
+func (s *impl) getParkingLot(ctx context.Context, parkingLotId int) (*models.ParkingLot, error) {
+	if err := checkParkingLotAccess(ctx, parkingLotId); err != nil {
+		return nil, err
+	}
+
+	parkingLot, err := s.parkingLotRepo.GetParkingLotById(ctx, parkingLotId)
+	if err == nil && !auth.CanAccessTenant(ctx, parkingLot.TenantId) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "parking lot not found",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	return parkingLot, nil
+}
+
+// checkParkingLotAccess refuses a caller that is not scoped to a parking lot.
+func checkParkingLotAccess(ctx context.Context, parkingLotId int) error {
+	if !auth.CanAccessParkingLot(ctx, parkingLotId) {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusForbidden,
+			Message:    "No access to parking lot " + strconv.Itoa(parkingLotId),
+		}
+	}
+	return nil
+}
+
+// checkParkingLotFilter checks the parking lot a listing is filtered by, where zero lists every lot. Callers
+// scoped to some lots must filter by one of them.
+func checkParkingLotFilter(ctx context.Context, parkingLotId int) error {
+	if parkingLotId == 0 && !auth.CanAccessAllParkingLots(ctx) {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusForbidden,
+			Message:    "Parking lot id is required for callers scoped to parking lots",
+		}
+	}
+	if parkingLotId == 0 {
+		return nil
+	}
+	return checkParkingLotAccess(ctx, parkingLotId)
+}
+
+// parkingLotFromRequest validates a parking lot request and applies the defaults for optional fields.
+func parkingLotFromRequest(req *model.ParkingLotRequest) (*models.ParkingLot, error) {
+	parkingLot := &models.ParkingLot{
+		Name:               strings.TrimSpace(req.Name),
+		Address:            strings.TrimSpace(req.Address),
+		Timezone:           req.Timezone,
+		Status:             models.ParkingLotStatus(req.Status),
+		AllocationStrategy: req.AllocationStrategy,
+		Currency:           strings.ToUpper(strings.TrimSpace(req.Currency)),
+		Locale:             strings.TrimSpace(req.Locale),
+	}
+
+	if parkingLot.Name == "" {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Parking lot name is required",
+		}
+	}
+
+	if parkingLot.Timezone == "" {
+		parkingLot.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(parkingLot.Timezone); err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Unknown timezone " + parkingLot.Timezone,
+		}
+	}
+
+	switch parkingLot.Status {
+	case "":
+		parkingLot.Status = models.ParkingLotStatusActive
+	case models.ParkingLotStatusActive, models.ParkingLotStatusClosed:
+	default:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Parking lot status must be active or closed",
+		}
+	}
+
+	parkingLot.ReservationGraceMinutes = defaultReservationGraceMinutes
+	if req.ReservationGraceMinutes != nil {
+		parkingLot.ReservationGraceMinutes = *req.ReservationGraceMinutes
+	}
+	if parkingLot.ReservationGraceMinutes < 1 || parkingLot.ReservationGraceMinutes > maxReservationGraceMinutes {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Reservation grace period must be between 1 and " + strconv.Itoa(maxReservationGraceMinutes) + " minutes",
+		}
+	}
+
+	parkingLot.MaxDiscountPercent = defaultMaxDiscountPercent
+	if req.MaxDiscountPercent != nil {
+		parkingLot.MaxDiscountPercent = *req.MaxDiscountPercent
+	}
+	if parkingLot.MaxDiscountPercent < 1 || parkingLot.MaxDiscountPercent > 100 {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Maximum discount must be between 1 and 100 percent",
+		}
+	}
+
+	if parkingLot.Currency == "" {
+		parkingLot.Currency = money.DefaultCurrency
+	}
+	if !money.ValidCurrency(parkingLot.Currency) {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Currency must be an ISO 4217 currency code",
+		}
+	}
+
+	if parkingLot.Locale == "" {
+		parkingLot.Locale = money.DefaultLocale
+	}
+	if !money.ValidLocale(parkingLot.Locale) {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Locale must be one of " + strings.Join(money.Locales(), ", "),
+		}
+	}
+
+	if parkingLot.AllocationStrategy == "" {
+		parkingLot.AllocationStrategy = allocation.DefaultStrategy
+	}
+	if _, err := allocation.New(parkingLot.AllocationStrategy); err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Allocation strategy must be one of " + strings.Join(allocation.Names(), ", "),
+		}
+	}
+
+	return parkingLot, nil
+}
+
+// parkingLotLocation returns the time zone of a parking lot, UTC when it cannot be loaded.
+func parkingLotLocation(parkingLot *models.ParkingLot) *time.Location {
+	location, err := time.LoadLocation(parkingLot.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+func toParkingLotResponse(parkingLot *models.ParkingLot) *model.ParkingLotResponse {
+	return &model.ParkingLotResponse{
+		ID:                      parkingLot.ID,
+		TenantID:                parkingLot.TenantId,
+		Name:                    parkingLot.Name,
+		Address:                 parkingLot.Address,
+		Timezone:                parkingLot.Timezone,
+		Status:                  string(parkingLot.Status),
+		AllocationStrategy:      parkingLot.AllocationStrategy,
+		ReservationGraceMinutes: parkingLot.ReservationGraceMinutes,
+		MaxDiscountPercent:      parkingLot.MaxDiscountPercent,
+		Currency:                parkingLot.Currency,
+		Locale:                  parkingLot.Locale,
+		CreatedAt:               parkingLot.CreatedAt,
+		UpdatedAt:               parkingLot.UpdatedAt,
+	}
+}
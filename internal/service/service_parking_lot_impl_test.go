@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"testing"
+)
+
+func TestUpdateParkingLot_CurrencyInUseConflicts(t *testing.T) {
+	tests := []struct {
+		name  string
+		price func(fake *fakeRepo)
+	}{
+		{
+			name:  "tariffs",
+			price: func(fake *fakeRepo) {},
+		},
+		{
+			name: "pass products",
+			price: func(fake *fakeRepo) {
+				fake.tariffs = nil
+				fake.passProducts[1] = &models.PassProduct{ID: 1, ParkingLotId: 1, VehicleTypeId: 1, Name: "Monthly",
+					Price: 300000, Currency: "INR"}
+			},
+		},
+		{
+			name: "discount rules",
+			price: func(fake *fakeRepo) {
+				fake.tariffs = nil
+				code := "SAVE10"
+				fake.discountRules[1] = &models.DiscountRule{ID: 1, ParkingLotId: 1, Code: &code, Name: "Welcome",
+					Kind: models.DiscountKindFixedAmount, FixedAmount: 1000, Currency: "INR"}
+			},
+		},
+		{
+			name: "validations",
+			price: func(fake *fakeRepo) {
+				// The rule of the validation is gone, the validation keeps its amounts
+				fake.tariffs = nil
+				fake.sessions[1] = &models.ParkingSession{ID: 1, ParkingLotId: 1, VehicleTypeId: 1,
+					VehicleNumber: "KA-01-0001", Status: models.ParkingSessionStatusClosed}
+				fake.validations[1] = &models.Validation{ID: 1, SessionId: 1, DiscountRuleId: 1, Name: "Welcome",
+					Kind: models.DiscountKindFixedAmount, FixedAmount: 1000, Currency: "INR", Amount: 1000}
+			},
+		},
+		{
+			name: "penalty fees",
+			price: func(fake *fakeRepo) {
+				fake.tariffs = nil
+				fake.parkingLots[1].LostTicketFee = 50000
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeRepo(1)
+			tt.price(fake)
+			svc := NewParkingLotService(fake)
+
+			_, err := svc.UpdateParkingLot(context.Background(), 1, &model.ParkingLotRequest{
+				Name: "Parking Lot A", Currency: "EUR",
+			})
+			wantErrorStatus(t, "UpdateParkingLot() of the currency", err, http.StatusConflict)
+			if got := fake.parkingLots[1].Currency; got != "INR" {
+				t.Errorf("currency = %q, want INR", got)
+			}
+		})
+	}
+}
+
+func TestUpdateParkingLot_CurrencyOfUnpricedLotChanges(t *testing.T) {
+	fake := newFakeRepo(1)
+	fake.tariffs = nil
+	// Other lots pricing in the currency do not matter
+	fake.passProducts[1] = &models.PassProduct{ID: 1, ParkingLotId: 2, VehicleTypeId: 1, Name: "Monthly",
+		Price: 300000, Currency: "INR"}
+	svc := NewParkingLotService(fake)
+
+	resp, err := svc.UpdateParkingLot(context.Background(), 1, &model.ParkingLotRequest{
+		Name: "Parking Lot A", Currency: "EUR", Locale: "de-DE",
+	})
+	if err != nil {
+		t.Fatalf("UpdateParkingLot() error = %v", err)
+	}
+	if resp.Currency != "EUR" || fake.parkingLots[1].Currency != "EUR" {
+		t.Errorf("UpdateParkingLot() = %+v, want the lot charging in EUR", resp)
+	}
+}
@@ -0,0 +1,431 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/fare"
+	"parking_lot_service/internal/service/model"
+	"parking_lot_service/internal/service/pass"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPassVehicles is the number of vehicles that can be registered on a single pass.
+const maxPassVehicles = 5
+
+func (s *impl) GetPassProducts(ctx context.Context, parkingLotId int) ([]*model.PassProductResponse, error) {
+	if _, err := s.getParkingLot(ctx, parkingLotId); err != nil {
+		return nil, err
+	}
+
+	passProducts, err := s.parkingLotRepo.GetPassProducts(ctx, parkingLotId)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	resp := make([]*model.PassProductResponse, 0, len(passProducts))
+	for _, passProduct := range passProducts {
+		resp = append(resp, toPassProductResponse(passProduct))
+	}
+	return resp, nil
+}
+
+func (s *impl) CreatePassProduct(ctx context.Context, parkingLotId int,
+	req *model.PassProductRequest) (*model.PassProductResponse, error) {
+
+	parkingLot, err := s.getParkingLot(ctx, parkingLotId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.getVehicleType(ctx, req.VehicleID); err != nil {
+		return nil, err
+	}
+
+	passProduct := &models.PassProduct{
+		ParkingLotId:  parkingLotId,
+		VehicleTypeId: req.VehicleID,
+		Name:          strings.TrimSpace(req.Name),
+		Period:        models.PassPeriod(req.Period),
+		Coverage:      models.PassCoverage(req.Coverage),
+		Price:         req.Price.Amount,
+		Currency:      parkingLot.Currency,
+		ReservedSpots: req.ReservedSpots,
+	}
+
+	switch {
+	case passProduct.Name == "":
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Pass product name is required",
+		}
+	case passProduct.Period != models.PassPeriodWeekly && passProduct.Period != models.PassPeriodMonthly:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Pass period must be weekly or monthly",
+		}
+	case passProduct.Coverage != models.PassCoverageAllDay && passProduct.Coverage != models.PassCoverageWeekdays &&
+		passProduct.Coverage != models.PassCoverageNights:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Pass coverage must be all-day, weekdays or nights",
+		}
+	case passProduct.Price < 0 || passProduct.ReservedSpots < 0:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Price and reserved spots cannot be negative",
+		}
+	case !inCurrency(parkingLot.Currency, req.Price):
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Price must be in the currency of the parking lot, " + parkingLot.Currency,
+		}
+	}
+
+	err = s.parkingLotRepo.CreatePassProduct(ctx, passProduct)
+	if err != nil {
+		return nil, passWriteError(err)
+	}
+
+	return toPassProductResponse(passProduct), nil
+}
+
+func (s *impl) DeletePassProduct(ctx context.Context, passProductId uint) error {
+	if _, err := s.getPassProduct(ctx, passProductId); err != nil {
+		return err
+	}
+
+	err := s.parkingLotRepo.DeletePassProduct(ctx, passProductId)
+	if err != nil {
+		return passWriteError(err)
+	}
+	return nil
+}
+
+func (s *impl) CreatePass(ctx context.Context, req *model.PassRequest) (*model.PassResponse, error) {
+	passProduct, err := s.getPassProduct(ctx, req.PassProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	holderName := strings.TrimSpace(req.HolderName)
+	if holderName == "" {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Holder name is required",
+		}
+	}
+	vehicles, err := passVehiclesFromRequest(req.VehicleNumbers)
+	if err != nil {
+		return nil, err
+	}
+
+	validFrom := time.Now()
+	if req.ValidFrom != nil {
+		validFrom = *req.ValidFrom
+	}
+
+	parkingPass := &models.Pass{
+		PassProductId: passProduct.ID,
+		ParkingLotId:  passProduct.ParkingLotId,
+		VehicleTypeId: passProduct.VehicleTypeId,
+		HolderName:    holderName,
+		Price:         passProduct.Price,
+		Currency:      passProduct.Currency,
+		ValidFrom:     validFrom,
+		ValidTo:       pass.ValidUntil(passProduct.Period, validFrom),
+		Status:        models.PassStatusActive,
+		Vehicles:      vehicles,
+	}
+
+	err = s.parkingLotRepo.CreatePass(ctx, parkingPass)
+	if err != nil {
+		return nil, passWriteError(err)
+	}
+
+	return toPassResponse(parkingPass), nil
+}
+
+func (s *impl) GetPassById(ctx context.Context, passId uint) (*model.PassResponse, error) {
+	parkingPass, err := s.getPass(ctx, passId)
+	if err != nil {
+		return nil, err
+	}
+	return toPassResponse(parkingPass), nil
+}
+
+func (s *impl) UpdatePassVehicles(ctx context.Context, passId uint,
+	req *model.PassVehiclesRequest) (*model.PassResponse, error) {
+
+	parkingPass, err := s.getPass(ctx, passId)
+	if err != nil {
+		return nil, err
+	}
+	vehicles, err := passVehiclesFromRequest(req.VehicleNumbers)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.parkingLotRepo.ReplacePassVehicles(ctx, passId, vehicles)
+	if err != nil {
+		return nil, passWriteError(err)
+	}
+
+	parkingPass.Vehicles = vehicles
+	return toPassResponse(parkingPass), nil
+}
+
+func (s *impl) CancelPass(ctx context.Context, passId uint) error {
+	parkingPass, err := s.getPass(ctx, passId)
+	if err != nil {
+		return err
+	}
+	if parkingPass.Status != models.PassStatusActive {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Pass is already cancelled",
+		}
+	}
+
+	err = s.parkingLotRepo.CancelPass(ctx, passId, time.Now())
+	if err != nil {
+		return passWriteError(err)
+	}
+	return nil
+}
+
+// getPassProduct fetches a pass product from the repo and maps a missing product to a 404 response.
+func (s *impl) getPassProduct(ctx context.Context, passProductId uint) (*models.PassProduct, error) {
+	passProduct, err := s.parkingLotRepo.GetPassProductById(ctx, passProductId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "pass product not found",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	if err = checkParkingLotAccess(ctx, passProduct.ParkingLotId); err != nil {
+		return nil, err
+	}
+	return passProduct, nil
+}
+
+// getPass fetches a pass from the repo and maps a missing pass to a 404 response.
+func (s *impl) getPass(ctx context.Context, passId uint) (*models.Pass, error) {
+	parkingPass, err := s.parkingLotRepo.GetPassById(ctx, passId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "pass not found",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	if err = checkParkingLotAccess(ctx, parkingPass.ParkingLotId); err != nil {
+		return nil, err
+	}
+	return parkingPass, nil
+}
+
+// getActivePass returns the pass a vehicle parks on at the given time, or nil if the vehicle pays by the hour.
+func (s *impl) getActivePass(ctx context.Context, parkingLotId, vehicleTypeId int, vehicleNumber string,
+	at time.Time) (*models.Pass, error) {
+
+	parkingPass, err := s.parkingLotRepo.GetActivePass(ctx, parkingLotId, vehicleTypeId,
+		strings.TrimSpace(vehicleNumber), at)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	return parkingPass, nil
+}
+
+// addPassHolds adds the spots reserved by the pass products of a parking lot to the held spots per vehicle type.
+// A vehicle parking on a pass may take one of the spots reserved by its own product.
+func (s *impl) addPassHolds(ctx context.Context, txRepo repo.ParkingLotRepo, heldSpots map[int]int,
+	parkingLotId int, parkingPass *models.Pass) error {
+
+	holds, err := txRepo.GetPassHolds(ctx, parkingLotId)
+	if err != nil {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	for _, hold := range holds {
+		held := hold.HeldSpots
+		if parkingPass != nil && hold.PassProductId == parkingPass.PassProductId && held > 0 {
+			held--
+		}
+		heldSpots[hold.VehicleTypeId] += held
+	}
+	return nil
+}
+
+// getBillablePeriods returns the parts of a stay a pass holder pays for: the time outside the validity of the
+// pass and the time within it that its product does not cover. A pass or pass product that no longer exists
+// covers nothing.
+func (s *impl) getBillablePeriods(ctx context.Context, location *time.Location, passId uint,
+	entryTime, exitTime time.Time) ([]fare.Period, error) {
+
+	stay := []fare.Period{{From: entryTime, To: exitTime}}
+
+	parkingPass, err := s.parkingLotRepo.GetPassById(ctx, passId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return stay, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	passProduct, err := s.parkingLotRepo.GetPassProductById(ctx, parkingPass.PassProductId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return stay, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the part of the stay within the validity of the pass can be covered
+	from, to := entryTime, exitTime
+	if parkingPass.ValidFrom.After(from) {
+		from = parkingPass.ValidFrom
+	}
+	if parkingPass.ValidTo.Before(to) {
+		to = parkingPass.ValidTo
+	}
+	if !from.Before(to) {
+		return stay, nil
+	}
+
+	var periods []fare.Period
+	add := func(start, end time.Time) {
+		if last := len(periods) - 1; last >= 0 && periods[last].To.Equal(start) {
+			periods[last].To = end
+			return
+		}
+		periods = append(periods, fare.Period{From: start, To: end})
+	}
+	if entryTime.Before(from) {
+		add(entryTime, from)
+	}
+	for _, part := range pass.Uncovered(passProduct.Coverage, from, to, location) {
+		add(part[0], part[1])
+	}
+	if to.Before(exitTime) {
+		add(to, exitTime)
+	}
+	return periods, nil
+}
+
+// passVehiclesFromRequest validates the vehicle numbers of a pass request, dropping blanks and duplicates.
+func passVehiclesFromRequest(vehicleNumbers []string) ([]models.PassVehicle, error) {
+	var (
+		vehicles = make([]models.PassVehicle, 0, len(vehicleNumbers))
+		seen     = make(map[string]bool, len(vehicleNumbers))
+	)
+	for _, vehicleNumber := range vehicleNumbers {
+		vehicleNumber = strings.TrimSpace(vehicleNumber)
+		if vehicleNumber == "" || seen[vehicleNumber] {
+			continue
+		}
+		seen[vehicleNumber] = true
+		vehicles = append(vehicles, models.PassVehicle{VehicleNumber: vehicleNumber})
+	}
+
+	if len(vehicles) == 0 || len(vehicles) > maxPassVehicles {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "A pass needs between 1 and " + strconv.Itoa(maxPassVehicles) + " vehicle numbers",
+		}
+	}
+	return vehicles, nil
+}
+
+// passWriteError maps an error from writing a pass product or pass to a response.
+func passWriteError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    "Parking lot has no spots for this vehicle type",
+		}
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Pass product with this name already exists in the parking lot",
+		}
+	case errors.Is(err, repo.ErrPassCapacity):
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Pass products cannot reserve more spots than the parking space has",
+		}
+	case errors.Is(err, repo.ErrPassProductInUse):
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Pass product still has valid passes",
+		}
+	}
+	return &genericresponse.GenericResponse{
+		StatusCode: http.StatusInternalServerError,
+		Message:    err.Error(),
+	}
+}
+
+func toPassProductResponse(passProduct *models.PassProduct) *model.PassProductResponse {
+	return &model.PassProductResponse{
+		ID:            passProduct.ID,
+		ParkingLotID:  passProduct.ParkingLotId,
+		VehicleID:     passProduct.VehicleTypeId,
+		Name:          passProduct.Name,
+		Period:        string(passProduct.Period),
+		Coverage:      string(passProduct.Coverage),
+		Price:         money.New(passProduct.Price, passProduct.Currency),
+		ReservedSpots: passProduct.ReservedSpots,
+		CreatedAt:     passProduct.CreatedAt,
+	}
+}
+
+func toPassResponse(parkingPass *models.Pass) *model.PassResponse {
+	vehicleNumbers := make([]string, 0, len(parkingPass.Vehicles))
+	for _, vehicle := range parkingPass.Vehicles {
+		vehicleNumbers = append(vehicleNumbers, vehicle.VehicleNumber)
+	}
+
+	return &model.PassResponse{
+		ID:             parkingPass.ID,
+		PassProductID:  parkingPass.PassProductId,
+		ParkingLotID:   parkingPass.ParkingLotId,
+		VehicleID:      parkingPass.VehicleTypeId,
+		HolderName:     parkingPass.HolderName,
+		Price:          money.New(parkingPass.Price, parkingPass.Currency),
+		ValidFrom:      parkingPass.ValidFrom,
+		ValidTo:        parkingPass.ValidTo,
+		Status:         string(parkingPass.Status),
+		VehicleNumbers: vehicleNumbers,
+		CreatedAt:      parkingPass.CreatedAt,
+	}
+}
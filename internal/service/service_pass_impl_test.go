@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"testing"
+	"time"
+)
+
+// newFakeRepoWithPass returns a fake repo whose lot sells an all-day pass reserving reservedSpots spots,
+// with one pass registered for KA-01-0001 that became valid at validFrom.
+func newFakeRepoWithPass(totalSpots, reservedSpots int, validFrom time.Time) *fakeRepo {
+	fake := newFakeRepo(totalSpots)
+	fake.passProducts[1] = &models.PassProduct{ID: 1, ParkingLotId: 1, VehicleTypeId: 1, Name: "Monthly",
+		Period: models.PassPeriodMonthly, Coverage: models.PassCoverageAllDay, ReservedSpots: reservedSpots}
+	fake.passes[1] = &models.Pass{ID: 1, PassProductId: 1, ParkingLotId: 1, VehicleTypeId: 1, HolderName: "Staff",
+		ValidFrom: validFrom, ValidTo: validFrom.AddDate(0, 1, 0), Status: models.PassStatusActive,
+		Vehicles: []models.PassVehicle{{ID: 1, PassId: 1, VehicleNumber: "KA-01-0001"}}}
+	return fake
+}
+
+func TestUnParkVehicle_PassCoversStay(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		validFrom   time.Time
+		wantFare    money.Money
+		wantCovered bool
+	}{
+		{
+			name:        "Whole stay within the pass",
+			validFrom:   now.Add(-24 * time.Hour),
+			wantFare:    inr(0),
+			wantCovered: true,
+		},
+		{
+			name:        "Stay started before the pass",
+			validFrom:   now.Add(-time.Hour),
+			wantFare:    inr(4000), // 2 hours before the pass * HourlyRate: 20
+			wantCovered: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeRepoWithPass(1, 0, tt.validFrom)
+			svc := NewParkingLotService(fake)
+
+			parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+				ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0001",
+			})
+			if err != nil {
+				t.Fatalf("ParkVehicle() error = %v", err)
+			}
+			fake.sessions[1].EntryTime = now.Add(-3 * time.Hour)
+
+			unparked, err := svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{
+				TicketNumber: parked.ParkingTicket.TicketNumber,
+			})
+			if err != nil {
+				t.Fatalf("UnParkVehicle() error = %v", err)
+			}
+			receipt := unparked.Parking
+			if receipt.PassID == nil || *receipt.PassID != 1 {
+				t.Errorf("UnParkVehicle() pass = %v, want 1", receipt.PassID)
+			}
+			if receipt.TotalFare != tt.wantFare || receipt.CoveredByPass != tt.wantCovered {
+				t.Errorf("UnParkVehicle() fare = %v, covered = %v, want %v, %v",
+					receipt.TotalFare, receipt.CoveredByPass, tt.wantFare, tt.wantCovered)
+			}
+		})
+	}
+}
+
+func TestParkVehicle_PassReservedSpots(t *testing.T) {
+	fake := newFakeRepoWithPass(2, 1, time.Now().Add(-time.Hour))
+	svc := NewParkingLotService(fake)
+
+	// One of the two spots is reserved for the pass holders
+	_, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0002",
+	})
+	if err != nil {
+		t.Fatalf("ParkVehicle() walk-in error = %v", err)
+	}
+	_, err = svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0003",
+	})
+	var genericErr *genericresponse.GenericResponse
+	if !errors.As(err, &genericErr) || genericErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("ParkVehicle() second walk-in error = %v, want status %d", err, http.StatusNotFound)
+	}
+
+	_, err = svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0001",
+	})
+	if err != nil {
+		t.Fatalf("ParkVehicle() pass holder error = %v", err)
+	}
+	if got := fake.openSessions["KA-01-0001"].PassId; got == nil || *got != 1 {
+		t.Errorf("session pass = %v, want 1", got)
+	}
+}
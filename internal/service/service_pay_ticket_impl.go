@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo"
+	"parking_lot_service/internal/service/model"
+	"strings"
+	"time"
+)
+
+// PayTicket prices the stay of a parked vehicle at a pay station and invoices it, with a gateway the invoice is
+// paid right away. Once the invoice is settled, the vehicle has the exit grace period of its tariff to leave,
+// time after that is charged when it is unparked. A stay with nothing to pay is paid without an invoice.
+func (s *impl) PayTicket(ctx context.Context, req *model.PayTicketRequest) (*model.PayTicketResponse, error) {
+	parkingSession, err := s.getOpenParkingSession(ctx, req.TicketNumber)
+	if err != nil {
+		return nil, err
+	}
+	if parkingSession.PaidAt != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Ticket has already been paid",
+		}
+	}
+
+	pendingInvoice, err := s.getPendingInvoice(ctx, parkingSession)
+	if err != nil {
+		return nil, err
+	}
+
+	parkingLot, tariff, _, err := s.getSessionTariff(ctx, parkingSession)
+	if err != nil {
+		return nil, err
+	}
+
+	// A valid quote holds the fare at the time it was given, the exit grace period starts at payment
+	paidAt := time.Now()
+	stay, err := s.priceQuotedStay(ctx, parkingSession, parkingLot, tariff, req.QuoteID, req.DiscountCode, paidAt,
+		false)
+	if err != nil {
+		return nil, err
+	}
+
+	if stay.netFare.IsPositive() {
+		invoice, err := s.issueInvoice(ctx, parkingSession, pendingInvoice, stay, paidAt)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(req.Gateway) != "" {
+			invoice, err = s.chargeInvoice(ctx, invoice, req.Gateway, money.Money{}, req.Token)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		response := &model.PayTicketResponse{
+			Parking: toParkingReceipt(parkingSession, parkingLot, stay, paidAt, nil),
+			Invoice: toInvoiceResponse(invoice),
+		}
+		if invoice.Settled() {
+			response.ExitBy = paidAt.Add(tariff.ExitGrace()).Format(time.RFC3339)
+		}
+		return response, nil
+	}
+
+	parkingSession.PaidAt = &paidAt
+	parkingSession.Currency = stay.netFare.Currency
+	parkingSession.GrossFare = &stay.grossFare.Amount
+	parkingSession.Fare = &stay.netFare.Amount
+
+	err = s.parkingLotRepo.WithTx(ctx, func(txRepo repo.ParkingLotRepo) error {
+		err := txRepo.PayParkingSession(ctx, parkingSession)
+		if err != nil {
+			// The ticket has been paid or used by a concurrent request
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &genericresponse.GenericResponse{
+					StatusCode: http.StatusConflict,
+					Message:    "Ticket has already been paid",
+				}
+			}
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    "Unable to pay parking session",
+			}
+		}
+
+		// An invoice of an earlier visit to the pay station is no longer due
+		err = s.voidPendingInvoice(ctx, txRepo, pendingInvoice)
+		if err != nil {
+			return err
+		}
+
+		// Record what every discount took off the fare
+		if len(stay.validations) > 0 {
+			err = txRepo.SaveValidations(ctx, stay.validations)
+			if err != nil {
+				return &genericresponse.GenericResponse{
+					StatusCode: http.StatusInternalServerError,
+					Message:    "Unable to save discounts",
+				}
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.PayTicketResponse{
+		Parking: toParkingReceipt(parkingSession, parkingLot, stay, paidAt, nil),
+		ExitBy:  paidAt.Add(tariff.ExitGrace()).Format(time.RFC3339),
+	}, nil
+}
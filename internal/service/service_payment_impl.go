@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"parking_lot_service/internal/service/payment"
+	"strings"
+)
+
+// RefundPayment pays back part or all of a payment through the gateway it was made with. The payment stays
+// locked while the gateway pays the refund, so concurrent refunds cannot pay back more than was paid. A
+// refund that does not go through is recorded as failed. Refunds do not reopen a settled invoice.
+func (s *impl) RefundPayment(ctx context.Context, paymentId uint, req *model.RefundRequest) (
+	*model.PaymentResponse, error) {
+
+	charge, err := s.getPayment(ctx, paymentId)
+	if err != nil {
+		return nil, err
+	}
+	// The invoice tells the parking lot of the payment
+	if _, err = s.getInvoice(ctx, charge.InvoiceId); err != nil {
+		return nil, err
+	}
+	if charge.Kind != models.PaymentKindCharge || (charge.Status != models.PaymentStatusSucceeded &&
+		charge.Status != models.PaymentStatusPartiallyRefunded) {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Only successful payments that have not been refunded in full can be refunded",
+		}
+	}
+
+	gateway, err := s.gateways.Get(charge.Gateway)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Payment gateway " + charge.Gateway + " is no longer available",
+		}
+	}
+
+	amount := req.Amount
+	if amount.IsZero() {
+		amount = money.New(charge.Amount-charge.RefundedAmount, charge.Currency)
+	}
+	switch {
+	case amount.Currency != charge.Currency:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Refunds must be in the currency of the payment, " + charge.Currency,
+		}
+	case !amount.IsPositive():
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Amount must be more than zero",
+		}
+	case amount.Amount > charge.Amount-charge.RefundedAmount:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Amount is more than what is left to refund of the payment",
+		}
+	}
+
+	refund := &models.Payment{
+		InvoiceId: charge.InvoiceId,
+		RefundOf:  &charge.ID,
+		Kind:      models.PaymentKindRefund,
+		Gateway:   gateway.Name(),
+		Currency:  amount.Currency,
+		Amount:    amount.Amount,
+		Status:    models.PaymentStatusPending,
+		Message:   strings.TrimSpace(req.Reason),
+	}
+	return s.refundCharge(ctx, charge, gateway, refund)
+}
+
+// refundCharge sends a pending refund of a charge to the gateway the charge was made with, the refund is
+// recorded as failed when it does not go through.
+func (s *impl) refundCharge(ctx context.Context, charge *models.Payment, gateway payment.PaymentGateway,
+	refund *models.Payment) (*model.PaymentResponse, error) {
+
+	err := s.parkingLotRepo.CreatePayment(ctx, refund)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	err = s.parkingLotRepo.WithTx(ctx, func(txRepo repo.ParkingLotRepo) error {
+		refunded, err := txRepo.RefundPayment(ctx, charge.ID, refund.Amount)
+		if err != nil {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    "Unable to refund payment",
+			}
+		}
+		if !refunded {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusConflict,
+				Message:    "Payment has been refunded by a concurrent request",
+			}
+		}
+		err = txRepo.RefundInvoice(ctx, charge.InvoiceId, refund.Amount)
+		if err != nil {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    "Unable to refund invoice",
+			}
+		}
+
+		result, err := gateway.Refund(ctx, &payment.Refund{
+			Reference:        paymentReference(refund.ID),
+			PaymentReference: charge.Reference,
+			Amount:           money.New(refund.Amount, refund.Currency),
+		})
+		switch {
+		case err != nil:
+			refund.Message = err.Error()
+			return errGatewayUnavailable
+		case !result.Approved:
+			refund.Message = result.Message
+			return errPaymentDeclined
+		}
+
+		refund.Status = models.PaymentStatusSucceeded
+		refund.Reference = result.Reference
+		err = txRepo.CompletePayment(ctx, refund)
+		if err != nil {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    "Unable to record refund",
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, s.failPayment(ctx, refund, err)
+	}
+
+	refund, err = s.getPayment(ctx, refund.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toPaymentResponse(refund), nil
+}
+
+// getPayment resolves a payment or refund.
+func (s *impl) getPayment(ctx context.Context, paymentId uint) (*models.Payment, error) {
+	found, err := s.parkingLotRepo.GetPaymentById(ctx, paymentId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "payment not found",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	return found, nil
+}
+
+func toPaymentResponse(found *models.Payment) *model.PaymentResponse {
+	response := &model.PaymentResponse{
+		ID:           found.ID,
+		InvoiceID:    found.InvoiceId,
+		RefundOf:     found.RefundOf,
+		AdjustmentID: found.AdjustmentId,
+		Kind:         string(found.Kind),
+		Gateway:      found.Gateway,
+		Amount:       money.New(found.Amount, found.Currency),
+		Status:       string(found.Status),
+		Reference:    found.Reference,
+		Message:      found.Message,
+		CreatedAt:    found.CreatedAt,
+	}
+	if found.Kind == models.PaymentKindCharge {
+		response.RefundedAmount = optionalMoney(found.RefundedAmount, found.Currency)
+	}
+	return response
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/fare"
+	"parking_lot_service/internal/service/model"
+	"strings"
+	"time"
+)
+
+// Kinds of the penalty lines of a receipt.
+const (
+	penaltyLostTicket        = "lost-ticket"
+	penaltyLostTicketFullDay = "lost-ticket-full-day"
+	penaltyOverstay          = "overstay"
+)
+
+// identityDocumentTypes lists the documents a driver without a ticket can prove their identity with.
+var identityDocumentTypes = []string{"driving-licence", "passport", "national-id"}
+
+func (s *impl) GetPenaltyPolicy(ctx context.Context, parkingLotId int) (*model.PenaltyPolicyResponse, error) {
+	parkingLot, err := s.getParkingLot(ctx, parkingLotId)
+	if err != nil {
+		return nil, err
+	}
+	return toPenaltyPolicyResponse(parkingLot), nil
+}
+
+func (s *impl) UpdatePenaltyPolicy(ctx context.Context, parkingLotId int,
+	req *model.PenaltyPolicyRequest) (*model.PenaltyPolicyResponse, error) {
+
+	parkingLot, err := s.getParkingLot(ctx, parkingLotId)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !inCurrency(parkingLot.Currency, req.LostTicketFee, req.OverstaySurcharge):
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Penalties must be in the currency of the parking lot, " + parkingLot.Currency,
+		}
+	case req.LostTicketFee.IsNegative() || req.OverstaySurcharge.IsNegative():
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Penalties cannot be negative",
+		}
+	case req.MaxStayDays < 0:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Maximum stay cannot be negative",
+		}
+	case req.MaxStayDays == 0 && req.OverstaySurcharge.IsPositive():
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Overstay surcharge needs a maximum stay",
+		}
+	}
+
+	parkingLot.LostTicketFee = req.LostTicketFee.Amount
+	parkingLot.LostTicketFullDay = req.LostTicketFullDay
+	parkingLot.MaxStayDays = req.MaxStayDays
+	parkingLot.OverstaySurcharge = req.OverstaySurcharge.Amount
+	err = s.parkingLotRepo.UpdatePenaltyPolicy(ctx, parkingLot)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "parking lot not found",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	return toPenaltyPolicyResponse(parkingLot), nil
+}
+
+// LostTicketExit lets a vehicle out whose driver lost the ticket, once an operator has checked the identity of
+// the driver and the registration of the vehicle. The check is recorded, and the stay is unparked by the
+// vehicle number with the lost-ticket penalties of the lot. Like any exit, a fare left to pay is invoiced and
+// the vehicle is let out by calling this again once the invoice is settled.
+func (s *impl) LostTicketExit(ctx context.Context, req *model.LostTicketExitRequest) (*model.UnParkVehicleResponse,
+	error) {
+
+	lostTicketExit := &models.LostTicketExit{
+		VehicleNumber: strings.TrimSpace(req.VehicleNumber),
+		DriverName:    strings.TrimSpace(req.DriverName),
+		DocumentType:  strings.TrimSpace(req.DocumentType),
+		Operator:      strings.TrimSpace(req.Operator),
+	}
+	documentNumber := []rune(strings.TrimSpace(req.DocumentNumber))
+
+	switch {
+	case lostTicketExit.VehicleNumber == "":
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Vehicle number is required",
+		}
+	case lostTicketExit.DriverName == "" || len(lostTicketExit.DriverName) > 150:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Driver name is required and must be at most 150 characters",
+		}
+	case !validDocumentType(lostTicketExit.DocumentType):
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Document type must be one of " + strings.Join(identityDocumentTypes, ", "),
+		}
+	case len(documentNumber) < 4:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Document number must have at least 4 characters",
+		}
+	case !req.RegistrationVerified:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Registration of the vehicle must be verified",
+		}
+	case lostTicketExit.Operator == "" || len(lostTicketExit.Operator) > 100:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Operator is required and must be at most 100 characters",
+		}
+	}
+	lostTicketExit.DocumentNumber = string(documentNumber[len(documentNumber)-4:])
+
+	parkingSession, err := s.getParkedVehicleSession(ctx, lostTicketExit.VehicleNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	lostTicketExit.SessionId = parkingSession.ID
+	lostTicketExit.ParkingLotId = parkingSession.ParkingLotId
+	err = s.parkingLotRepo.CreateLostTicketExit(ctx, lostTicketExit)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    "Unable to record lost ticket",
+		}
+	}
+
+	return s.unParkSession(ctx, parkingSession, "", 0, true)
+}
+
+// stayPenalties works out the penalties a parking lot charges a stay leaving at exitTime, whose fare before
+// discounts is stayFare. A lost ticket pays the flat lost-ticket fee and, for lots that charge lost tickets a
+// full day, what the fare of a full day from entry comes to over the fare of the stay. A stay longer than the
+// maximum stay of the lot is surcharged for every started day past it.
+func stayPenalties(parkingSession *models.ParkingSession, parkingLot *models.ParkingLot, tariff *models.Tariff,
+	location *time.Location, holidays fare.Calendar, stayFare money.Money, exitTime time.Time,
+	lostTicket bool) ([]model.PenaltyLine, error) {
+
+	var lines []model.PenaltyLine
+	if lostTicket && parkingLot.LostTicketFee > 0 {
+		lines = append(lines, model.PenaltyLine{
+			Name:   "Lost ticket fee",
+			Kind:   penaltyLostTicket,
+			Amount: money.New(parkingLot.LostTicketFee, parkingLot.Currency),
+		})
+	}
+
+	// Pass holders are not charged for the time their pass covers, lost ticket or not
+	if lostTicket && parkingLot.LostTicketFullDay && parkingSession.PassId == nil {
+		fullDay := fare.Period{From: parkingSession.EntryTime, To: parkingSession.EntryTime.Add(24 * time.Hour)}
+		_, fullDayFare, err := calculateFare(tariff, []fare.Period{fullDay}, location, holidays)
+		if err != nil {
+			return nil, err
+		}
+		if shortfall := fullDayFare.Sub(stayFare); shortfall.IsPositive() {
+			lines = append(lines, model.PenaltyLine{
+				Name:   "Lost ticket, charged a full day",
+				Kind:   penaltyLostTicketFullDay,
+				Amount: shortfall,
+			})
+		}
+	}
+
+	if parkingLot.MaxStayDays > 0 && parkingLot.OverstaySurcharge > 0 {
+		over := exitTime.Sub(parkingSession.EntryTime) - time.Duration(parkingLot.MaxStayDays)*24*time.Hour
+		if over > 0 {
+			days := int64((over + 24*time.Hour - 1) / (24 * time.Hour))
+			lines = append(lines, model.PenaltyLine{
+				Name:   fmt.Sprintf("Overstay of %d days past the %d day limit", days, parkingLot.MaxStayDays),
+				Kind:   penaltyOverstay,
+				Amount: money.New(parkingLot.OverstaySurcharge, parkingLot.Currency).Mul(days),
+			})
+		}
+	}
+	return lines, nil
+}
+
+func validDocumentType(documentType string) bool {
+	for _, valid := range identityDocumentTypes {
+		if documentType == valid {
+			return true
+		}
+	}
+	return false
+}
+
+func toPenaltyPolicyResponse(parkingLot *models.ParkingLot) *model.PenaltyPolicyResponse {
+	return &model.PenaltyPolicyResponse{
+		ParkingLotID:      parkingLot.ID,
+		LostTicketFee:     money.New(parkingLot.LostTicketFee, parkingLot.Currency),
+		LostTicketFullDay: parkingLot.LostTicketFullDay,
+		MaxStayDays:       parkingLot.MaxStayDays,
+		OverstaySurcharge: money.New(parkingLot.OverstaySurcharge, parkingLot.Currency),
+	}
+}
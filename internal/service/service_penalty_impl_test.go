@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"parking_lot_service/internal/service/payment"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_stayPenalties(t *testing.T) {
+	tariff := &models.Tariff{Currency: "INR", HourlyRate: 2000}
+	parkingLot := &models.ParkingLot{Currency: "INR", LostTicketFee: 50000, LostTicketFullDay: true,
+		MaxStayDays: 2, OverstaySurcharge: 10000}
+	entry := time.Date(2024, time.March, 1, 8, 0, 0, 0, time.UTC)
+	passId := uint(7)
+
+	tests := []struct {
+		name       string
+		passId     *uint
+		stay       time.Duration
+		stayFare   money.Money
+		lostTicket bool
+		want       []model.PenaltyLine
+	}{
+		{
+			name:     "Stay within the maximum stay",
+			stay:     30 * time.Hour,
+			stayFare: inr(60000),
+		},
+		{
+			name:     "Stay past the maximum stay",
+			stay:     49 * time.Hour,
+			stayFare: inr(98000),
+			want: []model.PenaltyLine{
+				{Name: "Overstay of 1 days past the 2 day limit", Kind: penaltyOverstay, Amount: inr(10000)},
+			},
+		},
+		{
+			name:     "Every started day past the maximum stay is surcharged",
+			stay:     73 * time.Hour,
+			stayFare: inr(146000),
+			want: []model.PenaltyLine{
+				{Name: "Overstay of 2 days past the 2 day limit", Kind: penaltyOverstay, Amount: inr(20000)},
+			},
+		},
+		{
+			name:       "Lost ticket pays the fee and a full day",
+			stay:       90 * time.Minute,
+			stayFare:   inr(4000),
+			lostTicket: true,
+			want: []model.PenaltyLine{
+				{Name: "Lost ticket fee", Kind: penaltyLostTicket, Amount: inr(50000)},
+				{Name: "Lost ticket, charged a full day", Kind: penaltyLostTicketFullDay, Amount: inr(44000)},
+			},
+		},
+		{
+			name:       "Lost ticket of a stay longer than a day",
+			stay:       26 * time.Hour,
+			stayFare:   inr(52000),
+			lostTicket: true,
+			want: []model.PenaltyLine{
+				{Name: "Lost ticket fee", Kind: penaltyLostTicket, Amount: inr(50000)},
+			},
+		},
+		{
+			name:       "Lost ticket of a pass holder",
+			passId:     &passId,
+			stay:       90 * time.Minute,
+			lostTicket: true,
+			want: []model.PenaltyLine{
+				{Name: "Lost ticket fee", Kind: penaltyLostTicket, Amount: inr(50000)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parkingSession := &models.ParkingSession{EntryTime: entry, PassId: tt.passId}
+			got, err := stayPenalties(parkingSession, parkingLot, tariff, time.UTC, nil, tt.stayFare,
+				entry.Add(tt.stay), tt.lostTicket)
+			if err != nil {
+				t.Fatalf("stayPenalties() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stayPenalties() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLostTicketExit(t *testing.T) {
+	fake := newFakeRepo(1)
+	svc := NewParkingLotService(fake, payment.NewLocal())
+	_, err := svc.UpdatePenaltyPolicy(context.Background(), 1, &model.PenaltyPolicyRequest{
+		LostTicketFee: inr(50000), LostTicketFullDay: true,
+	})
+	if err != nil {
+		t.Fatalf("UpdatePenaltyPolicy() error = %v", err)
+	}
+
+	_, err = svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0001",
+	})
+	if err != nil {
+		t.Fatalf("ParkVehicle() error = %v", err)
+	}
+	fake.sessions[1].EntryTime = time.Now().Add(-90 * time.Minute)
+
+	req := &model.LostTicketExitRequest{
+		VehicleNumber: "KA-01-0001", DriverName: "A. Driver", DocumentType: "driving-licence",
+		DocumentNumber: "DL-0420110012345", Operator: "attendant-3",
+	}
+	_, err = svc.LostTicketExit(context.Background(), req)
+	wantErrorStatus(t, "LostTicketExit() without checking the registration", err, http.StatusBadRequest)
+
+	req.RegistrationVerified = true
+	unparked, err := svc.LostTicketExit(context.Background(), req)
+	if err != nil {
+		t.Fatalf("LostTicketExit() error = %v", err)
+	}
+	wantPenalties := []model.PenaltyLine{
+		{Name: "Lost ticket fee", Kind: penaltyLostTicket, Amount: inr(50000), FormattedAmount: "₹500.00"},
+		{Name: "Lost ticket, charged a full day", Kind: penaltyLostTicketFullDay, Amount: inr(44000),
+			FormattedAmount: "₹440.00"},
+	}
+	if unparked.Exited || !reflect.DeepEqual(unparked.Parking.Penalties, wantPenalties) {
+		t.Errorf("LostTicketExit() = %+v, want the penalties invoiced", unparked)
+	}
+	if unparked.Parking.GrossFare != inr(98000) || unparked.Parking.TotalFare != inr(98000) ||
+		unparked.Invoice == nil || unparked.Invoice.Amount != inr(98000) {
+		t.Errorf("LostTicketExit() fare = %v, invoice = %+v, want 980.00 INR", unparked.Parking.TotalFare,
+			unparked.Invoice)
+	}
+	if len(fake.lostTickets) != 1 || fake.lostTickets[0].DocumentNumber != "2345" ||
+		fake.lostTickets[0].SessionId != 1 || fake.lostTickets[0].Operator != "attendant-3" {
+		t.Errorf("lost tickets = %+v, want the check recorded with the last four characters", fake.lostTickets)
+	}
+
+	// Once the invoice is paid, the vehicle leaves without paying the penalties again
+	_, err = svc.PayInvoice(context.Background(), unparked.Invoice.ID, &model.PaymentRequest{Gateway: payment.Local})
+	if err != nil {
+		t.Fatalf("PayInvoice() error = %v", err)
+	}
+	exited, err := svc.LostTicketExit(context.Background(), req)
+	if err != nil {
+		t.Fatalf("LostTicketExit() after payment error = %v", err)
+	}
+	if !exited.Exited || len(exited.Parking.Penalties) != 0 || *fake.sessions[1].Fare != 98000 {
+		t.Errorf("LostTicketExit() after payment = %+v, want an exit for 980.00 INR", exited)
+	}
+}
+
+func TestUpdatePenaltyPolicy_Errors(t *testing.T) {
+	svc := NewParkingLotService(newFakeRepo(1))
+	tests := []struct {
+		name string
+		req  *model.PenaltyPolicyRequest
+	}{
+		{name: "Other currency", req: &model.PenaltyPolicyRequest{LostTicketFee: money.New(500, "USD")}},
+		{name: "Negative fee", req: &model.PenaltyPolicyRequest{LostTicketFee: inr(-500)}},
+		{name: "Negative maximum stay", req: &model.PenaltyPolicyRequest{MaxStayDays: -1}},
+		{name: "Surcharge without a maximum stay", req: &model.PenaltyPolicyRequest{OverstaySurcharge: inr(500)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.UpdatePenaltyPolicy(context.Background(), 1, tt.req)
+			wantErrorStatus(t, "UpdatePenaltyPolicy()", err, http.StatusBadRequest)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"strings"
+	"time"
+)
+
+// GetQuote prices the stay of a parked vehicle as if it left now and records the quote, without paying or changing
+// the session. The quote holds for the quote validity of the session's tariff: paying the ticket or unparking
+// with the ID of the quote within it charges the quoted fare, however long paying takes.
+func (s *impl) GetQuote(ctx context.Context, req *model.QuoteQuery) (*model.QuoteResponse, error) {
+	parkingSession, err := s.getQuotedParkingSession(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Discounts are taken off when the ticket is paid, the time charged after payment is not discounted
+	if parkingSession.PaidAt != nil && strings.TrimSpace(req.DiscountCode) != "" {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Ticket has already been paid, discount codes apply when paying",
+		}
+	}
+
+	// A partly paid invoice has to be paid in full, its fare is not quoted again
+	_, err = s.getPendingInvoice(ctx, parkingSession)
+	if err != nil {
+		return nil, err
+	}
+
+	parkingLot, tariff, _, err := s.getSessionTariff(ctx, parkingSession)
+	if err != nil {
+		return nil, err
+	}
+
+	// The stay is priced up to the recorded time again when the quote is used, which the database keeps to the
+	// second
+	quotedAt := time.Now().Truncate(time.Second)
+	stay, err := s.priceStay(ctx, parkingSession, parkingLot, tariff, quotedAt, req.DiscountCode, false)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &models.FareQuote{
+		SessionId:    parkingSession.ID,
+		Currency:     stay.netFare.Currency,
+		Fare:         stay.netFare.Amount,
+		DiscountCode: strings.ToUpper(strings.TrimSpace(req.DiscountCode)),
+		QuotedAt:     quotedAt,
+		ValidUntil:   quotedAt.Add(tariff.QuoteValidity()),
+	}
+	err = s.parkingLotRepo.CreateFareQuote(ctx, quote)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    "Unable to save quote",
+		}
+	}
+
+	var prepaidFare *money.Money
+	if parkingSession.PaidAt != nil {
+		prepaid := money.New(*parkingSession.Fare, parkingSession.Currency)
+		prepaidFare = &prepaid
+	}
+
+	return &model.QuoteResponse{
+		QuoteID:    quote.ID,
+		Parking:    toParkingReceipt(parkingSession, parkingLot, stay, quotedAt, prepaidFare),
+		QuotedAt:   quote.QuotedAt,
+		ValidUntil: quote.ValidUntil,
+	}, nil
+}
+
+// getQuotedParkingSession resolves the open parking session of a quote, by ticket number or vehicle number.
+func (s *impl) getQuotedParkingSession(ctx context.Context, req *model.QuoteQuery) (*models.ParkingSession,
+	error) {
+
+	ticketNumber := strings.TrimSpace(req.TicketNumber)
+	vehicleNumber := strings.TrimSpace(req.VehicleNumber)
+	switch {
+	case ticketNumber == "" && vehicleNumber == "":
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Ticket number or vehicle number is required",
+		}
+	case ticketNumber != "" && vehicleNumber != "":
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Quote either a ticket number or a vehicle number, not both",
+		}
+	case ticketNumber != "":
+		return s.getOpenParkingSession(ctx, ticketNumber)
+	}
+	return s.getParkedVehicleSession(ctx, vehicleNumber)
+}
+
+// getParkedVehicleSession resolves the open parking session of a vehicle number.
+func (s *impl) getParkedVehicleSession(ctx context.Context, vehicleNumber string) (*models.ParkingSession, error) {
+	parkingSessions, err := s.parkingLotRepo.GetParkingSessions(ctx, &repo.ParkingSessionFilter{
+		VehicleNumber: vehicleNumber,
+		Status:        models.ParkingSessionStatusOpen,
+		Limit:         1,
+	})
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	if len(parkingSessions) == 0 {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    "No vehicle with this number is parked",
+		}
+	}
+	if err = checkParkingLotAccess(ctx, parkingSessions[0].ParkingLotId); err != nil {
+		return nil, err
+	}
+	return parkingSessions[0], nil
+}
+
+// priceQuotedStay prices the stay of a parking session leaving now, or charges the fare of a quote of the
+// session that is still valid. A quote is only honoured with the discount code it was given for, and not when
+// its stay no longer comes to the quoted fare, e.g. once the discount code was used up.
+func (s *impl) priceQuotedStay(ctx context.Context, parkingSession *models.ParkingSession,
+	parkingLot *models.ParkingLot, tariff *models.Tariff, quoteId uint, discountCode string, now time.Time,
+	lostTicket bool) (*stayFare, error) {
+
+	if quoteId == 0 {
+		return s.priceStay(ctx, parkingSession, parkingLot, tariff, now, discountCode, lostTicket)
+	}
+
+	quote, err := s.parkingLotRepo.GetFareQuoteById(ctx, quoteId)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	if err != nil || quote.SessionId != parkingSession.ID {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    "No quote with this ID was given for the ticket",
+		}
+	}
+	if now.After(quote.ValidUntil) {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Quote has expired, ask for a new quote",
+		}
+	}
+	if code := strings.ToUpper(strings.TrimSpace(discountCode)); code != "" && code != quote.DiscountCode {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Quote was given for another discount code, ask for a new quote",
+		}
+	}
+
+	stay, err := s.priceStay(ctx, parkingSession, parkingLot, tariff, quote.QuotedAt, quote.DiscountCode, lostTicket)
+	if err != nil {
+		return nil, err
+	}
+	if stay.netFare != money.New(quote.Fare, quote.Currency) {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Quoted fare no longer applies, ask for a new quote",
+		}
+	}
+	return stay, nil
+}
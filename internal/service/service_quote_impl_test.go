@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"parking_lot_service/internal/service/payment"
+	"testing"
+	"time"
+)
+
+func TestGetQuote_FareHoldsUntilPaid(t *testing.T) {
+	fake := newFakeRepo(1)
+	fake.tariffs[0].ExitGraceMinutes = 15
+	svc := NewParkingLotService(fake, payment.NewLocal())
+
+	parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0001",
+	})
+	if err != nil {
+		t.Fatalf("ParkVehicle() error = %v", err)
+	}
+	ticketNumber := parked.ParkingTicket.TicketNumber
+	fake.sessions[1].EntryTime = time.Now().Add(-55 * time.Minute)
+
+	quote, err := svc.GetQuote(context.Background(), &model.QuoteQuery{VehicleNumber: "KA-01-0001"})
+	if err != nil {
+		t.Fatalf("GetQuote() error = %v", err)
+	}
+	if quote.Parking.TicketNumber != ticketNumber || quote.Parking.TotalFare != inr(2000) {
+		t.Errorf("GetQuote() = %+v, want a fare of 20.00 INR for %s", quote.Parking, ticketNumber)
+	}
+	if got := quote.ValidUntil.Sub(quote.QuotedAt); got != 10*time.Minute {
+		t.Errorf("GetQuote() valid for %v, want 10m", got)
+	}
+	if fake.sessions[1].PaidAt != nil || len(fake.invoices) != 0 {
+		t.Errorf("GetQuote() paid or invoiced the session, want it left as it is")
+	}
+
+	// Paying takes 8 minutes, the stay is past its first hour by then
+	elapse(fake, 8*time.Minute)
+
+	paid, err := svc.PayTicket(context.Background(), &model.PayTicketRequest{
+		TicketNumber: ticketNumber, Gateway: payment.Local, QuoteID: quote.QuoteID,
+	})
+	if err != nil {
+		t.Fatalf("PayTicket() error = %v", err)
+	}
+	if paid.Parking.TotalFare != inr(2000) || paid.Invoice == nil || paid.Invoice.Status != string(models.InvoiceStatusPaid) {
+		t.Errorf("PayTicket() = %+v, want the quoted fare of 20.00 INR paid", paid)
+	}
+
+	// The exit grace period starts at payment, not at the time of the quote
+	unparked, err := svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{TicketNumber: ticketNumber})
+	if err != nil {
+		t.Fatalf("UnParkVehicle() error = %v", err)
+	}
+	if !unparked.Exited || !unparked.Parking.TotalFare.IsZero() {
+		t.Errorf("UnParkVehicle() = %+v, want the vehicle to exit without paying more", unparked)
+	}
+	if got := inr(*fake.sessions[1].Fare); got != inr(2000) {
+		t.Errorf("session fare = %v, want 20.00 INR", got)
+	}
+}
+
+func TestGetQuote_Errors(t *testing.T) {
+	fake := newFakeRepo(1)
+	svc := NewParkingLotService(fake, payment.NewLocal())
+
+	parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0001",
+	})
+	if err != nil {
+		t.Fatalf("ParkVehicle() error = %v", err)
+	}
+	ticketNumber := parked.ParkingTicket.TicketNumber
+	fake.sessions[1].EntryTime = time.Now().Add(-90 * time.Minute)
+
+	_, err = svc.GetQuote(context.Background(), &model.QuoteQuery{})
+	wantErrorStatus(t, "GetQuote() without a ticket or vehicle", err, http.StatusBadRequest)
+	_, err = svc.GetQuote(context.Background(), &model.QuoteQuery{TicketNumber: ticketNumber, VehicleNumber: "KA-01-0001"})
+	wantErrorStatus(t, "GetQuote() with a ticket and a vehicle", err, http.StatusBadRequest)
+	_, err = svc.GetQuote(context.Background(), &model.QuoteQuery{VehicleNumber: "KA-01-9999"})
+	wantErrorStatus(t, "GetQuote() of a vehicle that is not parked", err, http.StatusNotFound)
+
+	quote, err := svc.GetQuote(context.Background(), &model.QuoteQuery{TicketNumber: ticketNumber})
+	if err != nil {
+		t.Fatalf("GetQuote() error = %v", err)
+	}
+	if quote.Parking.TotalFare != inr(4000) {
+		t.Errorf("GetQuote() fare = %v, want 40.00 INR", quote.Parking.TotalFare)
+	}
+
+	// A quote past its validity is not honoured, the driver asks for a new one
+	elapse(fake, 11*time.Minute)
+	_, err = svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{
+		TicketNumber: ticketNumber, QuoteID: quote.QuoteID,
+	})
+	wantErrorStatus(t, "UnParkVehicle() with an expired quote", err, http.StatusConflict)
+	if len(fake.invoices) != 0 {
+		t.Errorf("UnParkVehicle() with an expired quote issued %d invoices, want none", len(fake.invoices))
+	}
+}
+
+func TestUnParkVehicle_OnlyIssuedQuotesHold(t *testing.T) {
+	fake := newFakeRepo(3)
+	svc := NewParkingLotService(fake)
+
+	var tickets []string
+	for _, vehicleNumber := range []string{"KA-01-0001", "KA-01-0002"} {
+		parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+			ParkingLotID: 1, VehicleID: 1, VehicleNumber: vehicleNumber,
+		})
+		if err != nil {
+			t.Fatalf("ParkVehicle() error = %v", err)
+		}
+		fake.openSessions[vehicleNumber].EntryTime = time.Now().Add(-150 * time.Minute)
+		tickets = append(tickets, parked.ParkingTicket.TicketNumber)
+	}
+	quote, err := svc.GetQuote(context.Background(), &model.QuoteQuery{TicketNumber: tickets[1]})
+	if err != nil {
+		t.Fatalf("GetQuote() error = %v", err)
+	}
+
+	// A time sent back by the driver is no quote, the stay is priced up to the exit
+	var madeUp model.UnParkVehicleRequest
+	body := fmt.Sprintf(`{"ticket_number": %q, "quoted_at": %q}`, tickets[0],
+		time.Now().Add(-100*time.Minute).Format(time.RFC3339))
+	if err = json.Unmarshal([]byte(body), &madeUp); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	unparked, err := svc.UnParkVehicle(context.Background(), &madeUp)
+	if err != nil {
+		t.Fatalf("UnParkVehicle() with a made up quote time error = %v", err)
+	}
+	if unparked.Parking.TotalFare != inr(6000) {
+		t.Errorf("UnParkVehicle() with a made up quote time fare = %v, want 60.00 INR for 3 hours",
+			unparked.Parking.TotalFare)
+	}
+
+	tests := []struct {
+		name           string
+		quoteId        uint
+		discountCode   string
+		wantStatusCode int
+	}{
+		{name: "never given", quoteId: quote.QuoteID + 1, wantStatusCode: http.StatusNotFound},
+		{name: "given for another discount code", quoteId: quote.QuoteID, discountCode: "SAVE10",
+			wantStatusCode: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		_, err = svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{
+			TicketNumber: tickets[1], QuoteID: tt.quoteId, DiscountCode: tt.discountCode,
+		})
+		wantErrorStatus(t, "UnParkVehicle() with a quote "+tt.name, err, tt.wantStatusCode)
+	}
+
+	// The quote of one ticket does not hold for another
+	parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0003",
+	})
+	if err != nil {
+		t.Fatalf("ParkVehicle() error = %v", err)
+	}
+	_, err = svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{
+		TicketNumber: parked.ParkingTicket.TicketNumber, QuoteID: quote.QuoteID,
+	})
+	wantErrorStatus(t, "UnParkVehicle() with the quote of another ticket", err, http.StatusNotFound)
+}
+
+// elapse moves the stays and quotes of the fake repo back by d, as if d passed.
+func elapse(fake *fakeRepo, d time.Duration) {
+	for _, parkingSession := range fake.sessions {
+		parkingSession.EntryTime = parkingSession.EntryTime.Add(-d)
+	}
+	for _, quote := range fake.fareQuotes {
+		quote.QuotedAt = quote.QuotedAt.Add(-d)
+		quote.ValidUntil = quote.ValidUntil.Add(-d)
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"strings"
+)
+
+func (s *impl) GetRevenueReport(ctx context.Context, req *model.RevenueReportQuery) (*model.RevenueReportResponse, error) {
+	if err := checkParkingLotFilter(ctx, req.ParkingLotID); err != nil {
+		return nil, err
+	}
+	if req.ParkingLotID > 0 {
+		if _, err := s.getParkingLot(ctx, req.ParkingLotID); err != nil {
+			return nil, err
+		}
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if currency != "" && !money.ValidCurrency(currency) {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Currency must be an ISO 4217 currency code",
+		}
+	}
+
+	totals, err := s.parkingLotRepo.GetRevenueByCurrency(ctx, &repo.RevenueFilter{
+		ParkingLotId: req.ParkingLotID,
+		ExitedFrom:   req.From,
+		ExitedTo:     req.To,
+	})
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	resp := &model.RevenueReportResponse{Totals: make([]model.RevenueTotal, 0, len(totals))}
+	for _, total := range totals {
+		resp.Totals = append(resp.Totals, model.RevenueTotal{
+			Currency:  total.Currency,
+			Sessions:  total.Sessions,
+			GrossFare: money.New(total.GrossFare, total.Currency),
+			Fare:      money.New(total.Fare, total.Currency),
+		})
+	}
+
+	if currency != "" {
+		resp.Converted, err = s.convertRevenue(ctx, resp.Totals, currency)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// convertRevenue adds up the totals of a revenue report in one currency at the configured exchange rates. A
+// currency pair is converted at its own rate, or at the inverse of the rate of the opposite pair.
+func (s *impl) convertRevenue(ctx context.Context, totals []model.RevenueTotal,
+	currency string) (*model.ConvertedRevenue, error) {
+
+	exchangeRates, err := s.parkingLotRepo.GetExchangeRates(ctx)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	converted := &model.ConvertedRevenue{
+		Currency:  currency,
+		GrossFare: money.Zero(currency),
+		Fare:      money.Zero(currency),
+		Rates:     []model.AppliedRate{},
+	}
+	for _, total := range totals {
+		converted.Sessions += total.Sessions
+		if total.Currency == currency {
+			converted.GrossFare = converted.GrossFare.Add(total.GrossFare)
+			converted.Fare = converted.Fare.Add(total.Fare)
+			continue
+		}
+
+		rate, ok := findExchangeRate(exchangeRates, total.Currency, currency)
+		if !ok {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    "No exchange rate between " + total.Currency + " and " + currency,
+			}
+		}
+		converted.GrossFare = converted.GrossFare.Add(total.GrossFare.Convert(currency, rate))
+		converted.Fare = converted.Fare.Add(total.Fare.Convert(currency, rate))
+		converted.Rates = append(converted.Rates, model.AppliedRate{From: total.Currency, To: currency, Rate: rate.String()})
+	}
+	return converted, nil
+}
+
+// findExchangeRate returns the rate converting one currency into another, preferring the rate of the pair itself
+// over the inverse of the opposite pair. It reports false when neither pair has a rate.
+func findExchangeRate(exchangeRates []*models.ExchangeRate, from, to string) (money.Rate, bool) {
+	for _, inverse := range []bool{false, true} {
+		base, quote := from, to
+		if inverse {
+			base, quote = to, from
+		}
+		for _, exchangeRate := range exchangeRates {
+			if exchangeRate.BaseCurrency != base || exchangeRate.QuoteCurrency != quote {
+				continue
+			}
+			rate, err := money.ParseRate(exchangeRate.Rate)
+			if err != nil {
+				continue
+			}
+			if inverse {
+				return rate.Inverse(), true
+			}
+			return rate, true
+		}
+	}
+	return money.Rate{}, false
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetRevenueReport(t *testing.T) {
+	exitTime := time.Date(2024, time.January, 5, 12, 0, 0, 0, time.UTC)
+	closedSession := func(id uint, parkingLotId int, currency string, grossFare, fare int64) *models.ParkingSession {
+		return &models.ParkingSession{ID: id, ParkingLotId: parkingLotId, Status: models.ParkingSessionStatusClosed,
+			ExitTime: &exitTime, Currency: currency, GrossFare: &grossFare, Fare: &fare}
+	}
+	usd := func(amount int64) money.Money {
+		return money.New(amount, "USD")
+	}
+
+	tests := []struct {
+		name          string
+		currency      string
+		exchangeRates []*models.ExchangeRate
+		wantConverted *model.ConvertedRevenue
+		wantStatus    int
+	}{
+		{
+			name: "Totals per currency",
+		},
+		{
+			name:     "Converted at the rate of the pair",
+			currency: "usd",
+			exchangeRates: []*models.ExchangeRate{
+				{BaseCurrency: "INR", QuoteCurrency: "USD", Rate: "0.012"},
+				{BaseCurrency: "USD", QuoteCurrency: "INR", Rate: "80"},
+			},
+			wantConverted: &model.ConvertedRevenue{
+				Currency: "USD", Sessions: 3, GrossFare: usd(2500 + 120), Fare: usd(2000 + 90),
+				Rates: []model.AppliedRate{{From: "INR", To: "USD", Rate: "0.012"}},
+			},
+		},
+		{
+			name:          "Converted at the inverse rate of the opposite pair",
+			currency:      "INR",
+			exchangeRates: []*models.ExchangeRate{{BaseCurrency: "INR", QuoteCurrency: "USD", Rate: "0.0125"}},
+			wantConverted: &model.ConvertedRevenue{
+				Currency: "INR", Sessions: 3, GrossFare: inr(10000 + 200000), Fare: inr(7500 + 160000),
+				Rates: []model.AppliedRate{{From: "USD", To: "INR", Rate: "80"}},
+			},
+		},
+		{
+			name:       "No exchange rate",
+			currency:   "EUR",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeRepo(1)
+			fake.exchangeRates = tt.exchangeRates
+			fake.sessions[1] = closedSession(1, 1, "INR", 6000, 4500)
+			fake.sessions[2] = closedSession(2, 1, "INR", 4000, 3000)
+			fake.sessions[3] = closedSession(3, 2, "USD", 2500, 2000)
+			svc := NewParkingLotService(fake)
+
+			resp, err := svc.GetRevenueReport(context.Background(), &model.RevenueReportQuery{Currency: tt.currency})
+			if tt.wantStatus != 0 {
+				var genericErr *genericresponse.GenericResponse
+				if !errors.As(err, &genericErr) || genericErr.StatusCode != tt.wantStatus {
+					t.Fatalf("GetRevenueReport() error = %v, want status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetRevenueReport() error = %v", err)
+			}
+
+			wantTotals := []model.RevenueTotal{
+				{Currency: "INR", Sessions: 2, GrossFare: inr(10000), Fare: inr(7500)},
+				{Currency: "USD", Sessions: 1, GrossFare: usd(2500), Fare: usd(2000)},
+			}
+			if !reflect.DeepEqual(resp.Totals, wantTotals) {
+				t.Errorf("GetRevenueReport() totals = %+v, want %+v", resp.Totals, wantTotals)
+			}
+			if !reflect.DeepEqual(resp.Converted, tt.wantConverted) {
+				t.Errorf("GetRevenueReport() converted = %+v, want %+v", resp.Converted, tt.wantConverted)
+			}
+		})
+	}
+}
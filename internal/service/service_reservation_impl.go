@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/repo"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"strings"
+	"time"
+)
+
+const (
+	defaultReservationGraceMinutes = 15
+	maxReservationGraceMinutes     = 24 * 60
+)
+
+func (s *impl) CreateReservation(ctx context.Context, req *model.ReservationRequest) (*model.ReservationResponse, error) {
+	parkingLot, err := s.getParkingLot(ctx, req.ParkingLotID)
+	if err != nil {
+		return nil, err
+	}
+	if parkingLot.Status != models.ParkingLotStatusActive {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Parking lot is closed",
+		}
+	}
+	if _, err = s.getVehicleType(ctx, req.VehicleID); err != nil {
+		return nil, err
+	}
+
+	reservation := &models.Reservation{
+		ParkingLotId:  parkingLot.ID,
+		VehicleTypeId: req.VehicleID,
+	}
+	err = applyReservationWindow(reservation, parkingLot, req.VehicleNumber, req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.parkingLotRepo.CreateReservation(ctx, reservation)
+	if err != nil {
+		return nil, reservationWriteError(err)
+	}
+
+	return toReservationResponse(reservation), nil
+}
+
+func (s *impl) GetReservationById(ctx context.Context, reservationId uint) (*model.ReservationResponse, error) {
+	reservation, err := s.getReservation(ctx, reservationId)
+	if err != nil {
+		return nil, err
+	}
+	return toReservationResponse(reservation), nil
+}
+
+func (s *impl) UpdateReservation(ctx context.Context, reservationId uint,
+	req *model.ReservationUpdateRequest) (*model.ReservationResponse, error) {
+
+	reservation, err := s.getReservation(ctx, reservationId)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.Status != models.ReservationStatusBooked {
+		return nil, reservationWriteError(repo.ErrReservationNotBooked)
+	}
+
+	parkingLot, err := s.getParkingLot(ctx, reservation.ParkingLotId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = applyReservationWindow(reservation, parkingLot, req.VehicleNumber, req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.parkingLotRepo.UpdateReservation(ctx, reservation)
+	if err != nil {
+		return nil, reservationWriteError(err)
+	}
+
+	return toReservationResponse(reservation), nil
+}
+
+func (s *impl) CancelReservation(ctx context.Context, reservationId uint) error {
+	if _, err := s.getReservation(ctx, reservationId); err != nil {
+		return err
+	}
+
+	err := s.parkingLotRepo.CancelReservation(ctx, reservationId)
+	if err != nil {
+		return reservationWriteError(err)
+	}
+	return nil
+}
+
+// MarkNoShowReservations marks the reservations whose vehicle did not arrive within the grace period as no-shows.
+// Their holds are released as soon as the grace period ends, this only brings the status up to date.
+// It is run periodically by the scheduler rather than on a request.
+func (s *impl) MarkNoShowReservations(ctx context.Context) error {
+	_, err := s.parkingLotRepo.MarkNoShowReservations(ctx, time.Now())
+	return err
+}
+
+// getReservation fetches a reservation from the repo and maps a missing reservation to a 404 response.
+func (s *impl) getReservation(ctx context.Context, reservationId uint) (*models.Reservation, error) {
+	reservation, err := s.parkingLotRepo.GetReservationById(ctx, reservationId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "reservation not found",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	if err = checkParkingLotAccess(ctx, reservation.ParkingLotId); err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// getReservationForCheckIn fetches the reservation a vehicle is parked against and makes sure the vehicle
+// may check in to it at the given time.
+func (s *impl) getReservationForCheckIn(ctx context.Context, req *model.ParkVehicleRequest,
+	at time.Time) (*models.Reservation, error) {
+
+	reservation, err := s.getReservation(ctx, req.ReservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case reservation.ParkingLotId != req.ParkingLotID || reservation.VehicleTypeId != req.VehicleID:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Reservation is for another parking lot or vehicle type",
+		}
+	case !strings.EqualFold(reservation.VehicleNumber, strings.TrimSpace(req.VehicleNumber)):
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Reservation is for another vehicle",
+		}
+	case reservation.Status != models.ReservationStatusBooked:
+		return nil, reservationWriteError(repo.ErrReservationNotBooked)
+	case !at.Before(reservation.HoldUntil):
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Reservation has expired",
+		}
+	}
+	return reservation, nil
+}
+
+// getReservationHolds returns the spots held for reservations in a parking lot at the given time per vehicle type.
+func (s *impl) getReservationHolds(ctx context.Context, txRepo repo.ParkingLotRepo, parkingLotId int,
+	at time.Time) (map[int]int, error) {
+
+	holds, err := txRepo.GetReservationHolds(ctx, parkingLotId, at)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	heldSpots := make(map[int]int, len(holds))
+	for _, hold := range holds {
+		heldSpots[hold.VehicleTypeId] += hold.HeldSpots
+	}
+	return heldSpots, nil
+}
+
+// applyReservationWindow validates the vehicle and arrival window of a reservation request and copies them onto
+// a reservation. The spot is held from the start of the window for the lot's grace period, at most until its end.
+func applyReservationWindow(reservation *models.Reservation, parkingLot *models.ParkingLot, vehicleNumber string,
+	startTime, endTime time.Time) error {
+
+	vehicleNumber = strings.TrimSpace(vehicleNumber)
+	if vehicleNumber == "" {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Vehicle number is required",
+		}
+	}
+	if !startTime.Before(endTime) {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Reservation start time must be before its end time",
+		}
+	}
+
+	holdUntil := startTime.Add(time.Duration(parkingLot.ReservationGraceMinutes) * time.Minute)
+	if holdUntil.After(endTime) {
+		holdUntil = endTime
+	}
+	if !holdUntil.After(time.Now()) {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Reservation window has already passed",
+		}
+	}
+
+	reservation.VehicleNumber = vehicleNumber
+	reservation.StartTime = startTime
+	reservation.EndTime = endTime
+	reservation.HoldUntil = holdUntil
+	return nil
+}
+
+// reservationWriteError maps an error from booking, modifying or cancelling a reservation to a response.
+func reservationWriteError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    "Parking lot has no spots for this vehicle type",
+		}
+	case errors.Is(err, repo.ErrReservationCapacity):
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "No spots left to reserve in this window",
+		}
+	case errors.Is(err, repo.ErrReservationNotBooked):
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Reservation is no longer booked",
+		}
+	}
+	return &genericresponse.GenericResponse{
+		StatusCode: http.StatusInternalServerError,
+		Message:    err.Error(),
+	}
+}
+
+func toReservationResponse(reservation *models.Reservation) *model.ReservationResponse {
+	return &model.ReservationResponse{
+		ID:            reservation.ID,
+		ParkingLotID:  reservation.ParkingLotId,
+		VehicleID:     reservation.VehicleTypeId,
+		VehicleNumber: reservation.VehicleNumber,
+		StartTime:     reservation.StartTime,
+		EndTime:       reservation.EndTime,
+		HoldUntil:     reservation.HoldUntil,
+		Status:        string(reservation.Status),
+		SessionID:     reservation.SessionId,
+		CreatedAt:     reservation.CreatedAt,
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"testing"
+	"time"
+)
+
+func TestParkVehicle_ReservationHoldsSpotUntilCheckIn(t *testing.T) {
+	fake := newFakeRepo(2)
+	fake.reservations[1] = &models.Reservation{ID: 1, ParkingLotId: 1, VehicleTypeId: 1, VehicleNumber: "KA-01-0001",
+		StartTime: time.Now().Add(-5 * time.Minute), EndTime: time.Now().Add(time.Hour),
+		HoldUntil: time.Now().Add(10 * time.Minute), Status: models.ReservationStatusBooked}
+	svc := NewParkingLotService(fake)
+
+	// One of the two spots is held, so only one walk-in vehicle gets in
+	_, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0002",
+	})
+	if err != nil {
+		t.Fatalf("ParkVehicle() first walk-in error = %v", err)
+	}
+	_, err = svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0003",
+	})
+	var genericErr *genericresponse.GenericResponse
+	if !errors.As(err, &genericErr) || genericErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("ParkVehicle() second walk-in error = %v, want status %d", err, http.StatusNotFound)
+	}
+
+	// The reservation can only be checked in by its own vehicle
+	_, err = svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0003", ReservationID: 1,
+	})
+	if !errors.As(err, &genericErr) || genericErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("ParkVehicle() other vehicle error = %v, want status %d", err, http.StatusBadRequest)
+	}
+
+	parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0001", ReservationID: 1,
+	})
+	if err != nil {
+		t.Fatalf("ParkVehicle() check-in error = %v", err)
+	}
+	if parked.ParkingTicket.Spot == nil {
+		t.Fatalf("ParkVehicle() check-in got no spot")
+	}
+
+	reservation := fake.reservations[1]
+	if reservation.Status != models.ReservationStatusCheckedIn || reservation.SessionId == nil {
+		t.Errorf("reservation = %+v, want checked in with a session", reservation)
+	}
+	if got := fake.parkingSpaces[[2]int{1, 1}].AvailableSpots; got != 0 {
+		t.Errorf("AvailableSpots = %d, want 0", got)
+	}
+}
+
+func TestParkVehicle_NoShowReleasesHold(t *testing.T) {
+	fake := newFakeRepo(1)
+	fake.reservations[1] = &models.Reservation{ID: 1, ParkingLotId: 1, VehicleTypeId: 1, VehicleNumber: "KA-01-0001",
+		StartTime: time.Now().Add(-time.Hour), EndTime: time.Now().Add(time.Hour),
+		HoldUntil: time.Now().Add(-45 * time.Minute), Status: models.ReservationStatusBooked}
+	svc := NewParkingLotService(fake)
+
+	// The grace period has passed, the held spot is free for walk-ins again
+	_, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0002",
+	})
+	if err != nil {
+		t.Fatalf("ParkVehicle() walk-in error = %v", err)
+	}
+
+	_, err = svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0001", ReservationID: 1,
+	})
+	var genericErr *genericresponse.GenericResponse
+	if !errors.As(err, &genericErr) || genericErr.StatusCode != http.StatusConflict {
+		t.Fatalf("ParkVehicle() late check-in error = %v, want status %d", err, http.StatusConflict)
+	}
+	if got := fake.reservations[1].Status; got != models.ReservationStatusBooked {
+		t.Errorf("reservation status = %s, want %s", got, models.ReservationStatusBooked)
+	}
+}
+
+func TestParkVehicle_HoldsCountedOnceThePoolIsLocked(t *testing.T) {
+	fake := newFakeRepo(1)
+	svc := NewParkingLotService(fake)
+
+	// A reservation for now is booked while the walk-in vehicle waits for the pool
+	fake.lockHook = func() {
+		fake.reservations[1] = &models.Reservation{ID: 1, ParkingLotId: 1, VehicleTypeId: 1,
+			VehicleNumber: "KA-01-0001", StartTime: time.Now().Add(-time.Minute), EndTime: time.Now().Add(time.Hour),
+			HoldUntil: time.Now().Add(10 * time.Minute), Status: models.ReservationStatusBooked}
+	}
+
+	_, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0002",
+	})
+	wantErrorStatus(t, "ParkVehicle() of a walk-in after the last spot was reserved", err, http.StatusNotFound)
+	if got := fake.parkingSpaces[[2]int{1, 1}].AvailableSpots; got != 1 {
+		t.Errorf("AvailableSpots = %d, want the spot left for the reservation", got)
+	}
+
+	fake.lockHook = nil
+	if _, err = svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0001", ReservationID: 1,
+	}); err != nil {
+		t.Errorf("ParkVehicle() check-in error = %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultSessionPageSize = 50
+	maxSessionPageSize     = 200
+)
+
+func (s *impl) GetParkingSessions(ctx context.Context, req *model.ParkingSessionQuery) (*model.ParkingSessionPage, error) {
+	if err := checkParkingLotFilter(ctx, req.ParkingLotID); err != nil {
+		return nil, err
+	}
+
+	filter := &repo.ParkingSessionFilter{
+		VehicleNumber: strings.TrimSpace(req.VehicleNumber),
+		ParkingLotId:  req.ParkingLotID,
+		Status:        models.ParkingSessionStatus(req.Status),
+		EnteredFrom:   req.From,
+		EnteredTo:     req.To,
+		Limit:         req.Limit,
+	}
+
+	switch filter.Status {
+	case "", models.ParkingSessionStatusOpen, models.ParkingSessionStatusClosed:
+	default:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Session status must be open or closed",
+		}
+	}
+
+	switch {
+	case filter.Limit == 0:
+		filter.Limit = defaultSessionPageSize
+	case filter.Limit < 0 || filter.Limit > maxSessionPageSize:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Limit must be between 1 and " + strconv.Itoa(maxSessionPageSize),
+		}
+	}
+
+	if req.Cursor != "" {
+		beforeId, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Invalid cursor",
+			}
+		}
+		filter.BeforeId = beforeId
+	}
+
+	// Fetch one session more than requested to find out whether there is a next page
+	pageSize := filter.Limit
+	filter.Limit++
+
+	parkingSessions, err := s.parkingLotRepo.GetParkingSessions(ctx, filter)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	resp := &model.ParkingSessionPage{Sessions: make([]*model.ParkingSessionResponse, 0, pageSize)}
+	if len(parkingSessions) > pageSize {
+		parkingSessions = parkingSessions[:pageSize]
+		resp.NextCursor = encodeCursor(parkingSessions[pageSize-1].ID)
+	}
+	for _, parkingSession := range parkingSessions {
+		resp.Sessions = append(resp.Sessions, toParkingSessionResponse(parkingSession))
+	}
+	return resp, nil
+}
+
+// encodeCursor turns the ID of the last row of a page into an opaque cursor for the next page.
+func encodeCursor(id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(id), 10)))
+}
+
+// decodeCursor recovers the ID from a cursor built by encodeCursor.
+func decodeCursor(cursor string) (uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseUint(string(raw), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+func toParkingSessionResponse(parkingSession *models.ParkingSession) *model.ParkingSessionResponse {
+	return &model.ParkingSessionResponse{
+		ID:            parkingSession.ID,
+		TicketNumber:  parkingSession.TicketNumber,
+		VehicleNumber: parkingSession.VehicleNumber,
+		ParkingLotID:  parkingSession.ParkingLotId,
+		VehicleID:     parkingSession.VehicleTypeId,
+		VehicleName:   parkingSession.VehicleName,
+		SpotID:        parkingSession.SpotId,
+		SpotVehicleID: parkingSession.SpotVehicleTypeId,
+		EntryTime:     parkingSession.EntryTime,
+		PaidAt:        parkingSession.PaidAt,
+		ExitTime:      parkingSession.ExitTime,
+		GrossFare:     sessionFare(parkingSession.GrossFare, parkingSession.Currency),
+		Fare:          sessionFare(parkingSession.Fare, parkingSession.Currency),
+		Status:        string(parkingSession.Status),
+	}
+}
+
+// sessionFare returns a fare stored on a session in its currency, or nil while it has not been charged.
+func sessionFare(amount *int64, currency string) *money.Money {
+	if amount == nil {
+		return nil
+	}
+	fare := money.New(*amount, currency)
+	return &fare
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"parking_lot_service/internal/auth"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"testing"
+)
+
+func TestGetParkingSessions_PagesThroughHistory(t *testing.T) {
+	const stays = 5
+
+	fake := newFakeRepo(stays)
+	svc := NewParkingLotService(fake)
+
+	for i := 0; i < stays; i++ {
+		vehicleNumber := fmt.Sprintf("KA-01-%04d", i)
+		parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+			ParkingLotID: 1, VehicleID: 1, VehicleNumber: vehicleNumber,
+		})
+		if err != nil {
+			t.Fatalf("ParkVehicle() error = %v", err)
+		}
+		_, err = svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{
+			TicketNumber: parked.ParkingTicket.TicketNumber,
+		})
+		if err != nil {
+			t.Fatalf("UnParkVehicle() error = %v", err)
+		}
+	}
+
+	// The same vehicle can park again once its previous session is closed
+	_, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0000",
+	})
+	if err != nil {
+		t.Fatalf("ParkVehicle() again error = %v", err)
+	}
+
+	var (
+		pages  []int
+		seen   []uint
+		cursor string
+	)
+	for {
+		page, err := svc.GetParkingSessions(context.Background(), &model.ParkingSessionQuery{
+			Status: string(models.ParkingSessionStatusClosed),
+			Cursor: cursor,
+			Limit:  2,
+		})
+		if err != nil {
+			t.Fatalf("GetParkingSessions() error = %v", err)
+		}
+
+		pages = append(pages, len(page.Sessions))
+		for _, session := range page.Sessions {
+			if session.ExitTime == nil || session.Fare == nil {
+				t.Errorf("session %d is closed without exit time or fare", session.ID)
+			}
+			seen = append(seen, session.ID)
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	if fmt.Sprint(pages) != "[2 2 1]" {
+		t.Errorf("GetParkingSessions() page sizes = %v, want [2 2 1]", pages)
+	}
+	if fmt.Sprint(seen) != "[5 4 3 2 1]" {
+		t.Errorf("GetParkingSessions() session IDs = %v, want [5 4 3 2 1]", seen)
+	}
+}
+
+func TestGetParkingSessions_InvalidQuery(t *testing.T) {
+	svc := NewParkingLotService(newFakeRepo(1))
+
+	tests := []struct {
+		name  string
+		query *model.ParkingSessionQuery
+	}{
+		{name: "Unknown status", query: &model.ParkingSessionQuery{Status: "parked"}},
+		{name: "Limit above maximum", query: &model.ParkingSessionQuery{Limit: maxSessionPageSize + 1}},
+		{name: "Malformed cursor", query: &model.ParkingSessionQuery{Cursor: "not a cursor"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.GetParkingSessions(context.Background(), tt.query); err == nil {
+				t.Errorf("GetParkingSessions() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestGetParkingSessions_ScopedToParkingLots(t *testing.T) {
+	svc := NewParkingLotService(newFakeRepo(1))
+	ctx := auth.NewContext(context.Background(), &auth.Principal{
+		Subject: "asha", Role: auth.RoleAttendant, ParkingLotIDs: []int{1},
+	})
+
+	if _, err := svc.GetParkingSessions(ctx, &model.ParkingSessionQuery{ParkingLotID: 1}); err != nil {
+		t.Errorf("GetParkingSessions() of a lot of the caller error = %v", err)
+	}
+	_, err := svc.GetParkingSessions(ctx, &model.ParkingSessionQuery{ParkingLotID: 2})
+	wantErrorStatus(t, "GetParkingSessions() of another lot", err, http.StatusForbidden)
+	_, err = svc.GetParkingSessions(ctx, &model.ParkingSessionQuery{})
+	wantErrorStatus(t, "GetParkingSessions() of every lot", err, http.StatusForbidden)
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/repo"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"strings"
+	"time"
+)
+
+func (s *impl) GetSpots(ctx context.Context, parkingLotId, vehicleTypeId int) ([]*model.SpotResponse, error) {
+	if _, err := s.getParkingLot(ctx, parkingLotId); err != nil {
+		return nil, err
+	}
+
+	spots, err := s.parkingLotRepo.GetSpots(ctx, parkingLotId, vehicleTypeId)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	resp := make([]*model.SpotResponse, 0, len(spots))
+	for _, spot := range spots {
+		resp = append(resp, toSpotResponse(spot))
+	}
+	return resp, nil
+}
+
+func (s *impl) CreateSpot(ctx context.Context, parkingLotId int, req *model.SpotRequest) (*model.SpotResponse, error) {
+	parkingLot, err := s.getParkingLot(ctx, parkingLotId)
+	if err != nil {
+		return nil, err
+	}
+	vehicleType, err := s.getVehicleType(ctx, req.VehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	spot := &models.Spot{
+		ParkingLotId:  parkingLotId,
+		VehicleTypeId: vehicleType.ID,
+		SizeClass:     vehicleType.SizeClass,
+	}
+	if err = applySpotRequest(spot, req); err != nil {
+		return nil, err
+	}
+
+	// Add the spot and publish the grown capacity in a single transaction
+	err = s.parkingLotRepo.WithTx(ctx, func(txRepo repo.ParkingLotRepo) error {
+		err := txRepo.CreateSpot(ctx, spot)
+		if err != nil {
+			return spotWriteError(err)
+		}
+		return publishSpotCapacityChange(ctx, txRepo, parkingLot, spot.VehicleTypeId, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toSpotResponse(spot), nil
+}
+
+func (s *impl) UpdateSpot(ctx context.Context, spotId uint, req *model.SpotRequest) (*model.SpotResponse, error) {
+	spot, err := s.getSpot(ctx, spotId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = applySpotRequest(spot, req); err != nil {
+		return nil, err
+	}
+
+	err = s.parkingLotRepo.UpdateSpot(ctx, spot)
+	if err != nil {
+		return nil, spotWriteError(err)
+	}
+
+	return toSpotResponse(spot), nil
+}
+
+func (s *impl) DeleteSpot(ctx context.Context, spotId uint) error {
+	spot, err := s.getSpot(ctx, spotId)
+	if err != nil {
+		return err
+	}
+
+	parkingLot, err := s.getParkingLot(ctx, spot.ParkingLotId)
+	if err != nil {
+		return err
+	}
+
+	// Remove the spot and publish the shrunk capacity in a single transaction
+	return s.parkingLotRepo.WithTx(ctx, func(txRepo repo.ParkingLotRepo) error {
+		err := txRepo.DeleteSpot(ctx, spot)
+		if err != nil {
+			if errors.Is(err, repo.ErrSpotOccupied) {
+				return &genericresponse.GenericResponse{
+					StatusCode: http.StatusConflict,
+					Message:    "Spot is occupied by a vehicle",
+				}
+			}
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    err.Error(),
+			}
+		}
+		return publishSpotCapacityChange(ctx, txRepo, parkingLot, spot.VehicleTypeId, -1)
+	})
+}
+
+// publishSpotCapacityChange publishes the change of the capacity of a parking space by a spot added to or removed
+// from it. Spots are added and removed free, so the free spots change with the capacity.
+func publishSpotCapacityChange(ctx context.Context, txRepo repo.ParkingLotRepo, parkingLot *models.ParkingLot,
+	vehicleTypeId, change int) error {
+
+	parkingSpace, err := txRepo.GetParkingSpace(ctx, parkingLot.ID, vehicleTypeId)
+	if err != nil {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    "Unable to fetch parking space",
+		}
+	}
+	before := &models.ParkingSpace{
+		TotalSpots:     parkingSpace.TotalSpots - change,
+		AvailableSpots: parkingSpace.AvailableSpots - change,
+	}
+	return publishCapacityChange(ctx, txRepo, parkingLot, before, parkingSpace, time.Now())
+}
+
+// ReconcileParkingSpaces brings the spot counters of the parking spaces back in line with the spots.
+// It is run periodically by the scheduler rather than on a request.
+func (s *impl) ReconcileParkingSpaces(ctx context.Context) error {
+	return s.parkingLotRepo.ReconcileParkingSpaces(ctx)
+}
+
+// getSpot fetches a spot from the repo and maps a missing spot to a 404 response.
+func (s *impl) getSpot(ctx context.Context, spotId uint) (*models.Spot, error) {
+	spot, err := s.parkingLotRepo.GetSpotById(ctx, spotId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "spot not found",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	if err = checkParkingLotAccess(ctx, spot.ParkingLotId); err != nil {
+		return nil, err
+	}
+	return spot, nil
+}
+
+// applySpotRequest validates a spot request and copies its location and flags onto a spot.
+func applySpotRequest(spot *models.Spot, req *model.SpotRequest) error {
+	label := strings.TrimSpace(req.Label)
+	if label == "" {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Spot label is required",
+		}
+	}
+	if req.EntranceDistance < 0 {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Entrance distance cannot be negative",
+		}
+	}
+
+	spot.Level = req.Level
+	spot.Zone = strings.TrimSpace(req.Zone)
+	spot.Label = label
+	spot.Covered = req.Covered
+	spot.Handicap = req.Handicap
+	spot.EVCharger = req.EVCharger
+	spot.EntranceDistance = req.EntranceDistance
+	return nil
+}
+
+// spotWriteError maps an error from creating or updating a spot to a response.
+func spotWriteError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Spot with this label already exists in the parking lot",
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    "spot not found",
+		}
+	}
+	return &genericresponse.GenericResponse{
+		StatusCode: http.StatusInternalServerError,
+		Message:    err.Error(),
+	}
+}
+
+func toSpotResponse(spot *models.Spot) *model.SpotResponse {
+	return &model.SpotResponse{
+		ID:               spot.ID,
+		ParkingLotID:     spot.ParkingLotId,
+		VehicleID:        spot.VehicleTypeId,
+		Level:            spot.Level,
+		Zone:             spot.Zone,
+		Label:            spot.Label,
+		SizeClass:        spot.SizeClass,
+		Covered:          spot.Covered,
+		Handicap:         spot.Handicap,
+		EVCharger:        spot.EVCharger,
+		EntranceDistance: spot.EntranceDistance,
+		UsageCount:       spot.UsageCount,
+		Occupied:         spot.Occupied,
+	}
+}
+
+func toSpotLocation(spot *models.Spot) *model.SpotLocation {
+	return &model.SpotLocation{
+		SpotID:    spot.ID,
+		Level:     spot.Level,
+		Zone:      spot.Zone,
+		Label:     spot.Label,
+		VehicleID: spot.VehicleTypeId,
+		SizeClass: spot.SizeClass,
+	}
+}
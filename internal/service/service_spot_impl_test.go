@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"parking_lot_service/internal/events"
+	"parking_lot_service/internal/repo/models"
+	"testing"
+)
+
+func TestReconcileParkingSpaces_RepairsDrift(t *testing.T) {
+	tests := []struct {
+		name  string
+		drift func(fake *fakeRepo)
+	}{
+		{
+			name: "counters above the spots",
+			drift: func(fake *fakeRepo) {
+				fake.parkingSpaces[[2]int{1, 1}].TotalSpots = 5
+				fake.parkingSpaces[[2]int{1, 1}].AvailableSpots = 4
+			},
+		},
+		{
+			name: "counters below the spots",
+			drift: func(fake *fakeRepo) {
+				fake.parkingSpaces[[2]int{1, 1}].TotalSpots = 2
+				fake.parkingSpaces[[2]int{1, 1}].AvailableSpots = 0
+			},
+		},
+		{
+			name: "spot occupied without a session",
+			drift: func(fake *fakeRepo) {
+				fake.spots[3].Occupied = true
+				fake.parkingSpaces[[2]int{1, 1}].AvailableSpots = 1
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeRepo(3)
+			svc := NewParkingLotService(fake)
+			parkVehicles(t, svc, 1)
+			tt.drift(fake)
+
+			if err := svc.ReconcileParkingSpaces(context.Background()); err != nil {
+				t.Fatalf("ReconcileParkingSpaces() error = %v", err)
+			}
+			// One of the three spots is taken by the parked vehicle
+			wantParkingSpace(t, fake, "reconciled", 3, 2, 0, 3)
+			if !fake.spots[1].Occupied || fake.spots[3].Occupied {
+				t.Errorf("spots 1 and 3 occupied = %v and %v, want only the spot of the parked vehicle",
+					fake.spots[1].Occupied, fake.spots[3].Occupied)
+			}
+		})
+	}
+}
+
+func TestReconcileParkingSpaces_KeepsPendingRemovals(t *testing.T) {
+	fake := newFakeRepo(3)
+	svc := NewParkingLotService(fake)
+	parkVehicles(t, svc, 3)
+
+	parkingSpace := fake.parkingSpaces[[2]int{1, 1}]
+	parkingSpace.TotalSpots = 1
+	parkingSpace.AvailableSpots = 0
+	parkingSpace.PendingRemovals = 2
+
+	if err := svc.ReconcileParkingSpaces(context.Background()); err != nil {
+		t.Fatalf("ReconcileParkingSpaces() error = %v", err)
+	}
+	// The spots waiting to be removed are not counted
+	wantParkingSpace(t, fake, "reconciled over capacity", 1, -2, 2, 3)
+}
+
+func TestDeleteSpot_OccupiedConflicts(t *testing.T) {
+	fake := newFakeRepo(2)
+	svc := NewParkingLotService(fake)
+	parkVehicles(t, svc, 1)
+
+	err := svc.DeleteSpot(context.Background(), 1)
+	wantErrorStatus(t, "DeleteSpot() of an occupied spot", err, http.StatusConflict)
+	wantParkingSpace(t, fake, "occupied spot kept", 2, 1, 0, 2)
+
+	err = svc.DeleteSpot(context.Background(), 3)
+	wantErrorStatus(t, "DeleteSpot() of a missing spot", err, http.StatusNotFound)
+
+	if err = svc.DeleteSpot(context.Background(), 2); err != nil {
+		t.Fatalf("DeleteSpot() of a free spot error = %v", err)
+	}
+	wantParkingSpace(t, fake, "free spot removed", 1, 0, 0, 1)
+
+	// Only the removal that succeeded changed the capacity
+	var capacityChanges []*models.OutboxEvent
+	for _, event := range fake.publishedEvents() {
+		if events.Type(event.Type) == events.CapacityChanged {
+			capacityChanges = append(capacityChanges, event)
+		}
+	}
+	if len(capacityChanges) != 1 {
+		t.Errorf("CapacityChanged events = %d, want 1", len(capacityChanges))
+	}
+}
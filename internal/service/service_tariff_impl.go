@@ -0,0 +1,397 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"strings"
+	"time"
+)
+
+// bandTimeLayout is the layout of the times of day bounding a tariff band.
+const bandTimeLayout = "15:04"
+
+func (s *impl) GetTariffs(ctx context.Context, parkingLotId, vehicleTypeId int) ([]*model.TariffResponse, error) {
+	if err := checkParkingLotFilter(ctx, parkingLotId); err != nil {
+		return nil, err
+	}
+
+	tariffs, err := s.parkingLotRepo.GetTariffs(ctx, parkingLotId, vehicleTypeId)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	resp := make([]*model.TariffResponse, 0, len(tariffs))
+	for _, tariff := range tariffs {
+		resp = append(resp, toTariffResponse(tariff))
+	}
+	return resp, nil
+}
+
+func (s *impl) GetTariffById(ctx context.Context, tariffId uint) (*model.TariffResponse, error) {
+	tariff, err := s.getTariff(ctx, tariffId)
+	if err != nil {
+		return nil, err
+	}
+	return toTariffResponse(tariff), nil
+}
+
+func (s *impl) CreateTariff(ctx context.Context, req *model.TariffRequest) (*model.TariffResponse, error) {
+	parkingLot, err := s.getParkingLot(ctx, req.ParkingLotID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.getVehicleType(ctx, req.VehicleID); err != nil {
+		return nil, err
+	}
+
+	tariff := &models.Tariff{
+		TenantId:      parkingLot.TenantId,
+		ParkingLotId:  req.ParkingLotID,
+		VehicleTypeId: req.VehicleID,
+		EffectiveFrom: time.Now().UTC(),
+		EffectiveTo:   req.EffectiveTo,
+	}
+	if req.EffectiveFrom != nil {
+		// A version starting in the past would reprice the vehicles the version it closes already priced
+		if !req.EffectiveFrom.After(time.Now()) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Tariff can only become effective in the future",
+			}
+		}
+		tariff.EffectiveFrom = *req.EffectiveFrom
+	}
+	if tariff.EffectiveTo != nil && !tariff.EffectiveTo.After(tariff.EffectiveFrom) {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Tariff must end after it becomes effective",
+		}
+	}
+
+	if err = applyTariffRates(tariff, &req.TariffRatesRequest, parkingLot.Currency); err != nil {
+		return nil, err
+	}
+
+	err = s.parkingLotRepo.CreateTariff(ctx, tariff)
+	if err != nil {
+		if errors.Is(err, repo.ErrTariffOverlap) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusConflict,
+				Message:    "Tariff overlaps an existing version for this parking lot and vehicle type",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	return toTariffResponse(tariff), nil
+}
+
+func (s *impl) UpdateTariff(ctx context.Context, tariffId uint,
+	req *model.TariffRatesRequest) (*model.TariffResponse, error) {
+
+	tariff, err := s.getTariff(ctx, tariffId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Versions that already priced vehicles are immutable, prices change by creating a new version
+	if !tariff.EffectiveFrom.After(time.Now()) {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Tariff is already effective, create a new version instead",
+		}
+	}
+
+	parkingLot, err := s.getParkingLot(ctx, tariff.ParkingLotId)
+	if err != nil {
+		return nil, err
+	}
+	if err = applyTariffRates(tariff, req, parkingLot.Currency); err != nil {
+		return nil, err
+	}
+
+	err = s.parkingLotRepo.UpdateTariffRates(ctx, tariff)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	return toTariffResponse(tariff), nil
+}
+
+func (s *impl) DeleteTariff(ctx context.Context, tariffId uint) error {
+	tariff, err := s.getTariff(ctx, tariffId)
+	if err != nil {
+		return err
+	}
+
+	if !tariff.EffectiveFrom.After(time.Now()) {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Tariff is already effective and cannot be deleted",
+		}
+	}
+
+	err = s.parkingLotRepo.DeleteTariff(ctx, tariff)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "tariff not found",
+			}
+		}
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	return nil
+}
+
+// getTariff fetches a tariff version from the repo and maps a missing version to a 404 response.
+func (s *impl) getTariff(ctx context.Context, tariffId uint) (*models.Tariff, error) {
+	tariff, err := s.parkingLotRepo.GetTariffById(ctx, tariffId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "tariff not found",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	if err = checkParkingLotAccess(ctx, tariff.ParkingLotId); err != nil {
+		return nil, err
+	}
+	return tariff, nil
+}
+
+// getTariffEffectiveAt resolves the tariff version of a parking lot and vehicle type effective at the given time.
+func (s *impl) getTariffEffectiveAt(ctx context.Context, parkingLotId, vehicleTypeId int,
+	at time.Time) (*models.Tariff, error) {
+
+	tariff, err := s.parkingLotRepo.GetTariffEffectiveAt(ctx, parkingLotId, vehicleTypeId, at)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message: fmt.Sprintf("no tariff found for parking lot %d and vehicle type %d",
+					parkingLotId, vehicleTypeId),
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	return tariff, nil
+}
+
+// applyTariffRates validates the rates of a request and copies them onto a tariff version charged in the
+// currency of its parking lot.
+func applyTariffRates(tariff *models.Tariff, req *model.TariffRatesRequest, currency string) error {
+	if req.HourlyRate.IsNegative() || req.DayRate.IsNegative() || req.DayRateHours < 0 ||
+		req.FirstHourRate.IsNegative() || req.AdditionalHourRate.IsNegative() {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Tariff rates cannot be negative",
+		}
+	}
+
+	amounts := []money.Money{req.HourlyRate, req.DayRate, req.FirstHourRate, req.AdditionalHourRate, req.DailyCap}
+	for _, band := range req.Bands {
+		amounts = append(amounts, band.FlatRate, band.HourlyRate)
+	}
+	if !inCurrency(currency, amounts...) {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Tariff rates must be in the currency of the parking lot, " + currency,
+		}
+	}
+
+	hourly := req.HourlyRate.IsPositive()
+	firstAndAdditional := req.FirstHourRate.IsPositive() && req.AdditionalHourRate.IsPositive()
+	switch {
+	case hourly == firstAndAdditional:
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Tariff needs either an hourly rate or a first hour and an additional hour rate",
+		}
+	case req.DayRate.IsPositive() && (!hourly || req.DayRateHours == 0):
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Day rate needs an hourly rate and the number of hours before it applies",
+		}
+	}
+
+	// Partial hours are rounded up to a whole number of units that divide an hour
+	roundingMinutes := req.RoundingMinutes
+	if roundingMinutes == 0 {
+		roundingMinutes = 60
+	}
+	switch {
+	case roundingMinutes < 0 || 60%roundingMinutes != 0:
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Rounding minutes must divide an hour, e.g. 1, 15 or 60",
+		}
+	case req.EntryGraceMinutes < 0 || req.ExitGraceMinutes < 0:
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Grace periods cannot be negative",
+		}
+	case req.DailyCap.IsNegative():
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Daily cap cannot be negative",
+		}
+	}
+
+	// A quote gives the time it is valid for away, so it is kept short
+	quoteValidityMinutes := req.QuoteValidityMinutes
+	if quoteValidityMinutes == 0 {
+		quoteValidityMinutes = 10
+	}
+	if quoteValidityMinutes < 0 || quoteValidityMinutes > 60 {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Quote validity must be between 1 and 60 minutes",
+		}
+	}
+
+	bands, err := tariffBandsFromRequest(req.Bands)
+	if err != nil {
+		return err
+	}
+
+	tariff.Currency = currency
+	tariff.HourlyRate = req.HourlyRate.Amount
+	tariff.DayRate = req.DayRate.Amount
+	tariff.DayRateHours = req.DayRateHours
+	tariff.FirstHourRate = req.FirstHourRate.Amount
+	tariff.AdditionalHourRate = req.AdditionalHourRate.Amount
+	tariff.RoundingMinutes = roundingMinutes
+	tariff.EntryGraceMinutes = req.EntryGraceMinutes
+	tariff.ExitGraceMinutes = req.ExitGraceMinutes
+	tariff.QuoteValidityMinutes = quoteValidityMinutes
+	tariff.DailyCap = req.DailyCap.Amount
+	tariff.Bands = bands
+	return nil
+}
+
+// tariffBandsFromRequest validates the time bands of a tariff request.
+func tariffBandsFromRequest(reqs []model.TariffBandRequest) ([]models.TariffBand, error) {
+	bands := make([]models.TariffBand, 0, len(reqs))
+	for _, req := range reqs {
+		band := models.TariffBand{
+			Name:       strings.TrimSpace(req.Name),
+			Days:       models.BandDays(req.Days),
+			FlatRate:   req.FlatRate.Amount,
+			HourlyRate: req.HourlyRate.Amount,
+			Priority:   req.Priority,
+		}
+		start, startErr := time.Parse(bandTimeLayout, req.Start)
+		end, endErr := time.Parse(bandTimeLayout, req.End)
+
+		switch {
+		case band.Name == "":
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Tariff band name is required",
+			}
+		case band.Days != models.BandDaysEveryDay && band.Days != models.BandDaysWeekdays &&
+			band.Days != models.BandDaysWeekends && band.Days != models.BandDaysHolidays:
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Tariff band days must be every-day, weekdays, weekends or holidays",
+			}
+		case startErr != nil || endErr != nil:
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Tariff band start and end must be times of day as HH:MM",
+			}
+		case band.FlatRate < 0 || band.HourlyRate < 0 || (band.FlatRate > 0) == (band.HourlyRate > 0):
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Tariff band " + band.Name + " needs either a flat rate or an hourly rate",
+			}
+		}
+
+		band.StartMinute = start.Hour()*60 + start.Minute()
+		band.EndMinute = end.Hour()*60 + end.Minute()
+		bands = append(bands, band)
+	}
+	return bands, nil
+}
+
+func toTariffResponse(tariff *models.Tariff) *model.TariffResponse {
+	return &model.TariffResponse{
+		ID:                   tariff.ID,
+		ParkingLotID:         tariff.ParkingLotId,
+		VehicleID:            tariff.VehicleTypeId,
+		HourlyRate:           tariff.Money(tariff.HourlyRate),
+		DayRate:              tariff.Money(tariff.DayRate),
+		DayRateHours:         tariff.DayRateHours,
+		FirstHourRate:        tariff.Money(tariff.FirstHourRate),
+		AdditionalHourRate:   tariff.Money(tariff.AdditionalHourRate),
+		RoundingMinutes:      tariff.RoundingMinutes,
+		EntryGraceMinutes:    tariff.EntryGraceMinutes,
+		ExitGraceMinutes:     tariff.ExitGraceMinutes,
+		QuoteValidityMinutes: tariff.QuoteValidityMinutes,
+		DailyCap:             tariff.Money(tariff.DailyCap),
+		EffectiveFrom:        tariff.EffectiveFrom,
+		EffectiveTo:          tariff.EffectiveTo,
+		Bands:                toTariffBandResponses(tariff),
+	}
+}
+
+func toTariffBandResponses(tariff *models.Tariff) []model.TariffBandResponse {
+	resp := make([]model.TariffBandResponse, 0, len(tariff.Bands))
+	for _, band := range tariff.Bands {
+		resp = append(resp, model.TariffBandResponse{
+			ID:         band.ID,
+			Name:       band.Name,
+			Days:       string(band.Days),
+			Start:      formatMinuteOfDay(band.StartMinute),
+			End:        formatMinuteOfDay(band.EndMinute),
+			FlatRate:   tariff.Money(band.FlatRate),
+			HourlyRate: tariff.Money(band.HourlyRate),
+			Priority:   band.Priority,
+		})
+	}
+	return resp
+}
+
+// formatMinuteOfDay formats minutes after midnight as a time of day.
+func formatMinuteOfDay(minute int) string {
+	return time.Date(0, time.January, 1, 0, minute, 0, 0, time.UTC).Format(bandTimeLayout)
+}
+
+// inCurrency reports whether the amounts of a request that are set are all in the given currency.
+func inCurrency(currency string, amounts ...money.Money) bool {
+	for _, amount := range amounts {
+		if !amount.IsZero() && amount.Currency != currency {
+			return false
+		}
+	}
+	return true
+}
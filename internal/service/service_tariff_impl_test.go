@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"testing"
+	"time"
+)
+
+// tariffRequest returns a request for an hourly tariff of lot 1 and vehicle type 1 effective over the given times.
+func tariffRequest(hourlyRate int64, effectiveFrom, effectiveTo *time.Time) *model.TariffRequest {
+	return &model.TariffRequest{
+		ParkingLotID:       1,
+		VehicleID:          1,
+		EffectiveFrom:      effectiveFrom,
+		EffectiveTo:        effectiveTo,
+		TariffRatesRequest: model.TariffRatesRequest{HourlyRate: inr(hourlyRate)},
+	}
+}
+
+func TestCreateTariff_EffectiveFromMustBeInFuture(t *testing.T) {
+	fake := newFakeRepo(1)
+	svc := NewParkingLotService(fake)
+
+	for _, effectiveFrom := range []time.Time{time.Now().Add(-time.Hour), time.Now()} {
+		_, err := svc.CreateTariff(context.Background(), tariffRequest(3000, &effectiveFrom, nil))
+		wantErrorStatus(t, "CreateTariff() effective from "+effectiveFrom.String(), err, http.StatusBadRequest)
+	}
+	if len(fake.tariffs) != 1 || fake.tariffs[0].EffectiveTo != nil {
+		t.Errorf("tariffs = %+v, want the live version left open", fake.tariffs)
+	}
+}
+
+func TestCreateTariff_ClosesOpenVersion(t *testing.T) {
+	fake := newFakeRepo(1)
+	svc := NewParkingLotService(fake)
+
+	effectiveFrom := time.Now().Add(24 * time.Hour).UTC()
+	created, err := svc.CreateTariff(context.Background(), tariffRequest(3000, &effectiveFrom, nil))
+	if err != nil {
+		t.Fatalf("CreateTariff() error = %v", err)
+	}
+	if !created.EffectiveFrom.Equal(effectiveFrom) || created.EffectiveTo != nil {
+		t.Errorf("CreateTariff() = %+v, want open ended from %v", created, effectiveFrom)
+	}
+
+	// The live version prices vehicles until the new one takes over
+	live := fake.tariffs[0]
+	if live.EffectiveTo == nil || !live.EffectiveTo.Equal(effectiveFrom) {
+		t.Errorf("live version effective to %v, want %v", live.EffectiveTo, effectiveFrom)
+	}
+}
+
+func TestCreateTariff_OverlapConflicts(t *testing.T) {
+	fake := newFakeRepo(1)
+	svc := NewParkingLotService(fake)
+
+	var (
+		tomorrow = time.Now().Add(24 * time.Hour).UTC()
+		nextWeek = tomorrow.Add(6 * 24 * time.Hour)
+		earlier  = tomorrow.Add(-time.Hour)
+		later    = tomorrow.Add(time.Hour)
+	)
+	if _, err := svc.CreateTariff(context.Background(), tariffRequest(3000, &tomorrow, &nextWeek)); err != nil {
+		t.Fatalf("CreateTariff() error = %v", err)
+	}
+
+	tests := []struct {
+		name                       string
+		effectiveFrom, effectiveTo *time.Time
+	}{
+		{name: "starting before a later version", effectiveFrom: &earlier},
+		{name: "starting within a closed version", effectiveFrom: &later},
+		{name: "ending within a closed version", effectiveFrom: &earlier, effectiveTo: &later},
+	}
+	for _, tt := range tests {
+		_, err := svc.CreateTariff(context.Background(), tariffRequest(4000, tt.effectiveFrom, tt.effectiveTo))
+		wantErrorStatus(t, "CreateTariff() "+tt.name, err, http.StatusConflict)
+	}
+	if len(fake.tariffs) != 2 || !fake.tariffs[0].EffectiveTo.Equal(tomorrow) ||
+		!fake.tariffs[1].EffectiveTo.Equal(nextWeek) {
+		t.Errorf("tariffs after rejected versions = %+v, want the versions unchanged", fake.tariffs)
+	}
+}
+
+func TestUnParkVehicle_PricedByTariffEffectiveAtEntry(t *testing.T) {
+	fake := newFakeRepo(2)
+	changedAt := time.Now().Add(-time.Hour)
+	fake.tariffs[0].EffectiveTo = &changedAt
+	fake.tariffs = append(fake.tariffs, &models.Tariff{ID: 2, ParkingLotId: 1, VehicleTypeId: 1, Currency: "INR",
+		HourlyRate: 5000, EffectiveFrom: changedAt})
+	svc := NewParkingLotService(fake)
+
+	tests := []struct {
+		vehicleNumber string
+		parkedFor     time.Duration
+		want          int64
+	}{
+		{vehicleNumber: "KA-01-0001", parkedFor: 90 * time.Minute, want: 4000}, // 2 hours of the earlier version
+		{vehicleNumber: "KA-01-0002", parkedFor: 30 * time.Minute, want: 5000}, // 1 hour of the later version
+	}
+	for _, tt := range tests {
+		parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+			ParkingLotID: 1, VehicleID: 1, VehicleNumber: tt.vehicleNumber,
+		})
+		if err != nil {
+			t.Fatalf("ParkVehicle() error = %v", err)
+		}
+		fake.openSessions[tt.vehicleNumber].EntryTime = time.Now().Add(-tt.parkedFor)
+
+		unparked, err := svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{
+			TicketNumber: parked.ParkingTicket.TicketNumber,
+		})
+		if err != nil {
+			t.Fatalf("UnParkVehicle() error = %v", err)
+		}
+		if got := unparked.Parking.TotalFare; got != inr(tt.want) {
+			t.Errorf("UnParkVehicle() after %v fare = %v, want %v", tt.parkedFor, got, inr(tt.want))
+		}
+	}
+}
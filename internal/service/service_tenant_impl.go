@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"parking_lot_service/internal/auth"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"strconv"
+	"strings"
+)
+
+func (s *impl) GetTenants(ctx context.Context) ([]*model.TenantResponse, error) {
+	tenants, err := s.parkingLotRepo.GetTenants(ctx)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	resp := make([]*model.TenantResponse, 0, len(tenants))
+	for _, tenant := range tenants {
+		if auth.CanAccessTenant(ctx, tenant.ID) {
+			resp = append(resp, toTenantResponse(tenant))
+		}
+	}
+	return resp, nil
+}
+
+func (s *impl) GetTenantById(ctx context.Context, tenantId uint) (*model.TenantResponse, error) {
+	tenant, err := s.getTenant(ctx, tenantId)
+	if err != nil {
+		return nil, err
+	}
+	return toTenantResponse(tenant), nil
+}
+
+func (s *impl) CreateTenant(ctx context.Context, req *model.TenantRequest) (*model.TenantResponse, error) {
+	if err := checkPlatform(ctx, "create tenants"); err != nil {
+		return nil, err
+	}
+	tenant, err := tenantFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.parkingLotRepo.CreateTenant(ctx, tenant)
+	if err != nil {
+		return nil, tenantError(err)
+	}
+
+	return toTenantResponse(tenant), nil
+}
+
+func (s *impl) UpdateTenant(ctx context.Context, tenantId uint, req *model.TenantRequest) (*model.TenantResponse, error) {
+	tenant, err := tenantFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = s.getTenant(ctx, tenantId); err != nil {
+		return nil, err
+	}
+	tenant.ID = tenantId
+
+	err = s.parkingLotRepo.UpdateTenant(ctx, tenant)
+	if err != nil {
+		return nil, tenantError(err)
+	}
+
+	return s.GetTenantById(ctx, tenantId)
+}
+
+// getTenant resolves a tenant the caller belongs to, or any tenant for platform admins.
+func (s *impl) getTenant(ctx context.Context, tenantId uint) (*models.Tenant, error) {
+	if !auth.CanAccessTenant(ctx, tenantId) {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusForbidden,
+			Message:    "No access to tenant " + strconv.FormatUint(uint64(tenantId), 10),
+		}
+	}
+
+	tenant, err := s.parkingLotRepo.GetTenantById(ctx, tenantId)
+	if err != nil {
+		return nil, tenantError(err)
+	}
+	return tenant, nil
+}
+
+// checkPlatform refuses callers of a tenant an action on data that all tenants share.
+func checkPlatform(ctx context.Context, action string) error {
+	if _, ok := auth.TenantFromContext(ctx); ok {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusForbidden,
+			Message:    "Only platform admins can " + action,
+		}
+	}
+	return nil
+}
+
+// tenantOfNewParkingLot decides the tenant a new parking lot belongs to. Callers of a tenant add lots to their
+// own tenant, platform admins to the tenant they name or else to the default tenant.
+func (s *impl) tenantOfNewParkingLot(ctx context.Context, requested uint) (uint, error) {
+	if tenantId, ok := auth.TenantFromContext(ctx); ok {
+		if requested != 0 && requested != tenantId {
+			return 0, &genericresponse.GenericResponse{
+				StatusCode: http.StatusForbidden,
+				Message:    "No access to tenant " + strconv.FormatUint(uint64(requested), 10),
+			}
+		}
+		return tenantId, nil
+	}
+
+	if requested == 0 {
+		return models.DefaultTenantId, nil
+	}
+	if _, err := s.getTenant(ctx, requested); err != nil {
+		return 0, err
+	}
+	return requested, nil
+}
+
+// tenantFromRequest validates a tenant request.
+func tenantFromRequest(req *model.TenantRequest) (*models.Tenant, error) {
+	tenant := &models.Tenant{Name: strings.TrimSpace(req.Name)}
+	if tenant.Name == "" {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Tenant name is required",
+		}
+	}
+	return tenant, nil
+}
+
+// tenantError maps an error of the repo about a tenant to a response.
+func tenantError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    "tenant not found",
+		}
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Tenant with this name already exists",
+		}
+	}
+	return &genericresponse.GenericResponse{
+		StatusCode: http.StatusInternalServerError,
+		Message:    err.Error(),
+	}
+}
+
+func toTenantResponse(tenant *models.Tenant) *model.TenantResponse {
+	return &model.TenantResponse{
+		ID:        tenant.ID,
+		Name:      tenant.Name,
+		CreatedAt: tenant.CreatedAt,
+		UpdatedAt: tenant.UpdatedAt,
+	}
+}
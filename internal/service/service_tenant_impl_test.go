@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"parking_lot_service/internal/auth"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"testing"
+)
+
+// newTwoTenantRepo returns a fake repo whose lot 1 belongs to the default tenant and lot 2 to tenant 2.
+func newTwoTenantRepo() *fakeRepo {
+	fake := newFakeRepo(1)
+	fake.tenants[2] = &models.Tenant{ID: 2, Name: "Harbour Properties"}
+	fake.parkingLots[2] = &models.ParkingLot{ID: 2, TenantId: 2, Name: "Harbour Mall", Timezone: "UTC",
+		Status: models.ParkingLotStatusActive, Currency: "INR", Locale: "en-IN"}
+	return fake
+}
+
+func TestParkingLots_IsolatedBetweenTenants(t *testing.T) {
+	fake := newTwoTenantRepo()
+	svc := NewParkingLotService(fake)
+
+	var (
+		admin     = auth.NewContext(context.Background(), &auth.Principal{Role: auth.RoleAdmin, TenantID: 1})
+		attendant = auth.NewContext(context.Background(),
+			&auth.Principal{Role: auth.RoleAttendant, TenantID: 1, AllParkingLots: true})
+		platform = auth.NewContext(context.Background(), &auth.Principal{Role: auth.RoleAdmin})
+	)
+
+	parkingLots, err := svc.GetParkingLots(admin)
+	if err != nil {
+		t.Fatalf("GetParkingLots() error = %v", err)
+	}
+	if len(parkingLots) != 1 || parkingLots[0].ID != 1 {
+		t.Errorf("GetParkingLots() = %+v, want lot 1 only", parkingLots)
+	}
+
+	// The lots of another tenant are missing to the caller, for reads as well as writes
+	_, err = svc.GetParkingLotById(admin, 2)
+	wantErrorStatus(t, "GetParkingLotById() of another tenant", err, http.StatusNotFound)
+	_, err = svc.UpdateParkingLot(admin, 2, &model.ParkingLotRequest{Name: "Taken Over"})
+	wantErrorStatus(t, "UpdateParkingLot() of another tenant", err, http.StatusNotFound)
+	_, err = svc.ParkVehicle(attendant, &model.ParkVehicleRequest{
+		ParkingLotID: 2, VehicleID: 1, VehicleNumber: "KA-01-1234",
+	})
+	wantErrorStatus(t, "ParkVehicle() at a lot of another tenant", err, http.StatusNotFound)
+	if fake.parkingLots[2].Name != "Harbour Mall" || len(fake.sessions) != 0 {
+		t.Errorf("lot of another tenant was changed: %+v, sessions %+v", fake.parkingLots[2], fake.sessions)
+	}
+
+	_, err = svc.CreateParkingLot(admin, &model.ParkingLotRequest{Name: "Harbour Annex", TenantID: 2})
+	wantErrorStatus(t, "CreateParkingLot() for another tenant", err, http.StatusForbidden)
+
+	// Platform admins reach every tenant
+	if _, err = svc.GetParkingLotById(platform, 2); err != nil {
+		t.Errorf("GetParkingLotById() by a platform admin error = %v", err)
+	}
+	_, err = svc.UpdateParkingLot(platform, 2, &model.ParkingLotRequest{Name: "Harbour Mall", TenantID: 1})
+	wantErrorStatus(t, "UpdateParkingLot() to another tenant", err, http.StatusConflict)
+}
+
+func TestCreateParkingLot_BelongsToTenant(t *testing.T) {
+	fake := newTwoTenantRepo()
+	svc := NewParkingLotService(fake)
+
+	tenantAdmin := auth.NewContext(context.Background(), &auth.Principal{Role: auth.RoleAdmin, TenantID: 2})
+	created, err := svc.CreateParkingLot(tenantAdmin, &model.ParkingLotRequest{Name: "Harbour Annex"})
+	if err != nil {
+		t.Fatalf("CreateParkingLot() error = %v", err)
+	}
+	if created.TenantID != 2 || fake.parkingLots[created.ID].TenantId != 2 {
+		t.Errorf("CreateParkingLot() by an admin of tenant 2 = tenant %d", created.TenantID)
+	}
+
+	platform := auth.NewContext(context.Background(), &auth.Principal{Role: auth.RoleAdmin})
+	created, err = svc.CreateParkingLot(platform, &model.ParkingLotRequest{Name: "Harbour East", TenantID: 2})
+	if err != nil {
+		t.Fatalf("CreateParkingLot() by a platform admin error = %v", err)
+	}
+	if created.TenantID != 2 {
+		t.Errorf("CreateParkingLot() by a platform admin = tenant %d, want 2", created.TenantID)
+	}
+	_, err = svc.CreateParkingLot(platform, &model.ParkingLotRequest{Name: "Nowhere", TenantID: 9})
+	wantErrorStatus(t, "CreateParkingLot() for an unknown tenant", err, http.StatusNotFound)
+}
+
+func TestParkVehicle_SessionBelongsToTenantOfLot(t *testing.T) {
+	fake := newFakeRepo(1)
+	fake.tenants[2] = &models.Tenant{ID: 2, Name: "Harbour Properties"}
+	fake.parkingLots[1].TenantId = 2
+	svc := NewParkingLotService(fake)
+
+	ctx := auth.NewContext(context.Background(), &auth.Principal{Role: auth.RoleKiosk, TenantID: 2,
+		ParkingLotIDs: []int{1}})
+	parked, err := svc.ParkVehicle(ctx, &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-1234",
+	})
+	if err != nil {
+		t.Fatalf("ParkVehicle() error = %v", err)
+	}
+
+	parkingSession := fake.openSessions["KA-01-1234"]
+	if parkingSession == nil || parkingSession.TenantId != 2 {
+		t.Errorf("session of ticket %s = %+v, want tenant 2", parked.ParkingTicket.TicketNumber, parkingSession)
+	}
+}
+
+func TestTenants_ScopedToCaller(t *testing.T) {
+	fake := newTwoTenantRepo()
+	svc := NewParkingLotService(fake)
+
+	var (
+		tenantAdmin = auth.NewContext(context.Background(), &auth.Principal{Role: auth.RoleAdmin, TenantID: 2})
+		platform    = auth.NewContext(context.Background(), &auth.Principal{Role: auth.RoleAdmin})
+	)
+
+	tenants, err := svc.GetTenants(tenantAdmin)
+	if err != nil {
+		t.Fatalf("GetTenants() error = %v", err)
+	}
+	if len(tenants) != 1 || tenants[0].ID != 2 {
+		t.Errorf("GetTenants() by an admin of tenant 2 = %+v, want tenant 2 only", tenants)
+	}
+
+	_, err = svc.GetTenantById(tenantAdmin, 1)
+	wantErrorStatus(t, "GetTenantById() of another tenant", err, http.StatusForbidden)
+	_, err = svc.UpdateTenant(tenantAdmin, 1, &model.TenantRequest{Name: "Taken Over"})
+	wantErrorStatus(t, "UpdateTenant() of another tenant", err, http.StatusForbidden)
+	_, err = svc.CreateTenant(tenantAdmin, &model.TenantRequest{Name: "Spin-off"})
+	wantErrorStatus(t, "CreateTenant() by an admin of a tenant", err, http.StatusForbidden)
+
+	renamed, err := svc.UpdateTenant(tenantAdmin, 2, &model.TenantRequest{Name: " Harbour Estates "})
+	if err != nil {
+		t.Fatalf("UpdateTenant() of the own tenant error = %v", err)
+	}
+	if renamed.Name != "Harbour Estates" || fake.tenants[1].Name != "Default" {
+		t.Errorf("UpdateTenant() = %+v, tenants %+v", renamed, fake.tenants)
+	}
+
+	created, err := svc.CreateTenant(platform, &model.TenantRequest{Name: "Riverside Holdings"})
+	if err != nil {
+		t.Fatalf("CreateTenant() by a platform admin error = %v", err)
+	}
+	if tenants, _ = svc.GetTenants(platform); len(tenants) != 3 || tenants[2].ID != created.ID {
+		t.Errorf("GetTenants() by a platform admin = %+v, want all 3 tenants", tenants)
+	}
+
+	// Vehicle types and exchange rates are shared by all tenants
+	_, err = svc.CreateVehicleType(tenantAdmin, &model.VehicleTypeRequest{
+		Code: "VANS", DisplayName: "Vans", SizeClass: models.SizeClassMedium,
+	})
+	wantErrorStatus(t, "CreateVehicleType() by an admin of a tenant", err, http.StatusForbidden)
+	err = svc.DeleteExchangeRate(tenantAdmin, 1)
+	wantErrorStatus(t, "DeleteExchangeRate() by an admin of a tenant", err, http.StatusForbidden)
+}
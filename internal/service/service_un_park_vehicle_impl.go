@@ -6,40 +6,193 @@ import (
 	"fmt"
 	"gorm.io/gorm"
 	"net/http"
+	"parking_lot_service/internal/events"
 	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo"
 	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/discount"
+	"parking_lot_service/internal/service/fare"
 	"parking_lot_service/internal/service/model"
+	"parking_lot_service/internal/ticket"
+	"strings"
 	"time"
 )
 
 func (s *impl) UnParkVehicle(ctx context.Context, req *model.UnParkVehicleRequest) (
 	*model.UnParkVehicleResponse, error) {
 
-	parkedVehicle, err := s.parkingLotRepo.GetParkedVehicle(ctx, req.VehicleNumber)
+	parkingSession, err := s.getOpenParkingSession(ctx, req.TicketNumber)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, &genericresponse.GenericResponse{
-				StatusCode: http.StatusNotFound,
-				Message:    "Record Not Found",
-			}
-		}
+		return nil, err
+	}
+	return s.unParkSession(ctx, parkingSession, req.DiscountCode, req.QuoteID, false)
+}
+
+// unParkSession prices the stay of an open parking session and lets its vehicle out, or invoices the fare left
+// to pay. A lost ticket adds the lost-ticket penalties of the lot to a stay that has not been paid yet.
+func (s *impl) unParkSession(ctx context.Context, parkingSession *models.ParkingSession, discountCode string,
+	quoteId uint, lostTicket bool) (*model.UnParkVehicleResponse, error) {
+
+	// Discounts are taken off when the ticket is paid, the time charged after payment is not discounted
+	if parkingSession.PaidAt != nil && strings.TrimSpace(discountCode) != "" {
 		return nil, &genericresponse.GenericResponse{
-			StatusCode: http.StatusInternalServerError,
-			Message:    err.Error(),
+			StatusCode: http.StatusConflict,
+			Message:    "Ticket has already been paid, discount codes apply when paying",
 		}
 	}
 
-	err = s.parkingLotRepo.DeleteParkedVehicle(ctx, parkedVehicle)
+	// The stay is priced again for this exit, in place of an invoice that has not been paid yet
+	pendingInvoice, err := s.getPendingInvoice(ctx, parkingSession)
+	if err != nil {
+		return nil, err
+	}
+
+	parkingLot, tariff, poolVehicleTypeId, err := s.getSessionTariff(ctx, parkingSession)
+	if err != nil {
+		return nil, err
+	}
+	parkingLotId := parkingLot.ID
+
+	// A valid quote holds the fare at the time it was given
+	exitTime := time.Now()
+	stay, err := s.priceQuotedStay(ctx, parkingSession, parkingLot, tariff, quoteId, discountCode, exitTime,
+		lostTicket)
+	if err != nil {
+		return nil, err
+	}
+
+	// A paid ticket only adds the time charged past the exit grace period to what was paid before
+	var prepaidFare *money.Money
+	grossFare, netFare := stay.grossFare, stay.netFare
+	if parkingSession.PaidAt != nil {
+		prepaid := money.New(*parkingSession.Fare, parkingSession.Currency)
+		prepaidFare = &prepaid
+		grossFare = money.New(*parkingSession.GrossFare, parkingSession.Currency).Add(stay.grossFare)
+		netFare = prepaid.Add(stay.netFare)
+	}
+
+	// A fare left to pay is invoiced, the vehicle exits once the invoice is paid or billed to an account
+	if stay.netFare.IsPositive() {
+		invoice, err := s.issueInvoice(ctx, parkingSession, pendingInvoice, stay, exitTime)
+		if err != nil {
+			return nil, err
+		}
+		return &model.UnParkVehicleResponse{
+			Parking: toParkingReceipt(parkingSession, parkingLot, stay, exitTime, prepaidFare),
+			Invoice: toInvoiceResponse(invoice),
+		}, nil
+	}
+
+	parkingSession.ExitTime = &exitTime
+	parkingSession.Currency = netFare.Currency
+	parkingSession.GrossFare = &grossFare.Amount
+	parkingSession.Fare = &netFare.Amount
+
+	// Close the parking session and give its spot back in a single transaction
+	err = s.parkingLotRepo.WithTx(ctx, func(txRepo repo.ParkingLotRepo) error {
+		err := txRepo.CloseParkingSession(ctx, parkingSession)
+		if err != nil {
+			// The vehicle has been unparked by a concurrent request
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &genericresponse.GenericResponse{
+					StatusCode: http.StatusNotFound,
+					Message:    "Record Not Found",
+				}
+			}
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    "Unable To Close Parking Session",
+			}
+		}
+
+		// An invoice of an earlier exit is no longer due
+		err = s.voidPendingInvoice(ctx, txRepo, pendingInvoice)
+		if err != nil {
+			return err
+		}
+
+		// Record what every discount took off the fare
+		if len(stay.validations) > 0 {
+			err = txRepo.SaveValidations(ctx, stay.validations)
+			if err != nil {
+				return &genericresponse.GenericResponse{
+					StatusCode: http.StatusInternalServerError,
+					Message:    "Unable to save discounts",
+				}
+			}
+		}
+
+		// Free the spot the vehicle was parked on, vehicles parked before spots existed have none
+		if parkingSession.SpotId != nil {
+			err = txRepo.ReleaseSpot(ctx, *parkingSession.SpotId)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return &genericresponse.GenericResponse{
+					StatusCode: http.StatusInternalServerError,
+					Message:    "Unable to release spot",
+				}
+			}
+		}
+
+		// Increment the available spots, only if not all spots are free already
+		released, err := txRepo.IncrementAvailableSpots(ctx, parkingLotId, poolVehicleTypeId)
+		if err != nil {
+			// If there is an error updating the parking space, return an internal server error
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    "Unable to update parking space",
+			}
+		}
+		if !released {
+			return s.allSpotsFreeError(ctx, txRepo, parkingLotId, poolVehicleTypeId)
+		}
+
+		// Tell the downstream systems about the vehicle, and about its pool if it was full until now
+		unparked := &events.VehicleUnparkedData{
+			TicketNumber:  parkingSession.TicketNumber,
+			VehicleNumber: parkingSession.VehicleNumber,
+			VehicleTypeID: parkingSession.VehicleTypeId,
+			EntryTime:     parkingSession.EntryTime,
+			ExitTime:      exitTime,
+			Fare:          netFare.Amount,
+			Currency:      netFare.Currency,
+			LostTicket:    lostTicket,
+		}
+		if parkingSession.SpotId != nil {
+			unparked.SpotID = *parkingSession.SpotId
+		}
+		err = publishEvent(ctx, txRepo, events.VehicleUnparked, parkingLot, exitTime, unparked)
+		if err != nil {
+			return err
+		}
+		return publishAvailabilityChange(ctx, txRepo, parkingLot, poolVehicleTypeId, 1, exitTime)
+	})
+
 	if err != nil {
+		return nil, err
+	}
+
+	// Create the response with parking receipt details
+	receipt := toParkingReceipt(parkingSession, parkingLot, stay, exitTime, prepaidFare)
+	response := &model.UnParkVehicleResponse{
+		Parking: receipt,
+		Exited:  true,
+	}
+	return response, nil
+
+}
+
+// getOpenParkingSession resolves the open parking session of a ticket number.
+func (s *impl) getOpenParkingSession(ctx context.Context, ticketNumber string) (*models.ParkingSession, error) {
+	ticketNumber = strings.TrimSpace(ticketNumber)
+	if !ticket.Valid(ticketNumber) {
 		return nil, &genericresponse.GenericResponse{
-			StatusCode: http.StatusInternalServerError,
-			Message:    "Unable To Delete Parked Vehicle",
+			StatusCode: http.StatusBadRequest,
+			Message:    "Invalid ticket number",
 		}
 	}
 
-	// Get the count of available parking spots for the given ParkingLotID and VehicleID
-	cnt, err := s.parkingLotRepo.GetAvailableParkingSpotsByParkingLotIdAndVehicleId(ctx,
-		int(req.ParkingLotID), int(req.VehicleID))
+	parkingSession, err := s.parkingLotRepo.GetParkingSessionByTicket(ctx, ticketNumber)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, &genericresponse.GenericResponse{
@@ -52,154 +205,407 @@ func (s *impl) UnParkVehicle(ctx context.Context, req *model.UnParkVehicleReques
 			Message:    err.Error(),
 		}
 	}
+	if err = checkParkingLotAccess(ctx, parkingSession.ParkingLotId); err != nil {
+		return nil, err
+	}
+	if parkingSession.Status != models.ParkingSessionStatusOpen {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Ticket has already been used",
+		}
+	}
+	return parkingSession, nil
+}
 
-	// Get the Maximum Count By Request ParkingLotId  and  VehicleId.
-	maxSpots, err := getMaxSpotsInParkingLot(req)
+// getSessionTariff resolves the parking lot of a session, the tariff version its stay is priced by and the
+// vehicle type whose pool the session is counted against.
+func (s *impl) getSessionTariff(ctx context.Context, parkingSession *models.ParkingSession) (
+	*models.ParkingLot, *models.Tariff, int, error) {
+
+	// The lot and vehicle type come from the session, so a ticket is always priced as the vehicle that entered
+	parkingLot, err := s.getParkingLot(ctx, parkingSession.ParkingLotId)
 	if err != nil {
-		return nil, err
+		return nil, nil, 0, err
 	}
 
-	// Check if the available spots exceed the maximum limit
-	if cnt >= maxSpots {
-		return nil, &genericresponse.GenericResponse{
-			StatusCode: http.StatusBadRequest,
-			Message:    "All Spots Are Already Free for this Vehicle Type",
+	// A vehicle that overflowed into a larger pool is counted against that pool, and priced by it if the lot says so
+	poolVehicleTypeId := parkingSession.VehicleTypeId
+	if parkingSession.SpotVehicleTypeId != 0 {
+		poolVehicleTypeId = parkingSession.SpotVehicleTypeId
+	}
+	pricedVehicleTypeId := parkingSession.VehicleTypeId
+	if parkingLot.UpsizePricing == models.UpsizePricingSpotClass {
+		pricedVehicleTypeId = poolVehicleTypeId
+	}
+
+	// Resolve the tariff that was effective when the vehicle entered, before the vehicle leaves the lot
+	tariff, err := s.getTariffEffectiveAt(ctx, parkingLot.ID, pricedVehicleTypeId, parkingSession.EntryTime)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return parkingLot, tariff, poolVehicleTypeId, nil
+}
+
+// stayFare is the price of a stay, or of the part of it after the ticket was paid.
+type stayFare struct {
+	fareLines     []model.FareLine
+	penaltyLines  []model.PenaltyLine
+	grossFare     money.Money
+	validations   []*models.Validation
+	netFare       money.Money
+	coveredByPass bool
+}
+
+// priceStay works out the fare of a parking session leaving at exitTime. The stay is priced in the lot's local
+// time, without the time covered by a pass and after the grace periods of the tariff. The merchant validations
+// of the session and the discount code are taken off the fare of a ticket that has not been paid yet, the
+// penalties of the lot are added to it after the discounts.
+func (s *impl) priceStay(ctx context.Context, parkingSession *models.ParkingSession, parkingLot *models.ParkingLot,
+	tariff *models.Tariff, exitTime time.Time, discountCode string, lostTicket bool) (*stayFare, error) {
+
+	var (
+		location = parkingLotLocation(parkingLot)
+		periods  []fare.Period
+		stay     = &stayFare{}
+		err      error
+	)
+	if period, ok := chargedPeriod(tariff, parkingSession, exitTime); ok {
+		periods = []fare.Period{period}
+
+		// A pass holder only pays for the parts of the stay the pass does not cover
+		if parkingSession.PassId != nil {
+			periods, err = s.getBillablePeriods(ctx, location, *parkingSession.PassId, period.From, period.To)
+			if err != nil {
+				return nil, &genericresponse.GenericResponse{
+					StatusCode: http.StatusInternalServerError,
+					Message:    err.Error(),
+				}
+			}
+			stay.coveredByPass = len(periods) == 0
 		}
 	}
 
-	// Update the parking space to increment the available spots
-	updateParkingPayload := &models.ParkingSpace{
-		ParkingLotId:   req.ParkingLotID,
-		VehicleTypeId:  req.VehicleID,
-		AvailableSpots: cnt + 1,
+	// A lost ticket may be charged a full day from entry, which can end after the exit
+	holidaysTo := exitTime
+	if fullDayEnd := parkingSession.EntryTime.Add(24 * time.Hour); lostTicket && parkingLot.LostTicketFullDay &&
+		fullDayEnd.After(exitTime) {
+		holidaysTo = fullDayEnd
 	}
-	err = s.parkingLotRepo.UpdateParkingSpace(ctx, updateParkingPayload)
+	holidays, err := s.getHolidayCalendar(ctx, parkingLot.ID, location, parkingSession.EntryTime, holidaysTo)
+	if err != nil {
+		return nil, err
+	}
+
+	stay.fareLines, stay.grossFare, err = calculateFare(tariff, periods, location, holidays)
 	if err != nil {
-		// If there is an error updating the parking space, return an internal server error
 		return nil, &genericresponse.GenericResponse{
 			StatusCode: http.StatusInternalServerError,
-			Message:    "Unable to update parking space",
+			Message:    err.Error(),
 		}
 	}
 
-	// Calculate the fare and duration
-	entryTime := parkedVehicle.EntryTime
-	exitTime := time.Now()
-	duration := exitTime.Sub(entryTime)
+	// Take the merchant validations and the discount code off the fare
+	if parkingSession.PaidAt == nil {
+		stay.validations, err = s.getSessionDiscounts(ctx, parkingSession, discountCode)
+		if err != nil {
+			return nil, err
+		}
+		discount.Apply(stay.validations, stay.grossFare, func(freeHours float64) money.Money {
+			free := time.Duration(freeHours * float64(time.Hour))
+			_, discounted, err := calculateFare(tariff, fare.Skip(periods, free), location, holidays)
+			if err != nil {
+				return stay.grossFare
+			}
+			return discounted
+		}, parkingLot.MaxDiscountPercent)
+	}
+	stay.netFare = discount.Net(stay.grossFare, stay.validations)
+
+	// Penalties are charged for a stay that has not been paid yet, discounts do not take them off
+	if parkingSession.PaidAt == nil {
+		stay.penaltyLines, err = stayPenalties(parkingSession, parkingLot, tariff, location, holidays,
+			stay.grossFare, exitTime, lostTicket)
+		if err != nil {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    err.Error(),
+			}
+		}
+		for _, line := range stay.penaltyLines {
+			stay.grossFare = stay.grossFare.Add(line.Amount)
+			stay.netFare = stay.netFare.Add(line.Amount)
+		}
+	}
+	return stay, nil
+}
 
-	totalFare, err := calculateFare(int(req.ParkingLotID), int(req.VehicleID), duration)
+// chargedPeriod returns the part of a stay leaving at exitTime that a tariff charges. A stay within the entry
+// grace period is free. Once the ticket has been paid, only the time since payment is charged, and only when
+// the vehicle leaves after the exit grace period.
+func chargedPeriod(tariff *models.Tariff, parkingSession *models.ParkingSession,
+	exitTime time.Time) (fare.Period, bool) {
 
+	if parkingSession.PaidAt != nil {
+		if !exitTime.After(parkingSession.PaidAt.Add(tariff.ExitGrace())) {
+			return fare.Period{}, false
+		}
+		return fare.Period{From: *parkingSession.PaidAt, To: exitTime}, true
+	}
+
+	if exitTime.Sub(parkingSession.EntryTime) <= tariff.EntryGrace() {
+		return fare.Period{}, false
+	}
+	return fare.Period{From: parkingSession.EntryTime, To: exitTime}, true
+}
+
+// toParkingReceipt builds the receipt of a priced stay of a parking session up to the given time, with the
+// amounts formatted for the locale of the parking lot. The prepaid fare is nil unless the ticket was paid
+// before leaving.
+func toParkingReceipt(parkingSession *models.ParkingSession, parkingLot *models.ParkingLot, stay *stayFare,
+	to time.Time, prepaidFare *money.Money) model.ParkingReceipt {
+
+	receipt := model.ParkingReceipt{
+		TicketNumber:       parkingSession.TicketNumber,
+		VehicleNumber:      parkingSession.VehicleNumber,
+		Currency:           stay.netFare.Currency,
+		Locale:             parkingLot.Locale,
+		FareLines:          make([]model.FareLine, 0, len(stay.fareLines)),
+		Penalties:          make([]model.PenaltyLine, 0, len(stay.penaltyLines)),
+		GrossFare:          stay.grossFare,
+		FormattedGrossFare: stay.grossFare.Format(parkingLot.Locale),
+		Discounts:          toDiscountLines(stay.validations, stay.grossFare.Currency),
+		TotalFare:          stay.netFare,
+		FormattedTotalFare: stay.netFare.Format(parkingLot.Locale),
+		PrepaidFare:        prepaidFare,
+		From:               parkingSession.EntryTime.Format(time.RFC3339),
+		To:                 to.Format(time.RFC3339),
+		VehicleID:          parkingSession.VehicleTypeId,
+		ParkingLotID:       parkingLot.ID,
+		ParkingLot:         parkingLot.Name,
+		PassID:             parkingSession.PassId,
+		CoveredByPass:      stay.coveredByPass,
+	}
+	if prepaidFare != nil {
+		receipt.FormattedPrepaidFare = prepaidFare.Format(parkingLot.Locale)
+	}
+	for _, line := range stay.fareLines {
+		line.FormattedAmount = line.Amount.Format(parkingLot.Locale)
+		receipt.FareLines = append(receipt.FareLines, line)
+	}
+	for _, line := range stay.penaltyLines {
+		line.FormattedAmount = line.Amount.Format(parkingLot.Locale)
+		receipt.Penalties = append(receipt.Penalties, line)
+	}
+	for i := range receipt.Discounts {
+		receipt.Discounts[i].FormattedAmount = receipt.Discounts[i].Amount.Format(parkingLot.Locale)
+	}
+	return receipt
+}
+
+// allSpotsFreeError explains why no spot could be given back: either the lot has no parking space
+// for the vehicle type at all, or all of its spots are already free.
+func (s *impl) allSpotsFreeError(ctx context.Context, txRepo repo.ParkingLotRepo,
+	parkingLotId, vehicleTypeId int) error {
+
+	_, err := txRepo.GetParkingSpace(ctx, parkingLotId, vehicleTypeId)
 	if err != nil {
-		return nil, &genericresponse.GenericResponse{
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "Record Not Found",
+			}
+		}
+		return &genericresponse.GenericResponse{
 			StatusCode: http.StatusInternalServerError,
 			Message:    err.Error(),
 		}
 	}
 
-	// Create the response with parking receipt details
-	response := &model.UnParkVehicleResponse{
-		Parking: model.ParkingReceipt{
-			VehicleNumber: req.VehicleNumber,
-			TotalFare:     totalFare,
-			From:          entryTime.Format(time.RFC3339),
-			To:            exitTime.Format(time.RFC3339),
-			VehicleID:     int(req.VehicleID),
-			ParkingLotID:  int(req.ParkingLotID),
-		},
+	return &genericresponse.GenericResponse{
+		StatusCode: http.StatusBadRequest,
+		Message:    "All Spots Are Already Free for this Vehicle Type",
 	}
-	return response, nil
+}
+
+// Names of the receipt lines that are not charged under a tariff band.
+const (
+	standardRateLine = "Standard rate"
+	dailyCapLine     = "Daily maximum"
+)
 
+// bandWindow identifies one window of a tariff band on a receipt.
+type bandWindow struct {
+	band  *models.TariffBand
+	start int64
 }
 
-func calculateFare(parkingLotID int, vehicleTypeId int, duration time.Duration) (float64, error) {
-	var tariffModels = map[int]map[int]model.Tariff{
-		1: {
-			1: {HourlyRate: 5},                                                       // Motorcycles/scooters
-			2: {HourlyRate: 20.5},                                                    // Cars/SUVs
-			3: {HourlyRate: 50, DayRate: 500, MaxDurationForDayRate: 24 * time.Hour}, // Buses/Trucks
-		},
-		2: {
-			1: {HourlyRate: 10.5},                          // Motorcycles/scooters
-			2: {FirstHourRate: 50, AdditionalHourRate: 25}, // Cars/SUVs
-			3: {HourlyRate: 100},                           // Buses/Trucks
-		},
+// calculateFare prices the charged periods of a stay under a tariff version in the lot's local time zone and
+// returns a line item per band window and stretch at the standard rates with the total fare. With a daily cap,
+// every 24 hours from the start of the first period are priced on their own and charged at most the cap, a
+// negative line takes off what a day is charged above it.
+func calculateFare(tariff *models.Tariff, periods []fare.Period, location *time.Location,
+	holidays fare.Calendar) ([]model.FareLine, money.Money, error) {
+
+	if tariff.DailyCap <= 0 || len(periods) == 0 {
+		lines, err := priceSegments(tariff, periods, location, holidays)
+		if err != nil {
+			return nil, money.Money{}, err
+		}
+		return lines, totalFare(tariff, lines), nil
 	}
 
-	tariff, ok := tariffModels[parkingLotID][vehicleTypeId]
-	if !ok {
-		return 0, fmt.Errorf("no tariff found for parking lot %d and vehicle type %d", parkingLotID, vehicleTypeId)
+	var (
+		lines    = make([]model.FareLine, 0, len(periods))
+		last     = periods[len(periods)-1].To
+		dailyCap = tariff.Money(tariff.DailyCap)
+	)
+	for from := periods[0].From; from.Before(last); from = from.Add(24 * time.Hour) {
+		to := from.Add(24 * time.Hour)
+		dayLines, err := priceSegments(tariff, fare.Clip(periods, from, to), location, holidays)
+		if err != nil {
+			return nil, money.Money{}, err
+		}
+		lines = append(lines, dayLines...)
+
+		if dayFare := totalFare(tariff, dayLines); dayFare.Cmp(dailyCap) > 0 {
+			if to.After(last) {
+				to = last
+			}
+			lines = append(lines, model.FareLine{
+				Name:   dailyCapLine,
+				From:   from.Format(time.RFC3339),
+				To:     to.Format(time.RFC3339),
+				Amount: dailyCap.Sub(dayFare),
+			})
+		}
 	}
+	return lines, totalFare(tariff, lines), nil
+}
+
+// priceSegments splits the charged periods of a stay across the time bands of a tariff version and prices every
+// part. A band window costs its flat rate once, or its hourly rate for the time the stay spends in it rounded up
+// to the granularity of the tariff. Time outside the bands is charged at the standard rates of the tariff, each
+// stretch as a stay of its own.
+func priceSegments(tariff *models.Tariff, periods []fare.Period, location *time.Location,
+	holidays fare.Calendar) ([]model.FareLine, error) {
+
+	var (
+		lines     = make([]model.FareLine, 0, len(periods))
+		windowAt  = make(map[bandWindow]int)
+		windowFor = make(map[bandWindow]time.Duration)
+	)
+	for _, period := range periods {
+		for _, segment := range fare.Split(tariff.Bands, period, location, holidays) {
+			if segment.Band == nil {
+				amount, err := standardFare(tariff, segment.To.Sub(segment.From))
+				if err != nil {
+					return nil, err
+				}
+				lines = append(lines, model.FareLine{
+					Name:   standardRateLine,
+					From:   segment.From.Format(time.RFC3339),
+					To:     segment.To.Format(time.RFC3339),
+					Amount: amount,
+				})
+				continue
+			}
 
-	// Calculate the number of hours rounded up
-	hours := int(duration.Hours())
-	remainingMinutes := int(duration.Minutes()) % 60
-	if remainingMinutes > 0 {
-		hours++
+			// A window interrupted by a band of higher priority stays a single line
+			window := bandWindow{band: segment.Band, start: segment.Window.UnixNano()}
+			i, ok := windowAt[window]
+			if !ok {
+				i = len(lines)
+				windowAt[window] = i
+				lines = append(lines, model.FareLine{
+					Name: segment.Band.Name,
+					From: segment.From.Format(time.RFC3339),
+				})
+			}
+			windowFor[window] += segment.To.Sub(segment.From)
+			lines[i].To = segment.To.Format(time.RFC3339)
+			lines[i].Amount = bandFare(tariff, segment.Band, windowFor[window])
+		}
 	}
+	return lines, nil
+}
 
+// totalFare adds up the amounts of the lines of a receipt.
+func totalFare(tariff *models.Tariff, lines []model.FareLine) money.Money {
+	total := tariff.Money(0)
+	for _, line := range lines {
+		total = total.Add(line.Amount)
+	}
+	return total
+}
+
+// bandFare prices the time a stay spends in one window of a tariff band.
+func bandFare(tariff *models.Tariff, band *models.TariffBand, duration time.Duration) money.Money {
+	if band.FlatRate > 0 {
+		return tariff.Money(band.FlatRate)
+	}
+	return hourlyFare(tariff.Money(band.HourlyRate), chargedMinutes(duration, tariff.Rounding()))
+}
+
+// chargedMinutes returns the minutes of a duration rounded up to the given granularity, seconds are not charged.
+func chargedMinutes(duration, rounding time.Duration) int64 {
+	duration = duration.Truncate(time.Minute)
+	units := duration / rounding
+	if duration%rounding > 0 {
+		units++
+	}
+	return int64((units * rounding) / time.Minute)
+}
+
+// hourlyFare charges an hourly rate for the given minutes. A fraction of the rate is rounded half up to a minor
+// unit of its currency.
+func hourlyFare(rate money.Money, minutes int64) money.Money {
+	return rate.MulRatio(minutes, 60, money.HalfUp)
+}
+
+// standardFare computes the fare of a stay of the given duration at the standard rates of a tariff version.
+func standardFare(tariff *models.Tariff, duration time.Duration) (money.Money, error) {
+	if tariff.HourlyRate <= 0 && (tariff.FirstHourRate <= 0 || tariff.AdditionalHourRate <= 0) {
+		return money.Money{}, fmt.Errorf("tariff %d has no hourly rate", tariff.ID)
+	}
+
+	// Calculate the number of minutes rounded up to the granularity of the tariff
+	minutes := chargedMinutes(duration, tariff.Rounding())
+	hourlyRate := tariff.Money(tariff.HourlyRate)
 	// Calculate the fare based on the tariff model
 	switch {
-	case tariff.DayRate > 0 && duration <= tariff.MaxDurationForDayRate:
+	case tariff.DayRate > 0 && duration <= tariff.MaxDurationForDayRate():
 		// Case 1: If a day rate exists and the duration is within the max duration for the day rate
 		{
-			return float64(hours) * tariff.HourlyRate, nil
+			return hourlyFare(hourlyRate, minutes), nil
 		}
-	case tariff.DayRate > 0 && duration > tariff.MaxDurationForDayRate:
+	case tariff.DayRate > 0 && duration > tariff.MaxDurationForDayRate():
 		// Case 2: If a day rate exists and the duration exceeds the max duration for the day rate
 		{
-			days := int(duration / tariff.MaxDurationForDayRate)
-			remainingHours := duration - time.Duration(days)*tariff.MaxDurationForDayRate
+			days := int64(duration / tariff.MaxDurationForDayRate())
+			remainingHours := duration - time.Duration(days)*tariff.MaxDurationForDayRate()
 
-			// Calculate the additional hours beyond the max day rate duration
-			additionalHours := int(remainingHours.Hours())
-			if remainingHours.Minutes() > float64(additionalHours*60) {
-				additionalHours++
-			}
+			// Calculate the additional minutes beyond the max day rate duration
+			additionalMinutes := chargedMinutes(remainingHours, tariff.Rounding())
 			days--
-			fare := (float64(days) * tariff.DayRate) + (float64(additionalHours) * tariff.HourlyRate) +
-				(float64(tariff.MaxDurationForDayRate.Hours()) * tariff.HourlyRate)
+			fare := tariff.Money(tariff.DayRate).Mul(days).
+				Add(hourlyFare(hourlyRate, additionalMinutes)).
+				Add(hourlyRate.Mul(int64(tariff.DayRateHours)))
 
 			return fare, nil
 		}
 	case tariff.FirstHourRate > 0 && tariff.AdditionalHourRate > 0:
 		// Case 3: If a special rate exists for the first hour and a different rate for additional hours
 		{
-			if hours == 1 {
-				return tariff.FirstHourRate, nil
+			firstHourRate := tariff.Money(tariff.FirstHourRate)
+			if minutes <= 60 {
+				return firstHourRate, nil
 			}
-			additionalHours := hours - 1
-			return tariff.FirstHourRate + float64(additionalHours)*tariff.AdditionalHourRate, nil
+			return firstHourRate.Add(hourlyFare(tariff.Money(tariff.AdditionalHourRate), minutes-60)), nil
 		}
 	default:
 		// Case 4: Default case with a standard hourly rate
 		{
-			return float64(hours) * tariff.HourlyRate, nil
-		}
-	}
-}
-
-func getMaxSpotsInParkingLot(req *model.UnParkVehicleRequest) (int, error) {
-	var maxSpots int
-
-	switch {
-	case req.ParkingLotID == 1 && req.VehicleID == 1:
-		maxSpots = 50
-	case req.ParkingLotID == 1 && req.VehicleID == 2:
-		maxSpots = 30
-	case req.ParkingLotID == 1 && req.VehicleID == 3:
-		maxSpots = 20
-	case req.ParkingLotID == 2 && req.VehicleID == 1:
-		maxSpots = 100
-	case req.ParkingLotID == 2 && req.VehicleID == 2:
-		maxSpots = 80
-	case req.ParkingLotID == 2 && req.VehicleID == 3:
-		maxSpots = 40
-	default:
-		// If the ParkingLotID and VehicleID combination is invalid, return a bad request error
-		return -1, &genericresponse.GenericResponse{
-			StatusCode: http.StatusBadRequest,
-			Message:    "Wrong Parking LotId or VehicleId",
+			return hourlyFare(hourlyRate, minutes), nil
 		}
 	}
-	return maxSpots, nil
 }
@@ -1,11 +1,73 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"parking_lot_service/internal/auth"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/money"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/fare"
+	"parking_lot_service/internal/service/model"
+	"parking_lot_service/internal/service/payment"
+	"parking_lot_service/internal/ticket"
+	"reflect"
+	"sync"
 	"testing"
 	"time"
 )
 
-func Test_calculateFare(t *testing.T) {
+// seededTariffs mirrors the tariffs seeded for Parking Lot A (1) and Parking Lot B (2).
+var seededTariffs = map[int]map[int]*models.Tariff{
+	1: {
+		1: {Currency: "INR", HourlyRate: 500},                                    // Motorcycles/scooters
+		2: {Currency: "INR", HourlyRate: 2050},                                   // Cars/SUVs
+		3: {Currency: "INR", HourlyRate: 5000, DayRate: 50000, DayRateHours: 24}, // Buses/Trucks
+	},
+	2: {
+		1: {Currency: "INR", HourlyRate: 1050},                              // Motorcycles/scooters
+		2: {Currency: "INR", FirstHourRate: 5000, AdditionalHourRate: 2500}, // Cars/SUVs
+		3: {Currency: "INR", HourlyRate: 10000},                             // Buses/Trucks
+	},
+}
+
+// inr returns an amount of paise.
+func inr(amount int64) money.Money {
+	return money.New(amount, "INR")
+}
+
+// payAndExit pays the invoice of a vehicle that was not let out through the local gateway and unparks it again.
+func payAndExit(t *testing.T, svc ParkingLotService, unparked *model.UnParkVehicleResponse) {
+	t.Helper()
+	if unparked.Exited {
+		return
+	}
+	if unparked.Invoice == nil {
+		t.Fatalf("UnParkVehicle() = %+v, want an invoice for a vehicle that did not exit", unparked)
+	}
+
+	invoice, err := svc.PayInvoice(context.Background(), unparked.Invoice.ID, &model.PaymentRequest{Gateway: payment.Local})
+	if err != nil {
+		t.Fatalf("PayInvoice() error = %v", err)
+	}
+	if invoice.Status != string(models.InvoiceStatusPaid) {
+		t.Fatalf("PayInvoice() status = %q, want %q", invoice.Status, models.InvoiceStatusPaid)
+	}
+
+	exited, err := svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{
+		TicketNumber: unparked.Parking.TicketNumber,
+	})
+	if err != nil {
+		t.Fatalf("UnParkVehicle() after payment error = %v", err)
+	}
+	if !exited.Exited {
+		t.Fatalf("UnParkVehicle() after payment = %+v, want the vehicle to exit", exited)
+	}
+}
+
+func Test_standardFare(t *testing.T) {
 	type args struct {
 		parkingLotID  int
 		vehicleTypeId int
@@ -14,7 +76,7 @@ func Test_calculateFare(t *testing.T) {
 	tests := []struct {
 		name    string
 		args    args
-		want    float64
+		want    money.Money
 		wantErr bool
 	}{
 		{
@@ -24,7 +86,7 @@ func Test_calculateFare(t *testing.T) {
 				vehicleTypeId: 1,
 				duration:      2 * time.Hour,
 			},
-			want:    10.0, // 2 hours * HourlyRate: 5
+			want:    inr(1000), // 2 hours * HourlyRate: 5
 			wantErr: false,
 		},
 		{
@@ -34,7 +96,7 @@ func Test_calculateFare(t *testing.T) {
 				vehicleTypeId: 1,
 				duration:      2*time.Hour + 10*time.Minute,
 			},
-			want:    15.0, // 3 hours * HourlyRate: 5
+			want:    inr(1500), // 3 hours * HourlyRate: 5
 			wantErr: false,
 		},
 		{
@@ -44,7 +106,7 @@ func Test_calculateFare(t *testing.T) {
 				vehicleTypeId: 2,
 				duration:      28 * time.Hour,
 			},
-			want:    574.0, // 28 hours * HourlyRate: 20.50/hr
+			want:    inr(57400), // 28 hours * HourlyRate: 20.50/hr
 			wantErr: false,
 		},
 		{
@@ -54,7 +116,7 @@ func Test_calculateFare(t *testing.T) {
 				vehicleTypeId: 2,
 				duration:      28*time.Hour + 1*time.Minute,
 			},
-			want:    594.5, // 29 hours * HourlyRate: 20.50/hr
+			want:    inr(59450), // 29 hours * HourlyRate: 20.50/hr
 			wantErr: false,
 		},
 		{
@@ -64,7 +126,7 @@ func Test_calculateFare(t *testing.T) {
 				vehicleTypeId: 3,
 				duration:      23 * time.Hour,
 			},
-			want:    1150.0, // 23 hours * HourlyRate: 50
+			want:    inr(115000), // 23 hours * HourlyRate: 50
 			wantErr: false,
 		},
 		{
@@ -74,17 +136,17 @@ func Test_calculateFare(t *testing.T) {
 				vehicleTypeId: 3,
 				duration:      50*time.Hour + 1*time.Minute,
 			},
-			want:    1850.0, // for first day=1200 based on 24*50 ,then second day 500, and for 2 hour 1 minutes 150.
+			want:    inr(185000), // for first day=1200 based on 24*50 ,then second day 500, and for 2 hour 1 minutes 150.
 			wantErr: false,
 		},
 		{
-			name: "Invalid case: No tariff found",
+			name: "Invalid case: Tariff without any rate",
 			args: args{
-				parkingLotID:  3, // Non-existing parking lot ID
+				parkingLotID:  3, // Parking lot without a seeded tariff
 				vehicleTypeId: 1,
 				duration:      2 * time.Hour,
 			},
-			want:    0.0,
+			want:    inr(0),
 			wantErr: true,
 		},
 		{
@@ -94,7 +156,7 @@ func Test_calculateFare(t *testing.T) {
 				vehicleTypeId: 1,
 				duration:      2 * time.Hour,
 			},
-			want:    21.0, // 2 hours * HourlyRate: 10.5
+			want:    inr(2100), // 2 hours * HourlyRate: 10.5
 			wantErr: false,
 		},
 		{
@@ -104,7 +166,7 @@ func Test_calculateFare(t *testing.T) {
 				vehicleTypeId: 1,
 				duration:      2*time.Hour + 10*time.Minute,
 			},
-			want:    31.5, // 3 hours * HourlyRate: 10.5
+			want:    inr(3150), // 3 hours * HourlyRate: 10.5
 			wantErr: false,
 		},
 		{
@@ -114,7 +176,7 @@ func Test_calculateFare(t *testing.T) {
 				vehicleTypeId: 2,
 				duration:      2 * time.Hour,
 			},
-			want:    75.0, // FirstHourRate: 50 + AdditionalHourRate: 25
+			want:    inr(7500), // FirstHourRate: 50 + AdditionalHourRate: 25
 			wantErr: false,
 		},
 		{
@@ -124,7 +186,7 @@ func Test_calculateFare(t *testing.T) {
 				vehicleTypeId: 2,
 				duration:      2*time.Hour + 10*time.Minute,
 			},
-			want:    100.0, //FirstHourRate: 50 + 1 AdditionalHourRate: 25+10 Extra Minutes mean 1 additional Hour (25)
+			want:    inr(10000), //FirstHourRate: 50 + 1 AdditionalHourRate: 25+10 Extra Minutes mean 1 additional Hour (25)
 			wantErr: false,
 		},
 		{
@@ -134,7 +196,7 @@ func Test_calculateFare(t *testing.T) {
 				vehicleTypeId: 2,
 				duration:      2 * time.Hour,
 			},
-			want:    75.0, //FirstHourRate: 50 + 1 AdditionalHourRate: 25+10 Extra Minutes mean 1 additional Hour (25)
+			want:    inr(7500), //FirstHourRate: 50 + 1 AdditionalHourRate: 25+10 Extra Minutes mean 1 additional Hour (25)
 			wantErr: false,
 		},
 		{
@@ -144,7 +206,7 @@ func Test_calculateFare(t *testing.T) {
 				vehicleTypeId: 2,
 				duration:      5 * time.Hour,
 			},
-			want:    150.0, // FirstHourRate: 50 + 4 * AdditionalHourRate: 25
+			want:    inr(15000), // FirstHourRate: 50 + 4 * AdditionalHourRate: 25
 			wantErr: false,
 		},
 		{
@@ -154,7 +216,7 @@ func Test_calculateFare(t *testing.T) {
 				vehicleTypeId: 3,
 				duration:      2 * time.Hour,
 			},
-			want:    200.0, // 2 hours * HourlyRate: 100
+			want:    inr(20000), // 2 hours * HourlyRate: 100
 			wantErr: false,
 		},
 		{
@@ -164,7 +226,7 @@ func Test_calculateFare(t *testing.T) {
 				vehicleTypeId: 3,
 				duration:      2*time.Hour + 10*time.Minute,
 			},
-			want:    300.0, // 3 hours * HourlyRate: 100
+			want:    inr(30000), // 3 hours * HourlyRate: 100
 			wantErr: false,
 		},
 		{
@@ -174,7 +236,7 @@ func Test_calculateFare(t *testing.T) {
 				vehicleTypeId: 3,
 				duration:      24 * time.Hour,
 			},
-			want:    1200.0, // DayRate: 1000
+			want:    inr(120000), // DayRate: 1000
 			wantErr: false,
 		},
 		{
@@ -184,21 +246,593 @@ func Test_calculateFare(t *testing.T) {
 				vehicleTypeId: 3,
 				duration:      24*time.Hour + 1*time.Minute,
 			},
-			want:    1250.0, // DayRate: 24*50  + 50 1 Minute
+			want:    inr(125000), // DayRate: 24*50  + 50 1 Minute
 			wantErr: false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := calculateFare(tt.args.parkingLotID, tt.args.vehicleTypeId, tt.args.duration)
+			tariff, ok := seededTariffs[tt.args.parkingLotID][tt.args.vehicleTypeId]
+			if !ok {
+				tariff = &models.Tariff{Currency: "INR"}
+			}
+			got, err := standardFare(tariff, tt.args.duration)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("calculateFare() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("standardFare() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if err == nil && got != tt.want {
+				t.Errorf("standardFare() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_calculateFare(t *testing.T) {
+	tariff := &models.Tariff{Currency: "INR", HourlyRate: 2000, Bands: []models.TariffBand{
+		{Name: "Night", Days: models.BandDaysEveryDay, StartMinute: 22 * 60, EndMinute: 6 * 60, FlatRate: 5000},
+		{Name: "Weekend", Days: models.BandDaysWeekends, FlatRate: 8000, Priority: 1},
+		{Name: "Holiday", Days: models.BandDaysHolidays, StartMinute: 8 * 60, EndMinute: 20 * 60, HourlyRate: 1000},
+	}}
+	// Friday, 5 January 2024
+	friday := func(hour, minute int) time.Time {
+		return time.Date(2024, time.January, 5, hour, minute, 0, 0, time.UTC)
+	}
+	holidays := fare.Calendar{"2024-01-03": true}
+
+	tests := []struct {
+		name      string
+		periods   []fare.Period
+		wantLines []model.FareLine
+		want      money.Money
+	}{
+		{
+			name:    "Evening into the weekend",
+			periods: []fare.Period{{From: friday(20, 0), To: friday(32, 0)}},
+			wantLines: []model.FareLine{
+				{Name: "Standard rate", From: "2024-01-05T20:00:00Z", To: "2024-01-05T22:00:00Z", Amount: inr(4000)},
+				{Name: "Night", From: "2024-01-05T22:00:00Z", To: "2024-01-06T00:00:00Z", Amount: inr(5000)},
+				{Name: "Weekend", From: "2024-01-06T00:00:00Z", To: "2024-01-06T08:00:00Z", Amount: inr(8000)},
+			},
+			want: inr(17000),
+		},
+		{
+			name: "Each charged period is priced on its own",
+			periods: []fare.Period{
+				{From: friday(9, 0), To: friday(10, 0)},
+				{From: friday(12, 0), To: friday(13, 0)},
+			},
+			wantLines: []model.FareLine{
+				{Name: "Standard rate", From: "2024-01-05T09:00:00Z", To: "2024-01-05T10:00:00Z", Amount: inr(2000)},
+				{Name: "Standard rate", From: "2024-01-05T12:00:00Z", To: "2024-01-05T13:00:00Z", Amount: inr(2000)},
+			},
+			want: inr(4000),
+		},
+		{
+			name:    "Hourly holiday band",
+			periods: []fare.Period{{From: friday(10, 0).AddDate(0, 0, -2), To: friday(12, 30).AddDate(0, 0, -2)}},
+			wantLines: []model.FareLine{
+				{Name: "Holiday", From: "2024-01-03T10:00:00Z", To: "2024-01-03T12:30:00Z", Amount: inr(3000)},
+			},
+			want: inr(3000),
+		},
+		{
+			name:      "Nothing to charge",
+			periods:   nil,
+			wantLines: []model.FareLine{},
+			want:      inr(0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, got, err := calculateFare(tariff, tt.periods, time.UTC, holidays)
+			if err != nil {
+				t.Fatalf("calculateFare() error = %v", err)
+			}
+			if got != tt.want {
 				t.Errorf("calculateFare() got = %v, want %v", got, tt.want)
 			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("calculateFare() lines = %+v, want %+v", lines, tt.wantLines)
+			}
+		})
+	}
+}
+
+func Test_standardFare_Rounding(t *testing.T) {
+	tests := []struct {
+		name     string
+		tariff   *models.Tariff
+		duration time.Duration
+		want     money.Money
+	}{
+		{
+			name:     "Per minute",
+			tariff:   &models.Tariff{Currency: "INR", HourlyRate: 2000, RoundingMinutes: 1},
+			duration: 90*time.Minute + 20*time.Second,
+			want:     inr(3000), // 90 minutes, seconds are not charged
+		},
+		{
+			name:     "Started quarter hours",
+			tariff:   &models.Tariff{Currency: "INR", HourlyRate: 2000, RoundingMinutes: 15},
+			duration: 76 * time.Minute,
+			want:     inr(3000), // 1.5 hours
+		},
+		{
+			name:     "Started hours",
+			tariff:   &models.Tariff{Currency: "INR", HourlyRate: 2000, RoundingMinutes: 60},
+			duration: 76 * time.Minute,
+			want:     inr(4000), // 2 hours
+		},
+		{
+			name:     "Unset rounding charges started hours",
+			tariff:   &models.Tariff{Currency: "INR", HourlyRate: 2000},
+			duration: 76 * time.Minute,
+			want:     inr(4000), // 2 hours
+		},
+		{
+			name:     "Additional hours by the quarter",
+			tariff:   &models.Tariff{Currency: "INR", FirstHourRate: 5000, AdditionalHourRate: 2000, RoundingMinutes: 15},
+			duration: 80 * time.Minute,
+			want:     inr(6000), // FirstHourRate: 50 + 0.5 * AdditionalHourRate: 20
+		},
+		{
+			name:     "Part of the first hour",
+			tariff:   &models.Tariff{Currency: "INR", FirstHourRate: 5000, AdditionalHourRate: 2000, RoundingMinutes: 15},
+			duration: 20 * time.Minute,
+			want:     inr(5000), // FirstHourRate: 50
+		},
+		{
+			name:     "Hours past the day rate by the quarter",
+			tariff:   &models.Tariff{Currency: "INR", HourlyRate: 1000, DayRate: 10000, DayRateHours: 12, RoundingMinutes: 15},
+			duration: 24*time.Hour + 10*time.Minute,
+			want:     inr(22250), // 12 * 10 + DayRate: 100 + 0.25 * 10
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := standardFare(tt.tariff, tt.duration)
+			if err != nil {
+				t.Fatalf("standardFare() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("standardFare() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_calculateFare_DailyCap(t *testing.T) {
+	// Friday, 5 January 2024
+	friday := func(hour, minute int) time.Time {
+		return time.Date(2024, time.January, 5, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		tariff    *models.Tariff
+		periods   []fare.Period
+		wantLines []model.FareLine
+		want      money.Money
+	}{
+		{
+			name:    "Day under the cap",
+			tariff:  &models.Tariff{Currency: "INR", HourlyRate: 2000, DailyCap: 10000},
+			periods: []fare.Period{{From: friday(9, 0), To: friday(12, 0)}},
+			wantLines: []model.FareLine{
+				{Name: "Standard rate", From: "2024-01-05T09:00:00Z", To: "2024-01-05T12:00:00Z", Amount: inr(6000)},
+			},
+			want: inr(6000),
+		},
+		{
+			name:    "Every 24 hours from entry are capped on their own",
+			tariff:  &models.Tariff{Currency: "INR", HourlyRate: 2000, DailyCap: 10000},
+			periods: []fare.Period{{From: friday(9, 0), To: friday(9+30, 0)}},
+			wantLines: []model.FareLine{
+				{Name: "Standard rate", From: "2024-01-05T09:00:00Z", To: "2024-01-06T09:00:00Z", Amount: inr(48000)},
+				{Name: "Daily maximum", From: "2024-01-05T09:00:00Z", To: "2024-01-06T09:00:00Z", Amount: inr(-38000)},
+				{Name: "Standard rate", From: "2024-01-06T09:00:00Z", To: "2024-01-06T15:00:00Z", Amount: inr(12000)},
+				{Name: "Daily maximum", From: "2024-01-06T09:00:00Z", To: "2024-01-06T15:00:00Z", Amount: inr(-2000)},
+			},
+			want: inr(20000),
+		},
+		{
+			name:    "Last part of a day under the cap",
+			tariff:  &models.Tariff{Currency: "INR", HourlyRate: 2000, DailyCap: 10000},
+			periods: []fare.Period{{From: friday(9, 0), To: friday(9+26, 0)}},
+			wantLines: []model.FareLine{
+				{Name: "Standard rate", From: "2024-01-05T09:00:00Z", To: "2024-01-06T09:00:00Z", Amount: inr(48000)},
+				{Name: "Daily maximum", From: "2024-01-05T09:00:00Z", To: "2024-01-06T09:00:00Z", Amount: inr(-38000)},
+				{Name: "Standard rate", From: "2024-01-06T09:00:00Z", To: "2024-01-06T11:00:00Z", Amount: inr(4000)},
+			},
+			want: inr(14000),
+		},
+		{
+			name: "Band and charged periods count towards the day they fall in",
+			tariff: &models.Tariff{Currency: "INR", HourlyRate: 2000, DailyCap: 10000, Bands: []models.TariffBand{
+				{Name: "Night", Days: models.BandDaysEveryDay, StartMinute: 22 * 60, EndMinute: 6 * 60, FlatRate: 5000},
+			}},
+			periods: []fare.Period{
+				{From: friday(18, 0), To: friday(23, 0)},
+				{From: friday(18+24, 0), To: friday(20+24, 0)},
+			},
+			wantLines: []model.FareLine{
+				{Name: "Standard rate", From: "2024-01-05T18:00:00Z", To: "2024-01-05T22:00:00Z", Amount: inr(8000)},
+				{Name: "Night", From: "2024-01-05T22:00:00Z", To: "2024-01-05T23:00:00Z", Amount: inr(5000)},
+				{Name: "Daily maximum", From: "2024-01-05T18:00:00Z", To: "2024-01-06T18:00:00Z", Amount: inr(-3000)},
+				{Name: "Standard rate", From: "2024-01-06T18:00:00Z", To: "2024-01-06T20:00:00Z", Amount: inr(4000)},
+			},
+			want: inr(14000),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, got, err := calculateFare(tt.tariff, tt.periods, time.UTC, nil)
+			if err != nil {
+				t.Fatalf("calculateFare() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("calculateFare() got = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("calculateFare() lines = %+v, want %+v", lines, tt.wantLines)
+			}
+		})
+	}
+}
+
+func Test_chargedPeriod(t *testing.T) {
+	tariff := &models.Tariff{Currency: "INR", HourlyRate: 2000, EntryGraceMinutes: 10, ExitGraceMinutes: 15}
+	entry := time.Date(2024, time.January, 5, 9, 0, 0, 0, time.UTC)
+	paidAt := entry.Add(2 * time.Hour)
+
+	tests := []struct {
+		name   string
+		paidAt *time.Time
+		exit   time.Time
+		want   fare.Period
+		wantOk bool
+	}{
+		{
+			name:   "Within the entry grace period",
+			exit:   entry.Add(10 * time.Minute),
+			wantOk: false,
+		},
+		{
+			name:   "Past the entry grace period the whole stay is charged",
+			exit:   entry.Add(11 * time.Minute),
+			want:   fare.Period{From: entry, To: entry.Add(11 * time.Minute)},
+			wantOk: true,
+		},
+		{
+			name:   "Within the exit grace period after payment",
+			paidAt: &paidAt,
+			exit:   paidAt.Add(15 * time.Minute),
+			wantOk: false,
+		},
+		{
+			name:   "Past the exit grace period the time since payment is charged",
+			paidAt: &paidAt,
+			exit:   paidAt.Add(40 * time.Minute),
+			want:   fare.Period{From: paidAt, To: paidAt.Add(40 * time.Minute)},
+			wantOk: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parkingSession := &models.ParkingSession{EntryTime: entry, PaidAt: tt.paidAt}
+			got, ok := chargedPeriod(tariff, parkingSession, tt.exit)
+			if ok != tt.wantOk || got != tt.want {
+				t.Errorf("chargedPeriod() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOk)
+			}
 		})
 	}
 }
+
+func TestPayTicket_ExitGrace(t *testing.T) {
+	tests := []struct {
+		name          string
+		paidAgo       time.Duration // How long before leaving the ticket was paid
+		wantOverstay  money.Money
+		wantTotalFare money.Money
+	}{
+		{
+			name:          "Leaving within the exit grace period",
+			paidAgo:       0,
+			wantOverstay:  inr(0),
+			wantTotalFare: inr(6000),
+		},
+		{
+			name:          "Leaving after the exit grace period",
+			paidAgo:       20 * time.Minute,
+			wantOverstay:  inr(2000), // 1 started hour since payment
+			wantTotalFare: inr(8000),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeRepo(1)
+			fake.tariffs[0].ExitGraceMinutes = 15
+			svc := NewParkingLotService(fake, payment.NewLocal())
+
+			parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+				ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0001",
+			})
+			if err != nil {
+				t.Fatalf("ParkVehicle() error = %v", err)
+			}
+			ticketNumber := parked.ParkingTicket.TicketNumber
+			fake.sessions[1].EntryTime = time.Now().Add(-3 * time.Hour)
+
+			paid, err := svc.PayTicket(context.Background(), &model.PayTicketRequest{
+				TicketNumber: ticketNumber, Gateway: payment.Local,
+			})
+			if err != nil {
+				t.Fatalf("PayTicket() error = %v", err)
+			}
+			if paid.Parking.TotalFare != inr(6000) || paid.ExitBy == "" {
+				t.Errorf("PayTicket() = %+v, want a fare of 60 and an exit deadline", paid)
+			}
+			if paid.Invoice == nil || paid.Invoice.Status != string(models.InvoiceStatusPaid) {
+				t.Errorf("PayTicket() invoice = %+v, want a paid invoice", paid.Invoice)
+			}
+
+			// A ticket is only paid once
+			_, err = svc.PayTicket(context.Background(), &model.PayTicketRequest{TicketNumber: ticketNumber})
+			var genericErr *genericresponse.GenericResponse
+			if !errors.As(err, &genericErr) || genericErr.StatusCode != http.StatusConflict {
+				t.Fatalf("PayTicket() paid ticket error = %v, want status %d", err, http.StatusConflict)
+			}
+
+			paidAt := fake.sessions[1].PaidAt.Add(-tt.paidAgo)
+			fake.sessions[1].PaidAt = &paidAt
+			unparked, err := svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{TicketNumber: ticketNumber})
+			if err != nil {
+				t.Fatalf("UnParkVehicle() error = %v", err)
+			}
+			prepaid := unparked.Parking.PrepaidFare
+			if prepaid == nil || *prepaid != inr(6000) || unparked.Parking.TotalFare != tt.wantOverstay {
+				t.Errorf("UnParkVehicle() prepaid = %v, fare = %v, want 60.00 INR and %v",
+					prepaid, unparked.Parking.TotalFare, tt.wantOverstay)
+			}
+			payAndExit(t, svc, unparked)
+			if got := inr(*fake.sessions[1].Fare); got != tt.wantTotalFare {
+				t.Errorf("session fare = %v, want %v", got, tt.wantTotalFare)
+			}
+		})
+	}
+}
+
+func TestUnParkVehicle_ConcurrentRequestsReleaseSpotOnce(t *testing.T) {
+	const totalSpots = 20
+
+	fake := newFakeRepo(totalSpots)
+	svc := NewParkingLotService(fake, payment.NewLocal())
+
+	ticketNumbers := make([]string, 0, totalSpots)
+	for i := 0; i < totalSpots; i++ {
+		parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+			ParkingLotID:  1,
+			VehicleID:     1,
+			VehicleNumber: fmt.Sprintf("KA-01-%04d", i),
+		})
+		if err != nil {
+			t.Fatalf("ParkVehicle() error = %v", err)
+		}
+		ticketNumbers = append(ticketNumbers, parked.ParkingTicket.TicketNumber)
+	}
+
+	// Every vehicle is unparked by several requests at once, only one of them may free its spot
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		unparked int
+		start    = make(chan struct{})
+	)
+	for i := 0; i < totalSpots; i++ {
+		for attempt := 0; attempt < 5; attempt++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+
+				_, err := svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{
+					TicketNumber: ticketNumbers[i],
+				})
+				if err == nil {
+					mu.Lock()
+					unparked++
+					mu.Unlock()
+				}
+			}(i)
+		}
+	}
+
+	close(start)
+	wg.Wait()
+
+	if unparked != totalSpots {
+		t.Errorf("UnParkVehicle() succeeded %d times, want %d", unparked, totalSpots)
+	}
+	if got := fake.parkingSpaces[[2]int{1, 1}].AvailableSpots; got != totalSpots {
+		t.Errorf("AvailableSpots = %d, want %d", got, totalSpots)
+	}
+	if got := fake.occupiedSpots(); got != 0 {
+		t.Errorf("occupied spots = %d, want 0", got)
+	}
+}
+
+func TestUnParkVehicle_OverflowedVehicleReturnsSpotToLargerPool(t *testing.T) {
+	tests := []struct {
+		name     string
+		pricing  models.UpsizePricing
+		wantFare money.Money
+	}{
+		{
+			name:     "Priced by the vehicle type",
+			pricing:  models.UpsizePricingVehicleType,
+			wantFare: inr(1000), // 2 hours * motorcycle HourlyRate: 5
+		},
+		{
+			name:     "Priced by the spot class",
+			pricing:  models.UpsizePricingSpotClass,
+			wantFare: inr(4000), // 2 hours * car HourlyRate: 20
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The only car spot is free, the motorcycle pool has no spots and overflows into the car pool
+			fake := newFakeRepo(1)
+			fake.parkingLots[1].UpsizePricing = tt.pricing
+			fake.vehicleTypes[2] = &models.VehicleType{ID: 2, Code: "MOTORCYCLES_SCOOTERS",
+				SizeClass: models.SizeClassSmall, AllowLargerSpots: true}
+			fake.parkingSpaces[[2]int{1, 2}] = &models.ParkingSpace{ID: 2, ParkingLotId: 1, VehicleTypeId: 2}
+			fake.upsizeRules = []*models.UpsizeRule{
+				{ID: 1, ParkingLotId: 1, VehicleTypeId: 2, OverflowVehicleTypeId: 1, Priority: 1},
+			}
+			fake.tariffs = append(fake.tariffs,
+				&models.Tariff{Currency: "INR", ID: 2, ParkingLotId: 1, VehicleTypeId: 2, HourlyRate: 500, EffectiveFrom: time.Unix(0, 0)})
+			svc := NewParkingLotService(fake, payment.NewLocal())
+
+			parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+				ParkingLotID: 1, VehicleID: 2, VehicleNumber: "KA-01-0001",
+			})
+			if err != nil {
+				t.Fatalf("ParkVehicle() error = %v", err)
+			}
+			if spot := parked.ParkingTicket.Spot; spot.VehicleID != 1 || spot.SizeClass != models.SizeClassMedium {
+				t.Errorf("ParkVehicle() spot = %+v, want a car spot", spot)
+			}
+			if got := fake.parkingSpaces[[2]int{1, 1}].AvailableSpots; got != 0 {
+				t.Errorf("car AvailableSpots = %d, want 0", got)
+			}
+
+			fake.sessions[1].EntryTime = time.Now().Add(-90 * time.Minute)
+			unparked, err := svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{
+				TicketNumber: parked.ParkingTicket.TicketNumber,
+			})
+			if err != nil {
+				t.Fatalf("UnParkVehicle() error = %v", err)
+			}
+			if unparked.Parking.TotalFare != tt.wantFare {
+				t.Errorf("UnParkVehicle() fare = %v, want %v", unparked.Parking.TotalFare, tt.wantFare)
+			}
+			payAndExit(t, svc, unparked)
+			if got := fake.parkingSpaces[[2]int{1, 1}].AvailableSpots; got != 1 {
+				t.Errorf("car AvailableSpots = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestUnParkVehicle_ByTicket(t *testing.T) {
+	fake := newFakeRepo(1)
+	svc := NewParkingLotService(fake, payment.NewLocal())
+
+	parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0001",
+	})
+	if err != nil {
+		t.Fatalf("ParkVehicle() error = %v", err)
+	}
+	ticketNumber := parked.ParkingTicket.TicketNumber
+	if !ticket.Valid(ticketNumber) {
+		t.Fatalf("ParkVehicle() ticket number = %q, want a valid one", ticketNumber)
+	}
+
+	// A mistyped ticket number fails the check digit before the repo is asked
+	mistyped := []byte(ticketNumber)
+	mistyped[0] = '0' + (mistyped[0]-'0'+1)%10
+	_, err = svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{TicketNumber: string(mistyped)})
+	var genericErr *genericresponse.GenericResponse
+	if !errors.As(err, &genericErr) || genericErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("UnParkVehicle() mistyped ticket error = %v, want status %d", err, http.StatusBadRequest)
+	}
+
+	fake.sessions[1].EntryTime = time.Now().Add(-90 * time.Minute)
+	unparked, err := svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{TicketNumber: ticketNumber})
+	if err != nil {
+		t.Fatalf("UnParkVehicle() error = %v", err)
+	}
+	receipt := unparked.Parking
+	if receipt.VehicleNumber != "KA-01-0001" || receipt.VehicleID != 1 || receipt.ParkingLotID != 1 {
+		t.Errorf("UnParkVehicle() receipt = %+v, want the parked vehicle", receipt)
+	}
+	if receipt.TotalFare != inr(4000) {
+		t.Errorf("UnParkVehicle() fare = %v, want 40", receipt.TotalFare)
+	}
+	payAndExit(t, svc, unparked)
+
+	// The ticket cannot be used a second time
+	_, err = svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{TicketNumber: ticketNumber})
+	if !errors.As(err, &genericErr) || genericErr.StatusCode != http.StatusConflict {
+		t.Fatalf("UnParkVehicle() used ticket error = %v, want status %d", err, http.StatusConflict)
+	}
+}
+
+func TestUnParkVehicle_ReceiptInLotCurrency(t *testing.T) {
+	fake := newFakeRepo(1)
+	fake.parkingLots[1].Currency = "EUR"
+	fake.parkingLots[1].Locale = "de-DE"
+	fake.tariffs[0].Currency = "EUR"
+	fake.tariffs[0].HourlyRate = 125050
+	svc := NewParkingLotService(fake, payment.NewLocal())
+
+	parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0001",
+	})
+	if err != nil {
+		t.Fatalf("ParkVehicle() error = %v", err)
+	}
+	fake.sessions[1].EntryTime = time.Now().Add(-90 * time.Minute)
+
+	unparked, err := svc.UnParkVehicle(context.Background(), &model.UnParkVehicleRequest{
+		TicketNumber: parked.ParkingTicket.TicketNumber,
+	})
+	if err != nil {
+		t.Fatalf("UnParkVehicle() error = %v", err)
+	}
+	receipt := unparked.Parking
+	if receipt.Currency != "EUR" || receipt.TotalFare != money.New(250100, "EUR") {
+		t.Errorf("UnParkVehicle() fare = %v %v, want 2501.00 EUR", receipt.Currency, receipt.TotalFare)
+	}
+	if receipt.FormattedTotalFare != "2.501,00 €" || receipt.FareLines[0].FormattedAmount != "2.501,00 €" {
+		t.Errorf("UnParkVehicle() formatted fare = %q, line %q, want 2.501,00 €",
+			receipt.FormattedTotalFare, receipt.FareLines[0].FormattedAmount)
+	}
+	payAndExit(t, svc, unparked)
+	if fare := fake.sessions[1].Fare; fake.sessions[1].Currency != "EUR" || fare == nil || *fare != 250100 {
+		t.Errorf("session fare = %v %v, want 250100 EUR", fare, fake.sessions[1].Currency)
+	}
+}
+
+func TestUnParkVehicle_ScopedToParkingLots(t *testing.T) {
+	fake := newFakeRepo(1)
+	svc := NewParkingLotService(fake)
+
+	parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0001",
+	})
+	if err != nil {
+		t.Fatalf("ParkVehicle() error = %v", err)
+	}
+
+	// A gate controller of another lot cannot let the vehicle out
+	ctx := auth.NewContext(context.Background(), &auth.Principal{
+		Subject: "gate-north", Role: auth.RoleGateController, ParkingLotIDs: []int{2},
+	})
+	_, err = svc.UnParkVehicle(ctx, &model.UnParkVehicleRequest{TicketNumber: parked.ParkingTicket.TicketNumber})
+	wantErrorStatus(t, "UnParkVehicle() from another lot", err, http.StatusForbidden)
+	_, err = svc.ParkVehicle(ctx, &model.ParkVehicleRequest{ParkingLotID: 1, VehicleID: 1, VehicleNumber: "KA-01-0002"})
+	wantErrorStatus(t, "ParkVehicle() from another lot", err, http.StatusForbidden)
+	if fake.sessions[1].Status != models.ParkingSessionStatusOpen {
+		t.Errorf("session status = %s, want it still open", fake.sessions[1].Status)
+	}
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+)
+
+func (s *impl) GetUpsizePolicy(ctx context.Context, parkingLotId int) (*model.UpsizePolicyResponse, error) {
+	parkingLot, err := s.getParkingLot(ctx, parkingLotId)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.parkingLotRepo.GetUpsizeRules(ctx, parkingLotId)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	return toUpsizePolicyResponse(parkingLot.ID, parkingLot.UpsizePricing, rules), nil
+}
+
+func (s *impl) UpdateUpsizePolicy(ctx context.Context, parkingLotId int,
+	req *model.UpsizePolicyRequest) (*model.UpsizePolicyResponse, error) {
+
+	if _, err := s.getParkingLot(ctx, parkingLotId); err != nil {
+		return nil, err
+	}
+
+	pricing := models.UpsizePricing(req.Pricing)
+	switch pricing {
+	case "":
+		pricing = models.UpsizePricingVehicleType
+	case models.UpsizePricingVehicleType, models.UpsizePricingSpotClass:
+	default:
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Upsize pricing must be vehicle-type or spot-class",
+		}
+	}
+
+	var (
+		rules      []*models.UpsizeRule
+		configured = make(map[int]bool, len(req.Rules))
+	)
+	for _, ruleReq := range req.Rules {
+		if configured[ruleReq.VehicleID] {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    fmt.Sprintf("Vehicle type %d is listed more than once", ruleReq.VehicleID),
+			}
+		}
+		configured[ruleReq.VehicleID] = true
+
+		vehicleRules, err := s.upsizeRulesFromRequest(ctx, parkingLotId, ruleReq)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, vehicleRules...)
+	}
+
+	err := s.parkingLotRepo.ReplaceUpsizePolicy(ctx, parkingLotId, pricing, rules)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "parking lot not found",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	return toUpsizePolicyResponse(parkingLotId, pricing, rules), nil
+}
+
+// upsizeRulesFromRequest validates the overflow vehicle types of one vehicle type and turns them into rules.
+// Only vehicle types that may use larger spots can overflow, and only into pools of a larger size class.
+func (s *impl) upsizeRulesFromRequest(ctx context.Context, parkingLotId int,
+	req *model.UpsizeRuleRequest) ([]*models.UpsizeRule, error) {
+
+	vehicleType, err := s.getVehicleType(ctx, req.VehicleID)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.OverflowVehicleIDs) > 0 && !vehicleType.AllowLargerSpots {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("Vehicle type %s may not use larger spots", vehicleType.Code),
+		}
+	}
+
+	rules := make([]*models.UpsizeRule, 0, len(req.OverflowVehicleIDs))
+	seen := make(map[int]bool, len(req.OverflowVehicleIDs))
+	for i, overflowVehicleId := range req.OverflowVehicleIDs {
+		overflowVehicleType, err := s.getVehicleType(ctx, overflowVehicleId)
+		if err != nil {
+			return nil, err
+		}
+		if seen[overflowVehicleId] || overflowVehicleType.SizeClass <= vehicleType.SizeClass {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusBadRequest,
+				Message: fmt.Sprintf("Vehicle type %s can only overflow once into each larger vehicle type",
+					vehicleType.Code),
+			}
+		}
+		seen[overflowVehicleId] = true
+
+		rules = append(rules, &models.UpsizeRule{
+			ParkingLotId:          parkingLotId,
+			VehicleTypeId:         vehicleType.ID,
+			OverflowVehicleTypeId: overflowVehicleId,
+			Priority:              i + 1,
+		})
+	}
+	return rules, nil
+}
+
+// getOverflowVehicleTypes resolves the vehicle types whose spots a vehicle type may overflow into
+// in a parking lot, in order of preference.
+func (s *impl) getOverflowVehicleTypes(ctx context.Context, parkingLotId int,
+	vehicleType *models.VehicleType) ([]*models.VehicleType, error) {
+
+	if !vehicleType.AllowLargerSpots {
+		return nil, nil
+	}
+
+	rules, err := s.parkingLotRepo.GetUpsizeRules(ctx, parkingLotId)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	var overflowVehicleTypes []*models.VehicleType
+	for _, rule := range rules {
+		if rule.VehicleTypeId != vehicleType.ID {
+			continue
+		}
+		overflowVehicleType, err := s.getVehicleType(ctx, rule.OverflowVehicleTypeId)
+		if err != nil {
+			return nil, err
+		}
+		overflowVehicleTypes = append(overflowVehicleTypes, overflowVehicleType)
+	}
+	return overflowVehicleTypes, nil
+}
+
+func toUpsizePolicyResponse(parkingLotId int, pricing models.UpsizePricing,
+	rules []*models.UpsizeRule) *model.UpsizePolicyResponse {
+
+	resp := &model.UpsizePolicyResponse{
+		ParkingLotID: parkingLotId,
+		Pricing:      string(pricing),
+		Rules:        []*model.UpsizeRuleRequest{},
+	}
+
+	// Rules arrive grouped by vehicle type and ordered by priority
+	for _, rule := range rules {
+		last := len(resp.Rules) - 1
+		if last < 0 || resp.Rules[last].VehicleID != rule.VehicleTypeId {
+			resp.Rules = append(resp.Rules, &model.UpsizeRuleRequest{VehicleID: rule.VehicleTypeId})
+			last++
+		}
+		resp.Rules[last].OverflowVehicleIDs = append(resp.Rules[last].OverflowVehicleIDs, rule.OverflowVehicleTypeId)
+	}
+	return resp
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/allocation"
+	"parking_lot_service/internal/service/model"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newFakeRepoWithOverflow returns a fake repo whose lot has a pool of one car spot and a pool of one truck spot,
+// and whose motorcycles may use larger spots while bicycles may not. Neither has a pool of its own.
+func newFakeRepoWithOverflow() *fakeRepo {
+	fake := newFakeRepo(1)
+	fake.vehicleTypes[2] = &models.VehicleType{ID: 2, Code: "MOTORCYCLES_SCOOTERS", SizeClass: models.SizeClassSmall,
+		AllowLargerSpots: true}
+	fake.vehicleTypes[3] = &models.VehicleType{ID: 3, Code: "BICYCLES", SizeClass: models.SizeClassSmall}
+	fake.vehicleTypes[4] = &models.VehicleType{ID: 4, Code: "TRUCKS", SizeClass: models.SizeClassLarge}
+	fake.parkingSpaces[[2]int{1, 4}] = &models.ParkingSpace{ID: 2, ParkingLotId: 1, VehicleTypeId: 4,
+		AvailableSpots: 1, TotalSpots: 1}
+	fake.spots[2] = &models.Spot{ID: 2, ParkingLotId: 1, VehicleTypeId: 4, Label: "TRUCKS-001",
+		SizeClass: models.SizeClassLarge}
+	return fake
+}
+
+func TestUpdateUpsizePolicy_ValidatesOverflowVehicleTypes(t *testing.T) {
+	fake := newFakeRepoWithOverflow()
+	svc := NewParkingLotService(fake)
+
+	tests := []struct {
+		name       string
+		req        *model.UpsizePolicyRequest
+		wantStatus int
+	}{
+		{
+			name:       "unknown pricing",
+			req:        &model.UpsizePolicyRequest{Pricing: "cheapest"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown overflow vehicle type",
+			req: &model.UpsizePolicyRequest{Rules: []*model.UpsizeRuleRequest{
+				{VehicleID: 2, OverflowVehicleIDs: []int{1, 9}},
+			}},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "overflow into itself",
+			req: &model.UpsizePolicyRequest{Rules: []*model.UpsizeRuleRequest{
+				{VehicleID: 2, OverflowVehicleIDs: []int{2}},
+			}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "overflow into the same size class",
+			req: &model.UpsizePolicyRequest{Rules: []*model.UpsizeRuleRequest{
+				{VehicleID: 2, OverflowVehicleIDs: []int{3}},
+			}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "overflow vehicle type listed twice",
+			req: &model.UpsizePolicyRequest{Rules: []*model.UpsizeRuleRequest{
+				{VehicleID: 2, OverflowVehicleIDs: []int{1, 4, 1}},
+			}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "vehicle type listed twice",
+			req: &model.UpsizePolicyRequest{Rules: []*model.UpsizeRuleRequest{
+				{VehicleID: 2, OverflowVehicleIDs: []int{1}},
+				{VehicleID: 2, OverflowVehicleIDs: []int{4}},
+			}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "vehicle type that may not use larger spots",
+			req: &model.UpsizePolicyRequest{Rules: []*model.UpsizeRuleRequest{
+				{VehicleID: 3, OverflowVehicleIDs: []int{1}},
+			}},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		_, err := svc.UpdateUpsizePolicy(context.Background(), 1, tt.req)
+		wantErrorStatus(t, "UpdateUpsizePolicy() with "+tt.name, err, tt.wantStatus)
+	}
+	if len(fake.upsizeRules) != 0 || fake.parkingLots[1].UpsizePricing != "" {
+		t.Errorf("upsize policy after invalid requests = %q with %+v, want none", fake.parkingLots[1].UpsizePricing,
+			fake.upsizeRules)
+	}
+}
+
+func TestUpdateUpsizePolicy_ParkVehicleFallsBackToOverflowPools(t *testing.T) {
+	fake := newFakeRepoWithOverflow()
+	svc := NewParkingLotService(fake)
+
+	resp, err := svc.UpdateUpsizePolicy(context.Background(), 1, &model.UpsizePolicyRequest{
+		Pricing: string(models.UpsizePricingSpotClass),
+		Rules:   []*model.UpsizeRuleRequest{{VehicleID: 2, OverflowVehicleIDs: []int{1, 4}}},
+	})
+	if err != nil {
+		t.Fatalf("UpdateUpsizePolicy() error = %v", err)
+	}
+	if resp.Pricing != string(models.UpsizePricingSpotClass) || len(resp.Rules) != 1 ||
+		!slices.Equal(resp.Rules[0].OverflowVehicleIDs, []int{1, 4}) {
+		t.Errorf("UpdateUpsizePolicy() = %+v, want motorcycles overflowing into cars, then trucks", resp)
+	}
+	if got, err := svc.GetUpsizePolicy(context.Background(), 1); err != nil || len(got.Rules) != 1 ||
+		!slices.Equal(got.Rules[0].OverflowVehicleIDs, []int{1, 4}) {
+		t.Errorf("GetUpsizePolicy() = %+v, %v, want the policy just set", got, err)
+	}
+
+	// Motorcycles have no pool of their own, they take the pools in the order of the policy
+	for _, wantPool := range []int{1, 4} {
+		parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+			ParkingLotID: 1, VehicleID: 2, VehicleNumber: fmt.Sprintf("KA-01-%04d", wantPool),
+		})
+		if err != nil {
+			t.Fatalf("ParkVehicle() error = %v", err)
+		}
+		if spot := parked.ParkingTicket.Spot; spot.VehicleID != wantPool {
+			t.Errorf("ParkVehicle() spot = %+v, want one of the pool of vehicle type %d", spot, wantPool)
+		}
+		if got := fake.parkingSpaces[[2]int{1, wantPool}].AvailableSpots; got != 0 {
+			t.Errorf("AvailableSpots of vehicle type %d = %d, want 0", wantPool, got)
+		}
+	}
+
+	_, err = svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 2, VehicleNumber: "KA-01-0009",
+	})
+	if err == nil {
+		t.Errorf("ParkVehicle() with every overflow pool full error = nil")
+	}
+}
+
+func TestParkVehicle_WithoutUpsizeRuleStaysInOwnPool(t *testing.T) {
+	fake := newFakeRepoWithOverflow()
+	fake.parkingLots[1].AllocationStrategy = allocation.SmallestFitting // Upsizes of its own accord when asked to
+	fake.parkingSpaces[[2]int{1, 2}] = &models.ParkingSpace{ID: 3, ParkingLotId: 1, VehicleTypeId: 2,
+		AvailableSpots: 1, TotalSpots: 1}
+	fake.spots[3] = &models.Spot{ID: 3, ParkingLotId: 1, VehicleTypeId: 2, Label: "MOTORCYCLES_SCOOTERS-001",
+		SizeClass: models.SizeClassSmall}
+	svc := NewParkingLotService(fake)
+
+	parked, err := svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 2, VehicleNumber: "KA-01-0001",
+	})
+	if err != nil {
+		t.Fatalf("ParkVehicle() error = %v", err)
+	}
+	if spot := parked.ParkingTicket.Spot; spot.VehicleID != 2 {
+		t.Errorf("ParkVehicle() spot = %+v, want the motorcycle spot", spot)
+	}
+
+	// Motorcycles may use larger spots, but the lot has no rule letting them into the free car and truck spots
+	_, err = svc.ParkVehicle(context.Background(), &model.ParkVehicleRequest{
+		ParkingLotID: 1, VehicleID: 2, VehicleNumber: "KA-01-0002",
+	})
+	wantErrorStatus(t, "ParkVehicle() with the own pool full", err, http.StatusNotFound)
+	if err != nil && !strings.Contains(err.Error(), "No Spots Available") {
+		t.Errorf("ParkVehicle() with the own pool full error = %v, want No Spots Available", err)
+	}
+	for _, pool := range []int{1, 4} {
+		if got := fake.parkingSpaces[[2]int{1, pool}].AvailableSpots; got != 1 {
+			t.Errorf("AvailableSpots of vehicle type %d = %d, want 1", pool, got)
+		}
+	}
+}
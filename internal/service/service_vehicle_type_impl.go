@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"parking_lot_service/internal/genericresponse"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"regexp"
+	"strings"
+)
+
+// vehicleTypeCodePattern restricts vehicle type codes to upper case letters, digits and underscores.
+var vehicleTypeCodePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+func (s *impl) GetVehicleTypes(ctx context.Context) ([]*model.VehicleTypeResponse, error) {
+	vehicleTypes, err := s.parkingLotRepo.GetVehicleTypes(ctx)
+	if err != nil {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	resp := make([]*model.VehicleTypeResponse, 0, len(vehicleTypes))
+	for _, vehicleType := range vehicleTypes {
+		resp = append(resp, toVehicleTypeResponse(vehicleType))
+	}
+	return resp, nil
+}
+
+func (s *impl) GetVehicleTypeById(ctx context.Context, vehicleTypeId int) (*model.VehicleTypeResponse, error) {
+	vehicleType, err := s.getVehicleType(ctx, vehicleTypeId)
+	if err != nil {
+		return nil, err
+	}
+	return toVehicleTypeResponse(vehicleType), nil
+}
+
+func (s *impl) CreateVehicleType(ctx context.Context, req *model.VehicleTypeRequest) (*model.VehicleTypeResponse, error) {
+	// Vehicle types are shared by every tenant
+	if err := checkPlatform(ctx, "register vehicle types"); err != nil {
+		return nil, err
+	}
+	vehicleType, err := vehicleTypeFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.parkingLotRepo.CreateVehicleType(ctx, vehicleType)
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusConflict,
+				Message:    "Vehicle type with this code already exists",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	return toVehicleTypeResponse(vehicleType), nil
+}
+
+func (s *impl) UpdateVehicleType(ctx context.Context, vehicleTypeId int,
+	req *model.VehicleTypeRequest) (*model.VehicleTypeResponse, error) {
+
+	if err := checkPlatform(ctx, "change vehicle types"); err != nil {
+		return nil, err
+	}
+	vehicleType, err := vehicleTypeFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	vehicleType.ID = vehicleTypeId
+
+	err = s.parkingLotRepo.UpdateVehicleType(ctx, vehicleType)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "vehicle type not found",
+			}
+		}
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusConflict,
+				Message:    "Vehicle type with this code already exists",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+
+	return s.GetVehicleTypeById(ctx, vehicleTypeId)
+}
+
+func (s *impl) DeleteVehicleType(ctx context.Context, vehicleTypeId int) error {
+	if err := checkPlatform(ctx, "remove vehicle types"); err != nil {
+		return err
+	}
+	_, err := s.getVehicleType(ctx, vehicleTypeId)
+	if err != nil {
+		return err
+	}
+
+	// A vehicle type can only be removed once no lot has spots configured for it
+	cnt, err := s.parkingLotRepo.CountParkingSpacesByVehicleTypeId(ctx, vehicleTypeId)
+	if err != nil {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	if cnt > 0 {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Vehicle type still has parking spaces configured",
+		}
+	}
+
+	// Parking sessions keep their vehicle type for the history and the revenue reports
+	cnt, err = s.parkingLotRepo.CountParkingSessionsByVehicleTypeId(ctx, vehicleTypeId)
+	if err != nil {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	if cnt > 0 {
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Vehicle type is used by parking sessions",
+		}
+	}
+
+	err = s.parkingLotRepo.DeleteVehicleType(ctx, vehicleTypeId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "vehicle type not found",
+			}
+		}
+		return &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	return nil
+}
+
+// getVehicleType fetches a vehicle type from the repo and maps a missing type to a 404 response.
+func (s *impl) getVehicleType(ctx context.Context, vehicleTypeId int) (*models.VehicleType, error) {
+	vehicleType, err := s.parkingLotRepo.GetVehicleTypeById(ctx, vehicleTypeId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &genericresponse.GenericResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    "vehicle type not found",
+			}
+		}
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    err.Error(),
+		}
+	}
+	return vehicleType, nil
+}
+
+// vehicleTypeFromRequest validates a vehicle type request and normalises its code.
+func vehicleTypeFromRequest(req *model.VehicleTypeRequest) (*models.VehicleType, error) {
+	vehicleType := &models.VehicleType{
+		Code:             strings.ToUpper(strings.TrimSpace(req.Code)),
+		DisplayName:      strings.TrimSpace(req.DisplayName),
+		SizeClass:        req.SizeClass,
+		AllowLargerSpots: req.AllowLargerSpots,
+	}
+
+	if !vehicleTypeCodePattern.MatchString(vehicleType.Code) {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Vehicle type code may only contain letters, digits and underscores",
+		}
+	}
+	if vehicleType.DisplayName == "" {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Vehicle type display name is required",
+		}
+	}
+	if vehicleType.SizeClass < 1 {
+		return nil, &genericresponse.GenericResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Vehicle type size class must be at least 1",
+		}
+	}
+
+	return vehicleType, nil
+}
+
+func toVehicleTypeResponse(vehicleType *models.VehicleType) *model.VehicleTypeResponse {
+	return &model.VehicleTypeResponse{
+		ID:               vehicleType.ID,
+		Code:             vehicleType.Code,
+		DisplayName:      vehicleType.DisplayName,
+		SizeClass:        vehicleType.SizeClass,
+		AllowLargerSpots: vehicleType.AllowLargerSpots,
+	}
+}
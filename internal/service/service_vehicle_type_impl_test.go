@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"parking_lot_service/internal/repo/models"
+	"parking_lot_service/internal/service/model"
+	"testing"
+	"time"
+)
+
+func TestVehicleTypes_DuplicateCodeConflicts(t *testing.T) {
+	fake := newFakeRepo(1)
+	svc := NewParkingLotService(fake)
+	ctx := context.Background()
+
+	// Codes are compared after they are normalised
+	_, err := svc.CreateVehicleType(ctx, &model.VehicleTypeRequest{
+		Code: " cars_suvs ", DisplayName: "Cars", SizeClass: models.SizeClassMedium,
+	})
+	wantErrorStatus(t, "CreateVehicleType() with a taken code", err, http.StatusConflict)
+
+	vans, err := svc.CreateVehicleType(ctx, &model.VehicleTypeRequest{
+		Code: "VANS", DisplayName: "Vans", SizeClass: models.SizeClassMedium,
+	})
+	if err != nil {
+		t.Fatalf("CreateVehicleType() error = %v", err)
+	}
+
+	_, err = svc.UpdateVehicleType(ctx, vans.ID, &model.VehicleTypeRequest{
+		Code: "CARS_SUVS", DisplayName: "Vans", SizeClass: models.SizeClassMedium,
+	})
+	wantErrorStatus(t, "UpdateVehicleType() to a taken code", err, http.StatusConflict)
+	if fake.vehicleTypes[vans.ID].Code != "VANS" {
+		t.Errorf("vehicle type after a conflicting update = %+v, want it unchanged", fake.vehicleTypes[vans.ID])
+	}
+
+	// Keeping its own code is no conflict
+	if _, err = svc.UpdateVehicleType(ctx, vans.ID, &model.VehicleTypeRequest{
+		Code: "VANS", DisplayName: "Vans and minibuses", SizeClass: models.SizeClassMedium,
+	}); err != nil {
+		t.Errorf("UpdateVehicleType() keeping its code error = %v", err)
+	}
+}
+
+func TestDeleteVehicleType_InUseConflicts(t *testing.T) {
+	fake := newFakeRepo(1)
+	fake.vehicleTypes[2] = &models.VehicleType{ID: 2, Code: "VANS", DisplayName: "Vans",
+		SizeClass: models.SizeClassMedium}
+	fake.vehicleTypes[3] = &models.VehicleType{ID: 3, Code: "BIKES", DisplayName: "Bikes",
+		SizeClass: models.SizeClassSmall}
+	svc := NewParkingLotService(fake)
+	ctx := context.Background()
+
+	// Type 1 has the parking space of lot 1
+	err := svc.DeleteVehicleType(ctx, 1)
+	wantErrorStatus(t, "DeleteVehicleType() with parking spaces", err, http.StatusConflict)
+
+	// Type 2 has no parking space left, but a closed session of a vehicle parked in the pool of type 1
+	exitTime := time.Now()
+	fake.sessions[1] = &models.ParkingSession{ID: 1, ParkingLotId: 1, VehicleTypeId: 2, SpotVehicleTypeId: 1,
+		VehicleNumber: "KA-01-0001", EntryTime: exitTime.Add(-time.Hour), ExitTime: &exitTime,
+		Status: models.ParkingSessionStatusClosed}
+	err = svc.DeleteVehicleType(ctx, 2)
+	wantErrorStatus(t, "DeleteVehicleType() with parking sessions", err, http.StatusConflict)
+
+	if _, ok := fake.vehicleTypes[1]; !ok {
+		t.Errorf("vehicle type 1 in use was removed")
+	}
+	if _, ok := fake.vehicleTypes[2]; !ok {
+		t.Errorf("vehicle type 2 in use was removed")
+	}
+
+	// A type nothing refers to can be removed
+	if err = svc.DeleteVehicleType(ctx, 3); err != nil {
+		t.Fatalf("DeleteVehicleType() of an unused type error = %v", err)
+	}
+	if _, ok := fake.vehicleTypes[3]; ok {
+		t.Errorf("unused vehicle type 3 was not removed")
+	}
+}
@@ -0,0 +1,60 @@
+package ticket
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+)
+
+// randomDigits is the number of random digits of a ticket number, a check digit is appended to them.
+const randomDigits = 12
+
+// Length is the number of digits of a ticket number.
+const Length = randomDigits + 1
+
+var maxRandom = new(big.Int).Exp(big.NewInt(10), big.NewInt(randomDigits), nil)
+
+// NewNumber returns a random ticket number ending in a Luhn check digit. The random part comes from
+// crypto/rand, so the numbers of other tickets cannot be derived from one's own.
+func NewNumber() (string, error) {
+	n, err := rand.Int(rand.Reader, maxRandom)
+	if err != nil {
+		return "", err
+	}
+
+	digits := n.String()
+	digits = strings.Repeat("0", randomDigits-len(digits)) + digits
+	return digits + string(checkDigit(digits)), nil
+}
+
+// Valid reports whether a ticket number has the expected length and a matching check digit,
+// which catches mistyped numbers before they are looked up.
+func Valid(number string) bool {
+	if len(number) != Length {
+		return false
+	}
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return checkDigit(number[:randomDigits]) == number[randomDigits]
+}
+
+// checkDigit computes the Luhn check digit of a string of decimal digits.
+func checkDigit(digits string) byte {
+	sum := 0
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return byte('0' + (10-sum%10)%10)
+}
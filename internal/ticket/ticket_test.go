@@ -0,0 +1,44 @@
+package ticket
+
+import "testing"
+
+func TestNewNumber(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		number, err := NewNumber()
+		if err != nil {
+			t.Fatalf("NewNumber() error = %v", err)
+		}
+		if !Valid(number) {
+			t.Fatalf("NewNumber() = %s, which is not valid", number)
+		}
+		if seen[number] {
+			t.Fatalf("NewNumber() returned %s twice", number)
+		}
+		seen[number] = true
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		name   string
+		number string
+		want   bool
+	}{
+		{name: "Valid ticket number", number: "7992739871003", want: true},
+		{name: "All zeros", number: "0000000000000", want: true},
+		{name: "Wrong check digit", number: "7992739871004", want: false},
+		{name: "Swapped digits", number: "9792739871003", want: false},
+		{name: "Too short", number: "799273987100", want: false},
+		{name: "Not a number", number: "79927398710A3", want: false},
+		{name: "Empty", number: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Valid(tt.number); got != tt.want {
+				t.Errorf("Valid(%q) = %v, want %v", tt.number, got, tt.want)
+			}
+		})
+	}
+}
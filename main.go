@@ -3,19 +3,87 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
+	"os"
 	"parking_lot_service/internal/di" // Import your container package
+	"parking_lot_service/internal/scheduler"
+	"parking_lot_service/internal/service/model"
 )
 
 func main() {
-	container := di.NewContainer()
-	if container == nil {
-		return
+	// verify-audit-log walks the hash chain of the audit log instead of serving, it exits with status 1 when
+	// the chain is broken. It only reads the database, which is neither migrated nor seeded.
+	if len(os.Args) > 1 && os.Args[1] == "verify-audit-log" {
+		os.Exit(verifyAuditLog(os.Args[2:]))
 	}
+
+	container, err := di.NewContainer()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Service could not start: %v\n", err)
+		os.Exit(1)
+	}
+
 	e := container.GetEchoInstance()
 	router := container.GetRouter()
 	router.MapRoutes(e)
+	scheduler.Start(context.Background(), container.GetJobs()...)
+	if dispatcher := container.GetEventDispatcher(); dispatcher != nil {
+		dispatcher.Start(context.Background())
+	}
 	port := ":8080"
 	fmt.Printf("Server started on port %s\n", port)
 	e.Logger.Fatal(e.Start(port))
 }
+
+// verifyAuditLog verifies the audit log. With -heads it compares the chains with the heads recorded in the file by
+// the last verification, which catches the newest entries being removed, and records the new heads once the log
+// is intact. The file must be kept where whoever can write the database cannot change it.
+func verifyAuditLog(args []string) int {
+	flags := flag.NewFlagSet("verify-audit-log", flag.ContinueOnError)
+	headsFile := flags.String("heads", "", "file of the chain heads recorded by the last verification")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	req := &model.AuditVerificationRequest{}
+	if *headsFile != "" {
+		content, err := os.ReadFile(*headsFile)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			// The first verification only records the heads
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Recorded heads could not be read: %v\n", err)
+			return 2
+		default:
+			if err = json.Unmarshal(content, &req.Heads); err != nil {
+				fmt.Fprintf(os.Stderr, "Recorded heads could not be read: %v\n", err)
+				return 2
+			}
+		}
+	}
+
+	result, err := di.NewAuditVerifier().VerifyAuditLog(context.Background(), req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Audit log could not be verified: %v\n", err)
+		return 2
+	}
+	if !result.Intact {
+		fmt.Printf("Audit log is broken: %s, %d entries before it are intact\n", result.Reason, result.Checked)
+		return 1
+	}
+
+	if *headsFile != "" {
+		content, _ := json.MarshalIndent(result.Heads, "", "  ")
+		if err = os.WriteFile(*headsFile, content, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Heads could not be recorded: %v\n", err)
+			return 2
+		}
+	}
+	fmt.Printf("Audit log is intact: %d entries in %d tenant chains\n", result.Checked, len(result.Heads))
+	return 0
+}